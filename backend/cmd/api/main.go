@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"log"
 	"os"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
@@ -16,6 +17,7 @@ import (
 	"moalemplus/internal/auth"
 	"moalemplus/internal/handlers"
 	"moalemplus/internal/middleware"
+	"moalemplus/internal/notify"
 )
 
 func main() {
@@ -64,6 +66,16 @@ func main() {
 	classHandler := handlers.NewClassHandler(db)
 	studentHandler := handlers.NewStudentHandler(db)
 	attendanceHandler := handlers.NewAttendanceHandler(db)
+	questionHandler := handlers.NewQuestionHandler(db)
+	testHandler := handlers.NewTestHandler(db)
+	attemptHandler := handlers.NewAttemptHandler(db)
+	gradebookHandler := handlers.NewGradebookHandler(db)
+	gradingScaleHandler := handlers.NewGradingScaleHandler(db)
+	parentHandler := handlers.NewParentHandler(db)
+	notificationHandler := handlers.NewNotificationHandler(db)
+	attendanceAlertHandler := handlers.NewAttendanceAlertHandler(db)
+	timetableHandler := handlers.NewTimetableHandler(db)
+	calendarHandler := handlers.NewCalendarHandler(db)
 
 	// API routes
 	api := app.Group("/api")
@@ -75,6 +87,8 @@ func main() {
 	authRoutes.Post("/refresh", authService.RefreshToken)
 	authRoutes.Post("/logout", middleware.AuthMiddleware(authService), authService.Logout)
 	authRoutes.Get("/me", middleware.AuthMiddleware(authService), authService.GetMe)
+	authRoutes.Post("/student/login", authService.StudentLogin)
+	authRoutes.Post("/parent/login", authService.ParentLogin)
 	
 	// Protected routes (require authentication) - use specific middleware instead of group
 	// Class routes
@@ -84,19 +98,133 @@ func main() {
 	api.Put("/classes/:id", middleware.AuthMiddleware(authService), classHandler.UpdateClass)
 	api.Delete("/classes/:id", middleware.AuthMiddleware(authService), classHandler.DeleteClass)
 	api.Get("/classes/:id/stats", middleware.AuthMiddleware(authService), classHandler.GetClassStats)
+	api.Get("/classes/:id/promotion", middleware.AuthMiddleware(authService), classHandler.GetPromotion)
+	api.Post("/classes/:id/promote", middleware.AuthMiddleware(authService), classHandler.PromoteClass)
 	
 	// Student routes
 	api.Get("/classes/:id/students", middleware.AuthMiddleware(authService), studentHandler.GetClassStudents)
 	api.Post("/classes/:id/students", middleware.AuthMiddleware(authService), studentHandler.CreateStudent)
+	api.Post("/classes/:id/students/bulk", middleware.AuthMiddleware(authService), studentHandler.BulkImportStudents)
+	api.Get("/classes/:id/students/export", middleware.AuthMiddleware(authService), studentHandler.ExportClassStudents)
+	api.Post("/classes/:id/enrollments", middleware.AuthMiddleware(authService), studentHandler.EnrollStudent)
+	api.Delete("/classes/:id/students/:studentId", middleware.AuthMiddleware(authService), studentHandler.UnenrollStudent)
 	api.Get("/students/:id", middleware.AuthMiddleware(authService), studentHandler.GetStudent)
 	api.Put("/students/:id", middleware.AuthMiddleware(authService), studentHandler.UpdateStudent)
 	api.Delete("/students/:id", middleware.AuthMiddleware(authService), studentHandler.DeleteStudent)
+	api.Post("/students/:id/transfer", middleware.AuthMiddleware(authService), studentHandler.TransferStudent)
+	api.Post("/students/:id/access-code", middleware.AuthMiddleware(authService), studentHandler.CreateStudentAccessCode)
+	api.Get("/students/:id/parents", middleware.AuthMiddleware(authService), parentHandler.GetStudentParents)
+	api.Post("/students/:id/parents", middleware.AuthMiddleware(authService), parentHandler.CreateStudentParent)
+	api.Put("/students/:id/parents/:parentId", middleware.AuthMiddleware(authService), parentHandler.UpdateStudentParent)
+	api.Delete("/students/:id/parents/:parentId", middleware.AuthMiddleware(authService), parentHandler.DeleteStudentParent)
+	api.Post("/students/:id/parents/:parentId/access-code", middleware.AuthMiddleware(authService), parentHandler.CreateParentAccessCode)
+	api.Get("/students/:id/report-card", middleware.AuthMiddleware(authService), studentHandler.GetReportCard)
+	api.Get("/students/:id/report-card/pdf", middleware.AuthMiddleware(authService), studentHandler.GetReportCardPDF)
 	
 	// Attendance routes
 	api.Post("/classes/:id/attendance", middleware.AuthMiddleware(authService), attendanceHandler.CreateAttendance)
+	api.Get("/classes/:id/attendance/export", middleware.AuthMiddleware(authService), attendanceHandler.ExportAttendanceSheet)
 	api.Get("/classes/:id/attendance/:date", middleware.AuthMiddleware(authService), attendanceHandler.GetClassAttendance)
 	api.Put("/attendance/:id", middleware.AuthMiddleware(authService), attendanceHandler.UpdateAttendance)
 	api.Get("/students/:id/attendance-report", middleware.AuthMiddleware(authService), attendanceHandler.GetStudentAttendanceReport)
+	api.Get("/attendance-alerts", middleware.AuthMiddleware(authService), attendanceAlertHandler.GetAttendanceAlerts)
+	api.Put("/attendance-alerts/:id", middleware.AuthMiddleware(authService), attendanceAlertHandler.UpdateAttendanceAlert)
+	api.Post("/attendance-alerts/:id/refer", middleware.AuthMiddleware(authService), attendanceAlertHandler.ReferAttendanceAlert)
+	api.Get("/attendance-alert-rules", middleware.AuthMiddleware(authService), attendanceAlertHandler.GetAttendanceAlertRules)
+	api.Put("/attendance-alert-rules", middleware.AuthMiddleware(authService), attendanceAlertHandler.SaveAttendanceAlertRules)
+	api.Delete("/attendance-alert-rules", middleware.AuthMiddleware(authService), attendanceAlertHandler.ResetAttendanceAlertRules)
+	api.Get("/attendance/pending", middleware.AuthMiddleware(authService), timetableHandler.GetPendingAttendance)
+	
+	// Timetable routes
+	api.Get("/timetable", middleware.AuthMiddleware(authService), timetableHandler.GetTimetable)
+	api.Get("/classes/:id/schedule", middleware.AuthMiddleware(authService), timetableHandler.GetClassSchedule)
+	api.Post("/classes/:id/schedule", middleware.AuthMiddleware(authService), timetableHandler.CreateClassSchedule)
+	api.Put("/schedule/:id", middleware.AuthMiddleware(authService), timetableHandler.UpdateClassSchedule)
+	api.Delete("/schedule/:id", middleware.AuthMiddleware(authService), timetableHandler.DeleteClassSchedule)
+	api.Get("/schedule-exceptions", middleware.AuthMiddleware(authService), timetableHandler.GetScheduleExceptions)
+	api.Post("/schedule-exceptions", middleware.AuthMiddleware(authService), timetableHandler.CreateScheduleException)
+	api.Delete("/schedule-exceptions/:id", middleware.AuthMiddleware(authService), timetableHandler.DeleteScheduleException)
+	api.Get("/dashboard/upcoming-classes", middleware.AuthMiddleware(authService), timetableHandler.GetUpcomingClasses)
+	
+	// School calendar routes
+	api.Get("/calendar", middleware.AuthMiddleware(authService), calendarHandler.GetCalendar)
+	api.Put("/calendar/terms/:schoolYear/:semester", middleware.AuthMiddleware(authService), calendarHandler.SaveSchoolTerm)
+	api.Delete("/calendar/terms/:schoolYear/:semester", middleware.AuthMiddleware(authService), calendarHandler.ResetSchoolTerm)
+	api.Post("/calendar/events", middleware.AuthMiddleware(authService), calendarHandler.CreateCalendarEvent)
+	api.Put("/calendar/events/:id", middleware.AuthMiddleware(authService), calendarHandler.UpdateCalendarEvent)
+	api.Delete("/calendar/events/:id", middleware.AuthMiddleware(authService), calendarHandler.DeleteCalendarEvent)
+	api.Post("/calendar/feed", middleware.AuthMiddleware(authService), calendarHandler.CreateCalendarFeed)
+	api.Delete("/calendar/feed", middleware.AuthMiddleware(authService), calendarHandler.DeleteCalendarFeed)
+	api.Get("/calendar/:token.ics", calendarHandler.GetCalendarFeed)
+	
+	// Gradebook routes
+	api.Get("/classes/:id/gradebook", middleware.AuthMiddleware(authService), gradebookHandler.GetGradebook)
+	api.Post("/classes/:id/grade-categories", middleware.AuthMiddleware(authService), gradebookHandler.CreateGradeCategory)
+	api.Put("/grade-categories/:id", middleware.AuthMiddleware(authService), gradebookHandler.UpdateGradeCategory)
+	api.Delete("/grade-categories/:id", middleware.AuthMiddleware(authService), gradebookHandler.DeleteGradeCategory)
+	api.Post("/classes/:id/grade-items", middleware.AuthMiddleware(authService), gradebookHandler.CreateGradeItem)
+	api.Post("/classes/:id/grade-items/import-test", middleware.AuthMiddleware(authService), gradebookHandler.ImportTestGrades)
+	api.Put("/grade-items/:id", middleware.AuthMiddleware(authService), gradebookHandler.UpdateGradeItem)
+	api.Delete("/grade-items/:id", middleware.AuthMiddleware(authService), gradebookHandler.DeleteGradeItem)
+	api.Put("/grade-items/:id/scores", middleware.AuthMiddleware(authService), gradebookHandler.SaveGradeScores)
+	api.Post("/classes/:id/close", middleware.AuthMiddleware(authService), gradebookHandler.CloseClass)
+	api.Get("/grading-scales", middleware.AuthMiddleware(authService), gradingScaleHandler.GetGradingScales)
+	api.Put("/grading-scales/:schoolType", middleware.AuthMiddleware(authService), gradingScaleHandler.SaveGradingScale)
+	api.Delete("/grading-scales/:schoolType", middleware.AuthMiddleware(authService), gradingScaleHandler.ResetGradingScale)
+
+	// Parent notification template routes
+	api.Get("/notification-templates", middleware.AuthMiddleware(authService), notificationHandler.GetNotificationTemplates)
+	api.Put("/notification-templates/:eventType", middleware.AuthMiddleware(authService), notificationHandler.SaveNotificationTemplate)
+	api.Delete("/notification-templates/:eventType", middleware.AuthMiddleware(authService), notificationHandler.ResetNotificationTemplate)
+	
+	// Question bank routes
+	api.Get("/questions", middleware.AuthMiddleware(authService), questionHandler.GetQuestions)
+	api.Post("/questions", middleware.AuthMiddleware(authService), questionHandler.CreateQuestion)
+	api.Get("/questions/search", middleware.AuthMiddleware(authService), questionHandler.SearchQuestions)
+	api.Post("/questions/bulk-import", middleware.AuthMiddleware(authService), questionHandler.BulkImportQuestions)
+	api.Get("/questions/:id", middleware.AuthMiddleware(authService), questionHandler.GetQuestion)
+	api.Put("/questions/:id", middleware.AuthMiddleware(authService), questionHandler.UpdateQuestion)
+	api.Delete("/questions/:id", middleware.AuthMiddleware(authService), questionHandler.DeleteQuestion)
+	api.Get("/questions/:id/rubric", middleware.AuthMiddleware(authService), questionHandler.GetRubric)
+	api.Put("/questions/:id/rubric", middleware.AuthMiddleware(authService), questionHandler.SaveRubric)
+	api.Delete("/questions/:id/rubric", middleware.AuthMiddleware(authService), questionHandler.DeleteRubric)
+	
+	// Test builder routes
+	api.Get("/tests", middleware.AuthMiddleware(authService), testHandler.GetTests)
+	api.Post("/tests", middleware.AuthMiddleware(authService), testHandler.CreateTest)
+	api.Post("/tests/generate", middleware.AuthMiddleware(authService), testHandler.GenerateTest)
+	api.Get("/tests/:id", middleware.AuthMiddleware(authService), testHandler.GetTest)
+	api.Put("/tests/:id", middleware.AuthMiddleware(authService), testHandler.UpdateTest)
+	api.Delete("/tests/:id", middleware.AuthMiddleware(authService), testHandler.DeleteTest)
+	api.Post("/tests/:id/publish", middleware.AuthMiddleware(authService), testHandler.PublishTest)
+	api.Post("/tests/:id/unpublish", middleware.AuthMiddleware(authService), testHandler.UnpublishTest)
+	api.Get("/tests/:id/variants", middleware.AuthMiddleware(authService), testHandler.GetVariants)
+	api.Post("/tests/:id/variants", middleware.AuthMiddleware(authService), testHandler.GenerateVariants)
+	api.Get("/tests/:id/variants/:label/answer-key", middleware.AuthMiddleware(authService), testHandler.GetVariantAnswerKey)
+	api.Post("/tests/:id/generate-pdf", middleware.AuthMiddleware(authService), testHandler.GenerateTestPDF)
+	api.Post("/tests/:id/generate-answer-key", middleware.AuthMiddleware(authService), testHandler.GenerateAnswerKeyPDF)
+	api.Post("/tests/:id/questions", middleware.AuthMiddleware(authService), testHandler.AddTestQuestion)
+	api.Put("/tests/:id/questions/order", middleware.AuthMiddleware(authService), testHandler.ReorderTestQuestions)
+	api.Put("/tests/:id/questions/:questionId", middleware.AuthMiddleware(authService), testHandler.UpdateTestQuestion)
+	api.Delete("/tests/:id/questions/:questionId", middleware.AuthMiddleware(authService), testHandler.RemoveTestQuestion)
+	api.Get("/tests/:id/analysis", middleware.AuthMiddleware(authService), testHandler.GetTestAnalysis)
+	api.Get("/tests/:id/grading-queue", middleware.AuthMiddleware(authService), testHandler.GetGradingQueue)
+	api.Put("/tests/:id/answers/:answerId/grade", middleware.AuthMiddleware(authService), testHandler.GradeAnswer)
+	
+	// Student test-taking routes (require a student token)
+	api.Get("/student/tests", middleware.StudentAuthMiddleware(authService), attemptHandler.GetStudentTests)
+	api.Post("/student/tests/:id/start", middleware.StudentAuthMiddleware(authService), attemptHandler.StartAttempt)
+	api.Get("/student/attempts/:id", middleware.StudentAuthMiddleware(authService), attemptHandler.GetAttempt)
+	api.Put("/student/attempts/:id/answers", middleware.StudentAuthMiddleware(authService), attemptHandler.SaveAnswers)
+	api.Post("/student/attempts/:id/submit", middleware.StudentAuthMiddleware(authService), attemptHandler.SubmitAttempt)
+
+	// Parent portal routes (require a parent token; only the password can be changed)
+	api.Put("/parent/password", middleware.ParentAuthMiddleware(authService), authService.SetParentPassword)
+	api.Get("/parent/students", middleware.ParentAuthMiddleware(authService), parentHandler.GetParentStudents)
+	api.Get("/parent/students/:id/attendance-report", middleware.ParentAuthMiddleware(authService), attendanceHandler.GetStudentAttendanceReport)
+	api.Get("/parent/students/:id/report-card", middleware.ParentAuthMiddleware(authService), studentHandler.GetReportCard)
+	api.Get("/parent/students/:id/report-card/pdf", middleware.ParentAuthMiddleware(authService), studentHandler.GetReportCardPDF)
+	api.Get("/parent/students/:id/test-results", middleware.ParentAuthMiddleware(authService), parentHandler.GetStudentTestResults)
 	
 	// Public endpoints (no auth required)
 	// Schools endpoint
@@ -182,6 +310,46 @@ func main() {
 		})
 	})
 
+	// Expire abandoned test attempts and refresh question statistics in the background
+	go func() {
+		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
+		for range ticker.C {
+			expired, err := handlers.ExpireAttempts(db)
+			if err != nil {
+				log.Println("Failed to expire test attempts:", err)
+			} else if expired > 0 {
+				log.Printf("Expired %d abandoned test attempts", expired)
+			}
+
+			if _, err := handlers.RefreshQuestionStatistics(db); err != nil {
+				log.Println("Failed to refresh question statistics:", err)
+			}
+		}
+	}()
+
+	// Deliver queued parent notifications in the background
+	notifiers, err := notify.FromEnv()
+	if err != nil {
+		log.Fatal("Failed to configure notifications:", err)
+	}
+	if len(notifiers) == 0 {
+		log.Println("NOTIFY_CHANNELS is not set; parent notifications stay queued until it is")
+	} else {
+		go func() {
+			ticker := time.NewTicker(30 * time.Second)
+			defer ticker.Stop()
+			for range ticker.C {
+				sent, err := handlers.DeliverNotifications(db, notifiers, 50)
+				if err != nil {
+					log.Println("Failed to deliver notifications:", err)
+				} else if sent > 0 {
+					log.Printf("Delivered %d parent notifications", sent)
+				}
+			}
+		}()
+	}
+
 	// Start server
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -0,0 +1,37 @@
+-- Add full-text search support to the question bank
+
+-- Normalize Arabic text for matching: strip tashkeel and tatweel, unify alef
+-- variants, map taa marbuta to haa and alef maqsura to yaa, convert
+-- Arabic-Indic digits. Must stay in sync with arabic.Normalize in the API.
+CREATE OR REPLACE FUNCTION normalize_arabic(input TEXT)
+RETURNS TEXT AS $$
+    SELECT btrim(regexp_replace(lower(
+        translate(
+            regexp_replace(COALESCE(input, ''), '[\u064B-\u065F\u0670\u0640]', '', 'g'),
+            'أإآٱةى٠١٢٣٤٥٦٧٨٩',
+            'ااااهي0123456789'
+        )
+    ), '\s+', ' ', 'g'));
+$$ LANGUAGE sql IMMUTABLE;
+
+-- Normalize an array of tags into a single searchable string
+CREATE OR REPLACE FUNCTION normalize_arabic(input TEXT[])
+RETURNS TEXT AS $$
+    SELECT normalize_arabic(array_to_string(input, ' '));
+$$ LANGUAGE sql IMMUTABLE;
+
+-- Weighted search document: question text ranks above tags, tags above explanations
+ALTER TABLE questions ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('simple', normalize_arabic(question_text)), 'A') ||
+    setweight(to_tsvector('simple', normalize_arabic(question_text_arabic)), 'A') ||
+    setweight(to_tsvector('simple', normalize_arabic(tags)), 'B') ||
+    setweight(to_tsvector('simple', normalize_arabic(explanation)), 'C') ||
+    setweight(to_tsvector('simple', normalize_arabic(explanation_arabic)), 'C')
+) STORED;
+
+-- Create index for better performance
+CREATE INDEX IF NOT EXISTS idx_questions_search_vector ON questions USING GIN(search_vector);
+
+-- Comments for clarity
+COMMENT ON FUNCTION normalize_arabic(TEXT) IS 'Arabic text normalization shared by question search';
+COMMENT ON COLUMN questions.search_vector IS 'Normalized full-text search document for the question bank';
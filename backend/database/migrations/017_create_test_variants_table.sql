@@ -0,0 +1,37 @@
+-- Create test_variants table (printed forms A, B, C... of the same test)
+CREATE TABLE IF NOT EXISTS test_variants (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    test_id UUID NOT NULL REFERENCES tests(id) ON DELETE CASCADE,
+    label VARCHAR(5) NOT NULL, -- e.g., "A", "B", "C"
+    variant_number INTEGER NOT NULL,
+    seed BIGINT NOT NULL,
+    layout JSONB NOT NULL DEFAULT '[]', -- Questions in display order with their option permutation
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    -- Ensure unique variant labels per test
+    UNIQUE(test_id, label),
+    UNIQUE(test_id, variant_number)
+);
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_test_variants_test_id ON test_variants(test_id);
+
+-- Create trigger to update updated_at timestamp
+CREATE TRIGGER update_test_variants_updated_at
+    BEFORE UPDATE ON test_variants
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- Add constraint to validate variant number (must be positive)
+ALTER TABLE test_variants ADD CONSTRAINT check_test_variant_number_positive
+    CHECK (variant_number > 0);
+
+-- Record which variant a submission was answered on
+ALTER TABLE test_submissions ADD COLUMN IF NOT EXISTS variant_id UUID REFERENCES test_variants(id) ON DELETE RESTRICT;
+
+CREATE INDEX IF NOT EXISTS idx_test_submissions_variant_id ON test_submissions(variant_id);
+
+-- Comments for clarity
+COMMENT ON COLUMN test_variants.layout IS 'Array of {question_id, option_map} in display order; option_map maps displayed letters to canonical letters';
+COMMENT ON COLUMN test_submissions.variant_id IS 'Variant the student answered; answers are stored as displayed and mapped back when grading';
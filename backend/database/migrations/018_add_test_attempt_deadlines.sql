@@ -0,0 +1,16 @@
+-- Track when an online test attempt must be submitted
+ALTER TABLE test_submissions ADD COLUMN IF NOT EXISTS expires_at TIMESTAMP WITH TIME ZONE;
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_test_submissions_expires_at ON test_submissions(expires_at) WHERE status = 'in_progress';
+
+-- Ensure a student has at most one attempt in progress per test
+CREATE UNIQUE INDEX IF NOT EXISTS idx_test_submissions_one_in_progress
+    ON test_submissions(test_id, student_id) WHERE status = 'in_progress';
+
+-- Add constraint to validate the deadline
+ALTER TABLE test_submissions ADD CONSTRAINT check_test_submission_expires_valid
+    CHECK (expires_at IS NULL OR expires_at >= started_at);
+
+-- Comments for clarity
+COMMENT ON COLUMN test_submissions.expires_at IS 'Deadline of the attempt: started_at plus the test duration, capped at the scheduled end';
@@ -0,0 +1,14 @@
+-- Track how each answer was graded
+ALTER TABLE test_submission_answers ADD COLUMN IF NOT EXISTS grading_status VARCHAR(20) NOT NULL DEFAULT 'pending';
+ALTER TABLE test_submission_answers ADD COLUMN IF NOT EXISTS graded_at TIMESTAMP WITH TIME ZONE;
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_test_submission_answers_grading_status ON test_submission_answers(grading_status);
+
+-- Add constraint to validate grading status
+ALTER TABLE test_submission_answers ADD CONSTRAINT check_test_submission_answer_grading_status
+    CHECK (grading_status IN ('pending', 'auto_graded', 'pending_manual', 'manually_graded'));
+
+-- Comments for clarity
+COMMENT ON COLUMN test_submission_answers.grading_status IS 'pending until the attempt ends; objective answers become auto_graded, short answers and essays pending_manual until a teacher grades them';
+COMMENT ON COLUMN test_submission_answers.graded_at IS 'When points_awarded was last set';
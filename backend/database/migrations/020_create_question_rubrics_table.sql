@@ -0,0 +1,36 @@
+-- Create question_rubrics table (grading criteria for short answer and essay questions)
+CREATE TABLE IF NOT EXISTS question_rubrics (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    question_id UUID NOT NULL REFERENCES questions(id) ON DELETE CASCADE,
+    criteria JSONB NOT NULL DEFAULT '[]', -- [{id, name, name_arabic, levels: [{label, label_arabic, description, points}]}]
+    max_points INTEGER NOT NULL,
+    created_by UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    -- Ensure one rubric per question
+    UNIQUE(question_id)
+);
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_question_rubrics_created_by ON question_rubrics(created_by);
+
+-- Create trigger to update updated_at timestamp
+CREATE TRIGGER update_question_rubrics_updated_at
+    BEFORE UPDATE ON question_rubrics
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- Add constraint to validate max points (must be positive)
+ALTER TABLE question_rubrics ADD CONSTRAINT check_question_rubric_max_points_positive
+    CHECK (max_points > 0);
+
+-- Record how a teacher graded an answer
+ALTER TABLE test_submission_answers ADD COLUMN IF NOT EXISTS rubric_scores JSONB;
+ALTER TABLE test_submission_answers ADD COLUMN IF NOT EXISTS feedback TEXT;
+ALTER TABLE test_submission_answers ADD COLUMN IF NOT EXISTS graded_by UUID REFERENCES users(id) ON DELETE SET NULL;
+
+-- Comments for clarity
+COMMENT ON COLUMN question_rubrics.max_points IS 'Sum of the highest level of each criterion; awarded points are scaled to the question''s points in the test';
+COMMENT ON COLUMN test_submission_answers.rubric_scores IS 'Array of {criterion_id, points} awarded by the teacher';
+COMMENT ON COLUMN test_submission_answers.graded_by IS 'Teacher who graded the answer manually';
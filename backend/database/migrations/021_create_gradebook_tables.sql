@@ -0,0 +1,92 @@
+-- Create grade_categories table (weighted parts of a class grade)
+CREATE TABLE IF NOT EXISTS grade_categories (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    class_id UUID NOT NULL REFERENCES classes(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    name_arabic VARCHAR(100) NOT NULL,
+    category_type VARCHAR(20) NOT NULL DEFAULT 'other' CHECK (category_type IN ('quiz', 'exam', 'homework', 'participation', 'project', 'other')),
+    weight DECIMAL(5,2) NOT NULL, -- Percentage of the final grade
+    sort_order INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    -- Ensure unique category names per class
+    UNIQUE(class_id, name)
+);
+
+-- Create grade_items table (a graded piece of work within a category)
+CREATE TABLE IF NOT EXISTS grade_items (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    class_id UUID NOT NULL REFERENCES classes(id) ON DELETE CASCADE,
+    category_id UUID NOT NULL REFERENCES grade_categories(id) ON DELETE CASCADE,
+    title VARCHAR(255) NOT NULL,
+    title_arabic VARCHAR(255) NOT NULL,
+    max_score DECIMAL(6,2) NOT NULL,
+    due_date DATE,
+    test_id UUID REFERENCES tests(id) ON DELETE SET NULL, -- Set when scores are imported from an online test
+    created_by UUID NOT NULL REFERENCES users(id),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    -- Ensure a test is imported into a class gradebook only once
+    UNIQUE(class_id, test_id)
+);
+
+-- Create grades table (a student's score on a grade item)
+CREATE TABLE IF NOT EXISTS grades (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    grade_item_id UUID NOT NULL REFERENCES grade_items(id) ON DELETE CASCADE,
+    student_id UUID NOT NULL REFERENCES students(id) ON DELETE CASCADE,
+    score DECIMAL(6,2),
+    is_excused BOOLEAN NOT NULL DEFAULT false,
+    notes TEXT,
+    recorded_by UUID NOT NULL REFERENCES users(id),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    -- Ensure one score per student per item
+    UNIQUE(grade_item_id, student_id)
+);
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_grade_categories_class_id ON grade_categories(class_id);
+CREATE INDEX IF NOT EXISTS idx_grade_items_class_id ON grade_items(class_id);
+CREATE INDEX IF NOT EXISTS idx_grade_items_category_id ON grade_items(category_id);
+CREATE INDEX IF NOT EXISTS idx_grade_items_test_id ON grade_items(test_id);
+CREATE INDEX IF NOT EXISTS idx_grades_grade_item_id ON grades(grade_item_id);
+CREATE INDEX IF NOT EXISTS idx_grades_student_id ON grades(student_id);
+
+-- Create triggers to update updated_at timestamp
+CREATE TRIGGER update_grade_categories_updated_at
+    BEFORE UPDATE ON grade_categories
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TRIGGER update_grade_items_updated_at
+    BEFORE UPDATE ON grade_items
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TRIGGER update_grades_updated_at
+    BEFORE UPDATE ON grades
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- Add constraints to validate gradebook data
+ALTER TABLE grade_categories ADD CONSTRAINT check_grade_category_weight_valid
+    CHECK (weight > 0 AND weight <= 100);
+
+ALTER TABLE grade_items ADD CONSTRAINT check_grade_item_max_score_positive
+    CHECK (max_score > 0);
+
+ALTER TABLE grades ADD CONSTRAINT check_grade_score_positive
+    CHECK (score IS NULL OR score >= 0);
+
+-- Record when a class's semester was closed and its final grades written
+ALTER TABLE classes ADD COLUMN IF NOT EXISTS closed_at TIMESTAMP WITH TIME ZONE;
+
+-- Comments for clarity
+COMMENT ON COLUMN grade_categories.weight IS 'Percentage of the semester grade; categories without items are left out and the rest reweighted';
+COMMENT ON COLUMN grades.score IS 'Points out of grade_items.max_score; NULL when no score was recorded yet';
+COMMENT ON COLUMN grades.is_excused IS 'Excused items do not count towards the student''s grade';
+COMMENT ON COLUMN classes.closed_at IS 'Set when the class is closed; the gradebook is read-only afterwards and student_classes.final_grade holds the result';
@@ -0,0 +1,51 @@
+-- Create grading_scales table (bands mapping numeric grades to descriptors)
+CREATE TABLE IF NOT EXISTS grading_scales (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    school_id UUID REFERENCES schools(id) ON DELETE CASCADE, -- NULL for the ministry default
+    school_type VARCHAR(20) NOT NULL CHECK (school_type IN ('primary', 'intermediate', 'secondary')),
+    min_grade DECIMAL(5,2) NOT NULL,
+    letter VARCHAR(5) NOT NULL,
+    descriptor VARCHAR(50) NOT NULL,
+    descriptor_arabic VARCHAR(50) NOT NULL,
+    is_passing BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create indexes for better performance
+CREATE UNIQUE INDEX IF NOT EXISTS idx_grading_scales_band
+    ON grading_scales(COALESCE(school_id, '00000000-0000-0000-0000-000000000000'), school_type, min_grade);
+CREATE INDEX IF NOT EXISTS idx_grading_scales_school_id ON grading_scales(school_id);
+
+-- Create trigger to update updated_at timestamp
+CREATE TRIGGER update_grading_scales_updated_at
+    BEFORE UPDATE ON grading_scales
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- Add constraint to validate band thresholds
+ALTER TABLE grading_scales ADD CONSTRAINT check_grading_scale_min_grade_valid
+    CHECK (min_grade >= 0 AND min_grade <= 100);
+
+-- Insert the ministry default scales
+INSERT INTO grading_scales (school_type, min_grade, letter, descriptor, descriptor_arabic, is_passing) VALUES
+('primary', 90, 'A', 'Excellent', 'ممتاز', true),
+('primary', 80, 'B', 'Very Good', 'جيد جداً', true),
+('primary', 70, 'C', 'Good', 'جيد', true),
+('primary', 50, 'D', 'Pass', 'مقبول', true),
+('primary', 0, 'F', 'Weak', 'ضعيف', false),
+('intermediate', 90, 'A', 'Excellent', 'ممتاز', true),
+('intermediate', 80, 'B', 'Very Good', 'جيد جداً', true),
+('intermediate', 65, 'C', 'Good', 'جيد', true),
+('intermediate', 50, 'D', 'Pass', 'مقبول', true),
+('intermediate', 0, 'F', 'Weak', 'ضعيف', false),
+('secondary', 90, 'A', 'Excellent', 'ممتاز', true),
+('secondary', 80, 'B', 'Very Good', 'جيد جداً', true),
+('secondary', 65, 'C', 'Good', 'جيد', true),
+('secondary', 50, 'D', 'Pass', 'مقبول', true),
+('secondary', 0, 'F', 'Weak', 'ضعيف', false)
+ON CONFLICT DO NOTHING;
+
+-- Comments for clarity
+COMMENT ON TABLE grading_scales IS 'A grade falls in the band with the highest min_grade not above it';
+COMMENT ON COLUMN grading_scales.school_id IS 'NULL rows are the ministry default; a school''s own rows for a stage replace the default for that stage';
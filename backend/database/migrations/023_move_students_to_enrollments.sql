@@ -0,0 +1,22 @@
+-- Enroll every student in the class they were created in. Classes closed
+-- before this migration already wrote an enrollment with the final grade.
+INSERT INTO student_classes (student_id, class_id, enrollment_date, is_active)
+SELECT id, class_id, enrollment_date, is_active
+FROM students
+ON CONFLICT (student_id, class_id) DO NOTHING;
+
+-- Students who completed a class stay on its roster
+UPDATE student_classes SET is_active = true
+WHERE completion_date IS NOT NULL
+  AND student_id IN (SELECT id FROM students WHERE is_active = true);
+
+-- Students now belong to classes only through student_classes
+ALTER TABLE students DROP COLUMN IF EXISTS class_id;
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_student_classes_class_active ON student_classes(class_id) WHERE is_active = true;
+
+-- Comments for clarity
+COMMENT ON TABLE student_classes IS 'Enrollment of students in classes; a student may take several classes with different teachers';
+COMMENT ON COLUMN student_classes.is_active IS 'Whether the student is on the class roster; false once withdrawn from the class';
+COMMENT ON COLUMN student_classes.completion_date IS 'Date the class was closed and the final grade written';
@@ -0,0 +1,79 @@
+-- Create parents table (one record per parent, shared by siblings)
+CREATE TABLE IF NOT EXISTS parents (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    civil_id VARCHAR(12) UNIQUE,
+    first_name VARCHAR(100) NOT NULL,
+    last_name VARCHAR(100) NOT NULL,
+    arabic_name VARCHAR(255) NOT NULL,
+    phone_primary VARCHAR(20) NOT NULL,
+    phone_secondary VARCHAR(20),
+    email VARCHAR(255),
+    workplace VARCHAR(255),
+    job_title VARCHAR(100),
+    address TEXT,
+    is_active BOOLEAN DEFAULT true,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_parents_civil_id ON parents(civil_id);
+CREATE INDEX IF NOT EXISTS idx_parents_phone_primary ON parents(phone_primary);
+CREATE INDEX IF NOT EXISTS idx_parents_email ON parents(email);
+CREATE INDEX IF NOT EXISTS idx_parents_active ON parents(is_active);
+
+-- Create trigger to update updated_at timestamp
+CREATE TRIGGER update_parents_updated_at
+    BEFORE UPDATE ON parents
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- Add constraint to validate civil_id format (12 digits, nullable)
+ALTER TABLE parents ADD CONSTRAINT check_parents_civil_id_format
+    CHECK (civil_id IS NULL OR civil_id ~ '^[0-9]{12}$');
+
+-- Add constraint to validate phone format
+ALTER TABLE parents ADD CONSTRAINT check_parents_phone_primary_format
+    CHECK (phone_primary ~ '^[0-9+\-\s()]+$');
+
+ALTER TABLE parents ADD CONSTRAINT check_parents_phone_secondary_format
+    CHECK (phone_secondary IS NULL OR phone_secondary ~ '^[0-9+\-\s()]+$');
+
+-- Add constraint to validate email format
+ALTER TABLE parents ADD CONSTRAINT check_parents_email_format
+    CHECK (email IS NULL OR email ~ '^[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}$');
+
+-- Move the contact details of existing rows into parents, keeping their IDs
+INSERT INTO parents (id, civil_id, first_name, last_name, arabic_name, phone_primary, phone_secondary,
+                     email, workplace, job_title, address, is_active, created_at, updated_at)
+SELECT id, civil_id, first_name, last_name, arabic_name, phone_primary, phone_secondary,
+       email, workplace, job_title, address, is_active, created_at, updated_at
+FROM student_parents
+ON CONFLICT DO NOTHING;
+
+-- student_parents now links a student to a parent record
+ALTER TABLE student_parents ADD COLUMN IF NOT EXISTS parent_id UUID REFERENCES parents(id) ON DELETE CASCADE;
+UPDATE student_parents SET parent_id = id WHERE parent_id IS NULL;
+ALTER TABLE student_parents ALTER COLUMN parent_id SET NOT NULL;
+
+ALTER TABLE student_parents
+    DROP COLUMN IF EXISTS civil_id,
+    DROP COLUMN IF EXISTS first_name,
+    DROP COLUMN IF EXISTS last_name,
+    DROP COLUMN IF EXISTS arabic_name,
+    DROP COLUMN IF EXISTS phone_primary,
+    DROP COLUMN IF EXISTS phone_secondary,
+    DROP COLUMN IF EXISTS email,
+    DROP COLUMN IF EXISTS workplace,
+    DROP COLUMN IF EXISTS job_title,
+    DROP COLUMN IF EXISTS address;
+
+ALTER TABLE student_parents ADD CONSTRAINT student_parents_student_parent_unique
+    UNIQUE (student_id, parent_id);
+
+CREATE INDEX IF NOT EXISTS idx_student_parents_parent_id ON student_parents(parent_id);
+
+-- Comments for clarity
+COMMENT ON TABLE parents IS 'Parents and guardians; siblings link to the same record through student_parents';
+COMMENT ON TABLE student_parents IS 'Links students to their parents with the relationship and primary contact flag';
+COMMENT ON COLUMN student_parents.is_active IS 'False once the contact was removed from the student';
@@ -0,0 +1,16 @@
+-- Add parent portal sign-in to parents
+ALTER TABLE parents
+    ADD COLUMN IF NOT EXISTS password_hash VARCHAR(255),
+    ADD COLUMN IF NOT EXISTS otp_hash VARCHAR(255),
+    ADD COLUMN IF NOT EXISTS otp_expires_at TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN IF NOT EXISTS otp_attempts INTEGER DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS last_login_at TIMESTAMP WITH TIME ZONE;
+
+-- Add constraint to keep failed access code attempts non-negative
+ALTER TABLE parents ADD CONSTRAINT check_parents_otp_attempts
+    CHECK (otp_attempts >= 0);
+
+-- Comments for clarity
+COMMENT ON COLUMN parents.password_hash IS 'Parent portal password, set by the parent after signing in with an access code';
+COMMENT ON COLUMN parents.otp_hash IS 'One-time access code issued by a teacher, cleared once used';
+COMMENT ON COLUMN parents.otp_attempts IS 'Failed sign-ins with the current access code';
@@ -0,0 +1,71 @@
+-- Create notification_templates table (Arabic messages sent to parents)
+CREATE TABLE IF NOT EXISTS notification_templates (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    school_id UUID REFERENCES schools(id) ON DELETE CASCADE, -- NULL for the default template
+    event_type VARCHAR(20) NOT NULL CHECK (event_type IN ('absent', 'late')),
+    subject VARCHAR(255) NOT NULL,
+    body TEXT NOT NULL,
+    is_active BOOLEAN DEFAULT true,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create notification_outbox table (messages waiting for delivery)
+CREATE TABLE IF NOT EXISTS notification_outbox (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    school_id UUID REFERENCES schools(id) ON DELETE SET NULL,
+    student_id UUID NOT NULL REFERENCES students(id) ON DELETE CASCADE,
+    parent_id UUID NOT NULL REFERENCES parents(id) ON DELETE CASCADE,
+    attendance_id UUID REFERENCES attendance(id) ON DELETE CASCADE,
+    event_type VARCHAR(20) NOT NULL CHECK (event_type IN ('absent', 'late')),
+    phone VARCHAR(20),
+    email VARCHAR(255),
+    subject VARCHAR(255) NOT NULL,
+    body TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'sent', 'failed', 'cancelled')),
+    channel VARCHAR(20),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    sent_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create indexes for better performance
+CREATE UNIQUE INDEX IF NOT EXISTS idx_notification_templates_event
+    ON notification_templates(COALESCE(school_id, '00000000-0000-0000-0000-000000000000'), event_type);
+CREATE INDEX IF NOT EXISTS idx_notification_outbox_due
+    ON notification_outbox(next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_notification_outbox_student_id ON notification_outbox(student_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_notification_outbox_attendance_event
+    ON notification_outbox(attendance_id, parent_id, event_type);
+
+-- Create triggers to update updated_at timestamp
+CREATE TRIGGER update_notification_templates_updated_at
+    BEFORE UPDATE ON notification_templates
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TRIGGER update_notification_outbox_updated_at
+    BEFORE UPDATE ON notification_outbox
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- Add constraint to require an address to deliver to
+ALTER TABLE notification_outbox ADD CONSTRAINT check_notification_outbox_recipient
+    CHECK (phone IS NOT NULL OR email IS NOT NULL);
+
+-- Insert the default templates
+INSERT INTO notification_templates (event_type, subject, body) VALUES
+('absent', 'غياب الطالب {{student_name}}',
+ 'ولي أمر الطالب {{student_name}} المحترم، نحيطكم علماً بأن ابنكم تغيب عن حصة {{subject_name}} ({{class_name}}) يوم {{day}} {{date}}. {{school_name}}'),
+('late', 'تأخر الطالب {{student_name}}',
+ 'ولي أمر الطالب {{student_name}} المحترم، نحيطكم علماً بأن ابنكم تأخر عن حصة {{subject_name}} ({{class_name}}) يوم {{day}} {{date}}. {{school_name}}')
+ON CONFLICT DO NOTHING;
+
+-- Comments for clarity
+COMMENT ON COLUMN notification_templates.school_id IS 'NULL rows are the defaults; a school''s own template for an event replaces the default';
+COMMENT ON TABLE notification_outbox IS 'Parent notifications, queued with the change that caused them and delivered in the background';
+COMMENT ON COLUMN notification_outbox.channel IS 'Channel the message was last sent or tried on';
+COMMENT ON COLUMN notification_outbox.status IS 'cancelled when the attendance was corrected before delivery';
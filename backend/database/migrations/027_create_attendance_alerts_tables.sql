@@ -0,0 +1,94 @@
+-- Create attendance_alert_rules table (early-warning thresholds per school)
+CREATE TABLE IF NOT EXISTS attendance_alert_rules (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    school_id UUID REFERENCES schools(id) ON DELETE CASCADE, -- NULL for the default rules
+    consecutive_absences INTEGER NOT NULL DEFAULT 3,
+    absence_rate DECIMAL(5,2) NOT NULL DEFAULT 10.00,
+    min_recorded_days INTEGER NOT NULL DEFAULT 10,
+    late_count INTEGER NOT NULL DEFAULT 5,
+    notify_parent BOOLEAN NOT NULL DEFAULT false,
+    refer_social_worker BOOLEAN NOT NULL DEFAULT false,
+    social_worker_name VARCHAR(255),
+    social_worker_phone VARCHAR(20),
+    social_worker_email VARCHAR(255),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create attendance_alerts table (the early-warning feed of each teacher)
+CREATE TABLE IF NOT EXISTS attendance_alerts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    teacher_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    class_id UUID NOT NULL REFERENCES classes(id) ON DELETE CASCADE,
+    student_id UUID NOT NULL REFERENCES students(id) ON DELETE CASCADE,
+    rule_type VARCHAR(30) NOT NULL CHECK (rule_type IN ('consecutive_absences', 'absence_rate', 'repeated_lateness')),
+    since DATE NOT NULL,
+    value DECIMAL(6,2) NOT NULL,
+    threshold DECIMAL(6,2) NOT NULL,
+    message TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'acknowledged', 'resolved')),
+    note TEXT,
+    acknowledged_at TIMESTAMP WITH TIME ZONE,
+    resolved_at TIMESTAMP WITH TIME ZONE,
+    parent_notified_at TIMESTAMP WITH TIME ZONE,
+    referred_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    -- One alert per occurrence of a rule
+    UNIQUE(class_id, student_id, rule_type, since)
+);
+
+-- Create indexes for better performance
+CREATE UNIQUE INDEX IF NOT EXISTS idx_attendance_alert_rules_school
+    ON attendance_alert_rules(COALESCE(school_id, '00000000-0000-0000-0000-000000000000'));
+CREATE INDEX IF NOT EXISTS idx_attendance_alerts_teacher_status ON attendance_alerts(teacher_id, status);
+CREATE INDEX IF NOT EXISTS idx_attendance_alerts_student_id ON attendance_alerts(student_id);
+
+-- Create triggers to update updated_at timestamp
+CREATE TRIGGER update_attendance_alert_rules_updated_at
+    BEFORE UPDATE ON attendance_alert_rules
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TRIGGER update_attendance_alerts_updated_at
+    BEFORE UPDATE ON attendance_alerts
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- Add constraints to validate thresholds (zero turns a rule off)
+ALTER TABLE attendance_alert_rules ADD CONSTRAINT check_attendance_alert_rules_thresholds
+    CHECK (consecutive_absences >= 0 AND late_count >= 0 AND min_recorded_days >= 0
+           AND absence_rate >= 0 AND absence_rate <= 100);
+
+-- Insert the default rules
+INSERT INTO attendance_alert_rules (consecutive_absences, absence_rate, min_recorded_days, late_count)
+VALUES (3, 10.00, 10, 5)
+ON CONFLICT DO NOTHING;
+
+-- Alerts can notify the parent or refer the student to the social worker
+ALTER TABLE notification_outbox ALTER COLUMN parent_id DROP NOT NULL;
+ALTER TABLE notification_outbox ADD COLUMN IF NOT EXISTS alert_id UUID REFERENCES attendance_alerts(id) ON DELETE CASCADE;
+
+ALTER TABLE notification_outbox DROP CONSTRAINT IF EXISTS notification_outbox_event_type_check;
+ALTER TABLE notification_outbox ADD CONSTRAINT notification_outbox_event_type_check
+    CHECK (event_type IN ('absent', 'late', 'absence_alert', 'referral'));
+
+ALTER TABLE notification_templates DROP CONSTRAINT IF EXISTS notification_templates_event_type_check;
+ALTER TABLE notification_templates ADD CONSTRAINT notification_templates_event_type_check
+    CHECK (event_type IN ('absent', 'late', 'absence_alert', 'referral'));
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_notification_outbox_alert_event
+    ON notification_outbox(alert_id, event_type) WHERE alert_id IS NOT NULL;
+
+INSERT INTO notification_templates (event_type, subject, body) VALUES
+('absence_alert', 'تنبيه بشأن حضور الطالب {{student_name}}',
+ 'ولي أمر الطالب {{student_name}} المحترم، نود إعلامكم بأن ابنكم سجل {{alert}} في مادة {{subject_name}} ({{class_name}}). نرجو التواصل مع المدرسة. {{school_name}}'),
+('referral', 'إحالة الطالب {{student_name}} إلى الأخصائي الاجتماعي',
+ 'الأخصائي الاجتماعي المحترم، يرجى متابعة الطالب {{student_name}} من {{class_name}}، حيث سجل {{alert}} في مادة {{subject_name}}. {{note}} المعلم: {{teacher_name}}. {{school_name}}')
+ON CONFLICT DO NOTHING;
+
+-- Comments for clarity
+COMMENT ON COLUMN attendance_alert_rules.school_id IS 'NULL row holds the defaults; a school''s own row replaces them';
+COMMENT ON COLUMN attendance_alerts.since IS 'First day of the run of absences, or first recorded day of the semester';
+COMMENT ON COLUMN attendance_alerts.value IS 'Days absent in a row, absence percentage or times late when last evaluated';
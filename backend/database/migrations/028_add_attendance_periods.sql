@@ -0,0 +1,17 @@
+-- Add period_number to attendance so a class can be marked once per period
+ALTER TABLE attendance ADD COLUMN IF NOT EXISTS period_number SMALLINT NOT NULL DEFAULT 1;
+
+-- One record per student per class per period instead of per day
+ALTER TABLE attendance DROP CONSTRAINT IF EXISTS attendance_student_id_class_id_date_key;
+ALTER TABLE attendance ADD CONSTRAINT attendance_student_class_date_period_unique
+    UNIQUE (student_id, class_id, date, period_number);
+
+-- Add constraint to validate period_number
+ALTER TABLE attendance ADD CONSTRAINT check_attendance_period_number
+    CHECK (period_number BETWEEN 1 AND 12);
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_attendance_class_date_period ON attendance(class_id, date, period_number);
+
+-- Comments for clarity
+COMMENT ON COLUMN attendance.period_number IS 'Period of the school day; a double period or a class meeting twice a day has a record per period';
@@ -0,0 +1,53 @@
+-- Create class_schedules table (the weekly timetable of each class)
+CREATE TABLE IF NOT EXISTS class_schedules (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    class_id UUID NOT NULL REFERENCES classes(id) ON DELETE CASCADE,
+    day_of_week SMALLINT NOT NULL, -- 0 = Sunday ... 4 = Thursday
+    period_number SMALLINT NOT NULL,
+    start_time TIME NOT NULL,
+    end_time TIME NOT NULL,
+    room VARCHAR(50),
+    starts_on DATE, -- NULL for no start date
+    ends_on DATE, -- NULL for no end date
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create schedule_exceptions table (days or periods on which classes do not meet)
+CREATE TABLE IF NOT EXISTS schedule_exceptions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    teacher_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    class_id UUID REFERENCES classes(id) ON DELETE CASCADE, -- NULL for all of the teacher's classes
+    date DATE NOT NULL,
+    period_number SMALLINT, -- NULL for the whole day
+    reason VARCHAR(255),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_class_schedules_class_id ON class_schedules(class_id);
+CREATE INDEX IF NOT EXISTS idx_class_schedules_day ON class_schedules(day_of_week, start_time);
+CREATE INDEX IF NOT EXISTS idx_schedule_exceptions_teacher_date ON schedule_exceptions(teacher_id, date);
+
+-- Create trigger to update updated_at timestamp
+CREATE TRIGGER update_class_schedules_updated_at
+    BEFORE UPDATE ON class_schedules
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- Add constraints to validate the timetable
+ALTER TABLE class_schedules ADD CONSTRAINT check_class_schedules_day_of_week
+    CHECK (day_of_week BETWEEN 0 AND 4);
+ALTER TABLE class_schedules ADD CONSTRAINT check_class_schedules_period_number
+    CHECK (period_number BETWEEN 1 AND 12);
+ALTER TABLE class_schedules ADD CONSTRAINT check_class_schedules_times
+    CHECK (end_time > start_time);
+ALTER TABLE class_schedules ADD CONSTRAINT check_class_schedules_dates
+    CHECK (starts_on IS NULL OR ends_on IS NULL OR ends_on >= starts_on);
+ALTER TABLE schedule_exceptions ADD CONSTRAINT check_schedule_exceptions_period_number
+    CHECK (period_number IS NULL OR period_number BETWEEN 1 AND 12);
+
+-- Comments for clarity
+COMMENT ON TABLE class_schedules IS 'Weekly sessions of a class; starts_on and ends_on limit an entry to a semester';
+COMMENT ON COLUMN class_schedules.day_of_week IS 'Day of the school week, 0 = Sunday to 4 = Thursday';
+COMMENT ON TABLE schedule_exceptions IS 'Holidays and cancelled sessions; a row without class_id or period_number covers all classes or the whole day';
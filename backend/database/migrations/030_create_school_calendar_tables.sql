@@ -0,0 +1,74 @@
+-- Create school_terms table (semester dates of each school year)
+CREATE TABLE IF NOT EXISTS school_terms (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    school_id UUID REFERENCES schools(id) ON DELETE CASCADE, -- NULL for the ministry dates
+    school_year VARCHAR(10) NOT NULL, -- e.g., "2025-2026"
+    semester VARCHAR(20) NOT NULL CHECK (semester IN ('first', 'second')),
+    starts_on DATE NOT NULL,
+    ends_on DATE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create calendar_events table (holidays and exam periods)
+CREATE TABLE IF NOT EXISTS calendar_events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    school_id UUID REFERENCES schools(id) ON DELETE CASCADE, -- NULL for national holidays
+    school_year VARCHAR(10), -- NULL for an event that recurs every year
+    event_type VARCHAR(20) NOT NULL CHECK (event_type IN ('holiday', 'exam')),
+    name VARCHAR(255) NOT NULL,
+    starts_on DATE,
+    ends_on DATE,
+    calendar VARCHAR(10) CHECK (calendar IN ('gregorian', 'hijri')),
+    month SMALLINT,
+    day SMALLINT,
+    duration_days SMALLINT NOT NULL DEFAULT 1,
+    replaces_event_id UUID REFERENCES calendar_events(id) ON DELETE CASCADE,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create indexes for better performance
+CREATE UNIQUE INDEX IF NOT EXISTS idx_school_terms_school_year_semester
+    ON school_terms(COALESCE(school_id, '00000000-0000-0000-0000-000000000000'), school_year, semester);
+CREATE INDEX IF NOT EXISTS idx_calendar_events_school_id ON calendar_events(school_id);
+CREATE INDEX IF NOT EXISTS idx_calendar_events_dates ON calendar_events(starts_on, ends_on);
+
+-- Create triggers to update updated_at timestamp
+CREATE TRIGGER update_school_terms_updated_at
+    BEFORE UPDATE ON school_terms
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TRIGGER update_calendar_events_updated_at
+    BEFORE UPDATE ON calendar_events
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- Add constraints to validate dates
+ALTER TABLE school_terms ADD CONSTRAINT check_school_terms_dates
+    CHECK (ends_on >= starts_on);
+ALTER TABLE calendar_events ADD CONSTRAINT check_calendar_events_dates
+    CHECK (
+        (school_year IS NOT NULL AND starts_on IS NOT NULL AND ends_on >= starts_on AND calendar IS NULL)
+        OR (school_year IS NULL AND starts_on IS NULL AND calendar IS NOT NULL
+            AND month BETWEEN 1 AND 12 AND day BETWEEN 1 AND 31)
+    );
+ALTER TABLE calendar_events ADD CONSTRAINT check_calendar_events_duration_days
+    CHECK (duration_days BETWEEN 1 AND 366);
+
+-- Insert the national holidays that fall on the same date every year
+INSERT INTO calendar_events (event_type, name, calendar, month, day, duration_days) VALUES
+('holiday', 'رأس السنة الميلادية', 'gregorian', 1, 1, 1),
+('holiday', 'العيد الوطني وعيد التحرير', 'gregorian', 2, 25, 2),
+('holiday', 'رأس السنة الهجرية', 'hijri', 1, 1, 1),
+('holiday', 'المولد النبوي الشريف', 'hijri', 3, 12, 1),
+('holiday', 'الإسراء والمعراج', 'hijri', 7, 27, 1),
+('holiday', 'عيد الفطر', 'hijri', 10, 1, 3),
+('holiday', 'يوم عرفة وعيد الأضحى', 'hijri', 12, 9, 4);
+
+-- Comments for clarity
+COMMENT ON TABLE school_terms IS 'Semester dates; a school row overrides the ministry row for the same year and semester';
+COMMENT ON COLUMN calendar_events.calendar IS 'Calendar of month and day for an event that recurs every year; Hijri dates are computed and may be a day off the sighting';
+COMMENT ON COLUMN calendar_events.replaces_event_id IS 'Recurring event whose occurrence in school_year this event replaces, e.g. the announced dates of Eid';
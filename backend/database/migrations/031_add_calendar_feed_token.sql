@@ -0,0 +1,10 @@
+-- Add a secret token to users for their calendar subscription feed
+ALTER TABLE users ADD COLUMN IF NOT EXISTS calendar_feed_token_hash VARCHAR(64);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS calendar_feed_created_at TIMESTAMP WITH TIME ZONE;
+
+-- Create indexes for better performance
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_calendar_feed_token_hash
+    ON users(calendar_feed_token_hash) WHERE calendar_feed_token_hash IS NOT NULL;
+
+-- Comments for clarity
+COMMENT ON COLUMN users.calendar_feed_token_hash IS 'SHA-256 of the token in the teacher''s .ics feed URL; only the hash is kept, so a lost URL is replaced by issuing a new one';
@@ -0,0 +1,15 @@
+-- Messages are marked sending while a provider is called, so that each
+-- delivery is recorded on its own instead of in one batch transaction
+ALTER TABLE notification_outbox ADD COLUMN IF NOT EXISTS claimed_at TIMESTAMP WITH TIME ZONE;
+
+ALTER TABLE notification_outbox DROP CONSTRAINT IF EXISTS notification_outbox_status_check;
+ALTER TABLE notification_outbox ADD CONSTRAINT notification_outbox_status_check
+    CHECK (status IN ('pending', 'sending', 'sent', 'failed', 'cancelled'));
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_notification_outbox_sending
+    ON notification_outbox(claimed_at) WHERE status = 'sending';
+
+-- Comments for clarity
+COMMENT ON COLUMN notification_outbox.status IS 'sending while a provider is called; cancelled when the attendance was corrected before delivery';
+COMMENT ON COLUMN notification_outbox.claimed_at IS 'When delivery started; a message left sending for too long was interrupted and is queued again';
@@ -0,0 +1,17 @@
+-- Track when grading of an attempt was completed. Expired attempts keep
+-- their status when graded, so the status alone does not tell.
+ALTER TABLE test_submissions ADD COLUMN IF NOT EXISTS graded_at TIMESTAMP WITH TIME ZONE;
+
+UPDATE test_submissions ts SET graded_at = ts.updated_at
+WHERE ts.graded_at IS NULL AND ts.status IN ('graded', 'expired')
+  AND NOT EXISTS (
+      SELECT 1 FROM test_submission_answers tsa
+      WHERE tsa.submission_id = ts.id AND tsa.grading_status IN ('pending', 'pending_manual')
+  );
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_test_submissions_graded
+    ON test_submissions(student_id) WHERE graded_at IS NOT NULL;
+
+-- Comments for clarity
+COMMENT ON COLUMN test_submissions.graded_at IS 'When every answer of a submitted or expired attempt was graded; scores are final from then on';
@@ -0,0 +1,14 @@
+-- Add sign-in with a teacher-issued access code to students
+ALTER TABLE students
+    ADD COLUMN IF NOT EXISTS access_code_hash VARCHAR(255),
+    ADD COLUMN IF NOT EXISTS access_code_created_at TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN IF NOT EXISTS access_code_attempts INTEGER DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS last_login_at TIMESTAMP WITH TIME ZONE;
+
+-- Add constraint to keep failed access code attempts non-negative
+ALTER TABLE students ADD CONSTRAINT check_students_access_code_attempts
+    CHECK (access_code_attempts >= 0);
+
+-- Comments for clarity
+COMMENT ON COLUMN students.access_code_hash IS 'Access code a teacher gives the student to sign in for online tests; a new code replaces it';
+COMMENT ON COLUMN students.access_code_attempts IS 'Failed sign-ins since the last successful one; the code stops working at the limit';
@@ -0,0 +1,26 @@
+-- Index the substring and tag matches of question search, which the
+-- full-text index cannot serve
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- Normalize every tag of an array, keeping them apart
+CREATE OR REPLACE FUNCTION normalize_arabic_tags(input TEXT[])
+RETURNS TEXT[] AS $$
+    SELECT COALESCE(array_agg(normalize_arabic(tag)), '{}') FROM unnest(input) AS tag;
+$$ LANGUAGE sql IMMUTABLE;
+
+ALTER TABLE questions ADD COLUMN IF NOT EXISTS search_text TEXT GENERATED ALWAYS AS (
+    normalize_arabic(question_text) || ' ' || normalize_arabic(question_text_arabic) || ' ' ||
+    normalize_arabic(explanation) || ' ' || normalize_arabic(explanation_arabic)
+) STORED;
+
+ALTER TABLE questions ADD COLUMN IF NOT EXISTS search_tags TEXT[] GENERATED ALWAYS AS (
+    normalize_arabic_tags(tags)
+) STORED;
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_questions_search_text ON questions USING GIN(search_text gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_questions_search_tags ON questions USING GIN(search_tags);
+
+-- Comments for clarity
+COMMENT ON COLUMN questions.search_text IS 'Normalized question text and explanations, for substring matches of search terms';
+COMMENT ON COLUMN questions.search_tags IS 'Normalized tags, for whole-tag matches of search terms';
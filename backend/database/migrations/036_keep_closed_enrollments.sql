@@ -0,0 +1,11 @@
+-- Keep closed enrollments as history: re-enrolling a student adds a new
+-- row instead of reopening the old one, so a student may appear in a
+-- class more than once but be active in it only once
+ALTER TABLE student_classes DROP CONSTRAINT IF EXISTS student_classes_student_id_class_id_key;
+
+-- Create indexes for better performance
+CREATE UNIQUE INDEX IF NOT EXISTS idx_student_classes_active_enrollment
+    ON student_classes(student_id, class_id) WHERE is_active = true;
+
+-- Comments for clarity
+COMMENT ON INDEX idx_student_classes_active_enrollment IS 'A student has at most one active enrollment per class; closed ones are kept';
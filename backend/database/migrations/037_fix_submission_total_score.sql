@@ -0,0 +1,40 @@
+-- Fix the score trigger of test_submission_answers: the percentage summed
+-- the answers next to an ungrouped tests.total_points, so every change to
+-- an answer failed
+CREATE OR REPLACE FUNCTION update_submission_total_score()
+RETURNS TRIGGER AS $$
+DECLARE
+    target_submission_id UUID;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        target_submission_id := OLD.submission_id;
+    ELSE
+        target_submission_id := NEW.submission_id;
+    END IF;
+
+    UPDATE test_submissions ts
+    SET
+        total_score = scores.total,
+        percentage_score = CASE
+            WHEN t.total_points > 0 THEN ROUND((scores.total * 100.0) / t.total_points, 2)
+            ELSE 0.00
+        END
+    FROM tests t, (
+        SELECT COALESCE(SUM(points_awarded), 0) AS total
+        FROM test_submission_answers
+        WHERE submission_id = target_submission_id
+    ) scores
+    WHERE ts.test_id = t.id AND ts.id = target_submission_id;
+
+    -- Update is_passed based on passing score
+    UPDATE test_submissions ts
+    SET is_passed = (ts.total_score >= t.passing_score)
+    FROM tests t
+    WHERE ts.test_id = t.id AND ts.id = target_submission_id;
+
+    IF TG_OP = 'DELETE' THEN
+        RETURN OLD;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
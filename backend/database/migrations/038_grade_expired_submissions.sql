@@ -0,0 +1,19 @@
+-- Expired attempts are graded like submitted ones: once every answer is
+-- graded their status becomes graded. A missing submitted_at is what
+-- tells them apart.
+UPDATE test_submissions ts
+SET status = 'graded',
+    graded_at = COALESCE(ts.graded_at, ts.updated_at),
+    answers = CASE WHEN ts.answers = '{}' THEN COALESCE((
+        SELECT jsonb_object_agg(tsa.question_id::text, tsa.student_answer)
+        FROM test_submission_answers tsa WHERE tsa.submission_id = ts.id
+    ), '{}') ELSE ts.answers END,
+    updated_at = CURRENT_TIMESTAMP
+WHERE ts.status = 'expired'
+  AND NOT EXISTS (
+      SELECT 1 FROM test_submission_answers tsa
+      WHERE tsa.submission_id = ts.id AND tsa.grading_status IN ('pending', 'pending_manual')
+  );
+
+-- Comments for clarity
+COMMENT ON COLUMN test_submissions.graded_at IS 'When every answer of a submitted or expired attempt was graded; the status is graded from then on';
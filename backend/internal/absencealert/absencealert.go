@@ -0,0 +1,118 @@
+// Package absencealert flags students whose attendance in a class crosses
+// a school's early-warning thresholds: a run of consecutive absences, a
+// high absence rate over the semester, or repeated lateness.
+package absencealert
+
+import (
+	"fmt"
+	"time"
+)
+
+// Rule types
+const (
+	RuleConsecutiveAbsences = "consecutive_absences"
+	RuleAbsenceRate         = "absence_rate"
+	RuleRepeatedLateness    = "repeated_lateness"
+)
+
+// Thresholds configures the rules. A zero threshold turns its rule off.
+type Thresholds struct {
+	ConsecutiveAbsences int
+	// AbsenceRate is a percentage of the recorded days
+	AbsenceRate float64
+	// MinRecordedDays keeps the rate from firing on the first days of the
+	// semester, when one absence is already a high rate
+	MinRecordedDays int
+	LateCount       int
+}
+
+// Record is one day of a student's attendance in a class
+type Record struct {
+	Date   time.Time
+	Status string
+}
+
+// Finding is a rule a student's attendance broke. Since identifies the
+// occurrence: the first day of a run of absences, or the first recorded
+// day of the semester for the other rules.
+type Finding struct {
+	Rule      string
+	Value     float64
+	Threshold float64
+	Since     time.Time
+}
+
+// Evaluate checks a student's records for a class, ordered by date, and
+// returns the rules they break. Every run of absences at or over the
+// threshold is reported, so records entered late are still caught.
+func Evaluate(records []Record, t Thresholds) []Finding {
+	if len(records) == 0 {
+		return nil
+	}
+
+	var findings []Finding
+	var absent, late, run int
+	var runStart time.Time
+	flushRun := func() {
+		if t.ConsecutiveAbsences > 0 && run >= t.ConsecutiveAbsences {
+			findings = append(findings, Finding{
+				Rule:      RuleConsecutiveAbsences,
+				Value:     float64(run),
+				Threshold: float64(t.ConsecutiveAbsences),
+				Since:     runStart,
+			})
+		}
+		run = 0
+	}
+
+	for _, record := range records {
+		switch record.Status {
+		case "absent":
+			absent++
+			if run == 0 {
+				runStart = record.Date
+			}
+			run++
+			continue
+		case "late":
+			late++
+		}
+		flushRun()
+	}
+	flushRun()
+
+	first := records[0].Date
+	if t.AbsenceRate > 0 && len(records) >= t.MinRecordedDays {
+		rate := float64(absent) / float64(len(records)) * 100
+		if rate >= t.AbsenceRate {
+			findings = append(findings, Finding{
+				Rule:      RuleAbsenceRate,
+				Value:     rate,
+				Threshold: t.AbsenceRate,
+				Since:     first,
+			})
+		}
+	}
+	if t.LateCount > 0 && late >= t.LateCount {
+		findings = append(findings, Finding{
+			Rule:      RuleRepeatedLateness,
+			Value:     float64(late),
+			Threshold: float64(t.LateCount),
+			Since:     first,
+		})
+	}
+	return findings
+}
+
+// Describe words a finding in Arabic for alerts and messages to parents
+func Describe(f Finding) string {
+	switch f.Rule {
+	case RuleConsecutiveAbsences:
+		return fmt.Sprintf("غياب %d أيام متتالية ابتداءً من %s", int(f.Value), f.Since.Format("02/01/2006"))
+	case RuleAbsenceRate:
+		return fmt.Sprintf("نسبة غياب %.0f%% من أيام الفصل الدراسي", f.Value)
+	case RuleRepeatedLateness:
+		return fmt.Sprintf("تأخر %d مرات خلال الفصل الدراسي", int(f.Value))
+	}
+	return f.Rule
+}
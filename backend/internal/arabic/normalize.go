@@ -0,0 +1,85 @@
+// Package arabic provides helpers for working with Arabic text.
+package arabic
+
+import (
+	"strings"
+	"unicode"
+)
+
+// normalizer folds the letter variants that teachers and students type
+// interchangeably. It must stay in sync with the normalize_arabic SQL
+// function so that search terms match the indexed text.
+var normalizer = strings.NewReplacer(
+	"أ", "ا", "إ", "ا", "آ", "ا", "ٱ", "ا",
+	"ة", "ه",
+	"ى", "ي",
+	"٠", "0", "١", "1", "٢", "2", "٣", "3", "٤", "4",
+	"٥", "5", "٦", "6", "٧", "7", "٨", "8", "٩", "9",
+)
+
+// IsDiacritic reports whether r is a tashkeel mark, the superscript alef or
+// the tatweel, all of which carry no meaning for matching purposes.
+func IsDiacritic(r rune) bool {
+	return (r >= 0x064B && r <= 0x065F) || r == 0x0670 || r == 0x0640
+}
+
+// StripDiacritics removes tashkeel and tatweel from s
+func StripDiacritics(s string) string {
+	return strings.Map(func(r rune) rune {
+		if IsDiacritic(r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// Normalize prepares text for comparison: it strips diacritics, unifies alef
+// variants, maps taa marbuta to haa and alef maqsura to yaa, converts
+// Arabic-Indic digits, lower-cases Latin letters and collapses whitespace.
+func Normalize(s string) string {
+	s = normalizer.Replace(StripDiacritics(s))
+	return strings.Join(strings.Fields(strings.ToLower(s)), " ")
+}
+
+// Terms splits normalized text into search terms made only of letters and
+// digits, dropping duplicates
+func Terms(s string) []string {
+	fields := strings.FieldsFunc(Normalize(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	seen := map[string]bool{}
+	var terms []string
+	for _, field := range fields {
+		if seen[field] {
+			continue
+		}
+		seen[field] = true
+		terms = append(terms, field)
+	}
+	return terms
+}
+
+// ContainsArabic reports whether s contains any Arabic letter
+func ContainsArabic(s string) bool {
+	for _, r := range s {
+		if unicode.Is(unicode.Arabic, r) && unicode.IsLetter(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// digits maps Arabic-Indic and Eastern Arabic-Indic digits to ASCII
+var digits = strings.NewReplacer(
+	"٠", "0", "١", "1", "٢", "2", "٣", "3", "٤", "4",
+	"٥", "5", "٦", "6", "٧", "7", "٨", "8", "٩", "9",
+	"۰", "0", "۱", "1", "۲", "2", "۳", "3", "۴", "4",
+	"۵", "5", "۶", "6", "۷", "7", "۸", "8", "۹", "9",
+)
+
+// WesternDigits converts Arabic-Indic digits in s to ASCII digits and leaves
+// everything else as it is
+func WesternDigits(s string) string {
+	return digits.Replace(s)
+}
@@ -0,0 +1,205 @@
+package auth
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+
+	"moalemplus/internal/models"
+)
+
+// parentTokenTTL keeps a parent signed in for the day
+const parentTokenTTL = 12 * time.Hour
+
+// ParentOTPMaxAttempts is how many wrong access codes a parent may enter
+// before the code stops working and a teacher has to issue a new one
+const ParentOTPMaxAttempts = 5
+
+// ParentLogin authenticates a parent by civil ID and either their password
+// or a one-time access code issued by their child's teacher. Only parents
+// who are an active contact of an enrolled student can sign in.
+func (s *Service) ParentLogin(c *fiber.Ctx) error {
+	var req models.ParentLoginRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid request body",
+		})
+	}
+
+	req.CivilID = strings.TrimSpace(req.CivilID)
+	req.OTP = strings.TrimSpace(req.OTP)
+	if req.CivilID == "" || (req.Password == "" && req.OTP == "") {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Civil ID and a password or access code are required",
+		})
+	}
+
+	// Find parent by civil ID
+	var parent models.Parent
+	var passwordHash string
+	err := s.db.QueryRow(`
+		SELECT p.id, p.civil_id, p.first_name, p.last_name, p.arabic_name, p.phone_primary, p.phone_secondary,
+		       p.email, p.workplace, p.job_title, p.address, p.is_active, p.created_at, p.updated_at,
+		       COALESCE(p.password_hash, '')
+		FROM parents p
+		WHERE p.civil_id = $1 AND p.is_active = true
+		  AND EXISTS (
+		      SELECT 1 FROM student_parents sp
+		      JOIN students s ON sp.student_id = s.id
+		      WHERE sp.parent_id = p.id AND sp.is_active = true AND s.is_active = true
+		  )
+	`, req.CivilID).Scan(&parent.ID, &parent.CivilID, &parent.FirstName, &parent.LastName, &parent.ArabicName,
+		&parent.PhonePrimary, &parent.PhoneSecondary, &parent.Email, &parent.Workplace, &parent.JobTitle,
+		&parent.Address, &parent.IsActive, &parent.CreatedAt, &parent.UpdatedAt,
+		&passwordHash)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.Status(fiber.StatusUnauthorized).JSON(models.ErrorResponse{
+				Error:   true,
+				Message: "Invalid credentials",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Database error",
+		})
+	}
+
+	if req.OTP != "" {
+		if status, message := s.useParentOTP(parent.ID, req.OTP); status != 0 {
+			return c.Status(status).JSON(models.ErrorResponse{
+				Error:   true,
+				Message: message,
+			})
+		}
+	} else {
+		// Verify password
+		if passwordHash == "" || bcrypt.CompareHashAndPassword([]byte(passwordHash), []byte(req.Password)) != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(models.ErrorResponse{
+				Error:   true,
+				Message: "Invalid credentials",
+			})
+		}
+		_, err = s.db.Exec("UPDATE parents SET last_login_at = CURRENT_TIMESTAMP WHERE id = $1", parent.ID)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{
+				Error:   true,
+				Message: "Database error",
+			})
+		}
+	}
+
+	accessToken, expiresIn, err := generateRoleToken(RoleParent, "parent_id", parent.ID, parentTokenTTL)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to generate tokens",
+		})
+	}
+
+	return c.JSON(models.ParentAuthResponse{
+		Parent:      parent,
+		PasswordSet: passwordHash != "",
+		AccessToken: accessToken,
+		ExpiresIn:   expiresIn,
+	})
+}
+
+// useParentOTP checks a parent's one-time access code and uses it up. A
+// non-zero status means the sign-in must be rejected with the returned
+// message. Every guess uses up an attempt before it is checked and the code
+// is cleared only if it is still the one that was checked, so parallel
+// requests can neither get past the limit nor sign in twice with one code.
+func (s *Service) useParentOTP(parentID uuid.UUID, otp string) (int, string) {
+	var otpHash string
+	err := s.db.QueryRow(`
+		UPDATE parents SET otp_attempts = otp_attempts + 1
+		WHERE id = $1 AND otp_hash IS NOT NULL AND otp_expires_at > NOW() AND otp_attempts < $2
+		RETURNING otp_hash
+	`, parentID, ParentOTPMaxAttempts).Scan(&otpHash)
+	if err == sql.ErrNoRows {
+		return fiber.StatusUnauthorized, "Access code is invalid or has expired. Ask the teacher for a new one"
+	}
+	if err != nil {
+		return fiber.StatusInternalServerError, "Database error"
+	}
+	if bcrypt.CompareHashAndPassword([]byte(otpHash), []byte(otp)) != nil {
+		return fiber.StatusUnauthorized, "Invalid credentials"
+	}
+
+	result, err := s.db.Exec(`
+		UPDATE parents SET otp_hash = NULL, otp_expires_at = NULL, otp_attempts = 0,
+		       last_login_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND otp_hash = $2
+	`, parentID, otpHash)
+	if err != nil {
+		return fiber.StatusInternalServerError, "Database error"
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return fiber.StatusUnauthorized, "Access code is invalid or has expired. Ask the teacher for a new one"
+	}
+	return 0, ""
+}
+
+// SetParentPassword sets the signed-in parent's portal password, so later
+// sign-ins no longer need an access code
+func (s *Service) SetParentPassword(c *fiber.Ctx) error {
+	parentID := c.Locals("parent_id").(uuid.UUID)
+
+	var req models.SetParentPasswordRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid request body",
+		})
+	}
+
+	if len(req.Password) < 8 {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Password must be at least 8 characters",
+		})
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to hash password",
+		})
+	}
+
+	result, err := s.db.Exec(`
+		UPDATE parents SET password_hash = $1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $2 AND is_active = true
+	`, string(hashedPassword), parentID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to set password",
+		})
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Parent not found",
+		})
+	}
+
+	return c.JSON(models.SuccessResponse{
+		Success: true,
+		Message: "Password set successfully",
+	})
+}
+
+// VerifyParentToken verifies a parent token and returns the parent ID
+func (s *Service) VerifyParentToken(tokenString string) (uuid.UUID, error) {
+	return verifyRoleToken(tokenString, RoleParent, "parent_id")
+}
@@ -320,6 +320,11 @@ func (s *Service) VerifyToken(tokenString string) (uuid.UUID, error) {
 		return uuid.Nil, errors.New("invalid token claims")
 	}
 
+	// Student and parent tokens carry a role and must not reach teacher routes
+	if _, hasRole := claims["role"]; hasRole {
+		return uuid.Nil, errors.New("invalid token role")
+	}
+
 	userIDStr, ok := claims["user_id"].(string)
 	if !ok {
 		return uuid.Nil, errors.New("invalid user ID in token")
@@ -0,0 +1,189 @@
+package auth
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+
+	"moalemplus/internal/arabic"
+	"moalemplus/internal/models"
+)
+
+// Roles carried in the "role" claim of tokens issued to principals other
+// than teachers. Teacher tokens have no role claim.
+const (
+	RoleStudent = "student"
+	RoleParent  = "parent"
+)
+
+// studentTokenTTL covers a school day of test sessions
+const studentTokenTTL = 3 * time.Hour
+
+// StudentAccessCodeMaxAttempts is how many wrong access codes may be
+// entered in a row before the code stops working and a teacher has to
+// issue a new one
+const StudentAccessCodeMaxAttempts = 5
+
+// StudentLogin authenticates a student by student number and the access
+// code issued by their teacher
+func (s *Service) StudentLogin(c *fiber.Ctx) error {
+	var req models.StudentLoginRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid request body",
+		})
+	}
+
+	req.StudentNumber = strings.TrimSpace(req.StudentNumber)
+	req.AccessCode = arabic.WesternDigits(strings.TrimSpace(req.AccessCode))
+	if req.StudentNumber == "" || req.AccessCode == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Student number and access code are required",
+		})
+	}
+
+	// Find student by student number
+	var student models.Student
+	err := s.db.QueryRow(`
+		SELECT id, student_number, civil_id, first_name, last_name, arabic_name, date_of_birth,
+		       gender, nationality, enrollment_date, is_active, created_at, updated_at
+		FROM students WHERE student_number = $1 AND is_active = true
+	`, req.StudentNumber).Scan(&student.ID, &student.StudentNumber, &student.CivilID,
+		&student.FirstName, &student.LastName, &student.ArabicName, &student.DateOfBirth,
+		&student.Gender, &student.Nationality, &student.EnrollmentDate,
+		&student.IsActive, &student.CreatedAt, &student.UpdatedAt)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.Status(fiber.StatusUnauthorized).JSON(models.ErrorResponse{
+				Error:   true,
+				Message: "Invalid credentials",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Database error",
+		})
+	}
+
+	// Every guess uses up an attempt before it is checked, so parallel
+	// requests cannot get past the limit; a correct code gives it back.
+	// The code stops working once the attempts run out.
+	var codeHash string
+	err = s.db.QueryRow(`
+		UPDATE students SET access_code_attempts = access_code_attempts + 1
+		WHERE id = $1 AND access_code_hash IS NOT NULL AND access_code_attempts < $2
+		RETURNING access_code_hash
+	`, student.ID, StudentAccessCodeMaxAttempts).Scan(&codeHash)
+	if err == sql.ErrNoRows {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Access code is invalid or locked. Ask your teacher for a new one",
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Database error",
+		})
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(codeHash), []byte(req.AccessCode)); err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid credentials",
+		})
+	}
+	_, err = s.db.Exec(`
+		UPDATE students SET access_code_attempts = 0, last_login_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+	`, student.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Database error",
+		})
+	}
+
+	accessToken, expiresIn, err := generateRoleToken(RoleStudent, "student_id", student.ID, studentTokenTTL)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to generate tokens",
+		})
+	}
+
+	return c.JSON(models.StudentAuthResponse{
+		Student:     student,
+		AccessToken: accessToken,
+		ExpiresIn:   expiresIn,
+	})
+}
+
+// VerifyStudentToken verifies a student token and returns the student ID
+func (s *Service) VerifyStudentToken(tokenString string) (uuid.UUID, error) {
+	return verifyRoleToken(tokenString, RoleStudent, "student_id")
+}
+
+// generateRoleToken issues an access token for a non-teacher principal,
+// identified by idClaim and marked with role
+func generateRoleToken(role, idClaim string, id uuid.UUID, ttl time.Duration) (string, int64, error) {
+	expiry := time.Now().Add(ttl)
+	claims := jwt.MapClaims{
+		idClaim: id.String(),
+		"role":  role,
+		"exp":   expiry.Unix(),
+		"iat":   time.Now().Unix(),
+		"type":  "access",
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	tokenString, err := token.SignedString([]byte(getJWTSecret()))
+	if err != nil {
+		return "", 0, err
+	}
+	return tokenString, expiry.Unix(), nil
+}
+
+// verifyRoleToken verifies a token issued by generateRoleToken for role and
+// returns the ID in idClaim
+func verifyRoleToken(tokenString, role, idClaim string) (uuid.UUID, error) {
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, errors.New("unexpected signing method")
+		}
+		return []byte(getJWTSecret()), nil
+	})
+
+	if err != nil || !token.Valid {
+		return uuid.Nil, errors.New("invalid token")
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return uuid.Nil, errors.New("invalid token claims")
+	}
+
+	if claimRole, _ := claims["role"].(string); claimRole != role {
+		return uuid.Nil, errors.New("invalid token role")
+	}
+
+	idStr, ok := claims[idClaim].(string)
+	if !ok {
+		return uuid.Nil, errors.New("invalid ID in token")
+	}
+
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return uuid.Nil, errors.New("invalid ID format")
+	}
+
+	return id, nil
+}
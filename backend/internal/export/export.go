@@ -0,0 +1,52 @@
+// Package export writes tables, such as class rosters and attendance
+// sheets, as CSV files and Excel workbooks. PDF output goes through the pdf
+// package.
+package export
+
+import (
+	"encoding/csv"
+	"io"
+)
+
+// Supported export formats
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+	FormatPDF  = "pdf"
+)
+
+// ContentTypes are the MIME types of the export formats
+var ContentTypes = map[string]string{
+	FormatCSV:  "text/csv; charset=utf-8",
+	FormatXLSX: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	FormatPDF:  "application/pdf",
+}
+
+// Table is a titled grid of text cells. Details are printed above the
+// columns in workbooks and PDFs; Numeric columns are stored as numbers in
+// workbooks; Widths are relative column widths.
+type Table struct {
+	Title   string
+	Details []string
+	Columns []string
+	Numeric []bool
+	Widths  []float64
+	Rows    [][]string
+	Notes   []string
+}
+
+// WriteCSV writes the table's columns and rows. The UTF-8 byte order mark
+// lets Excel open Arabic text correctly.
+func WriteCSV(w io.Writer, t *Table) error {
+	if _, err := io.WriteString(w, "\xef\xbb\xbf"); err != nil {
+		return err
+	}
+	writer := csv.NewWriter(w)
+	if err := writer.Write(t.Columns); err != nil {
+		return err
+	}
+	if err := writer.WriteAll(t.Rows); err != nil {
+		return err
+	}
+	return writer.Error()
+}
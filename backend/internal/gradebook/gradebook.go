@@ -0,0 +1,119 @@
+// Package gradebook computes weighted semester grades from a class's grade
+// categories, items and recorded scores.
+//
+// Each category's percentage is the points earned over the points possible
+// on its counted items, and the grade is the weighted average of the
+// category percentages. Categories that have nothing to count are left out
+// and the remaining weights are scaled up, so a term with no homework yet
+// is graded on the other categories alone.
+package gradebook
+
+import (
+	"math"
+
+	"github.com/google/uuid"
+)
+
+// Category is a weighted part of the grade; Weight is a percentage
+type Category struct {
+	ID     uuid.UUID
+	Weight float64
+}
+
+// Item is a graded piece of work in a category
+type Item struct {
+	ID         uuid.UUID
+	CategoryID uuid.UUID
+	MaxScore   float64
+}
+
+// Score is a student's recorded result on an item. A nil Points means the
+// teacher has not entered a score yet.
+type Score struct {
+	Points  *float64
+	Excused bool
+}
+
+// CategoryResult holds a student's percentage in one category. Either is
+// nil when the category has nothing to count.
+type CategoryResult struct {
+	Running *float64
+	Final   *float64
+}
+
+// Result is a student's grade. Running counts only the items with a
+// recorded score; Final counts every item that is not excused, treating a
+// missing score as zero. Both are percentages rounded to two decimals and
+// nil when nothing counts.
+type Result struct {
+	Categories map[uuid.UUID]CategoryResult
+	Running    *float64
+	Final      *float64
+}
+
+// Compute grades one student. scores holds the student's scores by item.
+func Compute(categories []Category, items []Item, scores map[uuid.UUID]Score) Result {
+	running := map[uuid.UUID]*totals{}
+	final := map[uuid.UUID]*totals{}
+
+	for _, item := range items {
+		score := scores[item.ID]
+		if score.Excused {
+			continue
+		}
+		earned := 0.0
+		if score.Points != nil {
+			earned = *score.Points
+			add(running, item.CategoryID, earned, item.MaxScore)
+		}
+		add(final, item.CategoryID, earned, item.MaxScore)
+	}
+
+	result := Result{Categories: make(map[uuid.UUID]CategoryResult, len(categories))}
+	var runningSum, runningWeight, finalSum, finalWeight float64
+	for _, category := range categories {
+		var r CategoryResult
+		if t := running[category.ID]; t != nil && t.possible > 0 {
+			p := t.earned / t.possible * 100
+			runningSum += p * category.Weight
+			runningWeight += category.Weight
+			r.Running = percentage(p)
+		}
+		if t := final[category.ID]; t != nil && t.possible > 0 {
+			p := t.earned / t.possible * 100
+			finalSum += p * category.Weight
+			finalWeight += category.Weight
+			r.Final = percentage(p)
+		}
+		result.Categories[category.ID] = r
+	}
+
+	if runningWeight > 0 {
+		result.Running = percentage(runningSum / runningWeight)
+	}
+	if finalWeight > 0 {
+		result.Final = percentage(finalSum / finalWeight)
+	}
+	return result
+}
+
+// totals sums the points of a category's counted items
+type totals struct {
+	earned, possible float64
+}
+
+func add(sums map[uuid.UUID]*totals, categoryID uuid.UUID, earned, possible float64) {
+	t := sums[categoryID]
+	if t == nil {
+		t = &totals{}
+		sums[categoryID] = t
+	}
+	t.earned += earned
+	t.possible += possible
+}
+
+// percentage rounds p to two decimals
+func percentage(p float64) *float64 {
+	rounded := math.Round(p*100) / 100
+	return &rounded
+}
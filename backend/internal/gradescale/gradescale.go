@@ -0,0 +1,93 @@
+// Package gradescale maps numeric grades to the descriptors used on Kuwaiti
+// report cards (ممتاز, جيد جداً, جيد, مقبول, ضعيف). A scale is a list of
+// bands, each starting at a minimum grade; the thresholds differ between
+// primary, intermediate and secondary schools.
+package gradescale
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"moalemplus/internal/models"
+)
+
+// SchoolTypes are the school stages a scale can be defined for
+var SchoolTypes = []string{"primary", "intermediate", "secondary"}
+
+// IsSchoolType reports whether s is a known school stage
+func IsSchoolType(s string) bool {
+	for _, t := range SchoolTypes {
+		if s == t {
+			return true
+		}
+	}
+	return false
+}
+
+// Longest letter and descriptors a band can have, as stored in
+// grading_scales
+const (
+	maxLetterLength     = 5
+	maxDescriptorLength = 50
+)
+
+// Scale is a grading scale with its bands ordered from the highest minimum
+// grade down
+type Scale []models.GradingBand
+
+// New checks bands and returns them as a scale. Thresholds must be between
+// 0 and 100 and distinct, the lowest band must start at 0 so every grade has
+// a band, and the passing bands must be the top ones.
+func New(bands []models.GradingBand) (Scale, error) {
+	if len(bands) < 2 {
+		return nil, fmt.Errorf("A grading scale needs at least two bands")
+	}
+
+	scale := make(Scale, len(bands))
+	copy(scale, bands)
+	sort.SliceStable(scale, func(i, j int) bool { return scale[i].MinGrade > scale[j].MinGrade })
+
+	for i := range scale {
+		band := &scale[i]
+		band.Letter = strings.TrimSpace(band.Letter)
+		band.Descriptor = strings.TrimSpace(band.Descriptor)
+		band.DescriptorArabic = strings.TrimSpace(band.DescriptorArabic)
+		if band.Letter == "" || band.Descriptor == "" || band.DescriptorArabic == "" {
+			return nil, fmt.Errorf("Every band needs a letter, a descriptor and an Arabic descriptor")
+		}
+		if utf8.RuneCountInString(band.Letter) > maxLetterLength {
+			return nil, fmt.Errorf("Letter %q is too long", band.Letter)
+		}
+		if utf8.RuneCountInString(band.Descriptor) > maxDescriptorLength ||
+			utf8.RuneCountInString(band.DescriptorArabic) > maxDescriptorLength {
+			return nil, fmt.Errorf("Descriptors of band %q must be at most %d characters", band.Letter, maxDescriptorLength)
+		}
+		if band.MinGrade < 0 || band.MinGrade > 100 {
+			return nil, fmt.Errorf("Minimum grades must be between 0 and 100")
+		}
+		if i > 0 && band.MinGrade == scale[i-1].MinGrade {
+			return nil, fmt.Errorf("Two bands start at %g", band.MinGrade)
+		}
+		if i > 0 && band.IsPassing && !scale[i-1].IsPassing {
+			return nil, fmt.Errorf("Band %q passes but a higher band fails", band.Letter)
+		}
+	}
+	if scale[len(scale)-1].MinGrade != 0 {
+		return nil, fmt.Errorf("The lowest band must start at 0")
+	}
+	return scale, nil
+}
+
+// Classify returns the band a grade falls in: the one with the highest
+// minimum grade not above it
+func (s Scale) Classify(grade float64) *models.GradingBand {
+	for i := range s {
+		if grade >= s[i].MinGrade {
+			band := s[i]
+			return &band
+		}
+	}
+	return nil
+}
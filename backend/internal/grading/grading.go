@@ -0,0 +1,151 @@
+// Package grading scores student answers against the question bank's
+// correct answers.
+//
+// Objective question types are graded automatically. Short answer and essay
+// questions are left for the teacher.
+package grading
+
+import (
+	"encoding/json"
+	"math"
+	"strconv"
+	"strings"
+
+	"moalemplus/internal/arabic"
+	"moalemplus/internal/models"
+)
+
+// Grading states of a test_submission_answers row
+const (
+	StatusPending        = "pending"
+	StatusAutoGraded     = "auto_graded"
+	StatusPendingManual  = "pending_manual"
+	StatusManuallyGraded = "manually_graded"
+)
+
+// Result is the outcome of grading one answer
+type Result struct {
+	Points    int
+	IsCorrect bool
+	Status    string
+}
+
+// Grade scores answer to a question of the given type worth points. The
+// answer must already use the canonical option letters. Unanswered
+// questions score zero without needing a teacher.
+func Grade(questionType, correctAnswer string, points int, answer string) Result {
+	answer = strings.TrimSpace(answer)
+	if answer == "" {
+		return Result{Status: StatusAutoGraded}
+	}
+
+	var correct bool
+	switch questionType {
+	case models.QuestionTypeMultipleChoice:
+		correct = strings.EqualFold(answer, strings.TrimSpace(correctAnswer))
+	case models.QuestionTypeTrueFalse:
+		value, ok := parseTrueFalse(answer)
+		correct = ok && strconv.FormatBool(value) == strings.ToLower(strings.TrimSpace(correctAnswer))
+	case models.QuestionTypeFillBlank:
+		correct = MatchesAccepted(answer, correctAnswer)
+	case models.QuestionTypeMatching:
+		return gradeMatching(correctAnswer, points, answer)
+	default:
+		return Result{Status: StatusPendingManual}
+	}
+
+	if correct {
+		return Result{Points: points, IsCorrect: true, Status: StatusAutoGraded}
+	}
+	return Result{Status: StatusAutoGraded}
+}
+
+// trueFalseWords are the spellings students use for true and false
+var trueFalseWords = map[string]bool{
+	"true": true, "t": true, "صح": true, "صحيح": true, "نعم": true,
+	"false": false, "f": false, "خطا": false, "خاطئ": false, "لا": false,
+}
+
+func parseTrueFalse(answer string) (bool, bool) {
+	value, ok := trueFalseWords[arabic.Normalize(answer)]
+	return value, ok
+}
+
+// MatchesAccepted reports whether answer matches one of the accepted
+// answers, separated by "|". Text is compared after Arabic normalization.
+// An accepted answer of the form "value:tolerance" matches any number
+// within tolerance of value; a plain number matches numerically.
+func MatchesAccepted(answer, accepted string) bool {
+	normalized := arabic.Normalize(answer)
+	number, isNumber := parseNumber(normalized)
+
+	for _, candidate := range strings.Split(accepted, "|") {
+		candidate = arabic.Normalize(candidate)
+		if candidate == "" {
+			continue
+		}
+		if candidate == normalized {
+			return true
+		}
+		if !isNumber {
+			continue
+		}
+		value, tolerance := candidate, "0"
+		if v, t, ok := strings.Cut(candidate, ":"); ok {
+			value, tolerance = v, t
+		}
+		expected, ok := parseNumber(value)
+		if !ok {
+			continue
+		}
+		margin, ok := parseNumber(tolerance)
+		if !ok {
+			continue
+		}
+		if math.Abs(number-expected) <= math.Abs(margin)+1e-9 {
+			return true
+		}
+	}
+	return false
+}
+
+// parseNumber reads a number written with either decimal separator. The
+// text must already be normalized so that Arabic-Indic digits are ASCII.
+func parseNumber(s string) (float64, bool) {
+	s = strings.NewReplacer("٫", ".", " ", "").Replace(s)
+	value, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsNaN(value) || math.IsInf(value, 0) {
+		return 0, false
+	}
+	return value, true
+}
+
+// gradeMatching awards points in proportion to the correctly matched pairs,
+// rounded down
+func gradeMatching(correctAnswer string, points int, answer string) Result {
+	var expected, given map[string]string
+	if json.Unmarshal([]byte(correctAnswer), &expected) != nil || len(expected) == 0 {
+		return Result{Status: StatusPendingManual}
+	}
+	if json.Unmarshal([]byte(answer), &given) != nil {
+		return Result{Status: StatusAutoGraded}
+	}
+
+	normalizedGiven := make(map[string]string, len(given))
+	for left, right := range given {
+		normalizedGiven[arabic.Normalize(left)] = arabic.Normalize(right)
+	}
+
+	matched := 0
+	for left, right := range expected {
+		if normalizedGiven[arabic.Normalize(left)] == arabic.Normalize(right) {
+			matched++
+		}
+	}
+
+	return Result{
+		Points:    points * matched / len(expected),
+		IsCorrect: matched == len(expected),
+		Status:    StatusAutoGraded,
+	}
+}
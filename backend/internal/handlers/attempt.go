@@ -0,0 +1,761 @@
+package handlers
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"moalemplus/internal/models"
+	"moalemplus/internal/testvariant"
+)
+
+// attemptGraceSeconds is how long after the deadline a save or submit is
+// still accepted, to absorb network delays
+const attemptGraceSeconds = 30
+
+// expireAttemptsQuery closes overdue attempts as expired, keeping a copy of
+// the answers like a submission does. The grace period in seconds is bound
+// to $1; callers may narrow it with extra conditions.
+const expireAttemptsQuery = `
+	UPDATE test_submissions
+	SET status = 'expired',
+	    duration_seconds = GREATEST(1, EXTRACT(EPOCH FROM (expires_at - started_at))::int),
+	    answers = COALESCE((
+	        SELECT jsonb_object_agg(question_id::text, student_answer)
+	        FROM test_submission_answers WHERE submission_id = test_submissions.id
+	    ), '{}'),
+	    updated_at = CURRENT_TIMESTAMP
+	WHERE status = 'in_progress' AND expires_at < NOW() - make_interval(secs => $1)
+`
+
+// AttemptHandler serves the student-facing test-taking endpoints
+type AttemptHandler struct {
+	db *sql.DB
+}
+
+func NewAttemptHandler(db *sql.DB) *AttemptHandler {
+	return &AttemptHandler{db: db}
+}
+
+// ExpireAttempts marks every attempt that ran past its deadline as expired,
+// grades it and returns how many were expired
+func ExpireAttempts(db *sql.DB) (int64, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	expired, err := expireAndGrade(tx, "")
+	if err != nil {
+		return 0, err
+	}
+	return expired, tx.Commit()
+}
+
+// GetStudentTests lists the published tests of the student's classes
+func (h *AttemptHandler) GetStudentTests(c *fiber.Ctx) error {
+	studentID := c.Locals("student_id").(uuid.UUID)
+
+	query := `
+		SELECT t.id, t.title, t.title_arabic, t.test_type, t.duration_minutes, t.total_points,
+		       (SELECT COUNT(*) FROM test_questions tq WHERE tq.test_id = t.id) as question_count,
+		       t.scheduled_start, t.scheduled_end, t.allow_retakes, t.max_attempts,
+		       (SELECT COUNT(*) FROM test_submissions ts WHERE ts.test_id = t.id AND ts.student_id = st.id) as attempts_used,
+		       (SELECT ts.id FROM test_submissions ts
+		        WHERE ts.test_id = t.id AND ts.student_id = st.id AND ts.status = 'in_progress') as active_attempt_id
+		FROM tests t
+		JOIN student_classes sc ON sc.class_id = t.class_id AND sc.is_active = true
+		JOIN students st ON sc.student_id = st.id
+		WHERE st.id = $1 AND st.is_active = true AND t.is_active = true AND t.is_published = true
+		ORDER BY COALESCE(t.scheduled_start, t.created_at) DESC
+	`
+
+	rows, err := h.db.Query(query, studentID)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch tests",
+		})
+	}
+	defer rows.Close()
+
+	now := time.Now()
+	tests := []models.StudentTest{}
+	for rows.Next() {
+		var test models.StudentTest
+		var allowRetakes bool
+		var maxAttempts int
+		err := rows.Scan(&test.ID, &test.Title, &test.TitleArabic, &test.TestType,
+			&test.DurationMinutes, &test.TotalPoints, &test.QuestionCount,
+			&test.ScheduledStart, &test.ScheduledEnd, &allowRetakes, &maxAttempts,
+			&test.AttemptsUsed, &test.ActiveAttemptID)
+		if err != nil {
+			return c.Status(500).JSON(models.ErrorResponse{
+				Error:   true,
+				Message: "Failed to scan test",
+			})
+		}
+		test.AttemptsAllowed = attemptsAllowed(allowRetakes, maxAttempts)
+		test.CanStart = scheduleMessage(test.ScheduledStart, test.ScheduledEnd, now) == "" &&
+			(test.ActiveAttemptID != nil || test.AttemptsUsed < test.AttemptsAllowed)
+		tests = append(tests, test)
+	}
+
+	return c.JSON(tests)
+}
+
+// StartAttempt starts a new attempt at a test, or resumes the attempt the
+// student already has in progress
+func (h *AttemptHandler) StartAttempt(c *fiber.Ctx) error {
+	studentID := c.Locals("student_id").(uuid.UUID)
+
+	testUUID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid test ID",
+		})
+	}
+
+	test, err := getStudentTest(h.db, testUUID, studentID)
+	if err == sql.ErrNoRows {
+		return c.Status(404).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Test not found",
+		})
+	}
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch test",
+		})
+	}
+
+	if message := scheduleMessage(test.ScheduledStart, test.ScheduledEnd, time.Now()); message != "" {
+		return c.Status(403).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: message,
+		})
+	}
+
+	// Begin transaction
+	tx, err := h.db.Begin()
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to begin transaction",
+		})
+	}
+	defer tx.Rollback()
+
+	// Lock the student so that concurrent starts are serialized
+	if _, err := tx.Exec(`SELECT id FROM students WHERE id = $1 FOR UPDATE`, studentID); err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to start attempt",
+		})
+	}
+
+	// Close an abandoned attempt before deciding whether to resume
+	if _, err := expireAndGrade(tx, ` AND test_id = $2 AND student_id = $3`, test.ID, studentID); err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to start attempt",
+		})
+	}
+
+	var attemptID uuid.UUID
+	resumed := true
+	activeQuery := `
+		SELECT id FROM test_submissions
+		WHERE test_id = $1 AND student_id = $2 AND status = 'in_progress'
+	`
+	err = tx.QueryRow(activeQuery, test.ID, studentID).Scan(&attemptID)
+	if err == sql.ErrNoRows {
+		resumed = false
+
+		var used, lastAttempt int
+		countQuery := `
+			SELECT COUNT(*), COALESCE(MAX(attempt_number), 0)
+			FROM test_submissions WHERE test_id = $1 AND student_id = $2
+		`
+		if err := tx.QueryRow(countQuery, test.ID, studentID).Scan(&used, &lastAttempt); err != nil {
+			return c.Status(500).JSON(models.ErrorResponse{
+				Error:   true,
+				Message: "Failed to count attempts",
+			})
+		}
+		if used >= attemptsAllowed(test.AllowRetakes, test.MaxAttempts) {
+			return c.Status(409).JSON(models.ErrorResponse{
+				Error:   true,
+				Message: "No attempts left for this test",
+			})
+		}
+
+		var variantID *uuid.UUID
+		if test.IsRandomized {
+			variantID, err = h.pickVariant(tx, test.ID)
+			if err != nil {
+				return c.Status(500).JSON(models.ErrorResponse{
+					Error:   true,
+					Message: "Failed to assign a test variant",
+				})
+			}
+		}
+
+		// The deadline is the test duration, but never past the scheduled end
+		insertQuery := `
+			INSERT INTO test_submissions (test_id, student_id, attempt_number, variant_id, status, started_at, expires_at)
+			VALUES ($1, $2, $3, $4, 'in_progress', NOW(), LEAST(NOW() + make_interval(mins => $5), $6::timestamptz))
+			RETURNING id
+		`
+		err = tx.QueryRow(insertQuery, test.ID, studentID, lastAttempt+1, variantID,
+			test.DurationMinutes, test.ScheduledEnd).Scan(&attemptID)
+	}
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to start attempt",
+		})
+	}
+
+	// Commit transaction
+	if err := tx.Commit(); err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to commit transaction",
+		})
+	}
+
+	attempt, err := h.loadAttempt(attemptID, studentID, true)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch attempt",
+		})
+	}
+
+	if resumed {
+		return c.JSON(models.SuccessResponse{
+			Success: true,
+			Message: "Attempt resumed",
+			Data:    attempt,
+		})
+	}
+	return c.Status(201).JSON(models.SuccessResponse{
+		Success: true,
+		Message: "Attempt started",
+		Data:    attempt,
+	})
+}
+
+// GetAttempt returns an attempt with the student's saved answers. The
+// questions are included while the attempt is in progress.
+func (h *AttemptHandler) GetAttempt(c *fiber.Ctx) error {
+	studentID := c.Locals("student_id").(uuid.UUID)
+
+	attemptUUID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid attempt ID",
+		})
+	}
+
+	if err := expireAttempt(h.db, attemptUUID); err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch attempt",
+		})
+	}
+
+	attempt, err := h.loadAttempt(attemptUUID, studentID, true)
+	if err == sql.ErrNoRows {
+		return c.Status(404).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Attempt not found",
+		})
+	}
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch attempt",
+		})
+	}
+
+	return c.JSON(attempt)
+}
+
+// SaveAnswers stores answers while the attempt is in progress. Only the
+// questions sent are changed, so the client can save as the student goes.
+func (h *AttemptHandler) SaveAnswers(c *fiber.Ctx) error {
+	return h.updateAttempt(c, false)
+}
+
+// SubmitAttempt saves any answers sent with it and closes the attempt
+func (h *AttemptHandler) SubmitAttempt(c *fiber.Ctx) error {
+	return h.updateAttempt(c, true)
+}
+
+func (h *AttemptHandler) updateAttempt(c *fiber.Ctx, submit bool) error {
+	studentID := c.Locals("student_id").(uuid.UUID)
+
+	attemptUUID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid attempt ID",
+		})
+	}
+
+	var req models.SaveAnswersRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(400).JSON(models.ErrorResponse{
+				Error:   true,
+				Message: "Invalid request body",
+			})
+		}
+	}
+	if !submit && len(req.Answers) == 0 {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "No answers to save",
+		})
+	}
+
+	if err := expireAttempt(h.db, attemptUUID); err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to update attempt",
+		})
+	}
+
+	// Begin transaction
+	tx, err := h.db.Begin()
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to begin transaction",
+		})
+	}
+	defer tx.Rollback()
+
+	var testID uuid.UUID
+	var status string
+	var expiresAt, submittedAt *time.Time
+	lockQuery := `
+		SELECT test_id, status, expires_at, submitted_at FROM test_submissions
+		WHERE id = $1 AND student_id = $2
+		FOR UPDATE
+	`
+	err = tx.QueryRow(lockQuery, attemptUUID, studentID).Scan(&testID, &status, &expiresAt, &submittedAt)
+	if err == sql.ErrNoRows {
+		return c.Status(404).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Attempt not found",
+		})
+	}
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch attempt",
+		})
+	}
+
+	// An expired attempt is graded like a submitted one but never gets a
+	// submission time
+	switch {
+	case status == models.SubmissionInProgress:
+	case submittedAt == nil:
+		return c.Status(409).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Time is up for this attempt",
+		})
+	default:
+		return c.Status(409).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Attempt has already been submitted",
+		})
+	}
+
+	if len(req.Answers) > 0 {
+		if status, message := saveAttemptAnswers(tx, attemptUUID, testID, req.Answers); status != 0 {
+			return c.Status(status).JSON(models.ErrorResponse{
+				Error:   true,
+				Message: message,
+			})
+		}
+	}
+
+	if submit {
+		// Keep a copy of the answers on the submission itself
+		submitQuery := `
+			UPDATE test_submissions
+			SET status = 'submitted',
+			    submitted_at = NOW(),
+			    duration_seconds = GREATEST(1, EXTRACT(EPOCH FROM (NOW() - started_at))::int),
+			    answers = COALESCE((
+			        SELECT jsonb_object_agg(question_id::text, student_answer)
+			        FROM test_submission_answers WHERE submission_id = $1
+			    ), '{}'),
+			    updated_at = CURRENT_TIMESTAMP
+			WHERE id = $1
+		`
+		if _, err := tx.Exec(submitQuery, attemptUUID); err != nil {
+			return c.Status(500).JSON(models.ErrorResponse{
+				Error:   true,
+				Message: "Failed to submit attempt",
+			})
+		}
+
+		if err := gradeSubmission(tx, attemptUUID); err != nil {
+			return c.Status(500).JSON(models.ErrorResponse{
+				Error:   true,
+				Message: "Failed to grade attempt",
+			})
+		}
+	}
+
+	// Commit transaction
+	if err := tx.Commit(); err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to commit transaction",
+		})
+	}
+
+	if !submit {
+		return c.JSON(models.SuccessResponse{
+			Success: true,
+			Message: "Answers saved successfully",
+			Data: fiber.Map{
+				"saved":             len(req.Answers),
+				"remaining_seconds": remainingSeconds(expiresAt, time.Now()),
+			},
+		})
+	}
+
+	attempt, err := h.loadAttempt(attemptUUID, studentID, false)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch attempt",
+		})
+	}
+
+	return c.JSON(models.SuccessResponse{
+		Success: true,
+		Message: "Test submitted successfully",
+		Data:    attempt,
+	})
+}
+
+// saveAttemptAnswers upserts answers into test_submission_answers. A
+// non-zero status means the request must be rejected with the returned
+// message.
+func saveAttemptAnswers(tx *sql.Tx, attemptID, testID uuid.UUID, answers []models.AttemptAnswer) (int, string) {
+	rows, err := tx.Query(`SELECT question_id FROM test_questions WHERE test_id = $1`, testID)
+	if err != nil {
+		return 500, "Failed to fetch test questions"
+	}
+	inTest := map[uuid.UUID]bool{}
+	for rows.Next() {
+		var questionID uuid.UUID
+		if err := rows.Scan(&questionID); err != nil {
+			rows.Close()
+			return 500, "Failed to fetch test questions"
+		}
+		inTest[questionID] = true
+	}
+	rows.Close()
+
+	upsertQuery := `
+		INSERT INTO test_submission_answers (submission_id, question_id, student_answer, time_spent_seconds)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (submission_id, question_id) DO UPDATE
+		SET student_answer = EXCLUDED.student_answer,
+		    time_spent_seconds = COALESCE(EXCLUDED.time_spent_seconds, test_submission_answers.time_spent_seconds),
+		    updated_at = CURRENT_TIMESTAMP
+	`
+	for _, answer := range answers {
+		if !inTest[answer.QuestionID] {
+			return 400, "Question is not part of this test"
+		}
+		if answer.TimeSpentSeconds != nil && *answer.TimeSpentSeconds < 0 {
+			return 400, "Time spent cannot be negative"
+		}
+
+		text, err := answerText(answer.Answer)
+		if err != nil {
+			return 400, "Invalid answer format"
+		}
+
+		if text == "" {
+			_, err = tx.Exec(`DELETE FROM test_submission_answers WHERE submission_id = $1 AND question_id = $2`,
+				attemptID, answer.QuestionID)
+		} else {
+			var timeSpent *int
+			if answer.TimeSpentSeconds != nil && *answer.TimeSpentSeconds > 0 {
+				timeSpent = answer.TimeSpentSeconds
+			}
+			_, err = tx.Exec(upsertQuery, attemptID, answer.QuestionID, text, timeSpent)
+		}
+		if err != nil {
+			return 500, "Failed to save answers"
+		}
+	}
+	return 0, ""
+}
+
+// answerText converts an answer to the text stored in student_answer.
+// Strings are stored as is; objects (matching answers) as compact JSON.
+func answerText(raw json.RawMessage) (string, error) {
+	trimmed := bytes.TrimSpace(raw)
+	if len(trimmed) == 0 || string(trimmed) == "null" {
+		return "", nil
+	}
+	if trimmed[0] == '"' {
+		var s string
+		if err := json.Unmarshal(trimmed, &s); err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(s), nil
+	}
+	if trimmed[0] != '{' {
+		return "", errors.New("answer must be a string or an object")
+	}
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, trimmed); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// pickVariant assigns variants in turn as students start the test, so that
+// neighbours are likely to get different forms. It returns nil when the
+// test has no variants.
+func (h *AttemptHandler) pickVariant(tx *sql.Tx, testID uuid.UUID) (*uuid.UUID, error) {
+	var variantID uuid.UUID
+	query := `
+		SELECT tv.id FROM test_variants tv
+		WHERE tv.test_id = $1
+		ORDER BY tv.variant_number
+		OFFSET (SELECT COUNT(*) FROM test_submissions ts WHERE ts.test_id = $1)
+		       % GREATEST((SELECT COUNT(*) FROM test_variants WHERE test_id = $1), 1)
+		LIMIT 1
+	`
+	err := tx.QueryRow(query, testID).Scan(&variantID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &variantID, nil
+}
+
+// loadAttempt loads a student's attempt with its saved answers. With
+// withQuestions the questions are added while the attempt is in progress.
+func (h *AttemptHandler) loadAttempt(attemptID, studentID uuid.UUID, withQuestions bool) (*models.TestAttempt, error) {
+	query := `
+		SELECT ts.id, ts.test_id, ts.student_id, ts.attempt_number, ts.variant_id, ts.status,
+		       ts.started_at, ts.expires_at, ts.submitted_at, ts.graded_at, ts.duration_seconds,
+		       t.title, t.title_arabic, t.instructions, t.instructions_arabic, t.total_points,
+		       t.show_results_immediately, ts.total_score, ts.percentage_score, ts.is_passed
+		FROM test_submissions ts
+		JOIN tests t ON ts.test_id = t.id
+		WHERE ts.id = $1 AND ts.student_id = $2
+	`
+	var attempt models.TestAttempt
+	var showResults, isPassed bool
+	var totalScore int
+	var percentageScore float64
+	err := h.db.QueryRow(query, attemptID, studentID).Scan(&attempt.ID, &attempt.TestID,
+		&attempt.StudentID, &attempt.AttemptNumber, &attempt.VariantID, &attempt.Status,
+		&attempt.StartedAt, &attempt.ExpiresAt, &attempt.SubmittedAt, &attempt.GradedAt, &attempt.DurationSeconds,
+		&attempt.Title, &attempt.TitleArabic, &attempt.Instructions, &attempt.InstructionsArabic,
+		&attempt.TotalPoints, &showResults, &totalScore, &percentageScore, &isPassed)
+	if err != nil {
+		return nil, err
+	}
+
+	// Scores are only shown once grading is complete, including for
+	// attempts that ran out of time, and only when the teacher allows it
+	if showResults && attempt.GradedAt != nil {
+		attempt.TotalScore = &totalScore
+		attempt.PercentageScore = &percentageScore
+		attempt.IsPassed = &isPassed
+	}
+
+	rows, err := h.db.Query(`
+		SELECT question_id, COALESCE(student_answer, '')
+		FROM test_submission_answers WHERE submission_id = $1
+	`, attempt.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	attempt.Answers = map[string]string{}
+	for rows.Next() {
+		var questionID uuid.UUID
+		var answer string
+		if err := rows.Scan(&questionID, &answer); err != nil {
+			return nil, err
+		}
+		attempt.Answers[questionID.String()] = answer
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if attempt.Status != models.SubmissionInProgress {
+		return &attempt, nil
+	}
+	attempt.RemainingSeconds = remainingSeconds(attempt.ExpiresAt, time.Now())
+
+	if withQuestions {
+		attempt.Questions, err = h.attemptQuestions(attempt.TestID, attempt.VariantID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &attempt, nil
+}
+
+// attemptQuestions lays out the test's questions as the student sees them,
+// in the variant's order and lettering, without the correct answers
+func (h *AttemptHandler) attemptQuestions(testID uuid.UUID, variantID *uuid.UUID) ([]models.AttemptQuestion, error) {
+	questions, err := loadTestQuestions(h.db, uuid.Nil, testID)
+	if err != nil {
+		return nil, err
+	}
+
+	var variant *models.TestVariant
+	if variantID != nil {
+		variant = &models.TestVariant{}
+		row := h.db.QueryRow(`
+			SELECT id, test_id, label, variant_number, seed, layout, created_at, updated_at
+			FROM test_variants WHERE id = $1
+		`, *variantID)
+		if err := scanVariant(row, variant); err != nil {
+			return nil, err
+		}
+		// Keep the canonical order if the questions no longer match the
+		// variant; the option letters are still mapped per question
+		if ordered, ok := orderForVariant(questions, variant); ok {
+			questions = ordered
+		}
+	}
+
+	attemptQuestions := make([]models.AttemptQuestion, 0, len(questions))
+	for i, tq := range questions {
+		q := tq.Question
+		aq := models.AttemptQuestion{
+			Number:             i + 1,
+			QuestionID:         q.ID,
+			QuestionType:       q.QuestionType,
+			QuestionText:       q.QuestionText,
+			QuestionTextArabic: q.QuestionTextArabic,
+			Points:             tq.Points,
+		}
+
+		switch q.QuestionType {
+		case models.QuestionTypeMultipleChoice:
+			var options map[string]string
+			json.Unmarshal(q.Options, &options)
+			displayed := make(map[string]string, len(options))
+			for _, label := range optionLabels(q) {
+				canonical := label
+				if variant != nil {
+					canonical = testvariant.CanonicalAnswer(variant.Layout, q.ID, label)
+				}
+				displayed[label] = options[canonical]
+			}
+			aq.Options, _ = json.Marshal(displayed)
+		case models.QuestionTypeMatching:
+			// Sort the right-hand items so their order does not give away
+			// the pairs
+			var options struct {
+				Left  []string `json:"left"`
+				Right []string `json:"right"`
+			}
+			json.Unmarshal(q.Options, &options)
+			sort.Strings(options.Right)
+			aq.Options, _ = json.Marshal(options)
+		}
+
+		attemptQuestions = append(attemptQuestions, aq)
+	}
+	return attemptQuestions, nil
+}
+
+// getStudentTest loads a published test of one of the student's classes
+func getStudentTest(db *sql.DB, testID, studentID uuid.UUID) (*models.Test, error) {
+	query := `
+		SELECT ` + testColumns + `
+		FROM tests t
+		JOIN classes c ON t.class_id = c.id
+		JOIN student_classes sc ON sc.class_id = t.class_id AND sc.is_active = true
+		JOIN students st ON sc.student_id = st.id
+		WHERE t.id = $1 AND st.id = $2 AND st.is_active = true
+		  AND t.is_active = true AND t.is_published = true
+	`
+	var test models.Test
+	if err := scanTest(db.QueryRow(query, testID, studentID), &test); err != nil {
+		return nil, err
+	}
+	return &test, nil
+}
+
+// expireAttempt expires and grades a single attempt if it is past its
+// deadline
+func expireAttempt(db *sql.DB, attemptID uuid.UUID) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := expireAndGrade(tx, ` AND id = $2`, attemptID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// attemptsAllowed is the number of attempts a student gets at a test
+func attemptsAllowed(allowRetakes bool, maxAttempts int) int {
+	if !allowRetakes || maxAttempts < 1 {
+		return 1
+	}
+	return maxAttempts
+}
+
+// scheduleMessage explains why a test cannot be taken at now, or returns
+// an empty string when it is open
+func scheduleMessage(start, end *time.Time, now time.Time) string {
+	if start != nil && now.Before(*start) {
+		return "Test has not started yet"
+	}
+	if end != nil && !now.Before(*end) {
+		return "Test has already ended"
+	}
+	return ""
+}
+
+// remainingSeconds is the time left until the deadline, never negative
+func remainingSeconds(expiresAt *time.Time, now time.Time) int {
+	if expiresAt == nil || !now.Before(*expiresAt) {
+		return 0
+	}
+	return int(expiresAt.Sub(now).Seconds())
+}
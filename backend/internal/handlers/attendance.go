@@ -2,6 +2,8 @@ package handlers
 
 import (
 	"database/sql"
+	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -19,6 +21,15 @@ func NewAttendanceHandler(db *sql.DB) *AttendanceHandler {
 	return &AttendanceHandler{db: db}
 }
 
+// maxPeriodNumber is the last period of the school day
+const maxPeriodNumber = 12
+
+// attendanceDayStatus combines a student's periods on one day, grouped by
+// date, into a status for the day: present if they attended any period,
+// otherwise late, then excused, and absent only when absent from them all
+const attendanceDayStatus = `(ARRAY['present', 'late', 'excused', 'absent'])[MIN(CASE status
+			WHEN 'present' THEN 1 WHEN 'late' THEN 2 WHEN 'excused' THEN 3 ELSE 4 END)]`
+
 // CreateAttendance creates attendance records for a class
 func (h *AttendanceHandler) CreateAttendance(c *fiber.Ctx) error {
 	userID := c.Locals("user_id").(uuid.UUID)
@@ -51,10 +62,21 @@ func (h *AttendanceHandler) CreateAttendance(c *fiber.Ctx) error {
 		})
 	}
 	
-	// Check if attendance already exists for this date
+	// Attendance is taken per period; without one it is the first period
+	if req.PeriodNumber == 0 {
+		req.PeriodNumber = 1
+	}
+	if req.PeriodNumber < 1 || req.PeriodNumber > maxPeriodNumber {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: fmt.Sprintf("period_number must be between 1 and %d", maxPeriodNumber),
+		})
+	}
+	
+	// Check if attendance already exists for this date and period
 	var existingCount int
-	checkAttendanceQuery := `SELECT COUNT(*) FROM attendance WHERE class_id = $1 AND date = $2`
-	err = h.db.QueryRow(checkAttendanceQuery, req.ClassID, attendanceDate).Scan(&existingCount)
+	checkAttendanceQuery := `SELECT COUNT(*) FROM attendance WHERE class_id = $1 AND date = $2 AND period_number = $3`
+	err = h.db.QueryRow(checkAttendanceQuery, req.ClassID, attendanceDate, req.PeriodNumber).Scan(&existingCount)
 	if err != nil {
 		return c.Status(500).JSON(models.ErrorResponse{
 			Error:   true,
@@ -65,9 +87,26 @@ func (h *AttendanceHandler) CreateAttendance(c *fiber.Ctx) error {
 	if existingCount > 0 {
 		return c.Status(409).JSON(models.ErrorResponse{
 			Error:   true,
-			Message: "Attendance already recorded for this date",
+			Message: "Attendance already recorded for this date and period",
+		})
+	}
+	
+	// Only students enrolled in the class can be marked
+	enrolled, err := classStudentIDs(h.db, req.ClassID)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch class students",
 		})
 	}
+	for _, record := range req.Records {
+		if !enrolled[record.StudentID] {
+			return c.Status(400).JSON(models.ErrorResponse{
+				Error:   true,
+				Message: fmt.Sprintf("Student %s is not enrolled in this class", record.StudentID),
+			})
+		}
+	}
 	
 	// Begin transaction
 	tx, err := h.db.Begin()
@@ -80,8 +119,8 @@ func (h *AttendanceHandler) CreateAttendance(c *fiber.Ctx) error {
 	
 	// Insert attendance records
 	insertQuery := `
-		INSERT INTO attendance (id, student_id, class_id, date, status, notes, recorded_by)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		INSERT INTO attendance (id, student_id, class_id, date, period_number, status, notes, recorded_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 	`
 	
 	var attendanceIDs []uuid.UUID
@@ -94,7 +133,7 @@ func (h *AttendanceHandler) CreateAttendance(c *fiber.Ctx) error {
 			notes = &record.Notes
 		}
 		
-		_, err = tx.Exec(insertQuery, attendanceID, record.StudentID, req.ClassID, attendanceDate, record.Status, notes, userID)
+		_, err = tx.Exec(insertQuery, attendanceID, record.StudentID, req.ClassID, attendanceDate, req.PeriodNumber, record.Status, notes, userID)
 		if err != nil {
 			tx.Rollback()
 			if pqErr, ok := err.(*pq.Error); ok {
@@ -112,6 +151,24 @@ func (h *AttendanceHandler) CreateAttendance(c *fiber.Ctx) error {
 		}
 	}
 	
+	// Queue notices to the parents of absent and late students
+	if err := enqueueAttendanceNotices(tx, attendanceIDs); err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to queue parent notifications",
+		})
+	}
+	
+	// Raise early-warning alerts for students crossing the school's thresholds
+	if err := evaluateAttendanceAlerts(tx, attendanceIDs); err != nil {
+		tx.Rollback()
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to evaluate attendance alerts",
+		})
+	}
+	
 	// Commit transaction
 	err = tx.Commit()
 	if err != nil {
@@ -127,12 +184,14 @@ func (h *AttendanceHandler) CreateAttendance(c *fiber.Ctx) error {
 		Data: fiber.Map{
 			"attendance_ids": attendanceIDs,
 			"date":          attendanceDate,
+			"period_number": req.PeriodNumber,
 			"records_count": len(req.Records),
 		},
 	})
 }
 
-// GetClassAttendance retrieves attendance records for a class on a specific date
+// GetClassAttendance retrieves attendance records for a class on a specific
+// date, for every period or only the one given by ?period=
 func (h *AttendanceHandler) GetClassAttendance(c *fiber.Ctx) error {
 	userID := c.Locals("user_id").(uuid.UUID)
 	classID := c.Params("id")
@@ -166,20 +225,33 @@ func (h *AttendanceHandler) GetClassAttendance(c *fiber.Ctx) error {
 		})
 	}
 	
+	var period *int
+	if periodStr := c.Query("period"); periodStr != "" {
+		p, err := strconv.Atoi(periodStr)
+		if err != nil || p < 1 || p > maxPeriodNumber {
+			return c.Status(400).JSON(models.ErrorResponse{
+				Error:   true,
+				Message: fmt.Sprintf("period must be between 1 and %d", maxPeriodNumber),
+			})
+		}
+		period = &p
+	}
+	
 	// Get attendance records
 	query := `
 		SELECT 
-			a.id, a.student_id, a.class_id, a.date, a.status, a.notes, 
+			a.id, a.student_id, a.class_id, a.date, a.period_number, a.status, a.notes, 
 			a.recorded_by, a.recorded_at, a.created_at, a.updated_at,
 			s.first_name || ' ' || s.last_name as student_name,
 			s.student_number
 		FROM attendance a
 		JOIN students s ON a.student_id = s.id
 		WHERE a.class_id = $1 AND a.date = $2
-		ORDER BY s.student_number
+			AND ($3::smallint IS NULL OR a.period_number = $3)
+		ORDER BY a.period_number, s.student_number
 	`
 	
-	rows, err := h.db.Query(query, classUUID, attendanceDate)
+	rows, err := h.db.Query(query, classUUID, attendanceDate, period)
 	if err != nil {
 		return c.Status(500).JSON(models.ErrorResponse{
 			Error:   true,
@@ -193,7 +265,7 @@ func (h *AttendanceHandler) GetClassAttendance(c *fiber.Ctx) error {
 		var attendance models.Attendance
 		err := rows.Scan(
 			&attendance.ID, &attendance.StudentID, &attendance.ClassID,
-			&attendance.Date, &attendance.Status, &attendance.Notes,
+			&attendance.Date, &attendance.PeriodNumber, &attendance.Status, &attendance.Notes,
 			&attendance.RecordedBy, &attendance.RecordedAt, &attendance.CreatedAt,
 			&attendance.UpdatedAt, &attendance.StudentName, &attendance.StudentNumber,
 		)
@@ -259,8 +331,18 @@ func (h *AttendanceHandler) UpdateAttendance(c *fiber.Ctx) error {
 		notes = &req.Notes
 	}
 	
+	// Begin transaction
+	tx, err := h.db.Begin()
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to begin transaction",
+		})
+	}
+	defer tx.Rollback()
+	
 	var updatedAt time.Time
-	err = h.db.QueryRow(updateQuery, req.Status, notes, attendanceUUID).Scan(&updatedAt)
+	err = tx.QueryRow(updateQuery, req.Status, notes, attendanceUUID).Scan(&updatedAt)
 	if err != nil {
 		return c.Status(500).JSON(models.ErrorResponse{
 			Error:   true,
@@ -268,6 +350,29 @@ func (h *AttendanceHandler) UpdateAttendance(c *fiber.Ctx) error {
 		})
 	}
 	
+	// Queue a notice to the parent, or cancel one that is no longer true
+	if err := enqueueAttendanceNotices(tx, []uuid.UUID{attendanceUUID}); err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to queue parent notifications",
+		})
+	}
+	
+	if err := evaluateAttendanceAlerts(tx, []uuid.UUID{attendanceUUID}); err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to evaluate attendance alerts",
+		})
+	}
+	
+	// Commit transaction
+	if err := tx.Commit(); err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to commit transaction",
+		})
+	}
+	
 	return c.JSON(models.SuccessResponse{
 		Success: true,
 		Message: "Attendance updated successfully",
@@ -279,7 +384,6 @@ func (h *AttendanceHandler) UpdateAttendance(c *fiber.Ctx) error {
 
 // GetStudentAttendanceReport retrieves attendance report for a student
 func (h *AttendanceHandler) GetStudentAttendanceReport(c *fiber.Ctx) error {
-	userID := c.Locals("user_id").(uuid.UUID)
 	studentID := c.Params("id")
 	
 	studentUUID, err := uuid.Parse(studentID)
@@ -290,18 +394,11 @@ func (h *AttendanceHandler) GetStudentAttendanceReport(c *fiber.Ctx) error {
 		})
 	}
 	
-	// Check if student exists and belongs to user's class
-	var existingStudent models.Student
-	checkQuery := `
-		SELECT s.id FROM students s
-		JOIN classes c ON s.class_id = c.id
-		WHERE s.id = $1 AND c.teacher_id = $2 AND s.is_active = true
-	`
-	err = h.db.QueryRow(checkQuery, studentUUID, userID).Scan(&existingStudent.ID)
-	if err == sql.ErrNoRows {
-		return c.Status(404).JSON(models.ErrorResponse{
+	// Check if student belongs to the teacher's class or the parent's children
+	if _, status, msg := studentViewer(c, h.db, studentUUID); status != 0 {
+		return c.Status(status).JSON(models.ErrorResponse{
 			Error:   true,
-			Message: "Student not found",
+			Message: msg,
 		})
 	}
 	
@@ -325,7 +422,7 @@ func (h *AttendanceHandler) GetStudentAttendanceReport(c *fiber.Ctx) error {
 		})
 	}
 	
-	// Get attendance statistics
+	// Get attendance statistics per period recorded
 	statsQuery := `
 		SELECT 
 			COUNT(*) as total_days,
@@ -337,10 +434,10 @@ func (h *AttendanceHandler) GetStudentAttendanceReport(c *fiber.Ctx) error {
 		WHERE student_id = $1 AND date BETWEEN $2 AND $3
 	`
 	
-	var stats models.AttendanceStats
+	var periodStats models.AttendanceStats
 	err = h.db.QueryRow(statsQuery, studentUUID, startDateTime, endDateTime).Scan(
-		&stats.TotalDays, &stats.PresentDays, &stats.AbsentDays, 
-		&stats.LateDays, &stats.ExcusedDays,
+		&periodStats.TotalDays, &periodStats.PresentDays, &periodStats.AbsentDays, 
+		&periodStats.LateDays, &periodStats.ExcusedDays,
 	)
 	if err != nil {
 		return c.Status(500).JSON(models.ErrorResponse{
@@ -349,19 +446,100 @@ func (h *AttendanceHandler) GetStudentAttendanceReport(c *fiber.Ctx) error {
 		})
 	}
 	
+	// Combine the periods of each school day into one status for the day
+	daysQuery := `
+		SELECT date, ` + attendanceDayStatus + `, COUNT(*),
+			COUNT(CASE WHEN status = 'absent' THEN 1 END)
+		FROM attendance
+		WHERE student_id = $1 AND date BETWEEN $2 AND $3
+		GROUP BY date
+		ORDER BY date DESC
+	`
+	
+	dayRows, err := h.db.Query(daysQuery, studentUUID, startDateTime, endDateTime)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch attendance statistics",
+		})
+	}
+	defer dayRows.Close()
+	
+	var stats models.AttendanceStats
+	var days []models.AttendanceDay
+	recorded := map[time.Time]string{}
+	for dayRows.Next() {
+		var day models.AttendanceDay
+		if err := dayRows.Scan(&day.Date, &day.Status, &day.Periods, &day.AbsentPeriods); err != nil {
+			continue
+		}
+		stats.TotalDays++
+		switch day.Status {
+		case "present":
+			stats.PresentDays++
+		case "absent":
+			stats.AbsentDays++
+		case "late":
+			stats.LateDays++
+		case "excused":
+			stats.ExcusedDays++
+		}
+		days = append(days, day)
+		recorded[day.Date.UTC()] = day.Status
+	}
+	
 	// Calculate attendance rate
 	if stats.TotalDays > 0 {
 		stats.AttendanceRate = float64(stats.PresentDays) / float64(stats.TotalDays) * 100
 	}
 	
+	// With term dates in the school calendar, take the rate over the
+	// instructional days up to today and flag those without a record
+	var instructionalDays int
+	var missingDays []time.Time
+	calendarEnd := endDateTime
+	now := time.Now().In(schoolTime)
+	if today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC); calendarEnd.After(today) {
+		calendarEnd = today
+	}
+	if schoolID, err := studentSchoolID(h.db, studentUUID); err == nil && !startDateTime.After(calendarEnd) {
+		cal, err := loadSchoolCalendar(h.db, schoolID, startDateTime, calendarEnd)
+		if err != nil {
+			return c.Status(500).JSON(models.ErrorResponse{
+				Error:   true,
+				Message: "Failed to fetch school calendar",
+			})
+		}
+		if cal.HasTerms() {
+			var presentDays int
+			for _, day := range cal.SchoolDays(startDateTime, calendarEnd) {
+				instructionalDays++
+				status, ok := recorded[day]
+				if !ok {
+					missingDays = append(missingDays, day)
+				}
+				if status == "present" {
+					presentDays++
+				}
+			}
+			stats.AttendanceRate = 0
+			if instructionalDays > 0 {
+				stats.AttendanceRate = float64(presentDays) / float64(instructionalDays) * 100
+			}
+		}
+	}
+	if periodStats.TotalDays > 0 {
+		periodStats.AttendanceRate = float64(periodStats.PresentDays) / float64(periodStats.TotalDays) * 100
+	}
+	
 	// Get detailed attendance records
 	recordsQuery := `
 		SELECT 
-			a.id, a.student_id, a.class_id, a.date, a.status, a.notes, 
+			a.id, a.student_id, a.class_id, a.date, a.period_number, a.status, a.notes, 
 			a.recorded_by, a.recorded_at, a.created_at, a.updated_at
 		FROM attendance a
 		WHERE a.student_id = $1 AND a.date BETWEEN $2 AND $3
-		ORDER BY a.date DESC
+		ORDER BY a.date DESC, a.period_number
 	`
 	
 	rows, err := h.db.Query(recordsQuery, studentUUID, startDateTime, endDateTime)
@@ -378,7 +556,7 @@ func (h *AttendanceHandler) GetStudentAttendanceReport(c *fiber.Ctx) error {
 		var attendance models.Attendance
 		err := rows.Scan(
 			&attendance.ID, &attendance.StudentID, &attendance.ClassID,
-			&attendance.Date, &attendance.Status, &attendance.Notes,
+			&attendance.Date, &attendance.PeriodNumber, &attendance.Status, &attendance.Notes,
 			&attendance.RecordedBy, &attendance.RecordedAt, &attendance.CreatedAt,
 			&attendance.UpdatedAt,
 		)
@@ -391,7 +569,11 @@ func (h *AttendanceHandler) GetStudentAttendanceReport(c *fiber.Ctx) error {
 	report := models.AttendanceReport{
 		Date:        time.Now(),
 		Attendances: attendanceRecords,
+		Days:        days,
 		Stats:       stats,
+		PeriodStats: &periodStats,
+		InstructionalDays: instructionalDays,
+		MissingDays: missingDays,
 	}
 	
 	return c.JSON(report)
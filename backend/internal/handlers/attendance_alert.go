@@ -0,0 +1,669 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"moalemplus/internal/absencealert"
+	"moalemplus/internal/arabic"
+	"moalemplus/internal/models"
+	"moalemplus/internal/notify"
+)
+
+// attendanceAlertColumns are the columns scanned by scanAttendanceAlert
+const attendanceAlertColumns = `
+	a.id, a.class_id, a.student_id, a.rule_type, a.since, a.value, a.threshold, a.message,
+	a.status, a.note, a.acknowledged_at, a.resolved_at, a.parent_notified_at, a.referred_at,
+	a.created_at, a.updated_at, c.name, s.arabic_name, s.student_number
+`
+
+type AttendanceAlertHandler struct {
+	db *sql.DB
+}
+
+func NewAttendanceAlertHandler(db *sql.DB) *AttendanceAlertHandler {
+	return &AttendanceAlertHandler{db: db}
+}
+
+// GetAttendanceAlerts returns the teacher's alerts feed, newest first.
+// ?status= filters by status and defaults to the alerts not yet resolved;
+// ?class_id= narrows the feed to one class.
+func (h *AttendanceAlertHandler) GetAttendanceAlerts(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	conditions := []string{"a.teacher_id = $1", "c.is_active = true", "s.is_active = true"}
+	args := []interface{}{userID}
+
+	switch status := c.Query("status"); status {
+	case "":
+		conditions = append(conditions, "a.status <> 'resolved'")
+	case "all":
+	case models.AlertOpen, models.AlertAcknowledged, models.AlertResolved:
+		args = append(args, status)
+		conditions = append(conditions, "a.status = $2")
+	default:
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Status must be open, acknowledged, resolved or all",
+		})
+	}
+
+	if classID := c.Query("class_id"); classID != "" {
+		classUUID, err := uuid.Parse(classID)
+		if err != nil {
+			return c.Status(400).JSON(models.ErrorResponse{
+				Error:   true,
+				Message: "Invalid class ID",
+			})
+		}
+		args = append(args, classUUID)
+		conditions = append(conditions, fmt.Sprintf("a.class_id = $%d", len(args)))
+	}
+
+	query := `
+		SELECT ` + attendanceAlertColumns + `
+		FROM attendance_alerts a
+		JOIN classes c ON a.class_id = c.id
+		JOIN students s ON a.student_id = s.id
+		WHERE ` + strings.Join(conditions, " AND ") + `
+		ORDER BY a.created_at DESC
+	`
+	rows, err := h.db.Query(query, args...)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch alerts",
+		})
+	}
+	defer rows.Close()
+
+	alerts := []models.AttendanceAlert{}
+	for rows.Next() {
+		var alert models.AttendanceAlert
+		if err := scanAttendanceAlert(rows, &alert); err != nil {
+			return c.Status(500).JSON(models.ErrorResponse{
+				Error:   true,
+				Message: "Failed to scan alert",
+			})
+		}
+		alerts = append(alerts, alert)
+	}
+	if err := rows.Err(); err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch alerts",
+		})
+	}
+
+	return c.JSON(alerts)
+}
+
+// UpdateAttendanceAlert acknowledges or resolves an alert. A resolved
+// alert is closed for good; if the student keeps missing class a new run
+// of absences raises a new one.
+func (h *AttendanceAlertHandler) UpdateAttendanceAlert(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	alertUUID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid alert ID",
+		})
+	}
+
+	var req models.UpdateAttendanceAlertRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid request body",
+		})
+	}
+	if req.Status != models.AlertAcknowledged && req.Status != models.AlertResolved {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Status must be acknowledged or resolved",
+		})
+	}
+
+	if status, msg := checkOpenAlert(h.db, alertUUID, userID); status != 0 {
+		return c.Status(status).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: msg,
+		})
+	}
+
+	updateQuery := `
+		UPDATE attendance_alerts
+		SET status = $2, note = COALESCE($3, note),
+		    acknowledged_at = COALESCE(acknowledged_at, CURRENT_TIMESTAMP),
+		    resolved_at = CASE WHEN $2 = 'resolved' THEN CURRENT_TIMESTAMP END
+		WHERE id = $1
+	`
+	note := nullableString(strings.TrimSpace(req.Note))
+	if _, err := h.db.Exec(updateQuery, alertUUID, req.Status, note); err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to update alert",
+		})
+	}
+
+	alert, err := loadAttendanceAlert(h.db, alertUUID)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch alert",
+		})
+	}
+	return c.JSON(alert)
+}
+
+// ReferAttendanceAlert refers the student behind an alert to the school's
+// social worker by queueing a message to them
+func (h *AttendanceAlertHandler) ReferAttendanceAlert(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	alertUUID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid alert ID",
+		})
+	}
+
+	var req models.ReferAttendanceAlertRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid request body",
+		})
+	}
+
+	if status, msg := checkOpenAlert(h.db, alertUUID, userID); status != 0 {
+		return c.Status(status).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: msg,
+		})
+	}
+
+	// Begin transaction
+	tx, err := h.db.Begin()
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to begin transaction",
+		})
+	}
+	defer tx.Rollback()
+
+	var referredAt *time.Time
+	err = tx.QueryRow(`SELECT referred_at FROM attendance_alerts WHERE id = $1 FOR UPDATE`, alertUUID).Scan(&referredAt)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch alert",
+		})
+	}
+	if referredAt != nil {
+		return c.Status(409).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "The student was already referred for this alert",
+		})
+	}
+
+	queued, err := queueAlertMessage(tx, alertUUID, models.NotificationReferral, strings.TrimSpace(req.Note))
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to queue referral",
+		})
+	}
+	if !queued {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Add the school's social worker contact to the attendance alert rules first",
+		})
+	}
+
+	// Commit transaction
+	if err := tx.Commit(); err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to commit transaction",
+		})
+	}
+
+	alert, err := loadAttendanceAlert(h.db, alertUUID)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch alert",
+		})
+	}
+	return c.JSON(alert)
+}
+
+// GetAttendanceAlertRules returns the thresholds used by the teacher's
+// school
+func (h *AttendanceAlertHandler) GetAttendanceAlertRules(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	schoolID, err := userSchoolID(h.db, userID)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch school",
+		})
+	}
+
+	rules, err := loadAttendanceAlertRules(h.db, &schoolID)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch alert rules",
+		})
+	}
+	return c.JSON(rules)
+}
+
+// SaveAttendanceAlertRules sets the school's thresholds and what happens
+// when an alert is raised. New thresholds apply from the next attendance
+// taken.
+func (h *AttendanceAlertHandler) SaveAttendanceAlertRules(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	var req models.AttendanceAlertRules
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid request body",
+		})
+	}
+	req.SocialWorkerName = strings.TrimSpace(req.SocialWorkerName)
+	req.SocialWorkerPhone = arabic.WesternDigits(strings.TrimSpace(req.SocialWorkerPhone))
+	req.SocialWorkerEmail = strings.TrimSpace(req.SocialWorkerEmail)
+
+	var message string
+	switch {
+	case req.ConsecutiveAbsences < 0 || req.ConsecutiveAbsences > 60:
+		message = "Consecutive absences must be between 0 and 60"
+	case req.AbsenceRate < 0 || req.AbsenceRate > 100:
+		message = "Absence rate must be between 0 and 100"
+	case req.MinRecordedDays < 0 || req.MinRecordedDays > 200:
+		message = "Minimum recorded days must be between 0 and 200"
+	case req.LateCount < 0 || req.LateCount > 100:
+		message = "Late count must be between 0 and 100"
+	case len(req.SocialWorkerName) > 255:
+		message = "Social worker name is too long"
+	case req.SocialWorkerPhone != "" && (!phonePattern.MatchString(req.SocialWorkerPhone) || len(req.SocialWorkerPhone) > 20):
+		message = "Social worker phone may only contain digits, spaces, +, - and brackets (up to 20 characters)"
+	case req.SocialWorkerEmail != "" && (!emailPattern.MatchString(req.SocialWorkerEmail) || len(req.SocialWorkerEmail) > 255):
+		message = "Invalid social worker email address"
+	case req.ReferSocialWorker && req.SocialWorkerPhone == "" && req.SocialWorkerEmail == "":
+		message = "A social worker phone or email is required to refer students"
+	}
+	if message != "" {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: message,
+		})
+	}
+
+	schoolID, err := userSchoolID(h.db, userID)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch school",
+		})
+	}
+
+	upsertQuery := `
+		INSERT INTO attendance_alert_rules (school_id, consecutive_absences, absence_rate, min_recorded_days,
+		                                    late_count, notify_parent, refer_social_worker,
+		                                    social_worker_name, social_worker_phone, social_worker_email)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (COALESCE(school_id, '00000000-0000-0000-0000-000000000000')) DO UPDATE
+		SET consecutive_absences = EXCLUDED.consecutive_absences, absence_rate = EXCLUDED.absence_rate,
+		    min_recorded_days = EXCLUDED.min_recorded_days, late_count = EXCLUDED.late_count,
+		    notify_parent = EXCLUDED.notify_parent, refer_social_worker = EXCLUDED.refer_social_worker,
+		    social_worker_name = EXCLUDED.social_worker_name, social_worker_phone = EXCLUDED.social_worker_phone,
+		    social_worker_email = EXCLUDED.social_worker_email
+	`
+	_, err = h.db.Exec(upsertQuery, schoolID, req.ConsecutiveAbsences, req.AbsenceRate, req.MinRecordedDays,
+		req.LateCount, req.NotifyParent, req.ReferSocialWorker, nullableString(req.SocialWorkerName),
+		nullableString(req.SocialWorkerPhone), nullableString(req.SocialWorkerEmail))
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to save alert rules",
+		})
+	}
+
+	req.IsCustom = true
+	return c.JSON(req)
+}
+
+// ResetAttendanceAlertRules drops the school's thresholds so the defaults
+// apply again
+func (h *AttendanceAlertHandler) ResetAttendanceAlertRules(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	schoolID, err := userSchoolID(h.db, userID)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch school",
+		})
+	}
+
+	deleteQuery := `DELETE FROM attendance_alert_rules WHERE school_id = $1`
+	if _, err := h.db.Exec(deleteQuery, schoolID); err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to reset alert rules",
+		})
+	}
+
+	return c.JSON(models.SuccessResponse{
+		Success: true,
+		Message: "Alert rules reset to the defaults",
+	})
+}
+
+// checkOpenAlert returns a non-zero status and message unless the alert
+// belongs to the teacher and is not resolved yet
+func checkOpenAlert(db queryRower, alertID, userID uuid.UUID) (int, string) {
+	var status string
+	err := db.QueryRow(`SELECT status FROM attendance_alerts WHERE id = $1 AND teacher_id = $2`,
+		alertID, userID).Scan(&status)
+	if err == sql.ErrNoRows {
+		return 404, "Alert not found"
+	}
+	if err != nil {
+		return 500, "Failed to fetch alert"
+	}
+	if status == models.AlertResolved {
+		return 409, "Alert is already resolved"
+	}
+	return 0, ""
+}
+
+// loadAttendanceAlertRules returns the school's rules, or the defaults when
+// the school has none. Without any rules every threshold is off.
+func loadAttendanceAlertRules(db queryRower, schoolID *uuid.UUID) (*models.AttendanceAlertRules, error) {
+	query := `
+		SELECT school_id IS NOT NULL, consecutive_absences, absence_rate, min_recorded_days, late_count,
+		       notify_parent, refer_social_worker, COALESCE(social_worker_name, ''),
+		       COALESCE(social_worker_phone, ''), COALESCE(social_worker_email, '')
+		FROM attendance_alert_rules
+		WHERE school_id = $1 OR school_id IS NULL
+		ORDER BY school_id IS NULL
+		LIMIT 1
+	`
+	var rules models.AttendanceAlertRules
+	err := db.QueryRow(query, schoolID).Scan(&rules.IsCustom, &rules.ConsecutiveAbsences, &rules.AbsenceRate,
+		&rules.MinRecordedDays, &rules.LateCount, &rules.NotifyParent, &rules.ReferSocialWorker,
+		&rules.SocialWorkerName, &rules.SocialWorkerPhone, &rules.SocialWorkerEmail)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	return &rules, nil
+}
+
+// evaluateAttendanceAlerts re-checks the alert rules for the students and
+// classes of the given attendance records, in the same transaction as the
+// attendance change. New alerts notify the parent or refer the student
+// when the school's rules ask for it.
+func evaluateAttendanceAlerts(tx *sql.Tx, attendanceIDs []uuid.UUID) error {
+	ids := make([]string, len(attendanceIDs))
+	for i, id := range attendanceIDs {
+		ids[i] = id.String()
+	}
+
+	type subject struct {
+		classID, studentID, teacherID uuid.UUID
+		schoolID                      *uuid.UUID
+	}
+	pairsQuery := `
+		SELECT DISTINCT a.class_id, a.student_id, c.teacher_id, u.school_id
+		FROM attendance a
+		JOIN classes c ON a.class_id = c.id
+		JOIN users u ON c.teacher_id = u.id
+		WHERE a.id = ANY($1::uuid[])
+	`
+	rows, err := tx.Query(pairsQuery, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	var subjects []subject
+	for rows.Next() {
+		var s subject
+		if err := rows.Scan(&s.classID, &s.studentID, &s.teacherID, &s.schoolID); err != nil {
+			rows.Close()
+			return err
+		}
+		subjects = append(subjects, s)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// An existing alert only changes while it is not resolved. xmax is zero
+	// for a freshly inserted row, which tells new alerts from updated ones.
+	upsertQuery := `
+		INSERT INTO attendance_alerts (teacher_id, class_id, student_id, rule_type, since, value, threshold, message)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (class_id, student_id, rule_type, since) DO UPDATE
+		SET value = EXCLUDED.value, threshold = EXCLUDED.threshold, message = EXCLUDED.message
+		WHERE attendance_alerts.status <> 'resolved' AND attendance_alerts.value <> EXCLUDED.value
+		RETURNING id, xmax = 0
+	`
+	rulesBySchool := map[uuid.UUID]*models.AttendanceAlertRules{}
+	for _, s := range subjects {
+		var key uuid.UUID
+		if s.schoolID != nil {
+			key = *s.schoolID
+		}
+		rules, ok := rulesBySchool[key]
+		if !ok {
+			if rules, err = loadAttendanceAlertRules(tx, s.schoolID); err != nil {
+				return err
+			}
+			rulesBySchool[key] = rules
+		}
+
+		records, err := studentClassRecords(tx, s.studentID, s.classID)
+		if err != nil {
+			return err
+		}
+		findings := absencealert.Evaluate(records, absencealert.Thresholds{
+			ConsecutiveAbsences: rules.ConsecutiveAbsences,
+			AbsenceRate:         rules.AbsenceRate,
+			MinRecordedDays:     rules.MinRecordedDays,
+			LateCount:           rules.LateCount,
+		})
+
+		for _, f := range findings {
+			var alertID uuid.UUID
+			var inserted bool
+			err := tx.QueryRow(upsertQuery, s.teacherID, s.classID, s.studentID, f.Rule, f.Since,
+				math.Round(f.Value*100)/100, f.Threshold, absencealert.Describe(f)).Scan(&alertID, &inserted)
+			if err == sql.ErrNoRows {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			if !inserted {
+				continue
+			}
+
+			if rules.NotifyParent {
+				if _, err := queueAlertMessage(tx, alertID, models.NotificationAbsenceAlert, ""); err != nil {
+					return err
+				}
+			}
+			if rules.ReferSocialWorker {
+				if _, err := queueAlertMessage(tx, alertID, models.NotificationReferral, ""); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// studentClassRecords returns a student's attendance in a class by date,
+// one record per day however many periods were taken
+func studentClassRecords(tx *sql.Tx, studentID, classID uuid.UUID) ([]absencealert.Record, error) {
+	rows, err := tx.Query(`
+		SELECT date, `+attendanceDayStatus+` FROM attendance
+		WHERE student_id = $1 AND class_id = $2
+		GROUP BY date
+		ORDER BY date
+	`, studentID, classID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []absencealert.Record
+	for rows.Next() {
+		var record absencealert.Record
+		if err := rows.Scan(&record.Date, &record.Status); err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	return records, rows.Err()
+}
+
+// queueAlertMessage queues an alert's message to the student's primary
+// contact (absence_alert) or to the school's social worker (referral) and
+// records when it was sent. It reports false when there is nobody to send
+// to or the school turned the message off.
+func queueAlertMessage(tx *sql.Tx, alertID uuid.UUID, event, note string) (bool, error) {
+	var studentID uuid.UUID
+	var schoolID *uuid.UUID
+	var alertMessage, studentName, className, subjectName, schoolName, teacherName string
+	alertQuery := `
+		SELECT a.student_id, a.message, s.arabic_name, c.name, COALESCE(sub.name_arabic, ''),
+		       u.school_id, COALESCE(sch.name, ''), u.full_name
+		FROM attendance_alerts a
+		JOIN students s ON a.student_id = s.id
+		JOIN classes c ON a.class_id = c.id
+		JOIN users u ON a.teacher_id = u.id
+		LEFT JOIN schools sch ON u.school_id = sch.id
+		LEFT JOIN subjects sub ON c.subject_id = sub.id
+		WHERE a.id = $1
+	`
+	err := tx.QueryRow(alertQuery, alertID).Scan(&studentID, &alertMessage, &studentName, &className,
+		&subjectName, &schoolID, &schoolName, &teacherName)
+	if err != nil {
+		return false, err
+	}
+
+	vars := notify.DateVars(time.Now())
+	vars["student_name"] = studentName
+	vars["class_name"] = className
+	vars["subject_name"] = subjectName
+	vars["school_name"] = schoolName
+	vars["teacher_name"] = teacherName
+	vars["alert"] = alertMessage
+	vars["note"] = note
+
+	var parentID *uuid.UUID
+	var phone, email *string
+	sentColumn := "parent_notified_at"
+	if event == models.NotificationReferral {
+		rules, err := loadAttendanceAlertRules(tx, schoolID)
+		if err != nil {
+			return false, err
+		}
+		if rules.SocialWorkerPhone == "" && rules.SocialWorkerEmail == "" {
+			return false, nil
+		}
+		phone = nullableString(rules.SocialWorkerPhone)
+		email = nullableString(rules.SocialWorkerEmail)
+		vars["parent_name"] = ""
+		sentColumn = "referred_at"
+	} else {
+		var id uuid.UUID
+		var parentName, parentPhone string
+		contactQuery := `
+			SELECT p.id, p.arabic_name, p.phone_primary, p.email
+			FROM student_parents sp
+			JOIN parents p ON sp.parent_id = p.id AND p.is_active = true
+			WHERE sp.student_id = $1 AND sp.is_primary_contact = true AND sp.is_active = true
+		`
+		err := tx.QueryRow(contactQuery, studentID).Scan(&id, &parentName, &parentPhone, &email)
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		parentID, phone = &id, &parentPhone
+		vars["parent_name"] = parentName
+	}
+
+	template, err := loadNotificationTemplate(tx, schoolID, event)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if !template.IsActive {
+		return false, nil
+	}
+
+	insertQuery := `
+		INSERT INTO notification_outbox (school_id, student_id, parent_id, alert_id, event_type,
+		                                 phone, email, subject, body)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT DO NOTHING
+	`
+	_, err = tx.Exec(insertQuery, schoolID, studentID, parentID, alertID, event, phone, email,
+		notify.Render(template.Subject, vars), notify.Render(template.Body, vars))
+	if err != nil {
+		return false, err
+	}
+
+	if _, err := tx.Exec(`UPDATE attendance_alerts SET `+sentColumn+` = CURRENT_TIMESTAMP WHERE id = $1`,
+		alertID); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// loadAttendanceAlert loads an alert with its class and student names
+func loadAttendanceAlert(db queryRower, alertID uuid.UUID) (*models.AttendanceAlert, error) {
+	query := `
+		SELECT ` + attendanceAlertColumns + `
+		FROM attendance_alerts a
+		JOIN classes c ON a.class_id = c.id
+		JOIN students s ON a.student_id = s.id
+		WHERE a.id = $1
+	`
+	var alert models.AttendanceAlert
+	if err := scanAttendanceAlert(db.QueryRow(query, alertID), &alert); err != nil {
+		return nil, err
+	}
+	return &alert, nil
+}
+
+func scanAttendanceAlert(row rowScanner, alert *models.AttendanceAlert) error {
+	return row.Scan(&alert.ID, &alert.ClassID, &alert.StudentID, &alert.RuleType, &alert.Since,
+		&alert.Value, &alert.Threshold, &alert.Message, &alert.Status, &alert.Note,
+		&alert.AcknowledgedAt, &alert.ResolvedAt, &alert.ParentNotifiedAt, &alert.ReferredAt,
+		&alert.CreatedAt, &alert.UpdatedAt, &alert.ClassName, &alert.StudentName, &alert.StudentNumber)
+}
@@ -0,0 +1,714 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"moalemplus/internal/hijri"
+	"moalemplus/internal/models"
+	"moalemplus/internal/schoolcal"
+)
+
+// calendarEventColumns are the columns scanned by scanCalendarEvent
+const calendarEventColumns = `
+	id, school_id IS NULL, school_year, event_type, name, starts_on, ends_on,
+	calendar, month, day, duration_days, replaces_event_id
+`
+
+type CalendarHandler struct {
+	db *sql.DB
+}
+
+func NewCalendarHandler(db *sql.DB) *CalendarHandler {
+	return &CalendarHandler{db: db}
+}
+
+// GetCalendar returns the terms, holidays and exam periods of the
+// teacher's school in ?school_year=, which defaults to the current one
+func (h *CalendarHandler) GetCalendar(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	schoolYear := c.Query("school_year", schoolcal.SchoolYear(time.Now().In(schoolTime)))
+	from, to, err := schoolcal.YearRange(schoolYear)
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "School year must look like 2025-2026",
+		})
+	}
+
+	schoolID, err := userSchoolID(h.db, userID)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch school",
+		})
+	}
+
+	terms, err := loadSchoolTerms(h.db, schoolID, from, to)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch terms",
+		})
+	}
+	events, err := loadCalendarEvents(h.db, schoolID, from, to)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch calendar events",
+		})
+	}
+
+	calendar := models.SchoolCalendar{
+		SchoolYear: schoolYear,
+		Terms:      []models.SchoolTerm{},
+		Events:     events,
+	}
+	cal := newSchoolCalendar(terms, events)
+	for _, term := range terms {
+		if term.SchoolYear != schoolYear {
+			continue
+		}
+		term.InstructionalDays = len(cal.SchoolDays(term.StartsOn, term.EndsOn))
+		calendar.InstructionalDays += term.InstructionalDays
+		calendar.Terms = append(calendar.Terms, term)
+	}
+
+	return c.JSON(calendar)
+}
+
+// SaveSchoolTerm sets the dates of a semester for the teacher's school,
+// in place of the ministry dates
+func (h *CalendarHandler) SaveSchoolTerm(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	schoolYear := c.Params("schoolYear")
+	semester := c.Params("semester")
+	from, to, err := schoolcal.YearRange(schoolYear)
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "School year must look like 2025-2026",
+		})
+	}
+	if semester != "first" && semester != "second" {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Semester must be first or second",
+		})
+	}
+
+	var req models.SaveSchoolTermRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid request body",
+		})
+	}
+	startsOn, startErr := time.Parse("2006-01-02", req.StartsOn)
+	endsOn, endErr := time.Parse("2006-01-02", req.EndsOn)
+	var message string
+	switch {
+	case startErr != nil || endErr != nil:
+		message = "Invalid date format (YYYY-MM-DD)"
+	case endsOn.Before(startsOn):
+		message = "ends_on must not be before starts_on"
+	case startsOn.Before(from) || endsOn.After(to):
+		message = fmt.Sprintf("The term must fall between %s and %s", from.Format("2006-01-02"), to.Format("2006-01-02"))
+	}
+	if message != "" {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: message,
+		})
+	}
+
+	schoolID, err := userSchoolID(h.db, userID)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch school",
+		})
+	}
+
+	// The semesters of a year may not overlap
+	terms, err := loadSchoolTerms(h.db, schoolID, from, to)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch terms",
+		})
+	}
+	for _, term := range terms {
+		if term.SchoolYear == schoolYear && term.Semester != semester &&
+			!startsOn.After(term.EndsOn) && !endsOn.Before(term.StartsOn) {
+			return c.Status(409).JSON(models.ErrorResponse{
+				Error:   true,
+				Message: fmt.Sprintf("Overlaps the %s semester", term.Semester),
+			})
+		}
+	}
+
+	upsertQuery := `
+		INSERT INTO school_terms (school_id, school_year, semester, starts_on, ends_on)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (COALESCE(school_id, '00000000-0000-0000-0000-000000000000'), school_year, semester) DO UPDATE
+		SET starts_on = EXCLUDED.starts_on, ends_on = EXCLUDED.ends_on
+	`
+	if _, err := h.db.Exec(upsertQuery, schoolID, schoolYear, semester, startsOn, endsOn); err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to save term",
+		})
+	}
+
+	return c.JSON(models.SchoolTerm{
+		SchoolYear: schoolYear,
+		Semester:   semester,
+		StartsOn:   startsOn,
+		EndsOn:     endsOn,
+		IsCustom:   true,
+	})
+}
+
+// ResetSchoolTerm drops the school's dates for a semester so the ministry
+// dates apply again
+func (h *CalendarHandler) ResetSchoolTerm(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	schoolID, err := userSchoolID(h.db, userID)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch school",
+		})
+	}
+
+	deleteQuery := `DELETE FROM school_terms WHERE school_id = $1 AND school_year = $2 AND semester = $3`
+	if _, err := h.db.Exec(deleteQuery, schoolID, c.Params("schoolYear"), c.Params("semester")); err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to reset term",
+		})
+	}
+
+	return c.JSON(models.SuccessResponse{
+		Success: true,
+		Message: "Term reset to the ministry dates",
+	})
+}
+
+// CreateCalendarEvent adds a holiday or exam period to the teacher's
+// school calendar
+func (h *CalendarHandler) CreateCalendarEvent(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	var req models.SaveCalendarEventRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid request body",
+		})
+	}
+	span, err := validateCalendarEvent(&req)
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: err.Error(),
+		})
+	}
+
+	schoolID, err := userSchoolID(h.db, userID)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch school",
+		})
+	}
+	if req.ReplacesEventID != nil {
+		if status, msg := checkReplacedEvent(h.db, *req.ReplacesEventID, schoolID); status != 0 {
+			return c.Status(status).JSON(models.ErrorResponse{
+				Error:   true,
+				Message: msg,
+			})
+		}
+	}
+
+	var eventID uuid.UUID
+	insertQuery := `
+		INSERT INTO calendar_events (school_id, school_year, event_type, name, starts_on, ends_on,
+		                             calendar, month, day, duration_days, replaces_event_id, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		RETURNING id
+	`
+	err = h.db.QueryRow(insertQuery, schoolID, nullableString(req.SchoolYear), req.EventType, req.Name,
+		span.startsOn, span.endsOn, nullableString(req.Calendar), nullableInt(req.Month), nullableInt(req.Day),
+		span.days, req.ReplacesEventID, userID).Scan(&eventID)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to create calendar event",
+		})
+	}
+
+	event, err := loadCalendarEvent(h.db, eventID)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch calendar event",
+		})
+	}
+
+	return c.Status(201).JSON(event)
+}
+
+// UpdateCalendarEvent changes one of the school's events. National
+// holidays cannot be changed, only replaced for a school year.
+func (h *CalendarHandler) UpdateCalendarEvent(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	eventUUID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid event ID",
+		})
+	}
+
+	var req models.SaveCalendarEventRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid request body",
+		})
+	}
+	span, err := validateCalendarEvent(&req)
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: err.Error(),
+		})
+	}
+
+	schoolID, err := userSchoolID(h.db, userID)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch school",
+		})
+	}
+	if status, msg := checkSchoolEvent(h.db, eventUUID, schoolID); status != 0 {
+		return c.Status(status).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: msg,
+		})
+	}
+	if req.ReplacesEventID != nil {
+		if *req.ReplacesEventID == eventUUID {
+			return c.Status(400).JSON(models.ErrorResponse{
+				Error:   true,
+				Message: "An event cannot replace itself",
+			})
+		}
+		if status, msg := checkReplacedEvent(h.db, *req.ReplacesEventID, schoolID); status != 0 {
+			return c.Status(status).JSON(models.ErrorResponse{
+				Error:   true,
+				Message: msg,
+			})
+		}
+	}
+
+	updateQuery := `
+		UPDATE calendar_events
+		SET school_year = $2, event_type = $3, name = $4, starts_on = $5, ends_on = $6, calendar = $7,
+		    month = $8, day = $9, duration_days = $10, replaces_event_id = $11
+		WHERE id = $1
+	`
+	_, err = h.db.Exec(updateQuery, eventUUID, nullableString(req.SchoolYear), req.EventType, req.Name,
+		span.startsOn, span.endsOn, nullableString(req.Calendar), nullableInt(req.Month), nullableInt(req.Day),
+		span.days, req.ReplacesEventID)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to update calendar event",
+		})
+	}
+
+	event, err := loadCalendarEvent(h.db, eventUUID)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch calendar event",
+		})
+	}
+
+	return c.JSON(event)
+}
+
+// DeleteCalendarEvent removes one of the school's events
+func (h *CalendarHandler) DeleteCalendarEvent(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	eventUUID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid event ID",
+		})
+	}
+
+	schoolID, err := userSchoolID(h.db, userID)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch school",
+		})
+	}
+	if status, msg := checkSchoolEvent(h.db, eventUUID, schoolID); status != 0 {
+		return c.Status(status).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: msg,
+		})
+	}
+
+	if _, err := h.db.Exec(`DELETE FROM calendar_events WHERE id = $1`, eventUUID); err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to delete calendar event",
+		})
+	}
+
+	return c.JSON(models.SuccessResponse{
+		Success: true,
+		Message: "Calendar event deleted successfully",
+	})
+}
+
+// eventSpan is what validateCalendarEvent works out for the columns of
+// an event: its dates when it falls in a school year, and its length
+type eventSpan struct {
+	startsOn, endsOn *time.Time
+	days             int
+}
+
+// validateCalendarEvent checks an event request and works out its dates
+func validateCalendarEvent(req *models.SaveCalendarEventRequest) (*eventSpan, error) {
+	req.Name = strings.TrimSpace(req.Name)
+	req.SchoolYear = strings.TrimSpace(req.SchoolYear)
+
+	switch {
+	case req.EventType != models.CalendarHoliday && req.EventType != models.CalendarExam:
+		return nil, errors.New("Event type must be holiday or exam")
+	case req.Name == "":
+		return nil, errors.New("Name is required")
+	case utf8.RuneCountInString(req.Name) > 255:
+		return nil, errors.New("Name is too long")
+	}
+
+	if req.SchoolYear == "" {
+		return validateRecurringEvent(req)
+	}
+
+	from, to, err := schoolcal.YearRange(req.SchoolYear)
+	if err != nil {
+		return nil, errors.New("School year must look like 2025-2026")
+	}
+	if req.Calendar != "" || req.Month != 0 || req.Day != 0 {
+		return nil, errors.New("An event in a school year takes dates, not a month and day")
+	}
+
+	var start time.Time
+	switch {
+	case (req.StartsOn == "") == (req.HijriDate == ""):
+		return nil, errors.New("Give either starts_on or hijri_date")
+	case req.HijriDate != "":
+		date, err := hijri.Parse(req.HijriDate)
+		if err != nil {
+			return nil, errors.New("Invalid hijri_date (YYYY-MM-DD in the Hijri calendar)")
+		}
+		start = date.Gregorian()
+	default:
+		if start, err = time.Parse("2006-01-02", req.StartsOn); err != nil {
+			return nil, errors.New("Invalid starts_on format (YYYY-MM-DD)")
+		}
+	}
+
+	end := start.AddDate(0, 0, max(req.Days, 1)-1)
+	if req.EndsOn != "" {
+		if end, err = time.Parse("2006-01-02", req.EndsOn); err != nil {
+			return nil, errors.New("Invalid ends_on format (YYYY-MM-DD)")
+		}
+	}
+	switch {
+	case end.Before(start):
+		return nil, errors.New("ends_on must not be before starts_on")
+	case start.Before(from) || end.After(to):
+		return nil, fmt.Errorf("The event must fall between %s and %s", from.Format("2006-01-02"), to.Format("2006-01-02"))
+	}
+	return &eventSpan{startsOn: &start, endsOn: &end, days: int(end.Sub(start).Hours()/24) + 1}, nil
+}
+
+// validateRecurringEvent checks the month and day of an event that
+// recurs every year
+func validateRecurringEvent(req *models.SaveCalendarEventRequest) (*eventSpan, error) {
+	days := max(req.Days, 1)
+	switch {
+	case req.StartsOn != "" || req.EndsOn != "" || req.HijriDate != "":
+		return nil, errors.New("An event with dates needs a school year")
+	case req.ReplacesEventID != nil:
+		return nil, errors.New("Only an event in a school year can replace another")
+	case req.Calendar != schoolcal.CalendarGregorian && req.Calendar != schoolcal.CalendarHijri:
+		return nil, errors.New("Calendar must be gregorian or hijri")
+	case req.Month < 1 || req.Month > 12:
+		return nil, errors.New("Month must be between 1 and 12")
+	case days > 30:
+		return nil, errors.New("A recurring event lasts at most 30 days")
+	}
+
+	var monthLength int
+	if req.Calendar == schoolcal.CalendarHijri {
+		monthLength = 30
+	} else {
+		// A leap year, so that 29 February is allowed
+		monthLength = time.Date(2024, time.Month(req.Month)+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	}
+	if req.Day < 1 || req.Day > monthLength {
+		return nil, fmt.Errorf("Day must be between 1 and %d", monthLength)
+	}
+	return &eventSpan{days: days}, nil
+}
+
+// checkSchoolEvent returns a non-zero status and message unless the event
+// belongs to the school
+func checkSchoolEvent(db queryRower, eventID, schoolID uuid.UUID) (int, string) {
+	var national bool
+	query := `SELECT school_id IS NULL FROM calendar_events WHERE id = $1 AND (school_id = $2 OR school_id IS NULL)`
+	err := db.QueryRow(query, eventID, schoolID).Scan(&national)
+	if err == sql.ErrNoRows {
+		return 404, "Calendar event not found"
+	}
+	if err != nil {
+		return 500, "Failed to fetch calendar event"
+	}
+	if national {
+		return 403, "National holidays cannot be changed. Add an event that replaces it in this school year instead"
+	}
+	return 0, ""
+}
+
+// checkReplacedEvent returns a non-zero status and message unless the
+// event is a recurring event of the school's calendar
+func checkReplacedEvent(db queryRower, eventID, schoolID uuid.UUID) (int, string) {
+	var recurring bool
+	query := `SELECT school_year IS NULL FROM calendar_events WHERE id = $1 AND (school_id = $2 OR school_id IS NULL)`
+	err := db.QueryRow(query, eventID, schoolID).Scan(&recurring)
+	if err == sql.ErrNoRows {
+		return 400, "Replaced event not found"
+	}
+	if err != nil {
+		return 500, "Failed to fetch calendar event"
+	}
+	if !recurring {
+		return 400, "Only an event that recurs every year can be replaced"
+	}
+	return 0, ""
+}
+
+// loadSchoolTerms returns the terms that overlap from to to, taking the
+// school's dates over the ministry's
+func loadSchoolTerms(db *sql.DB, schoolID uuid.UUID, from, to time.Time) ([]models.SchoolTerm, error) {
+	query := `
+		SELECT DISTINCT ON (school_year, semester)
+		       school_year, semester, starts_on, ends_on, school_id IS NOT NULL
+		FROM school_terms
+		WHERE (school_id = $1 OR school_id IS NULL) AND starts_on <= $3 AND ends_on >= $2
+		ORDER BY school_year, semester, school_id IS NULL
+	`
+	rows, err := db.Query(query, schoolID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var terms []models.SchoolTerm
+	for rows.Next() {
+		var term models.SchoolTerm
+		if err := rows.Scan(&term.SchoolYear, &term.Semester, &term.StartsOn, &term.EndsOn, &term.IsCustom); err != nil {
+			return nil, err
+		}
+		terms = append(terms, term)
+	}
+	return terms, rows.Err()
+}
+
+// loadCalendarEvents returns the holidays and exam periods that overlap
+// from to to, ordered by date. Recurring events are listed once per
+// occurrence, except where an event replaces them in that school year.
+func loadCalendarEvents(db *sql.DB, schoolID uuid.UUID, from, to time.Time) ([]models.CalendarEvent, error) {
+	query := `
+		SELECT ` + calendarEventColumns + `
+		FROM calendar_events
+		WHERE (school_id = $1 OR school_id IS NULL)
+		  AND (school_year IS NULL OR replaces_event_id IS NOT NULL OR (starts_on <= $3 AND ends_on >= $2))
+	`
+	rows, err := db.Query(query, schoolID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var recurring, dated []models.CalendarEvent
+	replaced := map[uuid.UUID]map[string]bool{}
+	for rows.Next() {
+		var event models.CalendarEvent
+		if err := scanCalendarEvent(rows, &event); err != nil {
+			return nil, err
+		}
+		if event.IsRecurring {
+			recurring = append(recurring, event)
+			continue
+		}
+		if event.ReplacesEventID != nil {
+			if replaced[*event.ReplacesEventID] == nil {
+				replaced[*event.ReplacesEventID] = map[string]bool{}
+			}
+			replaced[*event.ReplacesEventID][*event.SchoolYear] = true
+		}
+		dated = append(dated, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	events := []models.CalendarEvent{}
+	for _, event := range dated {
+		if !event.StartsOn.After(to) && !event.EndsOn.Before(from) {
+			events = append(events, event)
+		}
+	}
+	for _, event := range recurring {
+		// Start early enough to catch an occurrence already under way
+		for _, span := range eventRule(event).Occurrences(from.AddDate(0, 0, -event.DurationDays), to) {
+			if span.End.Before(from) || replaced[event.ID][schoolcal.SchoolYear(span.Start)] {
+				continue
+			}
+			occurrence := event
+			occurrence.StartsOn, occurrence.EndsOn = span.Start, span.End
+			setHijriDate(&occurrence)
+			events = append(events, occurrence)
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].StartsOn.Before(events[j].StartsOn)
+	})
+	return events, nil
+}
+
+// loadCalendarEvent returns an event; a recurring one with the dates of
+// its next occurrence
+func loadCalendarEvent(db queryRower, eventID uuid.UUID) (*models.CalendarEvent, error) {
+	query := `SELECT ` + calendarEventColumns + ` FROM calendar_events WHERE id = $1`
+	var event models.CalendarEvent
+	if err := scanCalendarEvent(db.QueryRow(query, eventID), &event); err != nil {
+		return nil, err
+	}
+	if event.IsRecurring {
+		now := time.Now().In(schoolTime)
+		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+		if spans := eventRule(event).Occurrences(today, today.AddDate(1, 0, 0)); len(spans) > 0 {
+			event.StartsOn, event.EndsOn = spans[0].Start, spans[0].End
+			setHijriDate(&event)
+		}
+	}
+	return &event, nil
+}
+
+// loadSchoolCalendar returns the school's terms and holidays from from to
+// to
+func loadSchoolCalendar(db *sql.DB, schoolID uuid.UUID, from, to time.Time) (*schoolcal.Calendar, error) {
+	terms, err := loadSchoolTerms(db, schoolID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	events, err := loadCalendarEvents(db, schoolID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	return newSchoolCalendar(terms, events), nil
+}
+
+func newSchoolCalendar(terms []models.SchoolTerm, events []models.CalendarEvent) *schoolcal.Calendar {
+	cal := &schoolcal.Calendar{}
+	for _, term := range terms {
+		cal.Terms = append(cal.Terms, schoolcal.Span{Start: term.StartsOn, End: term.EndsOn})
+	}
+	for _, event := range events {
+		if event.EventType == models.CalendarHoliday {
+			cal.Holidays = append(cal.Holidays, schoolcal.Span{Start: event.StartsOn, End: event.EndsOn})
+		}
+	}
+	return cal
+}
+
+// studentSchoolID returns the school of a student's teachers, preferring
+// the classes the student is enrolled in now
+func studentSchoolID(db queryRower, studentID uuid.UUID) (uuid.UUID, error) {
+	var schoolID uuid.UUID
+	query := `
+		SELECT u.school_id
+		FROM student_classes sc
+		JOIN classes c ON sc.class_id = c.id
+		JOIN users u ON c.teacher_id = u.id
+		WHERE sc.student_id = $1
+		ORDER BY sc.is_active DESC, c.is_active DESC, sc.enrollment_date DESC
+		LIMIT 1
+	`
+	err := db.QueryRow(query, studentID).Scan(&schoolID)
+	return schoolID, err
+}
+
+func eventRule(event models.CalendarEvent) schoolcal.Rule {
+	rule := schoolcal.Rule{Days: event.DurationDays}
+	if event.Calendar != nil && event.Month != nil && event.Day != nil {
+		rule.Calendar, rule.Month, rule.Day = *event.Calendar, *event.Month, *event.Day
+	}
+	return rule
+}
+
+func setHijriDate(event *models.CalendarEvent) {
+	date := hijri.FromGregorian(event.StartsOn)
+	event.HijriDate = date.String()
+	event.HijriLabel = date.Arabic()
+}
+
+func scanCalendarEvent(row rowScanner, event *models.CalendarEvent) error {
+	var startsOn, endsOn *time.Time
+	if err := row.Scan(&event.ID, &event.IsNational, &event.SchoolYear, &event.EventType, &event.Name,
+		&startsOn, &endsOn, &event.Calendar, &event.Month, &event.Day, &event.DurationDays,
+		&event.ReplacesEventID); err != nil {
+		return err
+	}
+	event.IsRecurring = event.SchoolYear == nil
+	if startsOn != nil && endsOn != nil {
+		event.StartsOn, event.EndsOn = *startsOn, *endsOn
+		setHijriDate(event)
+	}
+	return nil
+}
+
+// nullableInt converts a zero int into a SQL NULL
+func nullableInt(n int) *int {
+	if n == 0 {
+		return nil
+	}
+	return &n
+}
@@ -0,0 +1,309 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"moalemplus/internal/ical"
+	"moalemplus/internal/models"
+)
+
+// The feed covers sessions and holidays from feedDaysBack days ago to
+// feedDaysAhead days from now
+const (
+	feedDaysBack  = 30
+	feedDaysAhead = 180
+)
+
+// feedUIDDomain ends the UIDs of the feed's events
+const feedUIDDomain = "@moalemplus"
+
+// CreateCalendarFeed issues the teacher's calendar subscription URL. A
+// URL issued before stops working.
+func (h *CalendarHandler) CreateCalendarFeed(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to generate feed token",
+		})
+	}
+	token := hex.EncodeToString(secret)
+
+	feed := models.CalendarFeed{
+		URL: fmt.Sprintf("%s/api/calendar/%s.ics", c.BaseURL(), token),
+	}
+	updateQuery := `
+		UPDATE users SET calendar_feed_token_hash = $1, calendar_feed_created_at = CURRENT_TIMESTAMP
+		WHERE id = $2
+		RETURNING calendar_feed_created_at
+	`
+	if err := h.db.QueryRow(updateQuery, feedTokenHash(token), userID).Scan(&feed.CreatedAt); err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to save feed token",
+		})
+	}
+
+	return c.Status(201).JSON(feed)
+}
+
+// DeleteCalendarFeed turns the teacher's calendar subscription off
+func (h *CalendarHandler) DeleteCalendarFeed(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	updateQuery := `UPDATE users SET calendar_feed_token_hash = NULL, calendar_feed_created_at = NULL WHERE id = $1`
+	if _, err := h.db.Exec(updateQuery, userID); err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to remove feed token",
+		})
+	}
+
+	return c.JSON(models.SuccessResponse{
+		Success: true,
+		Message: "Calendar feed removed successfully",
+	})
+}
+
+// GetCalendarFeed serves a teacher's timetable sessions, scheduled tests,
+// holidays and exam periods as an iCalendar feed. The token in the URL
+// takes the place of signing in, since calendar clients cannot.
+func (h *CalendarHandler) GetCalendarFeed(c *fiber.Ctx) error {
+	token := strings.TrimSuffix(c.Params("token"), ".ics")
+
+	var userID uuid.UUID
+	var teacherName string
+	query := `
+		SELECT id, full_name FROM users
+		WHERE calendar_feed_token_hash = $1 AND is_active = true
+	`
+	err := h.db.QueryRow(query, feedTokenHash(token)).Scan(&userID, &teacherName)
+	if err == sql.ErrNoRows {
+		return c.Status(404).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Calendar feed not found",
+		})
+	}
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch calendar feed",
+		})
+	}
+
+	now := time.Now().In(schoolTime)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	from, to := today.AddDate(0, 0, -feedDaysBack), today.AddDate(0, 0, feedDaysAhead)
+
+	schoolID, err := userSchoolID(h.db, userID)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch school",
+		})
+	}
+	events, err := loadCalendarEvents(h.db, schoolID, from, to)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch calendar events",
+		})
+	}
+	sessions, err := feedSessions(h.db, userID, schoolID, from, to)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch timetable",
+		})
+	}
+	tests, err := feedTests(h.db, userID, from)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch tests",
+		})
+	}
+
+	calendar := ical.Calendar{
+		ProductID: "-//Moalem Plus//Teacher Calendar//AR",
+		Name:      "معلم بلس - " + teacherName,
+		Stamp:     now,
+	}
+	for _, event := range events {
+		uid := "event-" + event.ID.String()
+		if event.IsRecurring {
+			uid += "-" + event.StartsOn.Format("20060102")
+		}
+		category := "عطلة"
+		if event.EventType == models.CalendarExam {
+			category = "اختبارات"
+		}
+		calendar.Events = append(calendar.Events, ical.Event{
+			UID:         uid + feedUIDDomain,
+			Start:       event.StartsOn,
+			End:         event.EndsOn,
+			AllDay:      true,
+			Summary:     event.Name,
+			Description: event.HijriLabel,
+			Categories:  []string{category},
+		})
+	}
+	calendar.Events = append(calendar.Events, sessions...)
+	calendar.Events = append(calendar.Events, tests...)
+
+	c.Set(fiber.HeaderContentType, "text/calendar; charset=utf-8")
+	c.Set(fiber.HeaderContentDisposition, `inline; filename="moalemplus.ics"`)
+	return c.Send(calendar.Bytes())
+}
+
+// feedSessions returns the meetings of the teacher's classes from from to
+// to, on school days and without those cancelled by a schedule exception
+func feedSessions(db *sql.DB, teacherID, schoolID uuid.UUID, from, to time.Time) ([]ical.Event, error) {
+	query := `
+		SELECT ` + classScheduleColumns + `
+		FROM class_schedules cs
+		JOIN classes c ON cs.class_id = c.id
+		LEFT JOIN subjects sub ON c.subject_id = sub.id
+		WHERE c.teacher_id = $1 AND c.is_active = true
+		  AND (cs.starts_on IS NULL OR cs.starts_on <= $3)
+		  AND (cs.ends_on IS NULL OR cs.ends_on >= $2)
+		ORDER BY cs.start_time
+	`
+	schedules, err := queryClassSchedules(db, query, teacherID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	type cancelled struct {
+		date    time.Time
+		classID *uuid.UUID
+		period  *int
+	}
+	rows, err := db.Query(`
+		SELECT date, class_id, period_number FROM schedule_exceptions
+		WHERE teacher_id = $1 AND date BETWEEN $2 AND $3
+	`, teacherID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	var exceptions []cancelled
+	for rows.Next() {
+		var e cancelled
+		if err := rows.Scan(&e.date, &e.classID, &e.period); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		e.date = e.date.UTC()
+		exceptions = append(exceptions, e)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	cal, err := loadSchoolCalendar(db, schoolID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []ical.Event
+	for _, day := range cal.SchoolDays(from, to) {
+	sessions:
+		for _, schedule := range schedules {
+			if schedule.DayOfWeek != int(day.Weekday()) ||
+				(schedule.StartsOn != nil && day.Before(*schedule.StartsOn)) ||
+				(schedule.EndsOn != nil && day.After(*schedule.EndsOn)) {
+				continue
+			}
+			for _, e := range exceptions {
+				if e.date.Equal(day) && (e.classID == nil || *e.classID == schedule.ClassID) &&
+					(e.period == nil || *e.period == schedule.PeriodNumber) {
+					continue sessions
+				}
+			}
+
+			event := ical.Event{
+				UID:         fmt.Sprintf("session-%s-%s%s", schedule.ID, day.Format("20060102"), feedUIDDomain),
+				Start:       clockOn(day, schedule.StartTime),
+				End:         clockOn(day, schedule.EndTime),
+				Summary:     schedule.ClassName,
+				Description: fmt.Sprintf("الحصة %d", schedule.PeriodNumber),
+				Categories:  []string{"حصة"},
+			}
+			if schedule.SubjectName != "" {
+				event.Summary = schedule.SubjectName + " - " + schedule.ClassName
+			}
+			if schedule.Room != nil {
+				event.Location = *schedule.Room
+			}
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+// feedTests returns the teacher's scheduled tests that end on or after
+// from. A test without an end runs for its duration.
+func feedTests(db *sql.DB, teacherID uuid.UUID, from time.Time) ([]ical.Event, error) {
+	query := `
+		SELECT t.id, t.title_arabic, c.name, t.scheduled_start,
+		       COALESCE(t.scheduled_end, t.scheduled_start + t.duration_minutes * INTERVAL '1 minute'),
+		       t.is_published
+		FROM tests t
+		JOIN classes c ON t.class_id = c.id
+		WHERE c.teacher_id = $1 AND t.is_active = true AND t.scheduled_start IS NOT NULL
+		  AND COALESCE(t.scheduled_end, t.scheduled_start) >= $2
+		ORDER BY t.scheduled_start
+	`
+	rows, err := db.Query(query, teacherID, from)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []ical.Event
+	for rows.Next() {
+		var testID uuid.UUID
+		var title, className string
+		var start, end time.Time
+		var published bool
+		if err := rows.Scan(&testID, &title, &className, &start, &end, &published); err != nil {
+			return nil, err
+		}
+		description := "منشور"
+		if !published {
+			description = "غير منشور"
+		}
+		events = append(events, ical.Event{
+			UID:         "test-" + testID.String() + feedUIDDomain,
+			Start:       start,
+			End:         end,
+			Summary:     "اختبار: " + title + " - " + className,
+			Description: description,
+			Categories:  []string{"اختبار"},
+		})
+	}
+	return events, rows.Err()
+}
+
+// clockOn returns the HH:MM time on a day in the school's time zone
+func clockOn(day time.Time, clock string) time.Time {
+	t, _ := time.Parse("15:04", clock)
+	return time.Date(day.Year(), day.Month(), day.Day(), t.Hour(), t.Minute(), 0, 0, schoolTime)
+}
+
+func feedTokenHash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
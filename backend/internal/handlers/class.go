@@ -32,7 +32,8 @@ func (h *ClassHandler) GetClasses(c *fiber.Ctx) error {
 			COUNT(st.id) as student_count
 		FROM classes c
 		LEFT JOIN subjects s ON c.subject_id = s.id
-		LEFT JOIN students st ON c.id = st.class_id AND st.is_active = true
+		LEFT JOIN student_classes sc ON c.id = sc.class_id AND sc.is_active = true
+		LEFT JOIN students st ON sc.student_id = st.id AND st.is_active = true
 		WHERE c.teacher_id = $1 AND c.is_active = true
 		GROUP BY c.id, s.name
 		ORDER BY c.created_at DESC
@@ -92,7 +93,8 @@ func (h *ClassHandler) GetClass(c *fiber.Ctx) error {
 			COUNT(st.id) as student_count
 		FROM classes c
 		LEFT JOIN subjects s ON c.subject_id = s.id
-		LEFT JOIN students st ON c.id = st.class_id AND st.is_active = true
+		LEFT JOIN student_classes sc ON c.id = sc.class_id AND sc.is_active = true
+		LEFT JOIN students st ON sc.student_id = st.id AND st.is_active = true
 		WHERE c.id = $1 AND c.teacher_id = $2 AND c.is_active = true
 		GROUP BY c.id, s.name
 	`
@@ -326,12 +328,13 @@ func (h *ClassHandler) GetClassStats(c *fiber.Ctx) error {
 	// Get class statistics
 	statsQuery := `
 		SELECT 
-			COUNT(s.id) as total_students,
-			COUNT(CASE WHEN s.is_active = true THEN 1 END) as active_students,
-			COUNT(CASE WHEN s.gender = 'male' AND s.is_active = true THEN 1 END) as male_students,
-			COUNT(CASE WHEN s.gender = 'female' AND s.is_active = true THEN 1 END) as female_students,
+			COUNT(DISTINCT s.id) as total_students,
+			COUNT(CASE WHEN sc.is_active = true AND s.is_active = true THEN 1 END) as active_students,
+			COUNT(CASE WHEN s.gender = 'male' AND sc.is_active = true AND s.is_active = true THEN 1 END) as male_students,
+			COUNT(CASE WHEN s.gender = 'female' AND sc.is_active = true AND s.is_active = true THEN 1 END) as female_students,
 			COALESCE(AVG(attendance_rates.rate), 0) as average_attendance
-		FROM students s
+		FROM student_classes sc
+		JOIN students s ON sc.student_id = s.id
 		LEFT JOIN (
 			SELECT 
 				student_id,
@@ -340,7 +343,7 @@ func (h *ClassHandler) GetClassStats(c *fiber.Ctx) error {
 			WHERE class_id = $1 AND date >= CURRENT_DATE - INTERVAL '30 days'
 			GROUP BY student_id
 		) attendance_rates ON s.id = attendance_rates.student_id
-		WHERE s.class_id = $1
+		WHERE sc.class_id = $1
 	`
 	
 	var stats models.ClassStats
@@ -0,0 +1,338 @@
+package handlers
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"moalemplus/internal/arabic"
+	"moalemplus/internal/models"
+)
+
+// classStudentCountQuery counts the students on a class's roster ($1)
+const classStudentCountQuery = `
+	SELECT COUNT(*) FROM student_classes sc
+	JOIN students s ON sc.student_id = s.id
+	WHERE sc.class_id = $1 AND sc.is_active = true AND s.is_active = true
+`
+
+// EnrollStudent adds an existing student, usually one already taught by
+// another teacher, to a class. The student is found by student number and
+// civil ID, or date of birth for a student without a civil ID.
+func (h *StudentHandler) EnrollStudent(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	classUUID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid class ID",
+		})
+	}
+
+	var req models.EnrollStudentRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid request body",
+		})
+	}
+	req.StudentNumber = strings.ToUpper(arabic.WesternDigits(strings.TrimSpace(req.StudentNumber)))
+	req.CivilID = arabic.WesternDigits(strings.TrimSpace(req.CivilID))
+	req.DateOfBirth = arabic.WesternDigits(strings.TrimSpace(req.DateOfBirth))
+	if req.StudentNumber == "" {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Student number is required",
+		})
+	}
+	if req.CivilID == "" && req.DateOfBirth == "" {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Civil ID or date of birth is required",
+		})
+	}
+	var dateOfBirth *time.Time
+	if req.DateOfBirth != "" {
+		date, err := time.Parse("2006-01-02", req.DateOfBirth)
+		if err != nil {
+			return c.Status(400).JSON(models.ErrorResponse{
+				Error:   true,
+				Message: "Date of birth must be YYYY-MM-DD",
+			})
+		}
+		dateOfBirth = &date
+	}
+
+	// Begin transaction
+	tx, err := h.db.Begin()
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to begin transaction",
+		})
+	}
+	defer tx.Rollback()
+
+	// The date of birth only stands in for a civil ID that is not on file
+	var student models.Student
+	studentQuery := `
+		SELECT id, student_number, civil_id, first_name, last_name, arabic_name, date_of_birth,
+		       gender, nationality, address, is_active, created_at, updated_at
+		FROM students
+		WHERE student_number = $1 AND is_active = true
+		  AND (civil_id = $2 OR (civil_id IS NULL AND date_of_birth = $3))
+	`
+	err = tx.QueryRow(studentQuery, req.StudentNumber, nullableString(req.CivilID), dateOfBirth).Scan(&student.ID, &student.StudentNumber,
+		&student.CivilID, &student.FirstName, &student.LastName, &student.ArabicName, &student.DateOfBirth,
+		&student.Gender, &student.Nationality, &student.Address, &student.IsActive, &student.CreatedAt,
+		&student.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return c.Status(404).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "No student matches these details",
+		})
+	}
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch student",
+		})
+	}
+
+	enrolledOn, status, msg := enrollInClass(tx, classUUID, userID, student.ID)
+	if status != 0 {
+		return c.Status(status).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: msg,
+		})
+	}
+
+	// Commit transaction
+	if err := tx.Commit(); err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to commit transaction",
+		})
+	}
+
+	student.ClassID = &classUUID
+	student.EnrollmentDate = enrolledOn
+	return c.Status(201).JSON(student)
+}
+
+// UnenrollStudent withdraws a student from one class. The student stays
+// enrolled in their other classes; attendance and grades already recorded
+// are kept.
+func (h *StudentHandler) UnenrollStudent(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	classUUID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid class ID",
+		})
+	}
+	studentUUID, err := uuid.Parse(c.Params("studentId"))
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid student ID",
+		})
+	}
+
+	if status, msg := checkOpenClass(h.db, classUUID, userID); status != 0 {
+		if status == 409 {
+			msg = "This class is closed. Its roster can no longer be changed"
+		}
+		return c.Status(status).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: msg,
+		})
+	}
+
+	result, err := h.db.Exec(`
+		UPDATE student_classes SET is_active = false
+		WHERE class_id = $1 AND student_id = $2 AND is_active = true
+	`, classUUID, studentUUID)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to withdraw student",
+		})
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return c.Status(404).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Student is not enrolled in this class",
+		})
+	}
+
+	return c.JSON(models.SuccessResponse{
+		Success: true,
+		Message: "Student withdrawn from the class",
+	})
+}
+
+// TransferStudent moves a student to another of the teacher's classes, such
+// as another section. The old enrollment is closed with today's date; the
+// attendance and grades recorded in the old class are kept.
+func (h *StudentHandler) TransferStudent(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	studentUUID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid student ID",
+		})
+	}
+
+	var req models.TransferStudentRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid request body",
+		})
+	}
+	fromUUID, err := uuid.Parse(req.FromClassID)
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid from_class_id",
+		})
+	}
+	toUUID, err := uuid.Parse(req.ToClassID)
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid to_class_id",
+		})
+	}
+	if fromUUID == toUUID {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Choose a different class to transfer the student to",
+		})
+	}
+
+	// Begin transaction
+	tx, err := h.db.Begin()
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to begin transaction",
+		})
+	}
+	defer tx.Rollback()
+
+	if status, msg := checkOpenClass(tx, fromUUID, userID); status != 0 {
+		if status == 409 {
+			msg = "This class is closed. Its roster can no longer be changed"
+		}
+		return c.Status(status).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: msg,
+		})
+	}
+
+	var completedOn time.Time
+	leaveQuery := `
+		UPDATE student_classes SET is_active = false, completion_date = CURRENT_DATE
+		WHERE student_id = $1 AND class_id = $2 AND is_active = true
+		RETURNING completion_date
+	`
+	err = tx.QueryRow(leaveQuery, studentUUID, fromUUID).Scan(&completedOn)
+	if err == sql.ErrNoRows {
+		return c.Status(404).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Student is not enrolled in this class",
+		})
+	}
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to close enrollment",
+		})
+	}
+
+	enrolledOn, status, msg := enrollInClass(tx, toUUID, userID, studentUUID)
+	if status != 0 {
+		return c.Status(status).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: msg,
+		})
+	}
+
+	// Commit transaction
+	if err := tx.Commit(); err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to commit transaction",
+		})
+	}
+
+	return c.JSON(models.SuccessResponse{
+		Success: true,
+		Message: "Student transferred successfully",
+		Data: fiber.Map{
+			"student_id":      studentUUID,
+			"from_class_id":   fromUUID,
+			"to_class_id":     toUUID,
+			"completion_date": completedOn,
+			"enrollment_date": enrolledOn,
+		},
+	})
+}
+
+// enrollInClass enrolls a student in one of the teacher's open classes.
+// A student who left the class before gets a new enrollment and the
+// closed one is kept as it was. The class row stays locked
+// until tx ends, so concurrent enrollments cannot overfill it.
+func enrollInClass(tx *sql.Tx, classID, userID, studentID uuid.UUID) (time.Time, int, string) {
+	var maxStudents int
+	var closedAt *time.Time
+	classQuery := `
+		SELECT max_students, closed_at FROM classes
+		WHERE id = $1 AND teacher_id = $2 AND is_active = true
+		FOR UPDATE
+	`
+	err := tx.QueryRow(classQuery, classID, userID).Scan(&maxStudents, &closedAt)
+	if err == sql.ErrNoRows {
+		return time.Time{}, 404, "Class not found"
+	}
+	if err != nil {
+		return time.Time{}, 500, "Failed to fetch class"
+	}
+	if closedAt != nil {
+		return time.Time{}, 409, "This class is closed. Students can no longer be enrolled"
+	}
+
+	var current int
+	if err := tx.QueryRow(classStudentCountQuery, classID).Scan(&current); err != nil {
+		return time.Time{}, 500, "Failed to check class capacity"
+	}
+	if current >= maxStudents {
+		return time.Time{}, 400, "Class is full"
+	}
+
+	enrollQuery := `
+		INSERT INTO student_classes (student_id, class_id)
+		VALUES ($1, $2)
+		ON CONFLICT (student_id, class_id) WHERE is_active = true DO NOTHING
+		RETURNING enrollment_date
+	`
+	var enrolledOn time.Time
+	err = tx.QueryRow(enrollQuery, studentID, classID).Scan(&enrolledOn)
+	if err == sql.ErrNoRows {
+		return time.Time{}, 409, "Student is already enrolled in this class"
+	}
+	if err != nil {
+		return time.Time{}, 500, "Failed to enroll student"
+	}
+	return enrolledOn, 0, ""
+}
@@ -0,0 +1,416 @@
+package handlers
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"moalemplus/internal/export"
+	"moalemplus/internal/models"
+	"moalemplus/internal/pdf"
+)
+
+// attendanceCodes are the letters printed for each attendance status on
+// the monthly sheet, in the order of its total columns
+var attendanceCodes = []struct {
+	Status string
+	Code   string
+	Label  string
+}{
+	{"present", "ح", "حاضر"},
+	{"absent", "غ", "غائب"},
+	{"late", "ت", "متأخر"},
+	{"excused", "ع", "غياب بعذر"},
+}
+
+// genderNames are the printed names of the genders
+var genderNames = map[string]string{
+	"male":   "ذكر",
+	"female": "أنثى",
+}
+
+// classExportHeader is the school and class printed above an export
+type classExportHeader struct {
+	SchoolName  string
+	District    string
+	ClassName   string
+	SubjectName string
+	TeacherName string
+	SchoolYear  string
+}
+
+// details lists the class lines printed beside the school block
+func (h *classExportHeader) details() []string {
+	lines := []string{}
+	if h.SubjectName != "" {
+		lines = append(lines, "المادة: "+h.SubjectName)
+	}
+	lines = append(lines, "الصف: "+h.ClassName, "المعلم: "+h.TeacherName, "العام الدراسي: "+h.SchoolYear)
+	return lines
+}
+
+// ExportClassStudents exports a class roster as ?format=xlsx (default),
+// csv or pdf
+func (h *StudentHandler) ExportClassStudents(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	classUUID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid class ID",
+		})
+	}
+
+	format, status, msg := exportFormat(c)
+	if status != 0 {
+		return c.Status(status).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: msg,
+		})
+	}
+
+	header, status, msg := loadClassExportHeader(h.db, classUUID, userID)
+	if status != 0 {
+		return c.Status(status).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: msg,
+		})
+	}
+
+	// Same students and 30-day attendance rate as GetClassStudents
+	query := `
+		SELECT s.student_number, s.arabic_name, COALESCE(s.civil_id, ''), s.date_of_birth,
+		       s.gender, s.nationality, rates.rate
+		FROM student_classes sc
+		JOIN students s ON sc.student_id = s.id
+		LEFT JOIN (
+			SELECT student_id, COUNT(*) FILTER (WHERE status = 'present') * 100.0 / COUNT(*) AS rate
+			FROM attendance
+			WHERE class_id = $1 AND date >= CURRENT_DATE - INTERVAL '30 days'
+			GROUP BY student_id
+		) rates ON s.id = rates.student_id
+		WHERE sc.class_id = $1 AND sc.is_active = true AND s.is_active = true
+		ORDER BY s.student_number
+	`
+	rows, err := h.db.Query(query, classUUID)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch students",
+		})
+	}
+	defer rows.Close()
+
+	table := &export.Table{
+		Title:   "كشف أسماء الطلاب",
+		Details: header.details(),
+		Columns: []string{"م", "رقم الطالب", "اسم الطالب", "الرقم المدني", "تاريخ الميلاد", "الجنس", "الجنسية", "نسبة الحضور %"},
+		Numeric: []bool{true, false, false, false, false, false, false, true},
+		Widths:  []float64{1, 2.5, 7, 3.2, 2.6, 1.5, 2.2, 2},
+		Rows:    [][]string{},
+		Notes:   []string{"نسبة الحضور محسوبة لآخر 30 يوماً."},
+	}
+	for rows.Next() {
+		var number, name, civilID, gender, nationality string
+		var dob time.Time
+		var rate *float64
+		if err := rows.Scan(&number, &name, &civilID, &dob, &gender, &nationality, &rate); err != nil {
+			return c.Status(500).JSON(models.ErrorResponse{
+				Error:   true,
+				Message: "Failed to scan student",
+			})
+		}
+		rateText := ""
+		if rate != nil {
+			rateText = strconv.FormatFloat(math.Round(*rate*10)/10, 'f', -1, 64)
+		}
+		table.Rows = append(table.Rows, []string{
+			strconv.Itoa(len(table.Rows) + 1), number, name, civilID, dob.Format("2006-01-02"),
+			genderNames[gender], nationality, rateText,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch students",
+		})
+	}
+
+	return sendExport(c, format, fmt.Sprintf("students-%s", classUUID), table, header, false, 10)
+}
+
+// ExportAttendanceSheet exports a month of a class's attendance as a grid
+// of students and school days for ?month=YYYY-MM (default this month) in
+// ?format=xlsx (default), csv or pdf
+func (h *AttendanceHandler) ExportAttendanceSheet(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	classUUID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid class ID",
+		})
+	}
+
+	format, status, msg := exportFormat(c)
+	if status != 0 {
+		return c.Status(status).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: msg,
+		})
+	}
+
+	now := time.Now()
+	start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	if month := c.Query("month"); month != "" {
+		start, err = time.Parse("2006-01", month)
+		if err != nil {
+			return c.Status(400).JSON(models.ErrorResponse{
+				Error:   true,
+				Message: "Invalid month format (YYYY-MM)",
+			})
+		}
+	}
+	end := start.AddDate(0, 1, 0)
+
+	header, status, msg := loadClassExportHeader(h.db, classUUID, userID)
+	if status != 0 {
+		return c.Status(status).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: msg,
+		})
+	}
+
+	// Students who left the class during the month keep their row
+	studentsQuery := `
+		SELECT s.id, s.student_number, s.arabic_name
+		FROM students s
+		WHERE (s.is_active = true AND s.id IN (
+		          SELECT student_id FROM student_classes WHERE class_id = $1 AND is_active = true))
+		   OR s.id IN (SELECT student_id FROM attendance WHERE class_id = $1 AND date >= $2 AND date < $3)
+		ORDER BY s.student_number
+	`
+	rows, err := h.db.Query(studentsQuery, classUUID, start, end)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch students",
+		})
+	}
+	type sheetStudent struct {
+		ID     uuid.UUID
+		Number string
+		Name   string
+	}
+	var students []sheetStudent
+	for rows.Next() {
+		var s sheetStudent
+		if err := rows.Scan(&s.ID, &s.Number, &s.Name); err != nil {
+			rows.Close()
+			return c.Status(500).JSON(models.ErrorResponse{
+				Error:   true,
+				Message: "Failed to scan student",
+			})
+		}
+		students = append(students, s)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch students",
+		})
+	}
+
+	recordsQuery := `
+		SELECT student_id, date, period_number, status
+		FROM attendance
+		WHERE class_id = $1 AND date >= $2 AND date < $3
+	`
+	rows, err = h.db.Query(recordsQuery, classUUID, start, end)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch attendance records",
+		})
+	}
+	// A class that meets more than once a day gets a column per period
+	type session struct{ day, period int }
+	statuses := map[uuid.UUID]map[session]string{}
+	sessions := map[session]bool{}
+	periods := map[int]int{}
+	for rows.Next() {
+		var studentID uuid.UUID
+		var date time.Time
+		var period int
+		var status string
+		if err := rows.Scan(&studentID, &date, &period, &status); err != nil {
+			rows.Close()
+			return c.Status(500).JSON(models.ErrorResponse{
+				Error:   true,
+				Message: "Failed to scan attendance record",
+			})
+		}
+		if statuses[studentID] == nil {
+			statuses[studentID] = map[session]string{}
+		}
+		key := session{date.Day(), period}
+		statuses[studentID][key] = status
+		if !sessions[key] {
+			sessions[key] = true
+			periods[key.day]++
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch attendance records",
+		})
+	}
+
+	// Only periods in which attendance was taken get a column
+	var sessionList []session
+	for key := range sessions {
+		sessionList = append(sessionList, key)
+	}
+	sort.Slice(sessionList, func(i, j int) bool {
+		if sessionList[i].day != sessionList[j].day {
+			return sessionList[i].day < sessionList[j].day
+		}
+		return sessionList[i].period < sessionList[j].period
+	})
+
+	codes := map[string]string{}
+	legend := ""
+	table := &export.Table{
+		Title:   fmt.Sprintf("سجل الحضور والغياب لشهر %d/%d", int(start.Month()), start.Year()),
+		Details: header.details(),
+		Columns: []string{"م", "رقم الطالب", "اسم الطالب"},
+		Numeric: []bool{true, false, false},
+		Widths:  []float64{1.2, 3, 9},
+		Rows:    [][]string{},
+	}
+	for _, key := range sessionList {
+		label := strconv.Itoa(key.day)
+		if periods[key.day] > 1 {
+			label = fmt.Sprintf("%d (%d)", key.day, key.period)
+		}
+		table.Columns = append(table.Columns, label)
+		table.Numeric = append(table.Numeric, false)
+		table.Widths = append(table.Widths, 1.3)
+	}
+	for _, ac := range attendanceCodes {
+		codes[ac.Status] = ac.Code
+		if legend != "" {
+			legend += "   "
+		}
+		legend += ac.Code + ": " + ac.Label
+		table.Columns = append(table.Columns, ac.Code)
+		table.Numeric = append(table.Numeric, true)
+		table.Widths = append(table.Widths, 1.5)
+	}
+	table.Notes = []string{legend}
+
+	for i, student := range students {
+		row := []string{strconv.Itoa(i + 1), student.Number, student.Name}
+		totals := map[string]int{}
+		for _, key := range sessionList {
+			status := statuses[student.ID][key]
+			totals[status]++
+			row = append(row, codes[status])
+		}
+		for _, ac := range attendanceCodes {
+			row = append(row, strconv.Itoa(totals[ac.Status]))
+		}
+		table.Rows = append(table.Rows, row)
+	}
+
+	fontSize := 9.0
+	if len(sessionList) > 15 {
+		fontSize = 7
+	}
+	filename := fmt.Sprintf("attendance-%s-%s", classUUID, start.Format("2006-01"))
+	return sendExport(c, format, filename, table, header, true, fontSize)
+}
+
+// exportFormat reads ?format=, which defaults to xlsx
+func exportFormat(c *fiber.Ctx) (string, int, string) {
+	format := c.Query("format", export.FormatXLSX)
+	if _, ok := export.ContentTypes[format]; !ok {
+		return "", 400, "Format must be xlsx, csv or pdf"
+	}
+	return format, 0, ""
+}
+
+// loadClassExportHeader checks that the class belongs to the teacher and
+// loads its school details
+func loadClassExportHeader(db *sql.DB, classID, userID uuid.UUID) (*classExportHeader, int, string) {
+	header := &classExportHeader{}
+	query := `
+		SELECT s.name, s.district, c.name, COALESCE(sub.name_arabic, ''), u.full_name, c.school_year
+		FROM classes c
+		JOIN users u ON c.teacher_id = u.id
+		JOIN schools s ON u.school_id = s.id
+		LEFT JOIN subjects sub ON c.subject_id = sub.id
+		WHERE c.id = $1 AND c.teacher_id = $2 AND c.is_active = true
+	`
+	err := db.QueryRow(query, classID, userID).Scan(&header.SchoolName, &header.District,
+		&header.ClassName, &header.SubjectName, &header.TeacherName, &header.SchoolYear)
+	if err == sql.ErrNoRows {
+		return nil, 404, "Class not found"
+	}
+	if err != nil {
+		return nil, 500, "Failed to load school details"
+	}
+	return header, 0, ""
+}
+
+// sendExport renders the table in the requested format and sends it as an
+// attachment
+func sendExport(c *fiber.Ctx, format, filename string, table *export.Table, header *classExportHeader,
+	landscape bool, fontSize float64) error {
+	var buf bytes.Buffer
+	var err error
+	switch format {
+	case export.FormatCSV:
+		err = export.WriteCSV(&buf, table)
+	case export.FormatXLSX:
+		details := append([]string{header.SchoolName}, table.Details...)
+		sheet := *table
+		sheet.Details = details
+		err = export.WriteXLSX(&buf, &sheet)
+	case export.FormatPDF:
+		err = pdf.RenderTable(&buf, &pdf.Table{
+			SchoolName: header.SchoolName,
+			District:   header.District,
+			Title:      table.Title,
+			Details:    table.Details,
+			Columns:    table.Columns,
+			Widths:     table.Widths,
+			Rows:       table.Rows,
+			Notes:      table.Notes,
+			Landscape:  landscape,
+			FontSize:   fontSize,
+		})
+	}
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to generate export",
+		})
+	}
+
+	c.Set(fiber.HeaderContentType, export.ContentTypes[format])
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s.%s"`, filename, format))
+	return c.Send(buf.Bytes())
+}
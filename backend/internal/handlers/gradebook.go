@@ -0,0 +1,1080 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"moalemplus/internal/gradebook"
+	"moalemplus/internal/models"
+)
+
+type GradebookHandler struct {
+	db *sql.DB
+}
+
+func NewGradebookHandler(db *sql.DB) *GradebookHandler {
+	return &GradebookHandler{db: db}
+}
+
+var gradeCategoryTypes = map[string]bool{
+	models.GradeCategoryQuiz:          true,
+	models.GradeCategoryExam:          true,
+	models.GradeCategoryHomework:      true,
+	models.GradeCategoryParticipation: true,
+	models.GradeCategoryProject:       true,
+	models.GradeCategoryOther:         true,
+}
+
+// GetGradebook returns a class's categories, items, scores and each
+// student's running and term grades
+func (h *GradebookHandler) GetGradebook(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	classUUID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid class ID",
+		})
+	}
+
+	book := models.Gradebook{ClassID: classUUID}
+	classQuery := `
+		SELECT name, school_year, semester, closed_at
+		FROM classes WHERE id = $1 AND teacher_id = $2 AND is_active = true
+	`
+	err = h.db.QueryRow(classQuery, classUUID, userID).Scan(&book.ClassName, &book.SchoolYear,
+		&book.Semester, &book.ClosedAt)
+	if err == sql.ErrNoRows {
+		return c.Status(404).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Class not found",
+		})
+	}
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch class",
+		})
+	}
+
+	if err := loadGradebook(h.db, &book); err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch gradebook",
+		})
+	}
+
+	return c.JSON(book)
+}
+
+// CreateGradeCategory adds a weighted category to a class's gradebook
+func (h *GradebookHandler) CreateGradeCategory(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	classUUID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid class ID",
+		})
+	}
+
+	var req models.SaveGradeCategoryRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid request body",
+		})
+	}
+
+	if status, msg := checkOpenClass(h.db, classUUID, userID); status != 0 {
+		return c.Status(status).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: msg,
+		})
+	}
+
+	if status, msg := h.validateGradeCategory(&req, classUUID, uuid.Nil); status != 0 {
+		return c.Status(status).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: msg,
+		})
+	}
+
+	category := models.GradeCategory{
+		ID:           uuid.New(),
+		ClassID:      classUUID,
+		Name:         req.Name,
+		NameArabic:   req.NameArabic,
+		CategoryType: req.CategoryType,
+		Weight:       req.Weight,
+		SortOrder:    req.SortOrder,
+	}
+	insertQuery := `
+		INSERT INTO grade_categories (id, class_id, name, name_arabic, category_type, weight, sort_order)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING created_at, updated_at
+	`
+	err = h.db.QueryRow(insertQuery, category.ID, category.ClassID, category.Name, category.NameArabic,
+		category.CategoryType, category.Weight, category.SortOrder).Scan(&category.CreatedAt, &category.UpdatedAt)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return c.Status(409).JSON(models.ErrorResponse{
+				Error:   true,
+				Message: "A category with this name already exists in the class",
+			})
+		}
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to create category",
+		})
+	}
+
+	return c.Status(201).JSON(category)
+}
+
+// UpdateGradeCategory updates a grade category
+func (h *GradebookHandler) UpdateGradeCategory(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	categoryUUID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid category ID",
+		})
+	}
+
+	var req models.SaveGradeCategoryRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid request body",
+		})
+	}
+
+	classUUID, status, msg := gradeCategoryClass(h.db, categoryUUID, userID)
+	if status != 0 {
+		return c.Status(status).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: msg,
+		})
+	}
+
+	if status, msg := h.validateGradeCategory(&req, classUUID, categoryUUID); status != 0 {
+		return c.Status(status).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: msg,
+		})
+	}
+
+	updateQuery := `
+		UPDATE grade_categories
+		SET name = $1, name_arabic = $2, category_type = $3, weight = $4, sort_order = $5,
+		    updated_at = CURRENT_TIMESTAMP
+		WHERE id = $6
+		RETURNING updated_at
+	`
+	var updatedAt time.Time
+	err = h.db.QueryRow(updateQuery, req.Name, req.NameArabic, req.CategoryType, req.Weight,
+		req.SortOrder, categoryUUID).Scan(&updatedAt)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return c.Status(409).JSON(models.ErrorResponse{
+				Error:   true,
+				Message: "A category with this name already exists in the class",
+			})
+		}
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to update category",
+		})
+	}
+
+	return c.JSON(models.SuccessResponse{
+		Success: true,
+		Message: "Category updated successfully",
+		Data: fiber.Map{
+			"updated_at": updatedAt,
+		},
+	})
+}
+
+// DeleteGradeCategory deletes a grade category with its items and scores
+func (h *GradebookHandler) DeleteGradeCategory(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	categoryUUID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid category ID",
+		})
+	}
+
+	if _, status, msg := gradeCategoryClass(h.db, categoryUUID, userID); status != 0 {
+		return c.Status(status).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: msg,
+		})
+	}
+
+	if _, err := h.db.Exec(`DELETE FROM grade_categories WHERE id = $1`, categoryUUID); err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to delete category",
+		})
+	}
+
+	return c.JSON(models.SuccessResponse{
+		Success: true,
+		Message: "Category deleted successfully",
+	})
+}
+
+// CreateGradeItem adds a graded piece of work to a class's gradebook
+func (h *GradebookHandler) CreateGradeItem(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	classUUID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid class ID",
+		})
+	}
+
+	var req models.SaveGradeItemRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid request body",
+		})
+	}
+
+	if status, msg := checkOpenClass(h.db, classUUID, userID); status != 0 {
+		return c.Status(status).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: msg,
+		})
+	}
+
+	item := models.GradeItem{
+		ID:        uuid.New(),
+		ClassID:   classUUID,
+		CreatedBy: userID,
+	}
+	if status, msg := h.applyGradeItemRequest(&req, &item); status != 0 {
+		return c.Status(status).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: msg,
+		})
+	}
+
+	insertQuery := `
+		INSERT INTO grade_items (id, class_id, category_id, title, title_arabic, max_score, due_date, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING created_at, updated_at
+	`
+	err = h.db.QueryRow(insertQuery, item.ID, item.ClassID, item.CategoryID, item.Title, item.TitleArabic,
+		item.MaxScore, item.DueDate, item.CreatedBy).Scan(&item.CreatedAt, &item.UpdatedAt)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to create grade item",
+		})
+	}
+
+	return c.Status(201).JSON(item)
+}
+
+// UpdateGradeItem updates a grade item. Lowering the maximum score below a
+// recorded score is rejected.
+func (h *GradebookHandler) UpdateGradeItem(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	itemUUID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid grade item ID",
+		})
+	}
+
+	var req models.SaveGradeItemRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid request body",
+		})
+	}
+
+	item, status, msg := findGradeItem(h.db, itemUUID, userID)
+	if status != 0 {
+		return c.Status(status).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: msg,
+		})
+	}
+	if item.TestID != nil && req.MaxScore != item.MaxScore {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Scores imported from a test are percentages. The maximum score cannot be changed",
+		})
+	}
+	if status, msg := h.applyGradeItemRequest(&req, item); status != 0 {
+		return c.Status(status).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: msg,
+		})
+	}
+
+	var highest float64
+	highestQuery := `SELECT COALESCE(MAX(score), 0) FROM grades WHERE grade_item_id = $1`
+	if err := h.db.QueryRow(highestQuery, item.ID).Scan(&highest); err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch scores",
+		})
+	}
+	if highest > item.MaxScore {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: fmt.Sprintf("A student already scored %g. The maximum score cannot be lower", highest),
+		})
+	}
+
+	updateQuery := `
+		UPDATE grade_items
+		SET category_id = $1, title = $2, title_arabic = $3, max_score = $4, due_date = $5,
+		    updated_at = CURRENT_TIMESTAMP
+		WHERE id = $6
+		RETURNING updated_at
+	`
+	err = h.db.QueryRow(updateQuery, item.CategoryID, item.Title, item.TitleArabic, item.MaxScore,
+		item.DueDate, item.ID).Scan(&item.UpdatedAt)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to update grade item",
+		})
+	}
+
+	return c.JSON(item)
+}
+
+// DeleteGradeItem deletes a grade item and its scores
+func (h *GradebookHandler) DeleteGradeItem(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	itemUUID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid grade item ID",
+		})
+	}
+
+	if _, status, msg := findGradeItem(h.db, itemUUID, userID); status != 0 {
+		return c.Status(status).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: msg,
+		})
+	}
+
+	if _, err := h.db.Exec(`DELETE FROM grade_items WHERE id = $1`, itemUUID); err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to delete grade item",
+		})
+	}
+
+	return c.JSON(models.SuccessResponse{
+		Success: true,
+		Message: "Grade item deleted successfully",
+	})
+}
+
+// SaveGradeScores records scores for a grade item. A score that is empty,
+// not excused and has no notes is cleared.
+func (h *GradebookHandler) SaveGradeScores(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	itemUUID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid grade item ID",
+		})
+	}
+
+	var req models.SaveGradeScoresRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid request body",
+		})
+	}
+	if len(req.Scores) == 0 {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "At least one score is required",
+		})
+	}
+
+	item, status, msg := findGradeItem(h.db, itemUUID, userID)
+	if status != 0 {
+		return c.Status(status).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: msg,
+		})
+	}
+
+	students, err := classStudentIDs(h.db, item.ClassID)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch students",
+		})
+	}
+	seen := map[uuid.UUID]bool{}
+	for _, score := range req.Scores {
+		if !students[score.StudentID] {
+			return c.Status(400).JSON(models.ErrorResponse{
+				Error:   true,
+				Message: fmt.Sprintf("Student %s is not in this class", score.StudentID),
+			})
+		}
+		if seen[score.StudentID] {
+			return c.Status(400).JSON(models.ErrorResponse{
+				Error:   true,
+				Message: fmt.Sprintf("Student %s is scored more than once", score.StudentID),
+			})
+		}
+		seen[score.StudentID] = true
+		if score.Score != nil && (*score.Score < 0 || *score.Score > item.MaxScore) {
+			return c.Status(400).JSON(models.ErrorResponse{
+				Error:   true,
+				Message: fmt.Sprintf("Scores must be between 0 and %g", item.MaxScore),
+			})
+		}
+	}
+
+	// Begin transaction
+	tx, err := h.db.Begin()
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to begin transaction",
+		})
+	}
+	defer tx.Rollback()
+
+	upsertQuery := `
+		INSERT INTO grades (grade_item_id, student_id, score, is_excused, notes, recorded_by)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (grade_item_id, student_id) DO UPDATE
+		SET score = EXCLUDED.score, is_excused = EXCLUDED.is_excused, notes = EXCLUDED.notes,
+		    recorded_by = EXCLUDED.recorded_by, updated_at = CURRENT_TIMESTAMP
+	`
+	deleteQuery := `DELETE FROM grades WHERE grade_item_id = $1 AND student_id = $2`
+	for _, score := range req.Scores {
+		notes := strings.TrimSpace(score.Notes)
+		if score.Score == nil && !score.IsExcused && notes == "" {
+			_, err = tx.Exec(deleteQuery, item.ID, score.StudentID)
+		} else {
+			_, err = tx.Exec(upsertQuery, item.ID, score.StudentID, score.Score, score.IsExcused,
+				nullableString(notes), userID)
+		}
+		if err != nil {
+			return c.Status(500).JSON(models.ErrorResponse{
+				Error:   true,
+				Message: "Failed to save scores",
+			})
+		}
+	}
+
+	// Commit transaction
+	if err := tx.Commit(); err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to commit transaction",
+		})
+	}
+
+	return c.JSON(models.SuccessResponse{
+		Success: true,
+		Message: "Scores saved successfully",
+		Data: fiber.Map{
+			"grade_item_id": item.ID,
+			"saved":         len(req.Scores),
+		},
+	})
+}
+
+// ImportTestGrades records the results of one of the class's online tests
+// as a grade item out of 100. Each student's best finished attempt counts;
+// attempts still waiting for manual grading are skipped, and importing the
+// test again refreshes the scores.
+func (h *GradebookHandler) ImportTestGrades(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	classUUID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid class ID",
+		})
+	}
+
+	var req models.ImportTestGradesRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid request body",
+		})
+	}
+
+	testUUID, err := uuid.Parse(req.TestID)
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid test ID",
+		})
+	}
+	categoryUUID, err := uuid.Parse(req.CategoryID)
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid category ID",
+		})
+	}
+
+	if status, msg := checkOpenClass(h.db, classUUID, userID); status != 0 {
+		return c.Status(status).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: msg,
+		})
+	}
+
+	test, err := getTeacherTest(h.db, testUUID, userID)
+	if err == sql.ErrNoRows || (err == nil && test.ClassID != classUUID) {
+		return c.Status(404).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Test not found in this class",
+		})
+	}
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch test",
+		})
+	}
+
+	if status, msg := checkClassCategory(h.db, categoryUUID, classUUID); status != 0 {
+		return c.Status(status).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: msg,
+		})
+	}
+
+	// Begin transaction
+	tx, err := h.db.Begin()
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to begin transaction",
+		})
+	}
+	defer tx.Rollback()
+
+	var itemID uuid.UUID
+	itemQuery := `
+		INSERT INTO grade_items (class_id, category_id, title, title_arabic, max_score, due_date, test_id, created_by)
+		VALUES ($1, $2, $3, $4, 100, $5::timestamptz::date, $6, $7)
+		ON CONFLICT (class_id, test_id) DO UPDATE
+		SET category_id = EXCLUDED.category_id, title = EXCLUDED.title,
+		    title_arabic = EXCLUDED.title_arabic, updated_at = CURRENT_TIMESTAMP
+		RETURNING id
+	`
+	err = tx.QueryRow(itemQuery, classUUID, categoryUUID, test.Title, test.TitleArabic,
+		test.ScheduledEnd, test.ID, userID).Scan(&itemID)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to create grade item",
+		})
+	}
+
+	// Excused students keep their excuse; everyone else gets their best
+	// fully graded attempt
+	importQuery := `
+		INSERT INTO grades (grade_item_id, student_id, score, recorded_by)
+		SELECT $1, ts.student_id, MAX(ts.percentage_score), $3
+		FROM test_submissions ts
+		JOIN students st ON ts.student_id = st.id
+		JOIN student_classes sc ON sc.student_id = st.id AND sc.class_id = $4 AND sc.is_active = true
+		WHERE ts.test_id = $2 AND st.is_active = true AND ` + gradedSubmission + `
+		GROUP BY ts.student_id
+		ON CONFLICT (grade_item_id, student_id) DO UPDATE
+		SET score = EXCLUDED.score, recorded_by = EXCLUDED.recorded_by, updated_at = CURRENT_TIMESTAMP
+		WHERE NOT grades.is_excused
+	`
+	result, err := tx.Exec(importQuery, itemID, test.ID, userID, classUUID)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to import scores",
+		})
+	}
+	imported, _ := result.RowsAffected()
+
+	var awaitingGrading int
+	awaitingQuery := `
+		SELECT COUNT(DISTINCT ts.student_id)
+		FROM test_submissions ts
+		JOIN test_submission_answers tsa ON tsa.submission_id = ts.id
+		WHERE ts.test_id = $1 AND tsa.grading_status IN ('pending', 'pending_manual')
+		  AND ts.status <> 'in_progress'
+		  AND NOT EXISTS (SELECT 1 FROM grades g WHERE g.grade_item_id = $2 AND g.student_id = ts.student_id)
+	`
+	if err := tx.QueryRow(awaitingQuery, test.ID, itemID).Scan(&awaitingGrading); err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to count attempts awaiting grading",
+		})
+	}
+
+	// Commit transaction
+	if err := tx.Commit(); err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to commit transaction",
+		})
+	}
+
+	return c.JSON(models.SuccessResponse{
+		Success: true,
+		Message: "Test scores imported successfully",
+		Data: fiber.Map{
+			"grade_item_id":    itemID,
+			"imported":         imported,
+			"awaiting_grading": awaitingGrading,
+		},
+	})
+}
+
+// CloseClass computes every student's term grade, writes it to
+// student_classes.final_grade and makes the gradebook read-only
+func (h *GradebookHandler) CloseClass(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	classUUID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid class ID",
+		})
+	}
+
+	// Begin transaction
+	tx, err := h.db.Begin()
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to begin transaction",
+		})
+	}
+	defer tx.Rollback()
+
+	book := models.Gradebook{ClassID: classUUID}
+	classQuery := `
+		SELECT name, school_year, semester, closed_at
+		FROM classes WHERE id = $1 AND teacher_id = $2 AND is_active = true
+		FOR UPDATE
+	`
+	err = tx.QueryRow(classQuery, classUUID, userID).Scan(&book.ClassName, &book.SchoolYear,
+		&book.Semester, &book.ClosedAt)
+	if err == sql.ErrNoRows {
+		return c.Status(404).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Class not found",
+		})
+	}
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch class",
+		})
+	}
+	if book.ClosedAt != nil {
+		return c.Status(409).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "This class is already closed",
+		})
+	}
+
+	if err := loadGradebook(tx, &book); err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch gradebook",
+		})
+	}
+	if len(book.Items) == 0 {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "The gradebook has no grade items to compute final grades from",
+		})
+	}
+
+	// Students stay on the roster of the closed class with their final grade
+	enrollQuery := `
+		UPDATE student_classes SET completion_date = CURRENT_DATE, final_grade = $3
+		WHERE student_id = $1 AND class_id = $2 AND is_active = true
+	`
+	for _, student := range book.Students {
+		if _, err := tx.Exec(enrollQuery, student.StudentID, classUUID, student.TermGrade); err != nil {
+			return c.Status(500).JSON(models.ErrorResponse{
+				Error:   true,
+				Message: "Failed to save final grades",
+			})
+		}
+	}
+
+	var closedAt time.Time
+	closeQuery := `UPDATE classes SET closed_at = NOW(), updated_at = CURRENT_TIMESTAMP WHERE id = $1 RETURNING closed_at`
+	if err := tx.QueryRow(closeQuery, classUUID).Scan(&closedAt); err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to close class",
+		})
+	}
+
+	// Commit transaction
+	if err := tx.Commit(); err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to commit transaction",
+		})
+	}
+
+	book.ClosedAt = &closedAt
+	return c.JSON(models.SuccessResponse{
+		Success: true,
+		Message: "Class closed and final grades saved",
+		Data:    book,
+	})
+}
+
+// validateGradeCategory checks a category request and that the class's
+// weights still add up to at most 100. excludeID is the category being
+// updated, or uuid.Nil.
+func (h *GradebookHandler) validateGradeCategory(req *models.SaveGradeCategoryRequest, classID, excludeID uuid.UUID) (int, string) {
+	req.Name = strings.TrimSpace(req.Name)
+	req.NameArabic = strings.TrimSpace(req.NameArabic)
+	if req.Name == "" || req.NameArabic == "" {
+		return 400, "Name and Arabic name are required"
+	}
+	if req.CategoryType == "" {
+		req.CategoryType = models.GradeCategoryOther
+	}
+	if !gradeCategoryTypes[req.CategoryType] {
+		return 400, "Invalid category type"
+	}
+	if req.Weight <= 0 || req.Weight > 100 {
+		return 400, "Weight must be greater than 0 and at most 100"
+	}
+
+	var otherWeights float64
+	weightQuery := `SELECT COALESCE(SUM(weight), 0) FROM grade_categories WHERE class_id = $1 AND id <> $2`
+	if err := h.db.QueryRow(weightQuery, classID, excludeID).Scan(&otherWeights); err != nil {
+		return 500, "Failed to check category weights"
+	}
+	if otherWeights+req.Weight > 100 {
+		return 400, fmt.Sprintf("Category weights would add up to %g%%. The total cannot exceed 100%%", otherWeights+req.Weight)
+	}
+	return 0, ""
+}
+
+// applyGradeItemRequest validates a grade item request and copies it into item
+func (h *GradebookHandler) applyGradeItemRequest(req *models.SaveGradeItemRequest, item *models.GradeItem) (int, string) {
+	categoryID, err := uuid.Parse(req.CategoryID)
+	if err != nil {
+		return 400, "Invalid category ID"
+	}
+	if status, msg := checkClassCategory(h.db, categoryID, item.ClassID); status != 0 {
+		return status, msg
+	}
+
+	item.Title = strings.TrimSpace(req.Title)
+	item.TitleArabic = strings.TrimSpace(req.TitleArabic)
+	if item.Title == "" || item.TitleArabic == "" {
+		return 400, "Title and Arabic title are required"
+	}
+	if req.MaxScore <= 0 || req.MaxScore > 1000 {
+		return 400, "Maximum score must be greater than 0 and at most 1000"
+	}
+
+	item.DueDate = nil
+	if req.DueDate != "" {
+		dueDate, err := time.Parse("2006-01-02", req.DueDate)
+		if err != nil {
+			return 400, "Invalid due date format (YYYY-MM-DD)"
+		}
+		item.DueDate = &dueDate
+	}
+	item.CategoryID = categoryID
+	item.MaxScore = req.MaxScore
+	return 0, ""
+}
+
+// checkOpenClass returns a non-zero status and message when the class does
+// not belong to the teacher or its gradebook was closed
+func checkOpenClass(db queryRower, classID, userID uuid.UUID) (int, string) {
+	var closedAt *time.Time
+	query := `SELECT closed_at FROM classes WHERE id = $1 AND teacher_id = $2 AND is_active = true`
+	err := db.QueryRow(query, classID, userID).Scan(&closedAt)
+	if err == sql.ErrNoRows {
+		return 404, "Class not found"
+	}
+	if err != nil {
+		return 500, "Failed to fetch class"
+	}
+	if closedAt != nil {
+		return 409, "This class is closed. Its grades can no longer be changed"
+	}
+	return 0, ""
+}
+
+// checkClassCategory returns a non-zero status and message when the
+// category is not part of the class's gradebook
+func checkClassCategory(db queryRower, categoryID, classID uuid.UUID) (int, string) {
+	var id uuid.UUID
+	query := `SELECT id FROM grade_categories WHERE id = $1 AND class_id = $2`
+	err := db.QueryRow(query, categoryID, classID).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 400, "Category not found in this class"
+	}
+	if err != nil {
+		return 500, "Failed to fetch category"
+	}
+	return 0, ""
+}
+
+// gradeCategoryClass returns the class of a category in one of the
+// teacher's open classes
+func gradeCategoryClass(db *sql.DB, categoryID, userID uuid.UUID) (uuid.UUID, int, string) {
+	var classID uuid.UUID
+	err := db.QueryRow(`SELECT class_id FROM grade_categories WHERE id = $1`, categoryID).Scan(&classID)
+	if err == sql.ErrNoRows {
+		return uuid.Nil, 404, "Category not found"
+	}
+	if err != nil {
+		return uuid.Nil, 500, "Failed to fetch category"
+	}
+	if status, msg := checkOpenClass(db, classID, userID); status != 0 {
+		if status == 404 {
+			msg = "Category not found"
+		}
+		return uuid.Nil, status, msg
+	}
+	return classID, 0, ""
+}
+
+// findGradeItem loads a grade item of one of the teacher's open classes
+func findGradeItem(db *sql.DB, itemID, userID uuid.UUID) (*models.GradeItem, int, string) {
+	var item models.GradeItem
+	query := `
+		SELECT id, class_id, category_id, title, title_arabic, max_score, due_date, test_id,
+		       created_by, created_at, updated_at
+		FROM grade_items WHERE id = $1
+	`
+	err := db.QueryRow(query, itemID).Scan(&item.ID, &item.ClassID, &item.CategoryID, &item.Title,
+		&item.TitleArabic, &item.MaxScore, &item.DueDate, &item.TestID, &item.CreatedBy,
+		&item.CreatedAt, &item.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, 404, "Grade item not found"
+	}
+	if err != nil {
+		return nil, 500, "Failed to fetch grade item"
+	}
+	if status, msg := checkOpenClass(db, item.ClassID, userID); status != 0 {
+		if status == 404 {
+			msg = "Grade item not found"
+		}
+		return nil, status, msg
+	}
+	return &item, 0, ""
+}
+
+// classStudentIDs returns the students on a class's roster
+func classStudentIDs(db *sql.DB, classID uuid.UUID) (map[uuid.UUID]bool, error) {
+	rows, err := db.Query(`
+		SELECT s.id FROM student_classes sc
+		JOIN students s ON sc.student_id = s.id
+		WHERE sc.class_id = $1 AND sc.is_active = true AND s.is_active = true
+	`, classID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := map[uuid.UUID]bool{}
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids[id] = true
+	}
+	return ids, rows.Err()
+}
+
+// gradebookQuerier is the part of *sql.DB and *sql.Tx the gradebook reads use
+type gradebookQuerier interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// loadGradebook fills book with the categories, items and scores of
+// book.ClassID and computes each enrolled student's grades
+func loadGradebook(db gradebookQuerier, book *models.Gradebook) error {
+	book.Categories = []models.GradeCategory{}
+	book.Items = []models.GradeItem{}
+	book.Students = []models.GradebookStudent{}
+	book.TotalWeight = 0
+
+	rows, err := db.Query(`
+		SELECT id, class_id, name, name_arabic, category_type, weight, sort_order, created_at, updated_at
+		FROM grade_categories WHERE class_id = $1
+		ORDER BY sort_order, created_at
+	`, book.ClassID)
+	if err != nil {
+		return err
+	}
+	var categories []gradebook.Category
+	for rows.Next() {
+		var category models.GradeCategory
+		if err := rows.Scan(&category.ID, &category.ClassID, &category.Name, &category.NameArabic,
+			&category.CategoryType, &category.Weight, &category.SortOrder, &category.CreatedAt,
+			&category.UpdatedAt); err != nil {
+			rows.Close()
+			return err
+		}
+		book.Categories = append(book.Categories, category)
+		book.TotalWeight += category.Weight
+		categories = append(categories, gradebook.Category{ID: category.ID, Weight: category.Weight})
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	rows, err = db.Query(`
+		SELECT gi.id, gi.class_id, gi.category_id, gi.title, gi.title_arabic, gi.max_score,
+		       gi.due_date, gi.test_id, gi.created_by, gi.created_at, gi.updated_at
+		FROM grade_items gi
+		JOIN grade_categories gc ON gi.category_id = gc.id
+		WHERE gi.class_id = $1
+		ORDER BY gc.sort_order, gc.created_at, gi.due_date NULLS LAST, gi.created_at
+	`, book.ClassID)
+	if err != nil {
+		return err
+	}
+	var items []gradebook.Item
+	for rows.Next() {
+		var item models.GradeItem
+		if err := rows.Scan(&item.ID, &item.ClassID, &item.CategoryID, &item.Title, &item.TitleArabic,
+			&item.MaxScore, &item.DueDate, &item.TestID, &item.CreatedBy, &item.CreatedAt,
+			&item.UpdatedAt); err != nil {
+			rows.Close()
+			return err
+		}
+		book.Items = append(book.Items, item)
+		items = append(items, gradebook.Item{ID: item.ID, CategoryID: item.CategoryID, MaxScore: item.MaxScore})
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	rows, err = db.Query(`
+		SELECT s.id, s.student_number, s.arabic_name
+		FROM student_classes sc
+		JOIN students s ON sc.student_id = s.id
+		WHERE sc.class_id = $1 AND sc.is_active = true AND s.is_active = true
+		ORDER BY s.arabic_name
+	`, book.ClassID)
+	if err != nil {
+		return err
+	}
+	index := map[uuid.UUID]int{}
+	for rows.Next() {
+		var student models.GradebookStudent
+		if err := rows.Scan(&student.StudentID, &student.StudentNumber, &student.StudentName); err != nil {
+			rows.Close()
+			return err
+		}
+		student.Scores = []models.GradebookScore{}
+		index[student.StudentID] = len(book.Students)
+		book.Students = append(book.Students, student)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	rows, err = db.Query(`
+		SELECT g.student_id, g.grade_item_id, g.score, g.is_excused, g.notes
+		FROM grades g
+		JOIN grade_items gi ON g.grade_item_id = gi.id
+		WHERE gi.class_id = $1
+	`, book.ClassID)
+	if err != nil {
+		return err
+	}
+	scores := map[uuid.UUID]map[uuid.UUID]gradebook.Score{}
+	for rows.Next() {
+		var studentID uuid.UUID
+		var score models.GradebookScore
+		if err := rows.Scan(&studentID, &score.GradeItemID, &score.Score, &score.IsExcused, &score.Notes); err != nil {
+			rows.Close()
+			return err
+		}
+		i, ok := index[studentID]
+		if !ok {
+			continue
+		}
+		book.Students[i].Scores = append(book.Students[i].Scores, score)
+		if scores[studentID] == nil {
+			scores[studentID] = map[uuid.UUID]gradebook.Score{}
+		}
+		scores[studentID][score.GradeItemID] = gradebook.Score{Points: score.Score, Excused: score.IsExcused}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for i := range book.Students {
+		student := &book.Students[i]
+		result := gradebook.Compute(categories, items, scores[student.StudentID])
+		student.RunningGrade = result.Running
+		student.TermGrade = result.Final
+		student.CategoryGrades = make([]models.CategoryGrade, len(categories))
+		for j, category := range categories {
+			student.CategoryGrades[j] = models.CategoryGrade{
+				CategoryID: category.ID,
+				Running:    result.Categories[category.ID].Running,
+				Final:      result.Categories[category.ID].Final,
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+
+	"github.com/google/uuid"
+
+	"moalemplus/internal/grading"
+	"moalemplus/internal/models"
+	"moalemplus/internal/testvariant"
+)
+
+// gradedAnswer is a test question joined with a submission's answer to it
+type gradedAnswer struct {
+	questionID    uuid.UUID
+	questionType  string
+	correctAnswer string
+	points        int
+	answer        string
+	status        string
+}
+
+// gradeSubmission grades every question of a finished attempt. Unanswered
+// questions get a zero-point row so that each question has a result, and
+// answers a teacher already graded are left alone.
+func gradeSubmission(tx *sql.Tx, submissionID uuid.UUID) error {
+	var testID uuid.UUID
+	var variantID *uuid.UUID
+	err := tx.QueryRow(`SELECT test_id, variant_id FROM test_submissions WHERE id = $1`,
+		submissionID).Scan(&testID, &variantID)
+	if err != nil {
+		return err
+	}
+
+	// Answers are stored with the variant's option letters
+	var layout []models.VariantQuestion
+	if variantID != nil {
+		var raw []byte
+		if err := tx.QueryRow(`SELECT layout FROM test_variants WHERE id = $1`, *variantID).Scan(&raw); err != nil {
+			return err
+		}
+		if err := json.Unmarshal(raw, &layout); err != nil {
+			return err
+		}
+	}
+
+	rows, err := tx.Query(`
+		SELECT tq.question_id, q.question_type, q.correct_answer,
+		       COALESCE(tq.points_override, q.points),
+		       COALESCE(tsa.student_answer, ''), COALESCE(tsa.grading_status, 'pending')
+		FROM test_questions tq
+		JOIN questions q ON tq.question_id = q.id
+		LEFT JOIN test_submission_answers tsa ON tsa.submission_id = $1 AND tsa.question_id = tq.question_id
+		WHERE tq.test_id = $2
+	`, submissionID, testID)
+	if err != nil {
+		return err
+	}
+	var answers []gradedAnswer
+	for rows.Next() {
+		var a gradedAnswer
+		if err := rows.Scan(&a.questionID, &a.questionType, &a.correctAnswer, &a.points, &a.answer, &a.status); err != nil {
+			rows.Close()
+			return err
+		}
+		answers = append(answers, a)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	upsertQuery := `
+		INSERT INTO test_submission_answers (submission_id, question_id, is_correct, points_awarded, grading_status, graded_at)
+		VALUES ($1, $2, $3, $4, $5, CASE WHEN $6 THEN NOW() END)
+		ON CONFLICT (submission_id, question_id) DO UPDATE
+		SET is_correct = EXCLUDED.is_correct,
+		    points_awarded = EXCLUDED.points_awarded,
+		    grading_status = EXCLUDED.grading_status,
+		    graded_at = EXCLUDED.graded_at,
+		    updated_at = CURRENT_TIMESTAMP
+	`
+	for _, a := range answers {
+		if a.status == grading.StatusManuallyGraded {
+			continue
+		}
+
+		answer := a.answer
+		if a.questionType == models.QuestionTypeMultipleChoice {
+			answer = testvariant.CanonicalAnswer(layout, a.questionID, answer)
+		}
+		result := grading.Grade(a.questionType, a.correctAnswer, a.points, answer)
+
+		_, err := tx.Exec(upsertQuery, submissionID, a.questionID, result.IsCorrect, result.Points,
+			result.Status, result.Status == grading.StatusAutoGraded)
+		if err != nil {
+			return err
+		}
+	}
+
+	return completeGrading(tx, submissionID)
+}
+
+// gradedSubmission is the condition that the attempt ts finished, by
+// submission or by running out of time, and all its answers are graded
+const gradedSubmission = `ts.graded_at IS NOT NULL`
+
+// completeGrading marks a finished attempt, submitted or expired, as
+// graded once none of its answers is waiting for a teacher
+func completeGrading(tx *sql.Tx, submissionID uuid.UUID) error {
+	_, err := tx.Exec(`
+		UPDATE test_submissions
+		SET status = 'graded', graded_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND status IN ('submitted', 'graded', 'expired') AND graded_at IS NULL
+		  AND NOT EXISTS (
+		      SELECT 1 FROM test_submission_answers
+		      WHERE submission_id = $1 AND grading_status = 'pending_manual'
+		  )
+	`, submissionID)
+	return err
+}
+
+// expireAndGrade expires the overdue attempts matching conditions, appended
+// to expireAttemptsQuery, and grades what their students managed to answer
+func expireAndGrade(tx *sql.Tx, conditions string, args ...interface{}) (int64, error) {
+	rows, err := tx.Query(expireAttemptsQuery+conditions+` RETURNING id`,
+		append([]interface{}{attemptGraceSeconds}, args...)...)
+	if err != nil {
+		return 0, err
+	}
+	var expired []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		expired = append(expired, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, id := range expired {
+		if err := gradeSubmission(tx, id); err != nil {
+			return 0, err
+		}
+	}
+	return int64(len(expired)), nil
+}
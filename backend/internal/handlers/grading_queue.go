@@ -0,0 +1,329 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"moalemplus/internal/models"
+)
+
+// GetGradingQueue lists the answers of a test that are waiting for the
+// teacher, grouped by question. ?question_id limits it to one question.
+func (h *TestHandler) GetGradingQueue(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	testUUID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid test ID",
+		})
+	}
+
+	test, err := getTeacherTest(h.db, testUUID, userID)
+	if err == sql.ErrNoRows {
+		return c.Status(404).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Test not found",
+		})
+	}
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch test",
+		})
+	}
+
+	query := `
+		SELECT tsa.id, ts.id, st.id, st.arabic_name, ts.attempt_number, ts.submitted_at,
+		       q.id, tq.question_order, q.question_type, q.question_text, q.question_text_arabic,
+		       q.correct_answer, COALESCE(tsa.student_answer, ''), COALESCE(tq.points_override, q.points)
+		FROM test_submission_answers tsa
+		JOIN test_submissions ts ON tsa.submission_id = ts.id
+		JOIN students st ON ts.student_id = st.id
+		JOIN test_questions tq ON tq.test_id = ts.test_id AND tq.question_id = tsa.question_id
+		JOIN questions q ON tsa.question_id = q.id
+		WHERE ts.test_id = $1 AND tsa.grading_status = 'pending_manual'
+	`
+	args := []interface{}{test.ID}
+	if questionID := c.Query("question_id"); questionID != "" {
+		questionUUID, err := uuid.Parse(questionID)
+		if err != nil {
+			return c.Status(400).JSON(models.ErrorResponse{
+				Error:   true,
+				Message: "Invalid question ID",
+			})
+		}
+		query += " AND tsa.question_id = $2"
+		args = append(args, questionUUID)
+	}
+	query += " ORDER BY tq.question_order, st.arabic_name, ts.attempt_number"
+
+	rows, err := h.db.Query(query, args...)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch grading queue",
+		})
+	}
+	defer rows.Close()
+
+	items := []models.GradingQueueItem{}
+	for rows.Next() {
+		var item models.GradingQueueItem
+		err := rows.Scan(&item.AnswerID, &item.SubmissionID, &item.StudentID, &item.StudentName,
+			&item.AttemptNumber, &item.SubmittedAt, &item.QuestionID, &item.QuestionOrder,
+			&item.QuestionType, &item.QuestionText, &item.QuestionTextArabic, &item.ModelAnswer,
+			&item.StudentAnswer, &item.Points)
+		if err != nil {
+			return c.Status(500).JSON(models.ErrorResponse{
+				Error:   true,
+				Message: "Failed to scan answer",
+			})
+		}
+		items = append(items, item)
+	}
+
+	// Attach each question's rubric once
+	rubrics := map[uuid.UUID]*models.Rubric{}
+	for i := range items {
+		questionID := items[i].QuestionID
+		rubric, loaded := rubrics[questionID]
+		if !loaded {
+			rubric, err = getRubric(h.db, questionID)
+			if err != nil && err != sql.ErrNoRows {
+				return c.Status(500).JSON(models.ErrorResponse{
+					Error:   true,
+					Message: "Failed to fetch rubric",
+				})
+			}
+			rubrics[questionID] = rubric
+		}
+		items[i].Rubric = rubric
+	}
+
+	return c.JSON(items)
+}
+
+// GradeAnswer records the teacher's grade for one answer of a finished
+// attempt. Questions with a rubric are graded per criterion and the total is
+// scaled to the question's points; other questions take points directly.
+// Auto-graded answers may be overridden the same way.
+func (h *TestHandler) GradeAnswer(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	testUUID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid test ID",
+		})
+	}
+
+	answerUUID, err := uuid.Parse(c.Params("answerId"))
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid answer ID",
+		})
+	}
+
+	var req models.GradeAnswerRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid request body",
+		})
+	}
+
+	test, err := getTeacherTest(h.db, testUUID, userID)
+	if err == sql.ErrNoRows {
+		return c.Status(404).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Test not found",
+		})
+	}
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch test",
+		})
+	}
+
+	// Begin transaction
+	tx, err := h.db.Begin()
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to begin transaction",
+		})
+	}
+	defer tx.Rollback()
+
+	var submissionID, questionID uuid.UUID
+	var submissionStatus string
+	var questionPoints int
+	answerQuery := `
+		SELECT tsa.submission_id, tsa.question_id, ts.status, COALESCE(tq.points_override, q.points)
+		FROM test_submission_answers tsa
+		JOIN test_submissions ts ON tsa.submission_id = ts.id
+		JOIN test_questions tq ON tq.test_id = ts.test_id AND tq.question_id = tsa.question_id
+		JOIN questions q ON tsa.question_id = q.id
+		WHERE tsa.id = $1 AND ts.test_id = $2
+		FOR UPDATE OF tsa, ts
+	`
+	err = tx.QueryRow(answerQuery, answerUUID, test.ID).Scan(&submissionID, &questionID,
+		&submissionStatus, &questionPoints)
+	if err == sql.ErrNoRows {
+		return c.Status(404).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Answer not found",
+		})
+	}
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch answer",
+		})
+	}
+	if submissionStatus == models.SubmissionInProgress {
+		return c.Status(409).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "The student is still answering this test",
+		})
+	}
+
+	rubric, err := getRubric(tx, questionID)
+	if err != nil && err != sql.ErrNoRows {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch rubric",
+		})
+	}
+
+	var points int
+	var rubricScores json.RawMessage
+	if rubric != nil {
+		earned, err := rubricScore(rubric, req.CriterionScores)
+		if err != nil {
+			return c.Status(400).JSON(models.ErrorResponse{
+				Error:   true,
+				Message: err.Error(),
+			})
+		}
+		points = scaleRubricPoints(earned, rubric.MaxPoints, questionPoints)
+		rubricScores, _ = json.Marshal(req.CriterionScores)
+	} else {
+		if len(req.CriterionScores) > 0 {
+			return c.Status(400).JSON(models.ErrorResponse{
+				Error:   true,
+				Message: "This question has no rubric. Award points directly",
+			})
+		}
+		if req.Points == nil {
+			return c.Status(400).JSON(models.ErrorResponse{
+				Error:   true,
+				Message: "Points are required",
+			})
+		}
+		points = *req.Points
+	}
+	if points < 0 || points > questionPoints {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: fmt.Sprintf("Points must be between 0 and %d", questionPoints),
+		})
+	}
+
+	updateQuery := `
+		UPDATE test_submission_answers
+		SET points_awarded = $2, is_correct = $3, grading_status = 'manually_graded',
+		    graded_at = NOW(), graded_by = $4, feedback = $5, rubric_scores = $6,
+		    updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+	`
+	_, err = tx.Exec(updateQuery, answerUUID, points, points == questionPoints, userID,
+		nullableString(req.Feedback), nullableJSON(rubricScores))
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to grade answer",
+		})
+	}
+
+	if err := completeGrading(tx, submissionID); err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to update submission",
+		})
+	}
+
+	var totalScore int
+	statusQuery := `SELECT status, total_score FROM test_submissions WHERE id = $1`
+	if err := tx.QueryRow(statusQuery, submissionID).Scan(&submissionStatus, &totalScore); err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch submission",
+		})
+	}
+
+	// Commit transaction
+	if err := tx.Commit(); err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to commit transaction",
+		})
+	}
+
+	return c.JSON(models.SuccessResponse{
+		Success: true,
+		Message: "Answer graded successfully",
+		Data: fiber.Map{
+			"answer_id":         answerUUID,
+			"points_awarded":    points,
+			"max_points":        questionPoints,
+			"submission_id":     submissionID,
+			"submission_status": submissionStatus,
+			"total_score":       totalScore,
+		},
+	})
+}
+
+// rubricScore checks that every criterion of the rubric was scored within
+// its range and returns the points earned on the rubric
+func rubricScore(rubric *models.Rubric, scores []models.CriterionScore) (int, error) {
+	given := map[string]int{}
+	for _, score := range scores {
+		if _, duplicate := given[score.CriterionID]; duplicate {
+			return 0, fmt.Errorf("Criterion %q is scored more than once", score.CriterionID)
+		}
+		given[score.CriterionID] = score.Points
+	}
+
+	earned := 0
+	for _, criterion := range rubric.Criteria {
+		points, ok := given[criterion.ID]
+		if !ok {
+			return 0, fmt.Errorf("Criterion %q has no score", criterion.ID)
+		}
+		best := 0
+		for _, level := range criterion.Levels {
+			if level.Points > best {
+				best = level.Points
+			}
+		}
+		if points < 0 || points > best {
+			return 0, fmt.Errorf("Points for criterion %q must be between 0 and %d", criterion.ID, best)
+		}
+		earned += points
+		delete(given, criterion.ID)
+	}
+	for id := range given {
+		return 0, fmt.Errorf("Criterion %q is not part of the rubric", id)
+	}
+	return earned, nil
+}
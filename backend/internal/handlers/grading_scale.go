@@ -0,0 +1,222 @@
+package handlers
+
+import (
+	"database/sql"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"moalemplus/internal/gradescale"
+	"moalemplus/internal/models"
+)
+
+type GradingScaleHandler struct {
+	db *sql.DB
+}
+
+func NewGradingScaleHandler(db *sql.DB) *GradingScaleHandler {
+	return &GradingScaleHandler{db: db}
+}
+
+// GetGradingScales returns the scale used by the teacher's school for each
+// stage
+func (h *GradingScaleHandler) GetGradingScales(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	schoolID, err := userSchoolID(h.db, userID)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch school",
+		})
+	}
+
+	scales := []models.GradingScale{}
+	for _, schoolType := range gradescale.SchoolTypes {
+		scale, custom, err := loadGradingScale(h.db, schoolID, schoolType)
+		if err != nil {
+			return c.Status(500).JSON(models.ErrorResponse{
+				Error:   true,
+				Message: "Failed to fetch grading scales",
+			})
+		}
+		scales = append(scales, models.GradingScale{
+			SchoolType: schoolType,
+			IsCustom:   custom,
+			Bands:      scale,
+		})
+	}
+
+	return c.JSON(scales)
+}
+
+// SaveGradingScale replaces the school's scale for a stage. There are no
+// school administrators yet, so like the school calendar the scale is
+// shared and any teacher of the school may change it.
+func (h *GradingScaleHandler) SaveGradingScale(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	schoolType := c.Params("schoolType")
+	if !gradescale.IsSchoolType(schoolType) {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "School type must be primary, intermediate or secondary",
+		})
+	}
+
+	var req models.SaveGradingScaleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid request body",
+		})
+	}
+
+	scale, err := gradescale.New(req.Bands)
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: err.Error(),
+		})
+	}
+
+	schoolID, err := userSchoolID(h.db, userID)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch school",
+		})
+	}
+
+	// Begin transaction
+	tx, err := h.db.Begin()
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to begin transaction",
+		})
+	}
+	defer tx.Rollback()
+
+	deleteQuery := `DELETE FROM grading_scales WHERE school_id = $1 AND school_type = $2`
+	if _, err := tx.Exec(deleteQuery, schoolID, schoolType); err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to save grading scale",
+		})
+	}
+
+	insertQuery := `
+		INSERT INTO grading_scales (school_id, school_type, min_grade, letter, descriptor, descriptor_arabic, is_passing)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+	for _, band := range scale {
+		_, err := tx.Exec(insertQuery, schoolID, schoolType, band.MinGrade, band.Letter,
+			band.Descriptor, band.DescriptorArabic, band.IsPassing)
+		if err != nil {
+			return c.Status(500).JSON(models.ErrorResponse{
+				Error:   true,
+				Message: "Failed to save grading scale",
+			})
+		}
+	}
+
+	// Commit transaction
+	if err := tx.Commit(); err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to commit transaction",
+		})
+	}
+
+	return c.JSON(models.GradingScale{
+		SchoolType: schoolType,
+		IsCustom:   true,
+		Bands:      scale,
+	})
+}
+
+// ResetGradingScale drops the school's scale for a stage so the ministry
+// default applies again. Any teacher of the school may, as with
+// SaveGradingScale.
+func (h *GradingScaleHandler) ResetGradingScale(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	schoolType := c.Params("schoolType")
+	if !gradescale.IsSchoolType(schoolType) {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "School type must be primary, intermediate or secondary",
+		})
+	}
+
+	schoolID, err := userSchoolID(h.db, userID)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch school",
+		})
+	}
+
+	deleteQuery := `DELETE FROM grading_scales WHERE school_id = $1 AND school_type = $2`
+	if _, err := h.db.Exec(deleteQuery, schoolID, schoolType); err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to reset grading scale",
+		})
+	}
+
+	return c.JSON(models.SuccessResponse{
+		Success: true,
+		Message: "Grading scale reset to the ministry default",
+	})
+}
+
+// userSchoolID returns the school a teacher works at
+func userSchoolID(db queryRower, userID uuid.UUID) (uuid.UUID, error) {
+	var schoolID uuid.UUID
+	err := db.QueryRow(`SELECT school_id FROM users WHERE id = $1`, userID).Scan(&schoolID)
+	return schoolID, err
+}
+
+// loadGradingScale returns the school's scale for a stage, or the ministry
+// default when the school has none. custom reports which one it is.
+func loadGradingScale(db *sql.DB, schoolID uuid.UUID, schoolType string) (scale gradescale.Scale, custom bool, err error) {
+	query := `
+		SELECT school_id IS NOT NULL, min_grade, letter, descriptor, descriptor_arabic, is_passing
+		FROM grading_scales
+		WHERE school_type = $2 AND (school_id = $1 OR school_id IS NULL)
+		ORDER BY min_grade DESC
+	`
+	rows, err := db.Query(query, schoolID, schoolType)
+	if err != nil {
+		return nil, false, err
+	}
+	defer rows.Close()
+
+	var school, ministry gradescale.Scale
+	for rows.Next() {
+		var own bool
+		var band models.GradingBand
+		if err := rows.Scan(&own, &band.MinGrade, &band.Letter, &band.Descriptor,
+			&band.DescriptorArabic, &band.IsPassing); err != nil {
+			return nil, false, err
+		}
+		if own {
+			school = append(school, band)
+		} else {
+			ministry = append(ministry, band)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, false, err
+	}
+
+	if len(school) > 0 {
+		return school, true, nil
+	}
+	if ministry == nil {
+		ministry = gradescale.Scale{}
+	}
+	return ministry, false, nil
+}
@@ -0,0 +1,458 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"moalemplus/internal/models"
+	"moalemplus/internal/notify"
+)
+
+// notificationMaxAttempts is how often delivery is tried before a message
+// is marked failed
+const notificationMaxAttempts = 5
+
+// notificationEvents are the events that have a message template
+var notificationEvents = []string{
+	models.NotificationAbsent, models.NotificationLate,
+	models.NotificationAbsenceAlert, models.NotificationReferral,
+}
+
+type NotificationHandler struct {
+	db *sql.DB
+}
+
+func NewNotificationHandler(db *sql.DB) *NotificationHandler {
+	return &NotificationHandler{db: db}
+}
+
+// GetNotificationTemplates returns the template used by the teacher's
+// school for each event
+func (h *NotificationHandler) GetNotificationTemplates(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	schoolID, err := userSchoolID(h.db, userID)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch school",
+		})
+	}
+
+	templates := []models.NotificationTemplate{}
+	for _, event := range notificationEvents {
+		template, err := loadNotificationTemplate(h.db, &schoolID, event)
+		if err != nil {
+			return c.Status(500).JSON(models.ErrorResponse{
+				Error:   true,
+				Message: "Failed to fetch notification templates",
+			})
+		}
+		templates = append(templates, *template)
+	}
+
+	return c.JSON(templates)
+}
+
+// SaveNotificationTemplate sets the school's template for an event
+func (h *NotificationHandler) SaveNotificationTemplate(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	event := c.Params("eventType")
+	if !isNotificationEvent(event) {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Event type must be absent, late, absence_alert or referral",
+		})
+	}
+
+	var req models.SaveNotificationTemplateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid request body",
+		})
+	}
+	req.Subject = strings.TrimSpace(req.Subject)
+	req.Body = strings.TrimSpace(req.Body)
+	if req.Subject == "" || req.Body == "" {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Subject and body are required",
+		})
+	}
+	if utf8.RuneCountInString(req.Subject) > 255 {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Subject must be at most 255 characters",
+		})
+	}
+	for _, text := range []string{req.Subject, req.Body} {
+		if err := notify.CheckTemplate(text); err != nil {
+			return c.Status(400).JSON(models.ErrorResponse{
+				Error:   true,
+				Message: err.Error(),
+			})
+		}
+	}
+
+	schoolID, err := userSchoolID(h.db, userID)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch school",
+		})
+	}
+
+	upsertQuery := `
+		INSERT INTO notification_templates (school_id, event_type, subject, body, is_active)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (COALESCE(school_id, '00000000-0000-0000-0000-000000000000'), event_type) DO UPDATE
+		SET subject = EXCLUDED.subject, body = EXCLUDED.body, is_active = EXCLUDED.is_active
+	`
+	if _, err := h.db.Exec(upsertQuery, schoolID, event, req.Subject, req.Body, req.IsActive); err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to save notification template",
+		})
+	}
+
+	return c.JSON(models.NotificationTemplate{
+		EventType:    event,
+		Subject:      req.Subject,
+		Body:         req.Body,
+		IsActive:     req.IsActive,
+		IsCustom:     true,
+		Placeholders: notify.Placeholders,
+	})
+}
+
+// ResetNotificationTemplate drops the school's template for an event so
+// the default applies again
+func (h *NotificationHandler) ResetNotificationTemplate(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	event := c.Params("eventType")
+	if !isNotificationEvent(event) {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Event type must be absent, late, absence_alert or referral",
+		})
+	}
+
+	schoolID, err := userSchoolID(h.db, userID)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch school",
+		})
+	}
+
+	deleteQuery := `DELETE FROM notification_templates WHERE school_id = $1 AND event_type = $2`
+	if _, err := h.db.Exec(deleteQuery, schoolID, event); err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to reset notification template",
+		})
+	}
+
+	return c.JSON(models.SuccessResponse{
+		Success: true,
+		Message: "Notification template reset to the default",
+	})
+}
+
+// isNotificationEvent reports whether event has a message template
+func isNotificationEvent(event string) bool {
+	for _, e := range notificationEvents {
+		if event == e {
+			return true
+		}
+	}
+	return false
+}
+
+// loadNotificationTemplate returns the school's template for an event, or
+// the default when the school has none
+func loadNotificationTemplate(db queryRower, schoolID *uuid.UUID, event string) (*models.NotificationTemplate, error) {
+	query := `
+		SELECT school_id IS NOT NULL, subject, body, is_active
+		FROM notification_templates
+		WHERE event_type = $2 AND (school_id = $1 OR school_id IS NULL)
+		ORDER BY school_id IS NULL
+		LIMIT 1
+	`
+	template := models.NotificationTemplate{EventType: event, Placeholders: notify.Placeholders}
+	err := db.QueryRow(query, schoolID, event).Scan(&template.IsCustom, &template.Subject,
+		&template.Body, &template.IsActive)
+	if err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
+
+// enqueueAttendanceNotices queues a message to the primary contact of each
+// student marked absent or late in the given records, in the same
+// transaction as the attendance change. Pending messages for records that
+// were corrected to another status are cancelled.
+func enqueueAttendanceNotices(tx *sql.Tx, attendanceIDs []uuid.UUID) error {
+	ids := make([]string, len(attendanceIDs))
+	for i, id := range attendanceIDs {
+		ids[i] = id.String()
+	}
+
+	cancelQuery := `
+		UPDATE notification_outbox o SET status = 'cancelled'
+		FROM attendance a
+		WHERE o.attendance_id = a.id AND a.id = ANY($1::uuid[])
+		  AND o.status = 'pending' AND o.event_type <> a.status
+	`
+	if _, err := tx.Exec(cancelQuery, pq.Array(ids)); err != nil {
+		return err
+	}
+
+	query := `
+		SELECT a.id, a.status, a.date, s.id, s.arabic_name, c.name, COALESCE(sub.name_arabic, ''),
+		       u.school_id, COALESCE(sch.name, ''), p.id, p.arabic_name, p.phone_primary, p.email
+		FROM attendance a
+		JOIN students s ON a.student_id = s.id
+		JOIN classes c ON a.class_id = c.id
+		JOIN users u ON c.teacher_id = u.id
+		LEFT JOIN schools sch ON u.school_id = sch.id
+		LEFT JOIN subjects sub ON c.subject_id = sub.id
+		JOIN student_parents sp ON sp.student_id = s.id AND sp.is_primary_contact = true AND sp.is_active = true
+		JOIN parents p ON sp.parent_id = p.id AND p.is_active = true
+		WHERE a.id = ANY($1::uuid[]) AND a.status IN ('absent', 'late')
+		  -- Parents hear once a day per class, not once per period
+		  AND NOT EXISTS (
+		      SELECT 1 FROM attendance a2
+		      JOIN notification_outbox o2 ON o2.attendance_id = a2.id
+		      WHERE a2.student_id = a.student_id AND a2.class_id = a.class_id AND a2.date = a.date
+		        AND a2.id <> a.id AND o2.event_type = a.status AND o2.status <> 'cancelled'
+		  )
+	`
+	type notice struct {
+		attendanceID, studentID, parentID uuid.UUID
+		event                             string
+		schoolID                          *uuid.UUID
+		phone                             string
+		email                             *string
+		vars                              map[string]string
+	}
+	rows, err := tx.Query(query, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	var notices []notice
+	for rows.Next() {
+		var n notice
+		var date time.Time
+		var studentName, className, subjectName, schoolName, parentName string
+		if err := rows.Scan(&n.attendanceID, &n.event, &date, &n.studentID, &studentName, &className,
+			&subjectName, &n.schoolID, &schoolName, &n.parentID, &parentName, &n.phone, &n.email); err != nil {
+			rows.Close()
+			return err
+		}
+		n.vars = notify.DateVars(date)
+		n.vars["student_name"] = studentName
+		n.vars["parent_name"] = parentName
+		n.vars["class_name"] = className
+		n.vars["subject_name"] = subjectName
+		n.vars["school_name"] = schoolName
+		notices = append(notices, n)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// A corrected record that is marked again gets its cancelled message
+	// back instead of a second one
+	insertQuery := `
+		INSERT INTO notification_outbox (school_id, student_id, parent_id, attendance_id, event_type,
+		                                 phone, email, subject, body)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (attendance_id, parent_id, event_type) DO UPDATE
+		SET subject = EXCLUDED.subject, body = EXCLUDED.body, status = 'pending', attempts = 0,
+		    last_error = NULL, next_attempt_at = CURRENT_TIMESTAMP
+		WHERE notification_outbox.status = 'cancelled'
+	`
+	templates := map[string]*models.NotificationTemplate{}
+	for _, n := range notices {
+		key := n.event
+		if n.schoolID != nil {
+			key = n.schoolID.String() + "/" + n.event
+		}
+		template, ok := templates[key]
+		if !ok {
+			template, err = loadNotificationTemplate(tx, n.schoolID, n.event)
+			if err != nil && err != sql.ErrNoRows {
+				return err
+			}
+			templates[key] = template
+		}
+		if template == nil || !template.IsActive {
+			continue
+		}
+
+		_, err := tx.Exec(insertQuery, n.schoolID, n.studentID, n.parentID, n.attendanceID, n.event,
+			n.phone, n.email, notify.Render(template.Subject, n.vars), notify.Render(template.Body, n.vars))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeliverNotifications sends the due messages in the outbox and returns
+// how many were sent. Failed deliveries are retried with a growing delay
+// until notificationMaxAttempts is reached.
+func DeliverNotifications(db *sql.DB, notifiers []notify.Notifier, limit int) (int, error) {
+	return deliverOutbox(&sqlNotificationOutbox{db: db}, notifiers, limit)
+}
+
+// notificationOutbox is the queue DeliverNotifications works through. A
+// claimed message is marked sending, so that no one else sends it, and
+// stays so until its delivery is recorded.
+type notificationOutbox interface {
+	requeueInterrupted() error
+	claimNext() (*outboxMessage, error)
+	recordSent(id, channel string) error
+	recordFailure(id, status, channel, lastError string, retryMinutes int) error
+}
+
+// outboxMessage is a claimed message with the attempts made before
+type outboxMessage struct {
+	msg      notify.Message
+	attempts int
+}
+
+// deliverOutbox claims, sends and records one message at a time, so no
+// lock is held while a provider is called and a message that went out is
+// never sent again because another could not be recorded
+func deliverOutbox(outbox notificationOutbox, notifiers []notify.Notifier, limit int) (int, error) {
+	if err := outbox.requeueInterrupted(); err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for i := 0; i < limit; i++ {
+		m, err := outbox.claimNext()
+		if err != nil {
+			return sent, err
+		}
+		if m == nil {
+			break
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), notificationSendTimeout)
+		channel, sendErr := notify.Deliver(ctx, notifiers, m.msg)
+		cancel()
+
+		if sendErr == nil {
+			err = outbox.recordSent(m.msg.ID, channel)
+			if err == nil {
+				sent++
+			}
+		} else {
+			status := "pending"
+			if m.attempts+1 >= notificationMaxAttempts || errors.Is(sendErr, notify.ErrNoRecipient) {
+				status = "failed"
+			}
+			err = outbox.recordFailure(m.msg.ID, status, channel, sendErr.Error(),
+				retryDelayMinutes(m.attempts+1))
+		}
+		// The message stays sending until its claim expires
+		if err != nil {
+			return sent, fmt.Errorf("record delivery of %s: %w", m.msg.ID, err)
+		}
+	}
+	return sent, nil
+}
+
+// notificationSendTimeout bounds one provider call, and
+// notificationClaimTimeout how long a message may stay sending before its
+// delivery is taken to have been interrupted
+const (
+	notificationSendTimeout  = time.Minute
+	notificationClaimTimeout = 10 * time.Minute
+)
+
+type sqlNotificationOutbox struct {
+	db *sql.DB
+}
+
+// requeueInterrupted queues again the messages left sending by a delivery
+// that stopped, counting it as an attempt
+func (o *sqlNotificationOutbox) requeueInterrupted() error {
+	query := `
+		UPDATE notification_outbox
+		SET status = CASE WHEN attempts + 1 >= $2 THEN 'failed' ELSE 'pending' END,
+		    attempts = attempts + 1, claimed_at = NULL, next_attempt_at = CURRENT_TIMESTAMP,
+		    last_error = 'Delivery was interrupted'
+		WHERE status = 'sending' AND claimed_at < CURRENT_TIMESTAMP - make_interval(mins => $1)
+	`
+	_, err := o.db.Exec(query, int(notificationClaimTimeout/time.Minute), notificationMaxAttempts)
+	return err
+}
+
+// claimNext marks the most overdue message sending and returns it, or nil
+// when nothing is due. The statement commits on its own.
+func (o *sqlNotificationOutbox) claimNext() (*outboxMessage, error) {
+	query := `
+		UPDATE notification_outbox o SET status = 'sending', claimed_at = CURRENT_TIMESTAMP
+		FROM (
+		    SELECT id FROM notification_outbox
+		    WHERE status = 'pending' AND next_attempt_at <= CURRENT_TIMESTAMP
+		    ORDER BY next_attempt_at
+		    LIMIT 1
+		    FOR UPDATE SKIP LOCKED
+		) due
+		WHERE o.id = due.id
+		RETURNING o.id, COALESCE(o.phone, ''), COALESCE(o.email, ''), o.subject, o.body, o.attempts
+	`
+	var m outboxMessage
+	err := o.db.QueryRow(query).Scan(&m.msg.ID, &m.msg.Phone, &m.msg.Email, &m.msg.Subject,
+		&m.msg.Body, &m.attempts)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (o *sqlNotificationOutbox) recordSent(id, channel string) error {
+	_, err := o.db.Exec(`
+		UPDATE notification_outbox
+		SET status = 'sent', channel = $2, attempts = attempts + 1, last_error = NULL,
+		    claimed_at = NULL, sent_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND status = 'sending'
+	`, id, channel)
+	return err
+}
+
+func (o *sqlNotificationOutbox) recordFailure(id, status, channel, lastError string, retryMinutes int) error {
+	_, err := o.db.Exec(`
+		UPDATE notification_outbox
+		SET status = $2, channel = NULLIF($3, ''), attempts = attempts + 1, last_error = $4,
+		    claimed_at = NULL, next_attempt_at = CURRENT_TIMESTAMP + make_interval(mins => $5)
+		WHERE id = $1 AND status = 'sending'
+	`, id, status, channel, lastError, retryMinutes)
+	return err
+}
+
+// retryDelayMinutes grows the wait between attempts: 1, 4, 9, 16 minutes
+func retryDelayMinutes(attempts int) int {
+	return attempts * attempts
+}
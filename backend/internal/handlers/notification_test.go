@@ -0,0 +1,425 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	_ "github.com/lib/pq"
+
+	"moalemplus/internal/notify"
+)
+
+// memoryOutbox is an outbox with a clock counted in minutes, so retries
+// become due when the test moves the clock on
+type memoryOutbox struct {
+	now  int
+	rows []*memoryOutboxRow
+}
+
+type memoryOutboxRow struct {
+	msg       notify.Message
+	status    string
+	channel   string
+	lastError string
+	attempts  int
+	dueAt     int
+}
+
+func (o *memoryOutbox) add(msg notify.Message) *memoryOutboxRow {
+	row := &memoryOutboxRow{msg: msg, status: "pending", dueAt: o.now}
+	o.rows = append(o.rows, row)
+	return row
+}
+
+func (o *memoryOutbox) find(id string) *memoryOutboxRow {
+	for _, row := range o.rows {
+		if row.msg.ID == id {
+			return row
+		}
+	}
+	return nil
+}
+
+func (o *memoryOutbox) requeueInterrupted() error {
+	return nil
+}
+
+func (o *memoryOutbox) claimNext() (*outboxMessage, error) {
+	for _, row := range o.rows {
+		if row.status == "pending" && row.dueAt <= o.now {
+			row.status = "sending"
+			return &outboxMessage{msg: row.msg, attempts: row.attempts}, nil
+		}
+	}
+	return nil, nil
+}
+
+func (o *memoryOutbox) recordSent(id, channel string) error {
+	row := o.find(id)
+	if row == nil || row.status != "sending" {
+		return fmt.Errorf("message %s was not claimed", id)
+	}
+	row.status, row.channel, row.lastError = "sent", channel, ""
+	row.attempts++
+	return nil
+}
+
+func (o *memoryOutbox) recordFailure(id, status, channel, lastError string, retryMinutes int) error {
+	row := o.find(id)
+	if row == nil || row.status != "sending" {
+		return fmt.Errorf("message %s was not claimed", id)
+	}
+	row.status, row.channel, row.lastError = status, channel, lastError
+	row.attempts++
+	row.dueAt = o.now + retryMinutes
+	return nil
+}
+
+func TestDeliverOutboxSends(t *testing.T) {
+	outbox := &memoryOutbox{}
+	row := outbox.add(notify.Message{ID: "1", Phone: "99887766", Body: "غياب"})
+	fake := notify.NewFake()
+
+	sent, err := deliverOutbox(outbox, []notify.Notifier{fake}, 50)
+	if err != nil || sent != 1 {
+		t.Fatalf("deliverOutbox = %d, %v; want 1, nil", sent, err)
+	}
+	if row.status != "sent" || row.channel != notify.ChannelFake || row.attempts != 1 {
+		t.Errorf("row = %+v, want sent on fake after 1 attempt", row)
+	}
+	if len(fake.Sent()) != 1 {
+		t.Errorf("fake sent %d messages, want 1", len(fake.Sent()))
+	}
+}
+
+func TestDeliverOutboxFallsThroughWithoutRecipient(t *testing.T) {
+	outbox := &memoryOutbox{}
+	withPhone := outbox.add(notify.Message{ID: "1", Phone: "99887766"})
+	noAddress := outbox.add(notify.Message{ID: "2"})
+	email := &notify.EmailNotifier{Host: "mail.invalid", Port: 25, From: "school@example.com"}
+
+	sent, err := deliverOutbox(outbox, []notify.Notifier{email, notify.NewFake()}, 50)
+	if err != nil || sent != 1 {
+		t.Fatalf("deliverOutbox = %d, %v; want 1, nil", sent, err)
+	}
+	if withPhone.status != "sent" || withPhone.channel != notify.ChannelFake {
+		t.Errorf("message with phone = %+v, want sent on fake", withPhone)
+	}
+	// No channel can reach the parent, so retrying would not help
+	if noAddress.status != "failed" || noAddress.attempts != 1 {
+		t.Errorf("message without address = %+v, want failed after 1 attempt", noAddress)
+	}
+}
+
+func TestDeliverOutboxRetriesThenFails(t *testing.T) {
+	outbox := &memoryOutbox{}
+	row := outbox.add(notify.Message{ID: "1", Phone: "99887766"})
+	fake := notify.NewFake()
+	fake.Err = errors.New("gateway down")
+
+	for attempt := 1; attempt <= notificationMaxAttempts; attempt++ {
+		sent, err := deliverOutbox(outbox, []notify.Notifier{fake}, 50)
+		if err != nil || sent != 0 {
+			t.Fatalf("attempt %d: deliverOutbox = %d, %v; want 0, nil", attempt, sent, err)
+		}
+		if row.attempts != attempt {
+			t.Fatalf("attempt %d: attempts = %d", attempt, row.attempts)
+		}
+		if attempt == notificationMaxAttempts {
+			break
+		}
+		if row.status != "pending" {
+			t.Fatalf("attempt %d: status = %q, want pending", attempt, row.status)
+		}
+		if delay := row.dueAt - outbox.now; delay != attempt*attempt {
+			t.Errorf("attempt %d: retry in %d minutes, want %d", attempt, delay, attempt*attempt)
+		}
+
+		// Not due again until the delay has passed
+		outbox.now = row.dueAt - 1
+		if _, err := deliverOutbox(outbox, []notify.Notifier{fake}, 50); err != nil || row.attempts != attempt {
+			t.Fatalf("attempt %d: retried before the delay (%v)", attempt, err)
+		}
+		outbox.now = row.dueAt
+	}
+	if row.status != "failed" || row.lastError == "" {
+		t.Errorf("row = %+v, want failed with the provider error", row)
+	}
+}
+
+func TestDeliverOutboxSkipsCancelled(t *testing.T) {
+	outbox := &memoryOutbox{}
+	cancelled := outbox.add(notify.Message{ID: "1", Phone: "99887766"})
+	cancelled.status = "cancelled"
+	fake := notify.NewFake()
+
+	sent, err := deliverOutbox(outbox, []notify.Notifier{fake}, 50)
+	if err != nil || sent != 0 || len(fake.Sent()) != 0 {
+		t.Errorf("deliverOutbox = %d, %v with %d messages sent; want nothing sent", sent, err, len(fake.Sent()))
+	}
+}
+
+func TestDeliverOutboxLimit(t *testing.T) {
+	outbox := &memoryOutbox{}
+	for i := 0; i < 3; i++ {
+		outbox.add(notify.Message{ID: fmt.Sprint(i), Phone: "99887766"})
+	}
+
+	sent, err := deliverOutbox(outbox, []notify.Notifier{notify.NewFake()}, 2)
+	if err != nil || sent != 2 {
+		t.Fatalf("deliverOutbox = %d, %v; want 2, nil", sent, err)
+	}
+	if outbox.rows[2].status != "pending" {
+		t.Errorf("third message is %q, want pending", outbox.rows[2].status)
+	}
+}
+
+// testDB opens the database in TEST_DATABASE_URL. It must be migrated and
+// used only by these tests, since DeliverNotifications sends every due
+// message in it.
+func testDB(t *testing.T) *sql.DB {
+	t.Helper()
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	db, err := sql.Open("postgres", url)
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := db.Ping(); err != nil {
+		t.Fatalf("connect to database: %v", err)
+	}
+	return db
+}
+
+// attendanceFixture is a teacher's class with one student whose primary
+// contact is a parent with a phone number only
+type attendanceFixture struct {
+	db                            *sql.DB
+	teacherID, classID, studentID uuid.UUID
+	parentPhone                   string
+}
+
+func digits(n int) string {
+	s := ""
+	for i := 0; i < n; i++ {
+		s += fmt.Sprint(rand.Intn(10))
+	}
+	return s
+}
+
+func newAttendanceFixture(t *testing.T, db *sql.DB) *attendanceFixture {
+	t.Helper()
+	f := &attendanceFixture{db: db, parentPhone: "9" + digits(7)}
+	var schoolID, subjectID, parentID uuid.UUID
+
+	steps := []struct {
+		query string
+		args  []interface{}
+		dest  *uuid.UUID
+	}{
+		{`INSERT INTO schools (name, district, area, type) VALUES ('مدرسة الاختبار', 'العاصمة', 'الشامية', 'intermediate') RETURNING id`,
+			nil, &schoolID},
+		{`INSERT INTO subjects (name, name_arabic, code, school_type, grade_level) VALUES ('Science', 'العلوم', $1, 'intermediate', 7) RETURNING id`,
+			[]interface{}{"T" + digits(9)}, &subjectID},
+		{`INSERT INTO users (civil_id, full_name, email, phone, password_hash, school_id) VALUES ($1, 'معلم الاختبار', $2, '99999999', 'x', $3) RETURNING id`,
+			[]interface{}{digits(12), "teacher" + digits(8) + "@example.com", &schoolID}, &f.teacherID},
+		{`INSERT INTO classes (name, teacher_id, subject_id, school_year, semester, class_section) VALUES ('7/1', $1, $2, '2025-2026', 'first', '1') RETURNING id`,
+			[]interface{}{&f.teacherID, &subjectID}, &f.classID},
+		{`INSERT INTO students (student_number, first_name, last_name, arabic_name, date_of_birth, gender, nationality) VALUES ($1, 'Ahmad', 'Ali', 'أحمد علي', '2013-01-01', 'male', 'كويتي') RETURNING id`,
+			[]interface{}{"S" + digits(9)}, &f.studentID},
+		{`INSERT INTO parents (first_name, last_name, arabic_name, phone_primary) VALUES ('Ali', 'Ahmad', 'علي أحمد', $1) RETURNING id`,
+			[]interface{}{f.parentPhone}, &parentID},
+		{`INSERT INTO student_parents (student_id, parent_id, parent_type, is_primary_contact) VALUES ($1, $2, 'father', true) RETURNING id`,
+			[]interface{}{&f.studentID, &parentID}, new(uuid.UUID)},
+	}
+	for _, step := range steps {
+		if err := db.QueryRow(step.query, step.args...).Scan(step.dest); err != nil {
+			t.Fatalf("create fixture: %v\n%s", err, step.query)
+		}
+	}
+
+	t.Cleanup(func() {
+		db.Exec(`DELETE FROM students WHERE id = $1`, f.studentID)
+		db.Exec(`DELETE FROM parents WHERE id = $1`, parentID)
+		db.Exec(`DELETE FROM schools WHERE id = $1`, schoolID)
+		db.Exec(`DELETE FROM subjects WHERE id = $1`, subjectID)
+	})
+	return f
+}
+
+// mark records the student's attendance the way the attendance handlers
+// do, queueing the parent notice in the same transaction
+func (f *attendanceFixture) mark(t *testing.T, status string) uuid.UUID {
+	t.Helper()
+	tx, err := f.db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+
+	var attendanceID uuid.UUID
+	err = tx.QueryRow(`
+		INSERT INTO attendance (student_id, class_id, date, status, recorded_by)
+		VALUES ($1, $2, CURRENT_DATE, $3, $4)
+		ON CONFLICT (student_id, class_id, date, period_number) DO UPDATE SET status = EXCLUDED.status
+		RETURNING id
+	`, f.studentID, f.classID, status, f.teacherID).Scan(&attendanceID)
+	if err != nil {
+		t.Fatalf("record attendance: %v", err)
+	}
+	if err := enqueueAttendanceNotices(tx, []uuid.UUID{attendanceID}); err != nil {
+		t.Fatalf("enqueueAttendanceNotices: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	return attendanceID
+}
+
+type outboxState struct {
+	status    string
+	channel   sql.NullString
+	attempts  int
+	lastError sql.NullString
+	retryIn   time.Duration
+}
+
+func (f *attendanceFixture) outbox(t *testing.T) outboxState {
+	t.Helper()
+	var s outboxState
+	var retryIn float64
+	err := f.db.QueryRow(`
+		SELECT status, channel, attempts, last_error,
+		       EXTRACT(EPOCH FROM next_attempt_at - CURRENT_TIMESTAMP)
+		FROM notification_outbox WHERE student_id = $1
+	`, f.studentID).Scan(&s.status, &s.channel, &s.attempts, &s.lastError, &retryIn)
+	if err != nil {
+		t.Fatalf("read outbox: %v", err)
+	}
+	s.retryIn = time.Duration(retryIn) * time.Second
+	return s
+}
+
+func TestDeliverNotificationsSendsAbsenceNotice(t *testing.T) {
+	db := testDB(t)
+	f := newAttendanceFixture(t, db)
+	f.mark(t, "absent")
+	fake := notify.NewFake()
+
+	sent, err := DeliverNotifications(db, []notify.Notifier{fake}, 50)
+	if err != nil || sent != 1 {
+		t.Fatalf("DeliverNotifications = %d, %v; want 1, nil", sent, err)
+	}
+	if s := f.outbox(t); s.status != "sent" || s.channel.String != notify.ChannelFake || s.attempts != 1 {
+		t.Errorf("outbox = %+v, want sent on fake", s)
+	}
+	messages := fake.Sent()
+	if len(messages) != 1 || messages[0].Phone != f.parentPhone || messages[0].Body == "" {
+		t.Errorf("fake sent %+v, want the notice to %s", messages, f.parentPhone)
+	}
+}
+
+func TestDeliverNotificationsFallsThroughWithoutEmail(t *testing.T) {
+	db := testDB(t)
+	f := newAttendanceFixture(t, db)
+	f.mark(t, "late")
+	email := &notify.EmailNotifier{Host: "mail.invalid", Port: 25, From: "school@example.com"}
+
+	sent, err := DeliverNotifications(db, []notify.Notifier{email, notify.NewFake()}, 50)
+	if err != nil || sent != 1 {
+		t.Fatalf("DeliverNotifications = %d, %v; want 1, nil", sent, err)
+	}
+	if s := f.outbox(t); s.status != "sent" || s.channel.String != notify.ChannelFake {
+		t.Errorf("outbox = %+v, want sent on fake", s)
+	}
+}
+
+func TestDeliverNotificationsRetriesThenFails(t *testing.T) {
+	db := testDB(t)
+	f := newAttendanceFixture(t, db)
+	f.mark(t, "absent")
+	fake := notify.NewFake()
+	fake.Err = errors.New("gateway down")
+
+	for attempt := 1; attempt <= notificationMaxAttempts; attempt++ {
+		if _, err := DeliverNotifications(db, []notify.Notifier{fake}, 50); err != nil {
+			t.Fatalf("attempt %d: %v", attempt, err)
+		}
+		s := f.outbox(t)
+		if s.attempts != attempt {
+			t.Fatalf("attempt %d: attempts = %d", attempt, s.attempts)
+		}
+		if attempt == notificationMaxAttempts {
+			if s.status != "failed" || s.lastError.String == "" {
+				t.Errorf("outbox = %+v, want failed with the provider error", s)
+			}
+			break
+		}
+		want := time.Duration(attempt*attempt) * time.Minute
+		if s.status != "pending" || s.retryIn < want-time.Minute || s.retryIn > want {
+			t.Errorf("attempt %d: outbox = %+v, want pending for %v", attempt, s, want)
+		}
+
+		// Not retried before it is due
+		if _, err := DeliverNotifications(db, []notify.Notifier{fake}, 50); err != nil || f.outbox(t).attempts != attempt {
+			t.Fatalf("attempt %d: retried before the delay (%v)", attempt, err)
+		}
+		if _, err := db.Exec(`UPDATE notification_outbox SET next_attempt_at = CURRENT_TIMESTAMP WHERE student_id = $1`,
+			f.studentID); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestDeliverNotificationsCancelsCorrectedAbsence(t *testing.T) {
+	db := testDB(t)
+	f := newAttendanceFixture(t, db)
+	f.mark(t, "absent")
+	f.mark(t, "present")
+	fake := notify.NewFake()
+
+	sent, err := DeliverNotifications(db, []notify.Notifier{fake}, 50)
+	if err != nil || sent != 0 || len(fake.Sent()) != 0 {
+		t.Fatalf("DeliverNotifications = %d, %v; want nothing sent", sent, err)
+	}
+	if s := f.outbox(t); s.status != "cancelled" {
+		t.Errorf("outbox = %+v, want cancelled", s)
+	}
+
+	// Marked absent again, the cancelled notice is queued once more
+	f.mark(t, "absent")
+	if sent, err := DeliverNotifications(db, []notify.Notifier{fake}, 50); err != nil || sent != 1 {
+		t.Errorf("DeliverNotifications = %d, %v; want 1, nil", sent, err)
+	}
+}
+
+func TestDeliverNotificationsRequeuesInterrupted(t *testing.T) {
+	db := testDB(t)
+	f := newAttendanceFixture(t, db)
+	f.mark(t, "absent")
+	_, err := db.Exec(`
+		UPDATE notification_outbox
+		SET status = 'sending', claimed_at = CURRENT_TIMESTAMP - make_interval(mins => $2)
+		WHERE student_id = $1
+	`, f.studentID, int(notificationClaimTimeout/time.Minute)+1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sent, err := DeliverNotifications(db, []notify.Notifier{notify.NewFake()}, 50)
+	if err != nil || sent != 1 {
+		t.Fatalf("DeliverNotifications = %d, %v; want 1, nil", sent, err)
+	}
+	if s := f.outbox(t); s.status != "sent" || s.attempts != 2 {
+		t.Errorf("outbox = %+v, want sent on the second attempt", s)
+	}
+}
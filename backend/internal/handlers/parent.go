@@ -0,0 +1,471 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"moalemplus/internal/arabic"
+	"moalemplus/internal/models"
+	"moalemplus/internal/notify"
+)
+
+var (
+	phonePattern = regexp.MustCompile(`^[0-9+\-\s()]+$`)
+	emailPattern = regexp.MustCompile(`^[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}$`)
+)
+
+// studentParentColumns are the columns scanned by scanStudentParent
+const studentParentColumns = `
+	p.id, p.civil_id, p.first_name, p.last_name, p.arabic_name, p.phone_primary, p.phone_secondary,
+	p.email, p.workplace, p.job_title, p.address, p.is_active, p.created_at, p.updated_at,
+	sp.parent_type, sp.is_primary_contact,
+	(SELECT COUNT(*) FROM student_parents other
+	 WHERE other.parent_id = p.id AND other.student_id <> sp.student_id AND other.is_active = true)
+`
+
+type ParentHandler struct {
+	db *sql.DB
+}
+
+func NewParentHandler(db *sql.DB) *ParentHandler {
+	return &ParentHandler{db: db}
+}
+
+// GetStudentParents lists a student's parents and guardians, primary
+// contact first
+func (h *ParentHandler) GetStudentParents(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	studentUUID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid student ID",
+		})
+	}
+	if status, msg := checkTeacherStudent(h.db, studentUUID, userID); status != 0 {
+		return c.Status(status).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: msg,
+		})
+	}
+
+	parents, err := loadStudentParents(h.db, studentUUID)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch parents",
+		})
+	}
+	return c.JSON(parents)
+}
+
+// CreateStudentParent adds a parent to a student. A parent whose civil ID
+// is already on file, such as a sibling's father, is linked only when the
+// primary phone matches the one on file; details missing from the record
+// are filled in and the rest are kept.
+func (h *ParentHandler) CreateStudentParent(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	studentUUID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid student ID",
+		})
+	}
+	if status, msg := checkTeacherStudent(h.db, studentUUID, userID); status != 0 {
+		return c.Status(status).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: msg,
+		})
+	}
+
+	var req models.SaveStudentParentRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid request body",
+		})
+	}
+	if err := validateParentRequest(&req); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: err.Error(),
+		})
+	}
+
+	// Begin transaction
+	tx, err := h.db.Begin()
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to begin transaction",
+		})
+	}
+	defer tx.Rollback()
+
+	insertQuery := `
+		INSERT INTO parents (civil_id, first_name, last_name, arabic_name, phone_primary, phone_secondary,
+		                     email, workplace, job_title, address)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (civil_id) DO NOTHING
+		RETURNING id
+	`
+	var parentID uuid.UUID
+	err = tx.QueryRow(insertQuery, nullableString(req.CivilID), req.FirstName, req.LastName, req.ArabicName,
+		req.PhonePrimary, nullableString(req.PhoneSecondary), nullableString(req.Email),
+		nullableString(req.Workplace), nullableString(req.JobTitle), nullableString(req.Address)).Scan(&parentID)
+	if err == sql.ErrNoRows {
+		var phone string
+		err = tx.QueryRow(`SELECT id, phone_primary FROM parents WHERE civil_id = $1 FOR UPDATE`,
+			req.CivilID).Scan(&parentID, &phone)
+		// Knowing a civil ID is not enough to be shown someone's contact
+		// details or to sign in as them with an access code
+		if err == nil && notify.KuwaitPhone(phone) != notify.KuwaitPhone(req.PhonePrimary) {
+			return c.Status(409).JSON(models.ErrorResponse{
+				Error:   true,
+				Message: "A parent with this civil ID is on file with a different phone number",
+			})
+		}
+		if err == nil {
+			_, err = tx.Exec(`
+				UPDATE parents
+				SET phone_secondary = COALESCE(phone_secondary, $2), email = COALESCE(email, $3),
+				    workplace = COALESCE(workplace, $4), job_title = COALESCE(job_title, $5),
+				    address = COALESCE(address, $6), updated_at = CURRENT_TIMESTAMP
+				WHERE id = $1
+			`, parentID, nullableString(req.PhoneSecondary), nullableString(req.Email),
+				nullableString(req.Workplace), nullableString(req.JobTitle), nullableString(req.Address))
+		}
+	}
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to create parent",
+		})
+	}
+
+	// The first contact of a student becomes the primary one
+	linkQuery := `
+		INSERT INTO student_parents (student_id, parent_id, parent_type, is_primary_contact)
+		VALUES ($1, $2, $3, $4 OR NOT EXISTS (
+			SELECT 1 FROM student_parents WHERE student_id = $1 AND is_active = true
+		))
+		ON CONFLICT (student_id, parent_id) DO UPDATE
+		SET parent_type = EXCLUDED.parent_type, is_primary_contact = EXCLUDED.is_primary_contact,
+		    is_active = true, updated_at = CURRENT_TIMESTAMP
+		WHERE NOT student_parents.is_active
+		RETURNING id
+	`
+	var linkID uuid.UUID
+	err = tx.QueryRow(linkQuery, studentUUID, parentID, req.ParentType, req.IsPrimaryContact).Scan(&linkID)
+	if err == sql.ErrNoRows {
+		return c.Status(409).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "This parent is already a contact of the student",
+		})
+	}
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to link parent",
+		})
+	}
+
+	parent, err := loadStudentParent(tx, studentUUID, parentID)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch parent",
+		})
+	}
+
+	// Commit transaction
+	if err := tx.Commit(); err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to commit transaction",
+		})
+	}
+
+	return c.Status(201).JSON(parent)
+}
+
+// UpdateStudentParent updates a parent's details and their relationship to
+// the student. The details are shared with the parent's other children.
+func (h *ParentHandler) UpdateStudentParent(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	studentUUID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid student ID",
+		})
+	}
+	parentUUID, err := uuid.Parse(c.Params("parentId"))
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid parent ID",
+		})
+	}
+	if status, msg := checkTeacherStudent(h.db, studentUUID, userID); status != 0 {
+		return c.Status(status).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: msg,
+		})
+	}
+
+	var req models.SaveStudentParentRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid request body",
+		})
+	}
+	if err := validateParentRequest(&req); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: err.Error(),
+		})
+	}
+
+	// Begin transaction
+	tx, err := h.db.Begin()
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to begin transaction",
+		})
+	}
+	defer tx.Rollback()
+
+	linkQuery := `
+		UPDATE student_parents SET parent_type = $3, is_primary_contact = $4, updated_at = CURRENT_TIMESTAMP
+		WHERE student_id = $1 AND parent_id = $2 AND is_active = true
+	`
+	result, err := tx.Exec(linkQuery, studentUUID, parentUUID, req.ParentType, req.IsPrimaryContact)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to update parent",
+		})
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return c.Status(404).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Parent not found",
+		})
+	}
+
+	updateQuery := `
+		UPDATE parents
+		SET civil_id = $1, first_name = $2, last_name = $3, arabic_name = $4, phone_primary = $5,
+		    phone_secondary = $6, email = $7, workplace = $8, job_title = $9, address = $10,
+		    updated_at = CURRENT_TIMESTAMP
+		WHERE id = $11
+	`
+	_, err = tx.Exec(updateQuery, nullableString(req.CivilID), req.FirstName, req.LastName, req.ArabicName,
+		req.PhonePrimary, nullableString(req.PhoneSecondary), nullableString(req.Email),
+		nullableString(req.Workplace), nullableString(req.JobTitle), nullableString(req.Address), parentUUID)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return c.Status(409).JSON(models.ErrorResponse{
+				Error:   true,
+				Message: "Civil ID already belongs to another parent",
+			})
+		}
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to update parent",
+		})
+	}
+
+	parent, err := loadStudentParent(tx, studentUUID, parentUUID)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch parent",
+		})
+	}
+
+	// Commit transaction
+	if err := tx.Commit(); err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to commit transaction",
+		})
+	}
+
+	return c.JSON(parent)
+}
+
+// DeleteStudentParent removes a parent from a student's contacts. The
+// parent record stays linked to their other children.
+func (h *ParentHandler) DeleteStudentParent(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	studentUUID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid student ID",
+		})
+	}
+	parentUUID, err := uuid.Parse(c.Params("parentId"))
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid parent ID",
+		})
+	}
+	if status, msg := checkTeacherStudent(h.db, studentUUID, userID); status != 0 {
+		return c.Status(status).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: msg,
+		})
+	}
+
+	deleteQuery := `
+		UPDATE student_parents
+		SET is_active = false, is_primary_contact = false, updated_at = CURRENT_TIMESTAMP
+		WHERE student_id = $1 AND parent_id = $2 AND is_active = true
+	`
+	result, err := h.db.Exec(deleteQuery, studentUUID, parentUUID)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to delete parent",
+		})
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return c.Status(404).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Parent not found",
+		})
+	}
+
+	return c.JSON(models.SuccessResponse{
+		Success: true,
+		Message: "Parent removed successfully",
+	})
+}
+
+// checkTeacherStudent returns a non-zero status and message unless the
+// student is enrolled in one of the teacher's classes
+func checkTeacherStudent(db queryRower, studentID, userID uuid.UUID) (int, string) {
+	var id uuid.UUID
+	query := `
+		SELECT s.id FROM students s
+		JOIN student_classes sc ON sc.student_id = s.id AND sc.is_active = true
+		JOIN classes c ON sc.class_id = c.id
+		WHERE s.id = $1 AND c.teacher_id = $2 AND s.is_active = true
+		LIMIT 1
+	`
+	err := db.QueryRow(query, studentID, userID).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 404, "Student not found"
+	}
+	if err != nil {
+		return 500, "Failed to fetch student"
+	}
+	return 0, ""
+}
+
+// validateParentRequest checks a parent request against the parents table
+// rules
+func validateParentRequest(req *models.SaveStudentParentRequest) error {
+	req.CivilID = arabic.WesternDigits(strings.TrimSpace(req.CivilID))
+	req.FirstName = strings.TrimSpace(req.FirstName)
+	req.LastName = strings.TrimSpace(req.LastName)
+	req.ArabicName = strings.TrimSpace(req.ArabicName)
+	req.PhonePrimary = arabic.WesternDigits(strings.TrimSpace(req.PhonePrimary))
+	req.PhoneSecondary = arabic.WesternDigits(strings.TrimSpace(req.PhoneSecondary))
+	req.Email = strings.TrimSpace(req.Email)
+	req.Workplace = strings.TrimSpace(req.Workplace)
+	req.JobTitle = strings.TrimSpace(req.JobTitle)
+	req.Address = strings.TrimSpace(req.Address)
+
+	switch {
+	case req.ParentType != models.ParentTypeFather && req.ParentType != models.ParentTypeMother &&
+		req.ParentType != models.ParentTypeGuardian:
+		return errors.New("Parent type must be father, mother or guardian")
+	case req.CivilID != "" && !civilIDPattern.MatchString(req.CivilID):
+		return errors.New("Civil ID must be 12 digits")
+	case req.FirstName == "" || req.LastName == "" || req.ArabicName == "":
+		return errors.New("First name, last name and Arabic name are required")
+	case utf8.RuneCountInString(req.FirstName) > 100 || utf8.RuneCountInString(req.LastName) > 100 ||
+		utf8.RuneCountInString(req.ArabicName) > 255:
+		return errors.New("Name is too long")
+	case req.PhonePrimary == "":
+		return errors.New("Primary phone is required")
+	case !phonePattern.MatchString(req.PhonePrimary) || len(req.PhonePrimary) > 20:
+		return errors.New("Primary phone may only contain digits, spaces, +, - and brackets (up to 20 characters)")
+	case req.PhoneSecondary != "" && (!phonePattern.MatchString(req.PhoneSecondary) || len(req.PhoneSecondary) > 20):
+		return errors.New("Secondary phone may only contain digits, spaces, +, - and brackets (up to 20 characters)")
+	case req.Email != "" && (!emailPattern.MatchString(req.Email) || len(req.Email) > 255):
+		return errors.New("Invalid email address")
+	case utf8.RuneCountInString(req.Workplace) > 255:
+		return errors.New("Workplace is too long")
+	case utf8.RuneCountInString(req.JobTitle) > 100:
+		return errors.New("Job title is too long")
+	}
+	return nil
+}
+
+// loadStudentParents returns the active contacts of a student
+func loadStudentParents(db *sql.DB, studentID uuid.UUID) ([]models.StudentParent, error) {
+	query := `
+		SELECT ` + studentParentColumns + `
+		FROM student_parents sp
+		JOIN parents p ON sp.parent_id = p.id
+		WHERE sp.student_id = $1 AND sp.is_active = true
+		ORDER BY sp.is_primary_contact DESC, sp.created_at
+	`
+	rows, err := db.Query(query, studentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	parents := []models.StudentParent{}
+	for rows.Next() {
+		var parent models.StudentParent
+		if err := scanStudentParent(rows, &parent); err != nil {
+			return nil, err
+		}
+		parents = append(parents, parent)
+	}
+	return parents, rows.Err()
+}
+
+// loadStudentParent returns one active contact of a student
+func loadStudentParent(db queryRower, studentID, parentID uuid.UUID) (*models.StudentParent, error) {
+	query := `
+		SELECT ` + studentParentColumns + `
+		FROM student_parents sp
+		JOIN parents p ON sp.parent_id = p.id
+		WHERE sp.student_id = $1 AND sp.parent_id = $2 AND sp.is_active = true
+	`
+	var parent models.StudentParent
+	if err := scanStudentParent(db.QueryRow(query, studentID, parentID), &parent); err != nil {
+		return nil, err
+	}
+	return &parent, nil
+}
+
+func scanStudentParent(row interface{ Scan(...interface{}) error }, parent *models.StudentParent) error {
+	return row.Scan(&parent.ID, &parent.CivilID, &parent.FirstName, &parent.LastName, &parent.ArabicName,
+		&parent.PhonePrimary, &parent.PhoneSecondary, &parent.Email, &parent.Workplace, &parent.JobTitle,
+		&parent.Address, &parent.IsActive, &parent.CreatedAt, &parent.UpdatedAt, &parent.ParentType,
+		&parent.IsPrimaryContact, &parent.SiblingCount)
+}
@@ -0,0 +1,238 @@
+package handlers
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"moalemplus/internal/models"
+)
+
+// parentAccessCodeTTL gives the teacher time to pass the code on
+const parentAccessCodeTTL = 24 * time.Hour
+
+// CreateParentAccessCode issues a one-time code the parent uses with their
+// civil ID for their first sign-in to the parent portal. A new code
+// replaces the previous one.
+func (h *ParentHandler) CreateParentAccessCode(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	studentUUID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid student ID",
+		})
+	}
+	parentUUID, err := uuid.Parse(c.Params("parentId"))
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid parent ID",
+		})
+	}
+	if status, msg := checkTeacherStudent(h.db, studentUUID, userID); status != 0 {
+		return c.Status(status).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: msg,
+		})
+	}
+
+	var civilID sql.NullString
+	parentQuery := `
+		SELECT p.civil_id FROM parents p
+		JOIN student_parents sp ON sp.parent_id = p.id
+		WHERE sp.student_id = $1 AND sp.parent_id = $2 AND sp.is_active = true AND p.is_active = true
+	`
+	err = h.db.QueryRow(parentQuery, studentUUID, parentUUID).Scan(&civilID)
+	if err == sql.ErrNoRows {
+		return c.Status(404).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Parent not found",
+		})
+	}
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch parent",
+		})
+	}
+	if !civilID.Valid {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Add the parent's civil ID before giving portal access",
+		})
+	}
+
+	otp, codeHash, err := generateAccessCode()
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to generate access code",
+		})
+	}
+
+	code := models.ParentAccessCode{
+		Code:      otp,
+		ExpiresAt: time.Now().Add(parentAccessCodeTTL),
+	}
+	updateQuery := `
+		UPDATE parents SET otp_hash = $1, otp_expires_at = $2, otp_attempts = 0
+		WHERE id = $3
+	`
+	if _, err := h.db.Exec(updateQuery, codeHash, code.ExpiresAt, parentUUID); err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to save access code",
+		})
+	}
+
+	return c.Status(201).JSON(code)
+}
+
+// GetParentStudents lists the signed-in parent's children with their
+// current class
+func (h *ParentHandler) GetParentStudents(c *fiber.Ctx) error {
+	parentID := c.Locals("parent_id").(uuid.UUID)
+
+	query := `
+		SELECT s.id, s.student_number, s.first_name, s.last_name, s.arabic_name, s.date_of_birth,
+		       s.gender, s.nationality, s.enrollment_date, s.is_active, s.created_at, s.updated_at,
+		       latest.class_id, COALESCE(latest.name, '')
+		FROM student_parents sp
+		JOIN students s ON sp.student_id = s.id
+		LEFT JOIN LATERAL (
+			SELECT c.id AS class_id, c.name FROM student_classes sc
+			JOIN classes c ON sc.class_id = c.id
+			WHERE sc.student_id = s.id AND sc.is_active = true
+			ORDER BY sc.enrollment_date DESC
+			LIMIT 1
+		) latest ON true
+		WHERE sp.parent_id = $1 AND sp.is_active = true AND s.is_active = true
+		ORDER BY s.date_of_birth
+	`
+	rows, err := h.db.Query(query, parentID)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch students",
+		})
+	}
+	defer rows.Close()
+
+	students := []models.Student{}
+	for rows.Next() {
+		var student models.Student
+		if err := rows.Scan(&student.ID, &student.StudentNumber, &student.FirstName, &student.LastName,
+			&student.ArabicName, &student.DateOfBirth, &student.Gender, &student.Nationality,
+			&student.EnrollmentDate, &student.IsActive, &student.CreatedAt, &student.UpdatedAt,
+			&student.ClassID, &student.ClassName); err != nil {
+			return c.Status(500).JSON(models.ErrorResponse{
+				Error:   true,
+				Message: "Failed to scan student",
+			})
+		}
+		students = append(students, student)
+	}
+	if err := rows.Err(); err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch students",
+		})
+	}
+
+	return c.JSON(students)
+}
+
+// GetStudentTestResults lists a child's graded attempts at published tests.
+// Like the student, the parent only sees scores of tests whose results the
+// teacher chose to show.
+func (h *ParentHandler) GetStudentTestResults(c *fiber.Ctx) error {
+	studentUUID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid student ID",
+		})
+	}
+	if _, status, msg := studentViewer(c, h.db, studentUUID); status != 0 {
+		return c.Status(status).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: msg,
+		})
+	}
+
+	query := `
+		SELECT ts.id, t.id, t.title, t.title_arabic, c.name, COALESCE(sub.name_arabic, ''),
+		       ts.attempt_number, COALESCE(ts.submitted_at, ts.expires_at), ts.total_score, t.total_points,
+		       ts.percentage_score, ts.is_passed
+		FROM test_submissions ts
+		JOIN tests t ON ts.test_id = t.id
+		JOIN classes c ON t.class_id = c.id
+		LEFT JOIN subjects sub ON c.subject_id = sub.id
+		WHERE ts.student_id = $1 AND ` + gradedSubmission + `
+		  AND t.is_published = true AND t.is_active = true AND t.show_results_immediately = true
+		ORDER BY COALESCE(ts.submitted_at, ts.expires_at) DESC
+	`
+	rows, err := h.db.Query(query, studentUUID)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch test results",
+		})
+	}
+	defer rows.Close()
+
+	results := []models.ParentTestResult{}
+	for rows.Next() {
+		var result models.ParentTestResult
+		if err := rows.Scan(&result.SubmissionID, &result.TestID, &result.Title, &result.TitleArabic,
+			&result.ClassName, &result.SubjectName, &result.AttemptNumber, &result.SubmittedAt,
+			&result.TotalScore, &result.TotalPoints, &result.PercentageScore, &result.IsPassed); err != nil {
+			return c.Status(500).JSON(models.ErrorResponse{
+				Error:   true,
+				Message: "Failed to scan test result",
+			})
+		}
+		results = append(results, result)
+	}
+	if err := rows.Err(); err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch test results",
+		})
+	}
+
+	return c.JSON(results)
+}
+
+// studentViewer checks who may read a student's records. A parent token
+// must be linked to the student and gives a nil teacher; otherwise the
+// student must be in one of the teacher's classes and the teacher's ID is
+// returned to scope the queries.
+func studentViewer(c *fiber.Ctx, db queryRower, studentID uuid.UUID) (*uuid.UUID, int, string) {
+	if parentID, ok := c.Locals("parent_id").(uuid.UUID); ok {
+		var id uuid.UUID
+		query := `
+			SELECT s.id FROM students s
+			JOIN student_parents sp ON sp.student_id = s.id AND sp.is_active = true
+			WHERE s.id = $1 AND sp.parent_id = $2 AND s.is_active = true
+		`
+		err := db.QueryRow(query, studentID, parentID).Scan(&id)
+		if err == sql.ErrNoRows {
+			return nil, 404, "Student not found"
+		}
+		if err != nil {
+			return nil, 500, "Failed to fetch student"
+		}
+		return nil, 0, ""
+	}
+
+	userID := c.Locals("user_id").(uuid.UUID)
+	if status, msg := checkTeacherStudent(db, studentID, userID); status != 0 {
+		return nil, status, msg
+	}
+	return &userID, 0, ""
+}
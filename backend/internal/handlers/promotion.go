@@ -0,0 +1,307 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"moalemplus/internal/models"
+)
+
+// GetPromotion returns the promotion wizard's starting point for a class:
+// the suggested next term and each student's grade, with the students who
+// passed selected
+func (h *ClassHandler) GetPromotion(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	classUUID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid class ID",
+		})
+	}
+
+	var schoolID uuid.UUID
+	var schoolType string
+	preview := models.PromotionPreview{ClassID: classUUID, Students: []models.PromotionCandidate{}}
+	classQuery := `
+		SELECT c.name, c.class_section, c.school_year, c.semester, c.max_students, c.closed_at,
+		       COALESCE(u.school_id, '00000000-0000-0000-0000-000000000000'),
+		       COALESCE(sub.school_type, u.school_type, 'primary')
+		FROM classes c
+		JOIN users u ON c.teacher_id = u.id
+		LEFT JOIN subjects sub ON c.subject_id = sub.id
+		WHERE c.id = $1 AND c.teacher_id = $2 AND c.is_active = true
+	`
+	err = h.db.QueryRow(classQuery, classUUID, userID).Scan(&preview.ClassName, &preview.ClassSection,
+		&preview.SchoolYear, &preview.Semester, &preview.MaxStudents, &preview.ClosedAt, &schoolID, &schoolType)
+	if err == sql.ErrNoRows {
+		return c.Status(404).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Class not found",
+		})
+	}
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch class",
+		})
+	}
+	preview.NextSchoolYear, preview.NextSemester = nextTerm(preview.SchoolYear, preview.Semester)
+
+	scale, _, err := loadGradingScale(h.db, schoolID, schoolType)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch grading scale",
+		})
+	}
+
+	// A closed class's term grades are its final grades
+	book := models.Gradebook{ClassID: classUUID}
+	if err := loadGradebook(h.db, &book); err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch gradebook",
+		})
+	}
+	for _, student := range book.Students {
+		candidate := models.PromotionCandidate{
+			StudentID:     student.StudentID,
+			StudentNumber: student.StudentNumber,
+			StudentName:   student.StudentName,
+			Grade:         student.TermGrade,
+			IsFinal:       preview.ClosedAt != nil && student.TermGrade != nil,
+			Selected:      true,
+		}
+		if student.TermGrade != nil {
+			if band := scale.Classify(*student.TermGrade); band != nil {
+				passing := band.IsPassing
+				candidate.IsPassing = &passing
+				candidate.Selected = passing
+			}
+		}
+		preview.Students = append(preview.Students, candidate)
+	}
+
+	return c.JSON(preview)
+}
+
+// PromoteClass clones a class into a later term, with the same subject,
+// teacher and grade categories, and enrolls the selected students. The
+// source class and its enrollments are left as they are.
+func (h *ClassHandler) PromoteClass(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	classUUID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid class ID",
+		})
+	}
+
+	var req models.PromoteClassRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid request body",
+		})
+	}
+
+	var source models.Class
+	sourceQuery := `
+		SELECT name, subject_id, school_year, semester, class_section, max_students
+		FROM classes WHERE id = $1 AND teacher_id = $2 AND is_active = true
+	`
+	err = h.db.QueryRow(sourceQuery, classUUID, userID).Scan(&source.Name, &source.SubjectID,
+		&source.SchoolYear, &source.Semester, &source.ClassSection, &source.MaxStudents)
+	if err == sql.ErrNoRows {
+		return c.Status(404).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Class not found",
+		})
+	}
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch class",
+		})
+	}
+
+	class, status, msg := promotedClass(&source, &req)
+	if status != 0 {
+		return c.Status(status).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: msg,
+		})
+	}
+	class.TeacherID = userID
+
+	roster, err := classStudentIDs(h.db, classUUID)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch class students",
+		})
+	}
+	studentIDs := []uuid.UUID{}
+	seen := map[uuid.UUID]bool{}
+	for _, id := range req.StudentIDs {
+		studentID, err := uuid.Parse(id)
+		if err != nil {
+			return c.Status(400).JSON(models.ErrorResponse{
+				Error:   true,
+				Message: fmt.Sprintf("Invalid student ID %q", id),
+			})
+		}
+		if !roster[studentID] {
+			return c.Status(400).JSON(models.ErrorResponse{
+				Error:   true,
+				Message: fmt.Sprintf("Student %s is not in this class", studentID),
+			})
+		}
+		if !seen[studentID] {
+			seen[studentID] = true
+			studentIDs = append(studentIDs, studentID)
+		}
+	}
+	if len(studentIDs) > class.MaxStudents {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: fmt.Sprintf("The new class can hold at most %d students", class.MaxStudents),
+		})
+	}
+
+	// Begin transaction
+	tx, err := h.db.Begin()
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to begin transaction",
+		})
+	}
+	defer tx.Rollback()
+
+	class.ID = uuid.New()
+	insertQuery := `
+		INSERT INTO classes (id, name, teacher_id, subject_id, school_year, semester, class_section, max_students)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING is_active, created_at, updated_at
+	`
+	err = tx.QueryRow(insertQuery, class.ID, class.Name, class.TeacherID, class.SubjectID, class.SchoolYear,
+		class.Semester, class.ClassSection, class.MaxStudents).Scan(&class.IsActive, &class.CreatedAt, &class.UpdatedAt)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return c.Status(409).JSON(models.ErrorResponse{
+				Error:   true,
+				Message: "Class with this configuration already exists",
+			})
+		}
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to create class",
+		})
+	}
+
+	categoriesQuery := `
+		INSERT INTO grade_categories (class_id, name, name_arabic, category_type, weight, sort_order)
+		SELECT $1, name, name_arabic, category_type, weight, sort_order
+		FROM grade_categories WHERE class_id = $2
+	`
+	if _, err := tx.Exec(categoriesQuery, class.ID, classUUID); err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to copy grade categories",
+		})
+	}
+
+	enrollQuery := `INSERT INTO student_classes (student_id, class_id) VALUES ($1, $2)`
+	for _, studentID := range studentIDs {
+		if _, err := tx.Exec(enrollQuery, studentID, class.ID); err != nil {
+			return c.Status(500).JSON(models.ErrorResponse{
+				Error:   true,
+				Message: "Failed to enroll students",
+			})
+		}
+	}
+
+	// Commit transaction
+	if err := tx.Commit(); err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to commit transaction",
+		})
+	}
+
+	class.StudentCount = len(studentIDs)
+	return c.Status(201).JSON(models.SuccessResponse{
+		Success: true,
+		Message: "Class promoted successfully",
+		Data: fiber.Map{
+			"class":       class,
+			"student_ids": studentIDs,
+		},
+	})
+}
+
+// promotedClass fills in the new class from the request, falling back to
+// the source class and its next term
+func promotedClass(source *models.Class, req *models.PromoteClassRequest) (*models.Class, int, string) {
+	nextYear, nextSemester := nextTerm(source.SchoolYear, source.Semester)
+	class := &models.Class{
+		Name:         strings.TrimSpace(req.Name),
+		SubjectID:    source.SubjectID,
+		SchoolYear:   strings.TrimSpace(req.SchoolYear),
+		Semester:     req.Semester,
+		ClassSection: strings.TrimSpace(req.ClassSection),
+		MaxStudents:  req.MaxStudents,
+	}
+	if class.Name == "" {
+		class.Name = source.Name
+	}
+	if class.SchoolYear == "" {
+		class.SchoolYear = nextYear
+	}
+	if class.Semester == "" {
+		class.Semester = nextSemester
+	}
+	if class.ClassSection == "" {
+		class.ClassSection = source.ClassSection
+	}
+	if class.MaxStudents == 0 {
+		class.MaxStudents = source.MaxStudents
+	}
+
+	switch {
+	case class.Semester != "first" && class.Semester != "second":
+		return nil, 400, "Semester must be first or second"
+	case len(class.SchoolYear) > 10:
+		return nil, 400, "School year must be at most 10 characters (e.g. 2024-2025)"
+	case len(class.ClassSection) > 10:
+		return nil, 400, "Class section must be at most 10 characters"
+	case class.MaxStudents < 1 || class.MaxStudents > 50:
+		return nil, 400, "Max students must be between 1 and 50"
+	case class.SchoolYear == source.SchoolYear && class.Semester == source.Semester:
+		return nil, 400, "The new class must be in a different school year or semester"
+	}
+	return class, 0, ""
+}
+
+// nextTerm returns the term after the given one: the second semester of
+// the same year, or the first semester of the next "2024-2025" style year
+func nextTerm(schoolYear, semester string) (string, string) {
+	if semester == "first" {
+		return schoolYear, "second"
+	}
+	var start, end int
+	if _, err := fmt.Sscanf(schoolYear, "%d-%d", &start, &end); err != nil {
+		return schoolYear, "first"
+	}
+	return fmt.Sprintf("%d-%d", start+1, end+1), "first"
+}
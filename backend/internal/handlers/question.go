@@ -0,0 +1,602 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"moalemplus/internal/models"
+)
+
+type QuestionHandler struct {
+	db *sql.DB
+}
+
+func NewQuestionHandler(db *sql.DB) *QuestionHandler {
+	return &QuestionHandler{db: db}
+}
+
+// questionColumns is the select list shared by the question queries.
+// The caller's user ID must be bound to $1 for the is_owner column.
+const questionColumns = `
+	q.id, q.subject_id, q.curriculum_unit_id, q.question_text, q.question_text_arabic,
+	q.question_type, q.difficulty_level, q.points, q.options, q.correct_answer,
+	q.explanation, q.explanation_arabic, q.tags, q.created_by, q.is_public,
+	q.is_active, q.created_at, q.updated_at,
+	COALESCE(s.name_arabic, '') as subject_name,
+	(q.created_by = $1) as is_owner
+`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// queryRower is satisfied by both *sql.DB and *sql.Tx
+type queryRower interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// scanQuestion scans a row selected with questionColumns, followed by any
+// extra destinations
+func scanQuestion(row rowScanner, question *models.Question, extra ...interface{}) error {
+	var options []byte
+	dest := []interface{}{
+		&question.ID, &question.SubjectID, &question.CurriculumUnitID,
+		&question.QuestionText, &question.QuestionTextArabic,
+		&question.QuestionType, &question.DifficultyLevel, &question.Points,
+		&options, &question.CorrectAnswer, &question.Explanation,
+		&question.ExplanationArabic, &question.Tags, &question.CreatedBy,
+		&question.IsPublic, &question.IsActive, &question.CreatedAt,
+		&question.UpdatedAt, &question.SubjectName, &question.IsOwner,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return err
+	}
+	if len(options) > 0 {
+		question.Options = json.RawMessage(options)
+	}
+	if question.Tags == nil {
+		question.Tags = pq.StringArray{}
+	}
+	return nil
+}
+
+// GetQuestions retrieves the teacher's own questions plus public ones
+func (h *QuestionHandler) GetQuestions(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	conditions, args, err := questionFilters(c, userID)
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: err.Error(),
+		})
+	}
+
+	limit, offset := parseLimitOffset(c, 50, 200)
+	args = append(args, limit, offset)
+
+	query := `
+		SELECT ` + questionColumns + `
+		FROM questions q
+		LEFT JOIN subjects s ON q.subject_id = s.id
+		WHERE ` + strings.Join(conditions, " AND ") + fmt.Sprintf(`
+		ORDER BY q.created_at DESC
+		LIMIT $%d OFFSET $%d
+	`, len(args)-1, len(args))
+
+	rows, err := h.db.Query(query, args...)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch questions",
+		})
+	}
+	defer rows.Close()
+
+	var questions []models.Question
+	for rows.Next() {
+		var question models.Question
+		if err := scanQuestion(rows, &question); err != nil {
+			continue
+		}
+		questions = append(questions, question)
+	}
+
+	// Ensure we always return an array, never null
+	if questions == nil {
+		questions = []models.Question{}
+	}
+
+	return c.JSON(questions)
+}
+
+// questionFilters builds the WHERE conditions shared by the list and search
+// endpoints: visibility plus the optional bank filters. The user ID is bound
+// to $1.
+func questionFilters(c *fiber.Ctx, userID uuid.UUID) ([]string, []interface{}, error) {
+	conditions := []string{"q.is_active = true", "(q.created_by = $1 OR q.is_public = true)"}
+	args := []interface{}{userID}
+
+	addFilter := func(condition string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if subjectID := c.Query("subject_id"); subjectID != "" {
+		subjectUUID, err := uuid.Parse(subjectID)
+		if err != nil {
+			return nil, nil, errors.New("Invalid subject ID")
+		}
+		addFilter("q.subject_id = $%d", subjectUUID)
+	}
+
+	if unitID := c.Query("curriculum_unit_id"); unitID != "" {
+		unitUUID, err := uuid.Parse(unitID)
+		if err != nil {
+			return nil, nil, errors.New("Invalid curriculum unit ID")
+		}
+		addFilter("q.curriculum_unit_id = $%d", unitUUID)
+	}
+
+	if questionType := c.Query("question_type"); questionType != "" {
+		if !isValidQuestionType(questionType) {
+			return nil, nil, errors.New("Invalid question type")
+		}
+		addFilter("q.question_type = $%d", questionType)
+	}
+
+	if difficulty := c.Query("difficulty_level"); difficulty != "" {
+		if !isValidDifficulty(difficulty) {
+			return nil, nil, errors.New("Invalid difficulty level")
+		}
+		addFilter("q.difficulty_level = $%d", difficulty)
+	}
+
+	if tag := strings.ToLower(strings.TrimSpace(c.Query("tag"))); tag != "" {
+		addFilter("q.tags @> ARRAY[$%d]::text[]", tag)
+	}
+
+	if c.QueryBool("mine") {
+		conditions = append(conditions, "q.created_by = $1")
+	}
+
+	return conditions, args, nil
+}
+
+// GetQuestion retrieves a specific question visible to the teacher
+func (h *QuestionHandler) GetQuestion(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	questionUUID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid question ID",
+		})
+	}
+
+	query := `
+		SELECT ` + questionColumns + `
+		FROM questions q
+		LEFT JOIN subjects s ON q.subject_id = s.id
+		WHERE q.id = $2 AND q.is_active = true AND (q.created_by = $1 OR q.is_public = true)
+	`
+
+	var question models.Question
+	err = scanQuestion(h.db.QueryRow(query, userID, questionUUID), &question)
+	if err == sql.ErrNoRows {
+		return c.Status(404).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Question not found",
+		})
+	}
+
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch question",
+		})
+	}
+
+	return c.JSON(question)
+}
+
+// CreateQuestion adds a new question to the bank
+func (h *QuestionHandler) CreateQuestion(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	var req models.CreateQuestionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid request body",
+		})
+	}
+
+	fields, err := validateQuestionRequest(&req)
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: err.Error(),
+		})
+	}
+
+	// Create the question
+	questionID := uuid.New()
+	var createdAt, updatedAt time.Time
+	err = insertQuestion(h.db, questionID, userID, &req, fields).Scan(&createdAt, &updatedAt)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok {
+			if pqErr.Code == "23503" { // foreign key violation
+				return c.Status(400).JSON(models.ErrorResponse{
+					Error:   true,
+					Message: "Subject or curriculum unit does not exist",
+				})
+			}
+		}
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to create question",
+		})
+	}
+
+	question := models.Question{
+		ID:                 questionID,
+		SubjectID:          fields.subjectID,
+		CurriculumUnitID:   fields.curriculumUnitID,
+		QuestionText:       req.QuestionText,
+		QuestionTextArabic: req.QuestionTextArabic,
+		QuestionType:       req.QuestionType,
+		DifficultyLevel:    req.DifficultyLevel,
+		Points:             req.Points,
+		Options:            fields.options,
+		CorrectAnswer:      req.CorrectAnswer,
+		Explanation:        fields.explanation,
+		ExplanationArabic:  fields.explanationArabic,
+		Tags:               fields.tags,
+		CreatedBy:          userID,
+		IsPublic:           req.IsPublic,
+		IsActive:           true,
+		CreatedAt:          createdAt,
+		UpdatedAt:          updatedAt,
+		IsOwner:            true,
+	}
+
+	return c.Status(201).JSON(question)
+}
+
+// UpdateQuestion updates a question owned by the teacher
+func (h *QuestionHandler) UpdateQuestion(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	questionUUID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid question ID",
+		})
+	}
+
+	// Only the author may edit a question
+	if status, msg := h.checkQuestionOwner(questionUUID, userID); status != 0 {
+		return c.Status(status).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: msg,
+		})
+	}
+
+	var req models.UpdateQuestionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid request body",
+		})
+	}
+
+	fields, err := validateQuestionRequest(&req.CreateQuestionRequest)
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: err.Error(),
+		})
+	}
+
+	// Update the question
+	updateQuery := `
+		UPDATE questions
+		SET subject_id = $1, curriculum_unit_id = $2, question_text = $3,
+		    question_text_arabic = $4, question_type = $5, difficulty_level = $6,
+		    points = $7, options = $8, correct_answer = $9, explanation = $10,
+		    explanation_arabic = $11, tags = $12, is_public = $13,
+		    updated_at = CURRENT_TIMESTAMP
+		WHERE id = $14 AND created_by = $15
+		RETURNING updated_at
+	`
+
+	var updatedAt time.Time
+	err = h.db.QueryRow(updateQuery, fields.subjectID, fields.curriculumUnitID,
+		req.QuestionText, req.QuestionTextArabic, req.QuestionType, req.DifficultyLevel,
+		req.Points, nullableJSON(fields.options), req.CorrectAnswer, fields.explanation,
+		fields.explanationArabic, fields.tags, req.IsPublic, questionUUID, userID,
+	).Scan(&updatedAt)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok {
+			if pqErr.Code == "23503" { // foreign key violation
+				return c.Status(400).JSON(models.ErrorResponse{
+					Error:   true,
+					Message: "Subject or curriculum unit does not exist",
+				})
+			}
+		}
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to update question",
+		})
+	}
+
+	return c.JSON(models.SuccessResponse{
+		Success: true,
+		Message: "Question updated successfully",
+		Data: fiber.Map{
+			"updated_at": updatedAt,
+		},
+	})
+}
+
+// DeleteQuestion soft deletes a question owned by the teacher
+func (h *QuestionHandler) DeleteQuestion(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	questionUUID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid question ID",
+		})
+	}
+
+	// Only the author may delete a question
+	if status, msg := h.checkQuestionOwner(questionUUID, userID); status != 0 {
+		return c.Status(status).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: msg,
+		})
+	}
+
+	// Soft delete the question so existing tests keep their references
+	deleteQuery := `UPDATE questions SET is_active = false, updated_at = CURRENT_TIMESTAMP WHERE id = $1 AND created_by = $2`
+	_, err = h.db.Exec(deleteQuery, questionUUID, userID)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to delete question",
+		})
+	}
+
+	return c.JSON(models.SuccessResponse{
+		Success: true,
+		Message: "Question deleted successfully",
+	})
+}
+
+// checkQuestionOwner returns a non-zero status and message when the question
+// does not exist or was not written by the user
+func (h *QuestionHandler) checkQuestionOwner(questionID, userID uuid.UUID) (int, string) {
+	var createdBy uuid.UUID
+	var isPublic bool
+	checkQuery := `SELECT created_by, is_public FROM questions WHERE id = $1 AND is_active = true`
+	err := h.db.QueryRow(checkQuery, questionID).Scan(&createdBy, &isPublic)
+	if err == sql.ErrNoRows {
+		return 404, "Question not found"
+	}
+	if err != nil {
+		return 500, "Failed to fetch question"
+	}
+	if createdBy != userID {
+		if !isPublic {
+			return 404, "Question not found"
+		}
+		return 403, "Only the author can modify this question"
+	}
+	return 0, ""
+}
+
+// questionFields holds the parsed, database-ready values of a question request
+type questionFields struct {
+	subjectID         uuid.UUID
+	curriculumUnitID  *uuid.UUID
+	options           json.RawMessage
+	explanation       *string
+	explanationArabic *string
+	tags              pq.StringArray
+}
+
+// validateQuestionRequest checks a question request against the rules of
+// the questions table and normalizes it in place
+func validateQuestionRequest(req *models.CreateQuestionRequest) (*questionFields, error) {
+	fields := &questionFields{}
+
+	subjectID, err := uuid.Parse(req.SubjectID)
+	if err != nil {
+		return nil, errors.New("Invalid subject ID")
+	}
+	fields.subjectID = subjectID
+
+	if req.CurriculumUnitID != "" {
+		unitID, err := uuid.Parse(req.CurriculumUnitID)
+		if err != nil {
+			return nil, errors.New("Invalid curriculum unit ID")
+		}
+		fields.curriculumUnitID = &unitID
+	}
+
+	req.QuestionText = strings.TrimSpace(req.QuestionText)
+	req.QuestionTextArabic = strings.TrimSpace(req.QuestionTextArabic)
+	if req.QuestionText == "" || req.QuestionTextArabic == "" {
+		return nil, errors.New("Question text is required in both languages")
+	}
+
+	if !isValidQuestionType(req.QuestionType) {
+		return nil, errors.New("Invalid question type")
+	}
+
+	if !isValidDifficulty(req.DifficultyLevel) {
+		return nil, errors.New("Invalid difficulty level. Must be easy, medium, or hard")
+	}
+
+	if req.Points == 0 {
+		req.Points = 1
+	}
+	if req.Points < 0 {
+		return nil, errors.New("Points must be positive")
+	}
+
+	// Essays may omit the model answer
+	req.CorrectAnswer = strings.TrimSpace(req.CorrectAnswer)
+	if req.CorrectAnswer == "" && req.QuestionType != models.QuestionTypeEssay {
+		return nil, errors.New("Correct answer is required")
+	}
+
+	options, err := validateQuestionAnswer(req)
+	if err != nil {
+		return nil, err
+	}
+	fields.options = options
+
+	if req.Explanation != "" {
+		fields.explanation = &req.Explanation
+	}
+	if req.ExplanationArabic != "" {
+		fields.explanationArabic = &req.ExplanationArabic
+	}
+
+	fields.tags = pq.StringArray{}
+	seen := map[string]bool{}
+	for _, tag := range req.Tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		fields.tags = append(fields.tags, tag)
+	}
+
+	return fields, nil
+}
+
+// validateQuestionAnswer checks that options and correct_answer match the
+// layout expected for the question type
+func validateQuestionAnswer(req *models.CreateQuestionRequest) (json.RawMessage, error) {
+	switch req.QuestionType {
+	case models.QuestionTypeMultipleChoice:
+		var options map[string]string
+		if len(req.Options) == 0 || json.Unmarshal(req.Options, &options) != nil {
+			return nil, errors.New("Multiple choice questions need options as an object of label to text")
+		}
+		if len(options) < 2 {
+			return nil, errors.New("Multiple choice questions need at least two options")
+		}
+		req.CorrectAnswer = strings.ToUpper(req.CorrectAnswer)
+		if _, ok := options[req.CorrectAnswer]; !ok {
+			return nil, errors.New("Correct answer must be one of the option labels")
+		}
+		return req.Options, nil
+
+	case models.QuestionTypeTrueFalse:
+		answer := strings.ToLower(req.CorrectAnswer)
+		if answer != "true" && answer != "false" {
+			return nil, errors.New("True/false questions need a correct answer of true or false")
+		}
+		req.CorrectAnswer = answer
+		return nil, nil
+
+	case models.QuestionTypeMatching:
+		var options struct {
+			Left  []string `json:"left"`
+			Right []string `json:"right"`
+		}
+		if len(req.Options) == 0 || json.Unmarshal(req.Options, &options) != nil || len(options.Left) < 2 {
+			return nil, errors.New("Matching questions need options with at least two left items")
+		}
+		var pairs map[string]string
+		if json.Unmarshal([]byte(req.CorrectAnswer), &pairs) != nil {
+			return nil, errors.New("Matching questions need a correct answer mapping left items to right items")
+		}
+		right := map[string]bool{}
+		for _, item := range options.Right {
+			right[item] = true
+		}
+		for _, item := range options.Left {
+			if !right[pairs[item]] {
+				return nil, fmt.Errorf("Matching item %q has no valid pair", item)
+			}
+		}
+		return req.Options, nil
+	}
+
+	// Free-text types carry no options
+	return nil, nil
+}
+
+// insertQuestion inserts a validated question and returns its timestamps row
+func insertQuestion(db queryRower, questionID, userID uuid.UUID, req *models.CreateQuestionRequest, fields *questionFields) *sql.Row {
+	insertQuery := `
+		INSERT INTO questions (id, subject_id, curriculum_unit_id, question_text, question_text_arabic,
+		                       question_type, difficulty_level, points, options, correct_answer,
+		                       explanation, explanation_arabic, tags, created_by, is_public)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+		RETURNING created_at, updated_at
+	`
+	return db.QueryRow(insertQuery, questionID, fields.subjectID, fields.curriculumUnitID,
+		req.QuestionText, req.QuestionTextArabic, req.QuestionType, req.DifficultyLevel,
+		req.Points, nullableJSON(fields.options), req.CorrectAnswer, fields.explanation,
+		fields.explanationArabic, fields.tags, userID, req.IsPublic,
+	)
+}
+
+func isValidQuestionType(questionType string) bool {
+	switch questionType {
+	case models.QuestionTypeMultipleChoice, models.QuestionTypeTrueFalse,
+		models.QuestionTypeShortAnswer, models.QuestionTypeEssay,
+		models.QuestionTypeFillBlank, models.QuestionTypeMatching:
+		return true
+	}
+	return false
+}
+
+func isValidDifficulty(difficulty string) bool {
+	return difficulty == "easy" || difficulty == "medium" || difficulty == "hard"
+}
+
+// nullableJSON converts empty JSON into a SQL NULL
+func nullableJSON(raw json.RawMessage) interface{} {
+	if len(raw) == 0 {
+		return nil
+	}
+	return []byte(raw)
+}
+
+// parseLimitOffset reads limit and offset query parameters with bounds
+func parseLimitOffset(c *fiber.Ctx, defaultLimit, maxLimit int) (int, int) {
+	limit, err := strconv.Atoi(c.Query("limit"))
+	if err != nil || limit <= 0 {
+		limit = defaultLimit
+	}
+	if limit > maxLimit {
+		limit = maxLimit
+	}
+	offset, err := strconv.Atoi(c.Query("offset"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+	return limit, offset
+}
@@ -0,0 +1,240 @@
+package handlers
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"moalemplus/internal/importer"
+	"moalemplus/internal/models"
+)
+
+// BulkImportQuestions imports questions from a CSV, JSON, GIFT or Moodle XML
+// file. Every row is validated first; rows are only written when all of them
+// are valid, in a single transaction. With dry_run=true the validation
+// report is returned without writing anything.
+func (h *QuestionHandler) BulkImportQuestions(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	// Accept the file as multipart upload, or the raw request body
+	var (
+		reader io.Reader
+		format = strings.ToLower(formOrQuery(c, "format"))
+	)
+	if fileHeader, err := c.FormFile("file"); err == nil {
+		file, err := fileHeader.Open()
+		if err != nil {
+			return c.Status(400).JSON(models.ErrorResponse{
+				Error:   true,
+				Message: "Failed to read uploaded file",
+			})
+		}
+		defer file.Close()
+		reader = file
+		if format == "" {
+			format = importer.DetectFormat(fileHeader.Filename)
+		}
+	} else {
+		reader = bytes.NewReader(c.Body())
+	}
+
+	if format == "" {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Import format is required (csv, json, gift, moodle_xml)",
+		})
+	}
+
+	difficulty := strings.ToLower(formOrQuery(c, "difficulty_level"))
+	if difficulty == "" {
+		difficulty = "medium"
+	}
+
+	defaults := importer.Defaults{
+		SubjectID:        formOrQuery(c, "subject_id"),
+		CurriculumUnitID: formOrQuery(c, "curriculum_unit_id"),
+		DifficultyLevel:  difficulty,
+		IsPublic:         formOrQuery(c, "is_public") == "true",
+	}
+	dryRun := formOrQuery(c, "dry_run") == "true"
+
+	records, err := importer.Parse(format, reader, defaults)
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: err.Error(),
+		})
+	}
+
+	report := models.QuestionImportReport{
+		Format:    format,
+		DryRun:    dryRun,
+		TotalRows: len(records),
+		Errors:    []models.ImportRowError{},
+	}
+
+	// Validate every row against the same rules as CreateQuestion
+	fields := make([]*questionFields, len(records))
+	for i := range records {
+		if records[i].Err != nil {
+			report.Errors = append(report.Errors, models.ImportRowError{Row: records[i].Row, Message: records[i].Err.Error()})
+			continue
+		}
+		f, err := validateQuestionRequest(&records[i].Question)
+		if err != nil {
+			report.Errors = append(report.Errors, models.ImportRowError{Row: records[i].Row, Message: err.Error()})
+			continue
+		}
+		fields[i] = f
+	}
+
+	// Check referenced subjects and units up front so a foreign key error
+	// does not abort the transaction half way
+	rowErrors, err := h.checkImportReferences(records, fields)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to validate subjects and curriculum units",
+		})
+	}
+	report.Errors = append(report.Errors, rowErrors...)
+
+	report.ValidRows = report.TotalRows - countRows(report.Errors)
+
+	if dryRun {
+		return c.JSON(report)
+	}
+
+	if len(report.Errors) > 0 {
+		return c.Status(422).JSON(report)
+	}
+
+	// Begin transaction
+	tx, err := h.db.Begin()
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to begin transaction",
+		})
+	}
+
+	for i := range records {
+		questionID := uuid.New()
+		var createdAt, updatedAt time.Time
+		err = insertQuestion(tx, questionID, userID, &records[i].Question, fields[i]).Scan(&createdAt, &updatedAt)
+		if err != nil {
+			tx.Rollback()
+			message := "Failed to import questions"
+			if pqErr, ok := err.(*pq.Error); ok {
+				message = pqErr.Message
+			}
+			report.Errors = append(report.Errors, models.ImportRowError{Row: records[i].Row, Message: message})
+			report.ValidRows = report.TotalRows - countRows(report.Errors)
+			report.QuestionIDs = nil
+			return c.Status(422).JSON(report)
+		}
+		report.QuestionIDs = append(report.QuestionIDs, questionID)
+	}
+
+	// Commit transaction
+	if err := tx.Commit(); err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to commit imported questions",
+		})
+	}
+
+	report.ImportedCount = len(report.QuestionIDs)
+	return c.Status(201).JSON(report)
+}
+
+// checkImportReferences reports rows whose subject or curriculum unit does not
+// exist, or whose unit belongs to a different subject
+func (h *QuestionHandler) checkImportReferences(records []importer.Record, fields []*questionFields) ([]models.ImportRowError, error) {
+	var subjectIDs, unitIDs []string
+	for _, f := range fields {
+		if f == nil {
+			continue
+		}
+		subjectIDs = append(subjectIDs, f.subjectID.String())
+		if f.curriculumUnitID != nil {
+			unitIDs = append(unitIDs, f.curriculumUnitID.String())
+		}
+	}
+	if len(subjectIDs) == 0 {
+		return nil, nil
+	}
+
+	subjects := map[uuid.UUID]bool{}
+	rows, err := h.db.Query(`SELECT id FROM subjects WHERE id = ANY($1::uuid[])`, pq.Array(subjectIDs))
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err == nil {
+			subjects[id] = true
+		}
+	}
+	rows.Close()
+
+	units := map[uuid.UUID]uuid.UUID{}
+	if len(unitIDs) > 0 {
+		rows, err := h.db.Query(`SELECT id, subject_id FROM curriculum_units WHERE id = ANY($1::uuid[])`, pq.Array(unitIDs))
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var id, subjectID uuid.UUID
+			if err := rows.Scan(&id, &subjectID); err == nil {
+				units[id] = subjectID
+			}
+		}
+		rows.Close()
+	}
+
+	var rowErrors []models.ImportRowError
+	for i, f := range fields {
+		if f == nil {
+			continue
+		}
+		if !subjects[f.subjectID] {
+			rowErrors = append(rowErrors, models.ImportRowError{Row: records[i].Row, Message: "Subject does not exist"})
+			fields[i] = nil
+			continue
+		}
+		if f.curriculumUnitID != nil {
+			subjectID, ok := units[*f.curriculumUnitID]
+			if !ok {
+				rowErrors = append(rowErrors, models.ImportRowError{Row: records[i].Row, Message: "Curriculum unit does not exist"})
+				fields[i] = nil
+			} else if subjectID != f.subjectID {
+				rowErrors = append(rowErrors, models.ImportRowError{Row: records[i].Row, Message: "Curriculum unit belongs to a different subject"})
+				fields[i] = nil
+			}
+		}
+	}
+	return rowErrors, nil
+}
+
+// formOrQuery reads a multipart form value, falling back to the query string
+func formOrQuery(c *fiber.Ctx, key string) string {
+	if value := c.FormValue(key); value != "" {
+		return value
+	}
+	return c.Query(key)
+}
+
+// countRows counts the distinct rows mentioned in a list of row errors
+func countRows(rowErrors []models.ImportRowError) int {
+	rows := map[int]bool{}
+	for _, e := range rowErrors {
+		rows[e.Row] = true
+	}
+	return len(rows)
+}
@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"moalemplus/internal/arabic"
+	"moalemplus/internal/models"
+)
+
+// searchableFields maps the response field names to the question columns
+// that are checked for matches
+var searchableFields = []struct {
+	name   string
+	column string
+}{
+	{"question_text", "q.question_text"},
+	{"question_text_arabic", "q.question_text_arabic"},
+	{"explanation", "q.explanation"},
+	{"explanation_arabic", "q.explanation_arabic"},
+	{"tags", "q.tags"},
+}
+
+// SearchQuestions ranks questions matching the query across question text,
+// explanations and tags. Both the query and the stored text are normalized
+// so that spelling variants of Arabic letters and tashkeel do not matter.
+func (h *QuestionHandler) SearchQuestions(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	queryText := strings.TrimSpace(c.Query("q"))
+	terms := arabic.Terms(queryText)
+	if len(terms) == 0 {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Search query is required",
+		})
+	}
+
+	conditions, args, err := questionFilters(c, userID)
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: err.Error(),
+		})
+	}
+
+	page, err := strconv.Atoi(c.Query("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	pageSize, err := strconv.Atoi(c.Query("page_size", "20"))
+	if err != nil || pageSize < 1 {
+		pageSize = 20
+	}
+	if pageSize > 100 {
+		pageSize = 100
+	}
+
+	// Every term is matched as a prefix; any matching term is enough to be
+	// returned, and documents matching more terms rank higher
+	prefixes := make([]string, len(terms))
+	patterns := make(pq.StringArray, len(terms))
+	for i, term := range terms {
+		prefixes[i] = term + ":*"
+		patterns[i] = "%" + term + "%"
+	}
+
+	args = append(args, strings.Join(prefixes, " | "))
+	tsQueryParam := len(args)
+	args = append(args, patterns)
+	patternsParam := len(args)
+	args = append(args, pq.StringArray(terms))
+	termsParam := len(args)
+
+	// Substring matches catch terms that the text search parser splits
+	// differently, such as words glued to punctuation. Each is a separate
+	// LIKE so that the trigram index serves it; terms shorter than a
+	// trigram are left to the full-text match.
+	matches := []string{fmt.Sprintf("q.search_vector @@ to_tsquery('simple', $%d)", tsQueryParam)}
+	for _, term := range terms {
+		if utf8.RuneCountInString(term) >= 3 {
+			args = append(args, "%"+term+"%")
+			matches = append(matches, fmt.Sprintf("q.search_text LIKE $%d", len(args)))
+		}
+	}
+	matches = append(matches, fmt.Sprintf("q.search_tags && $%d::text[]", termsParam))
+	conditions = append(conditions, "("+strings.Join(matches, " OR ")+")")
+
+	// Only the returned page is checked field by field
+	var matchColumns []string
+	for _, field := range searchableFields {
+		matchColumns = append(matchColumns, fmt.Sprintf(
+			"normalize_arabic(%s) LIKE ANY($%d::text[])", field.column, patternsParam,
+		))
+	}
+
+	args = append(args, pageSize, (page-1)*pageSize)
+
+	query := `
+		WITH page AS (
+			SELECT q.id,
+				ts_rank(q.search_vector, to_tsquery('simple', $` + strconv.Itoa(tsQueryParam) + `)) as rank,
+				COUNT(*) OVER() as total
+			FROM questions q
+			WHERE ` + strings.Join(conditions, " AND ") + fmt.Sprintf(`
+			ORDER BY rank DESC, q.created_at DESC
+			LIMIT $%d OFFSET $%d
+		)
+		SELECT `, len(args)-1, len(args)) + questionColumns + `, page.rank,
+			` + strings.Join(matchColumns, ",\n\t\t\t") + `,
+			page.total
+		FROM page
+		JOIN questions q ON q.id = page.id
+		LEFT JOIN subjects s ON q.subject_id = s.id
+		ORDER BY page.rank DESC, q.created_at DESC
+	`
+
+	rows, err := h.db.Query(query, args...)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to search questions",
+		})
+	}
+	defer rows.Close()
+
+	response := models.QuestionSearchResponse{
+		Query:    queryText,
+		Terms:    terms,
+		Results:  []models.QuestionSearchResult{},
+		Page:     page,
+		PageSize: pageSize,
+	}
+
+	for rows.Next() {
+		var result models.QuestionSearchResult
+		matched := make([]bool, len(searchableFields))
+		extra := []interface{}{&result.Rank}
+		for i := range matched {
+			extra = append(extra, &matched[i])
+		}
+		extra = append(extra, &response.Total)
+
+		if err := scanQuestion(rows, &result.Question, extra...); err != nil {
+			continue
+		}
+
+		result.MatchedFields = []string{}
+		for i, field := range searchableFields {
+			if matched[i] {
+				result.MatchedFields = append(result.MatchedFields, field.name)
+			}
+		}
+		response.Results = append(response.Results, result)
+	}
+
+	return c.JSON(response)
+}
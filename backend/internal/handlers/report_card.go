@@ -0,0 +1,232 @@
+package handlers
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"moalemplus/internal/models"
+	"moalemplus/internal/pdf"
+)
+
+// GetReportCard returns a student's report card for ?school_year= and
+// ?semester=, which default to those of the student's latest enrollment in
+// one of the teacher's classes, or in any class when a parent asks
+func (h *StudentHandler) GetReportCard(c *fiber.Ctx) error {
+	card, status, msg := h.buildReportCard(c)
+	if status != 0 {
+		return c.Status(status).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: msg,
+		})
+	}
+	return c.JSON(card)
+}
+
+// GetReportCardPDF renders a student's report card
+func (h *StudentHandler) GetReportCardPDF(c *fiber.Ctx) error {
+	card, status, msg := h.buildReportCard(c)
+	if status != 0 {
+		return c.Status(status).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: msg,
+		})
+	}
+
+	sheet := &pdf.ReportCard{
+		SchoolName:    card.SchoolName,
+		District:      card.District,
+		StudentName:   card.StudentName,
+		StudentNumber: card.StudentNumber,
+		SchoolYear:    card.SchoolYear,
+		Semester:      card.Semester,
+		Average:       formatGrade(card.Average),
+	}
+	if card.AverageBand != nil {
+		sheet.AverageBand = card.AverageBand.DescriptorArabic
+	}
+	for _, subject := range card.Subjects {
+		row := pdf.ReportCardRow{
+			Subject:  subject.SubjectName,
+			Teacher:  subject.TeacherName,
+			Grade:    formatGrade(subject.Grade),
+			Absences: fmt.Sprint(subject.Attendance.AbsentDays),
+		}
+		if subject.Band != nil {
+			row.Letter = subject.Band.Letter
+			row.Descriptor = subject.Band.DescriptorArabic
+		}
+		if !subject.IsFinal && subject.Grade != nil {
+			row.Grade += "*"
+			sheet.Provisional = true
+		}
+		sheet.Rows = append(sheet.Rows, row)
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.RenderReportCard(&buf, sheet); err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to generate PDF",
+		})
+	}
+
+	c.Set(fiber.HeaderContentType, "application/pdf")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="report-card-%s-%s-%s.pdf"`,
+		card.StudentNumber, card.SchoolYear, card.Semester))
+	return c.Send(buf.Bytes())
+}
+
+// buildReportCard collects the grades of every class the student took in
+// the semester. Closed classes give their final grade; open ones give the
+// current term grade from the gradebook.
+func (h *StudentHandler) buildReportCard(c *fiber.Ctx) (*models.ReportCard, int, string) {
+	studentUUID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return nil, 400, "Invalid student ID"
+	}
+	teacherID, status, msg := studentViewer(c, h.db, studentUUID)
+	if status != 0 {
+		return nil, status, msg
+	}
+
+	card := &models.ReportCard{StudentID: studentUUID, GeneratedAt: time.Now()}
+	var schoolID uuid.UUID
+	studentQuery := `
+		SELECT s.student_number, s.arabic_name, cl.school_year, cl.semester,
+		       sch.id, sch.name, sch.district,
+		       COALESCE(sub.school_type, u.school_type, 'primary')
+		FROM students s
+		JOIN student_classes sc ON sc.student_id = s.id AND sc.is_active = true
+		JOIN classes cl ON sc.class_id = cl.id
+		JOIN users u ON cl.teacher_id = u.id
+		JOIN schools sch ON u.school_id = sch.id
+		LEFT JOIN subjects sub ON cl.subject_id = sub.id
+		WHERE s.id = $1 AND ($2::uuid IS NULL OR cl.teacher_id = $2) AND s.is_active = true
+		ORDER BY sc.enrollment_date DESC
+		LIMIT 1
+	`
+	err = h.db.QueryRow(studentQuery, studentUUID, teacherID).Scan(&card.StudentNumber, &card.StudentName,
+		&card.SchoolYear, &card.Semester, &schoolID, &card.SchoolName, &card.District,
+		&card.SchoolType)
+	if err == sql.ErrNoRows {
+		return nil, 404, "Student not found"
+	}
+	if err != nil {
+		return nil, 500, "Failed to fetch student"
+	}
+
+	if year := c.Query("school_year"); year != "" {
+		card.SchoolYear = year
+	}
+	if semester := c.Query("semester"); semester != "" {
+		if semester != "first" && semester != "second" {
+			return nil, 400, "Semester must be first or second"
+		}
+		card.Semester = semester
+	}
+
+	scale, _, err := loadGradingScale(h.db, schoolID, card.SchoolType)
+	if err != nil {
+		return nil, 500, "Failed to fetch grading scale"
+	}
+
+	subjectsQuery := `
+		SELECT c.id, c.name, COALESCE(sub.name_arabic, ''), u.full_name,
+		       sc.final_grade, c.closed_at IS NOT NULL
+		FROM student_classes sc
+		JOIN classes c ON sc.class_id = c.id
+		JOIN users u ON c.teacher_id = u.id
+		LEFT JOIN subjects sub ON c.subject_id = sub.id
+		WHERE sc.student_id = $1 AND sc.is_active = true
+		  AND c.school_year = $2 AND c.semester = $3
+		ORDER BY sub.name_arabic, c.name
+	`
+	rows, err := h.db.Query(subjectsQuery, studentUUID, card.SchoolYear, card.Semester)
+	if err != nil {
+		return nil, 500, "Failed to fetch classes"
+	}
+	card.Subjects = []models.ReportCardSubject{}
+	for rows.Next() {
+		var subject models.ReportCardSubject
+		var closed bool
+		if err := rows.Scan(&subject.ClassID, &subject.ClassName, &subject.SubjectName,
+			&subject.TeacherName, &subject.Grade, &closed); err != nil {
+			rows.Close()
+			return nil, 500, "Failed to scan class"
+		}
+		subject.IsFinal = closed && subject.Grade != nil
+		card.Subjects = append(card.Subjects, subject)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, 500, "Failed to fetch classes"
+	}
+
+	total, graded := 0.0, 0
+	for i := range card.Subjects {
+		subject := &card.Subjects[i]
+		if !subject.IsFinal {
+			book := models.Gradebook{ClassID: subject.ClassID}
+			if err := loadGradebook(h.db, &book); err != nil {
+				return nil, 500, "Failed to fetch gradebook"
+			}
+			subject.Grade = nil
+			for _, student := range book.Students {
+				if student.StudentID == studentUUID {
+					subject.Grade = student.TermGrade
+				}
+			}
+		}
+
+		// Counted in school days, not periods
+		attendanceQuery := `
+			SELECT COUNT(*),
+			       COUNT(*) FILTER (WHERE status = 'present'),
+			       COUNT(*) FILTER (WHERE status = 'absent'),
+			       COUNT(*) FILTER (WHERE status = 'late'),
+			       COUNT(*) FILTER (WHERE status = 'excused')
+			FROM (
+				SELECT ` + attendanceDayStatus + ` AS status
+				FROM attendance WHERE student_id = $1 AND class_id = $2
+				GROUP BY date
+			) days
+		`
+		stats := &subject.Attendance
+		err := h.db.QueryRow(attendanceQuery, studentUUID, subject.ClassID).Scan(&stats.TotalDays,
+			&stats.PresentDays, &stats.AbsentDays, &stats.LateDays, &stats.ExcusedDays)
+		if err != nil {
+			return nil, 500, "Failed to fetch attendance"
+		}
+		if stats.TotalDays > 0 {
+			stats.AttendanceRate = float64(stats.PresentDays) / float64(stats.TotalDays) * 100
+		}
+
+		if subject.Grade != nil {
+			subject.Band = scale.Classify(*subject.Grade)
+			total += *subject.Grade
+			graded++
+		}
+	}
+
+	if graded > 0 {
+		average := math.Round(total/float64(graded)*100) / 100
+		card.Average = &average
+		card.AverageBand = scale.Classify(average)
+	}
+	return card, 0, ""
+}
+
+// formatGrade prints a grade with at most two decimals, or a dash when
+// there is none
+func formatGrade(grade *float64) string {
+	if grade == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%g", math.Round(*grade*100)/100)
+}
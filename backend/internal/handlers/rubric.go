@@ -0,0 +1,262 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"moalemplus/internal/models"
+)
+
+// GetRubric retrieves the rubric of a question visible to the teacher
+func (h *QuestionHandler) GetRubric(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	questionUUID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid question ID",
+		})
+	}
+
+	var visible bool
+	checkQuery := `
+		SELECT EXISTS(SELECT 1 FROM questions
+		WHERE id = $1 AND is_active = true AND (created_by = $2 OR is_public = true))
+	`
+	if err := h.db.QueryRow(checkQuery, questionUUID, userID).Scan(&visible); err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch question",
+		})
+	}
+	if !visible {
+		return c.Status(404).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Question not found",
+		})
+	}
+
+	rubric, err := getRubric(h.db, questionUUID)
+	if err == sql.ErrNoRows {
+		return c.Status(404).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Rubric not found",
+		})
+	}
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch rubric",
+		})
+	}
+
+	return c.JSON(rubric)
+}
+
+// SaveRubric creates or replaces the rubric of one of the teacher's short
+// answer or essay questions
+func (h *QuestionHandler) SaveRubric(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	questionUUID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid question ID",
+		})
+	}
+
+	var req models.SaveRubricRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid request body",
+		})
+	}
+
+	if status, message := h.checkQuestionOwner(questionUUID, userID); status != 0 {
+		return c.Status(status).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: message,
+		})
+	}
+
+	var questionType string
+	if err := h.db.QueryRow(`SELECT question_type FROM questions WHERE id = $1`, questionUUID).Scan(&questionType); err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch question",
+		})
+	}
+	if questionType != models.QuestionTypeShortAnswer && questionType != models.QuestionTypeEssay {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Rubrics can only be attached to short answer and essay questions",
+		})
+	}
+
+	maxPoints, err := validateRubric(req.Criteria)
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: err.Error(),
+		})
+	}
+
+	criteria, err := json.Marshal(req.Criteria)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to encode rubric",
+		})
+	}
+
+	upsertQuery := `
+		INSERT INTO question_rubrics (question_id, criteria, max_points, created_by)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (question_id) DO UPDATE
+		SET criteria = EXCLUDED.criteria, max_points = EXCLUDED.max_points
+		RETURNING id, created_at, updated_at
+	`
+	rubric := models.Rubric{
+		QuestionID: questionUUID,
+		Criteria:   req.Criteria,
+		MaxPoints:  maxPoints,
+		CreatedBy:  userID,
+	}
+	err = h.db.QueryRow(upsertQuery, questionUUID, criteria, maxPoints, userID).Scan(
+		&rubric.ID, &rubric.CreatedAt, &rubric.UpdatedAt)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to save rubric",
+		})
+	}
+
+	return c.JSON(models.SuccessResponse{
+		Success: true,
+		Message: "Rubric saved successfully",
+		Data:    rubric,
+	})
+}
+
+// DeleteRubric removes the rubric of one of the teacher's questions
+func (h *QuestionHandler) DeleteRubric(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	questionUUID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid question ID",
+		})
+	}
+
+	if status, message := h.checkQuestionOwner(questionUUID, userID); status != 0 {
+		return c.Status(status).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: message,
+		})
+	}
+
+	result, err := h.db.Exec(`DELETE FROM question_rubrics WHERE question_id = $1`, questionUUID)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to delete rubric",
+		})
+	}
+	if deleted, _ := result.RowsAffected(); deleted == 0 {
+		return c.Status(404).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Rubric not found",
+		})
+	}
+
+	return c.JSON(models.SuccessResponse{
+		Success: true,
+		Message: "Rubric deleted successfully",
+	})
+}
+
+// getRubric loads the rubric of a question
+func getRubric(db queryRower, questionID uuid.UUID) (*models.Rubric, error) {
+	var rubric models.Rubric
+	var criteria []byte
+	err := db.QueryRow(`
+		SELECT id, question_id, criteria, max_points, created_by, created_at, updated_at
+		FROM question_rubrics WHERE question_id = $1
+	`, questionID).Scan(&rubric.ID, &rubric.QuestionID, &criteria, &rubric.MaxPoints,
+		&rubric.CreatedBy, &rubric.CreatedAt, &rubric.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(criteria, &rubric.Criteria); err != nil {
+		return nil, err
+	}
+	return &rubric, nil
+}
+
+// validateRubric checks the criteria, gives criteria without an ID one based
+// on their position and returns the rubric's maximum points
+func validateRubric(criteria []models.RubricCriterion) (int, error) {
+	if len(criteria) == 0 {
+		return 0, errors.New("A rubric needs at least one criterion")
+	}
+
+	maxPoints := 0
+	seen := map[string]bool{}
+	for i := range criteria {
+		criterion := &criteria[i]
+		criterion.ID = strings.TrimSpace(criterion.ID)
+		if criterion.ID == "" {
+			criterion.ID = fmt.Sprintf("c%d", i+1)
+		}
+		if seen[criterion.ID] {
+			return 0, fmt.Errorf("Criterion ID %q is used more than once", criterion.ID)
+		}
+		seen[criterion.ID] = true
+
+		if strings.TrimSpace(criterion.Name) == "" && strings.TrimSpace(criterion.NameArabic) == "" {
+			return 0, fmt.Errorf("Criterion %d needs a name", i+1)
+		}
+		if len(criterion.Levels) == 0 {
+			return 0, fmt.Errorf("Criterion %d needs at least one level", i+1)
+		}
+
+		best := 0
+		for _, level := range criterion.Levels {
+			if strings.TrimSpace(level.Label) == "" && strings.TrimSpace(level.LabelArabic) == "" {
+				return 0, fmt.Errorf("Every level of criterion %d needs a label", i+1)
+			}
+			if level.Points < 0 {
+				return 0, fmt.Errorf("Level points of criterion %d cannot be negative", i+1)
+			}
+			if level.Points > best {
+				best = level.Points
+			}
+		}
+		maxPoints += best
+	}
+
+	if maxPoints == 0 {
+		return 0, errors.New("A rubric must be worth at least one point")
+	}
+	return maxPoints, nil
+}
+
+// scaleRubricPoints converts points earned on a rubric to the question's
+// points in the test, rounding to the nearest point
+func scaleRubricPoints(earned, rubricMax, questionPoints int) int {
+	if rubricMax == questionPoints {
+		return earned
+	}
+	return (2*earned*questionPoints + rubricMax) / (2 * rubricMax)
+}
@@ -48,9 +48,10 @@ func (h *StudentHandler) GetClassStudents(c *fiber.Ctx) error {
 		SELECT 
 			s.id, s.student_number, s.civil_id, s.first_name, s.last_name, 
 			s.arabic_name, s.date_of_birth, s.gender, s.nationality, s.address,
-			s.class_id, s.enrollment_date, s.is_active, s.created_at, s.updated_at,
+			sc.class_id, sc.enrollment_date, s.is_active, s.created_at, s.updated_at,
 			COALESCE(attendance_rates.rate, 0) as attendance_rate
-		FROM students s
+		FROM student_classes sc
+		JOIN students s ON sc.student_id = s.id
 		LEFT JOIN (
 			SELECT 
 				student_id,
@@ -59,7 +60,7 @@ func (h *StudentHandler) GetClassStudents(c *fiber.Ctx) error {
 			WHERE class_id = $1 AND date >= CURRENT_DATE - INTERVAL '30 days'
 			GROUP BY student_id
 		) attendance_rates ON s.id = attendance_rates.student_id
-		WHERE s.class_id = $1 AND s.is_active = true
+		WHERE sc.class_id = $1 AND sc.is_active = true AND s.is_active = true
 		ORDER BY s.student_number
 	`
 	
@@ -118,8 +119,7 @@ func (h *StudentHandler) CreateStudent(c *fiber.Ctx) error {
 	
 	// Check if class is full
 	var currentStudentCount int
-	countQuery := `SELECT COUNT(*) FROM students WHERE class_id = $1 AND is_active = true`
-	err = h.db.QueryRow(countQuery, classUUID).Scan(&currentStudentCount)
+	err = h.db.QueryRow(classStudentCountQuery, classUUID).Scan(&currentStudentCount)
 	if err != nil {
 		return c.Status(500).JSON(models.ErrorResponse{
 			Error:   true,
@@ -142,12 +142,12 @@ func (h *StudentHandler) CreateStudent(c *fiber.Ctx) error {
 		})
 	}
 	
-	// Parse date of birth
-	dateOfBirth, err := time.Parse("2006-01-02", req.DateOfBirth)
+	// Validate against the students table rules
+	dateOfBirth, err := validateStudentRequest(&req)
 	if err != nil {
 		return c.Status(400).JSON(models.ErrorResponse{
 			Error:   true,
-			Message: "Invalid date of birth format (YYYY-MM-DD)",
+			Message: err.Error(),
 		})
 	}
 	
@@ -158,17 +158,27 @@ func (h *StudentHandler) CreateStudent(c *fiber.Ctx) error {
 	if err == nil {
 		return c.Status(409).JSON(models.ErrorResponse{
 			Error:   true,
-			Message: "Student number already exists",
+			Message: "Student number already exists. Enroll the existing student in this class instead",
 		})
 	}
 	
+	// Begin transaction
+	tx, err := h.db.Begin()
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to begin transaction",
+		})
+	}
+	defer tx.Rollback()
+	
 	// Create the student
 	studentID := uuid.New()
 	insertQuery := `
 		INSERT INTO students (id, student_number, civil_id, first_name, last_name, 
-		                     arabic_name, date_of_birth, gender, nationality, address, class_id)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
-		RETURNING enrollment_date, created_at, updated_at
+		                     arabic_name, date_of_birth, gender, nationality, address)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING created_at, updated_at
 	`
 	
 	var civilID *string
@@ -182,7 +192,7 @@ func (h *StudentHandler) CreateStudent(c *fiber.Ctx) error {
 	}
 	
 	var enrollmentDate, createdAt, updatedAt time.Time
-	err = h.db.QueryRow(insertQuery, studentID, req.StudentNumber, civilID, req.FirstName, req.LastName, req.ArabicName, dateOfBirth, req.Gender, req.Nationality, address, classUUID).Scan(&enrollmentDate, &createdAt, &updatedAt)
+	err = tx.QueryRow(insertQuery, studentID, req.StudentNumber, civilID, req.FirstName, req.LastName, req.ArabicName, dateOfBirth, req.Gender, req.Nationality, address).Scan(&createdAt, &updatedAt)
 	if err != nil {
 		if pqErr, ok := err.(*pq.Error); ok {
 			if pqErr.Code == "23505" { // unique violation
@@ -198,6 +208,24 @@ func (h *StudentHandler) CreateStudent(c *fiber.Ctx) error {
 		})
 	}
 	
+	// Enroll the student in the class
+	enrollQuery := `INSERT INTO student_classes (student_id, class_id) VALUES ($1, $2) RETURNING enrollment_date`
+	err = tx.QueryRow(enrollQuery, studentID, classUUID).Scan(&enrollmentDate)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to enroll student",
+		})
+	}
+	
+	// Commit transaction
+	if err = tx.Commit(); err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to commit transaction",
+		})
+	}
+	
 	student := models.Student{
 		ID:             studentID,
 		StudentNumber:  req.StudentNumber,
@@ -209,7 +237,7 @@ func (h *StudentHandler) CreateStudent(c *fiber.Ctx) error {
 		Gender:         req.Gender,
 		Nationality:    req.Nationality,
 		Address:        address,
-		ClassID:        classUUID,
+		ClassID:        &classUUID,
 		EnrollmentDate: enrollmentDate,
 		IsActive:       true,
 		CreatedAt:      createdAt,
@@ -232,20 +260,38 @@ func (h *StudentHandler) UpdateStudent(c *fiber.Ctx) error {
 		})
 	}
 	
-	// Check if student exists and belongs to user's class
+	// Check if student exists and belongs to user's class. Other teachers
+	// who have or had the student rely on their identity.
 	var existingStudent models.Student
+	var existingCivilID string
+	var shared bool
 	checkQuery := `
-		SELECT s.id, s.class_id FROM students s
-		JOIN classes c ON s.class_id = c.id
+		SELECT s.id, s.student_number, COALESCE(s.civil_id, ''), s.date_of_birth,
+		       EXISTS (
+		           SELECT 1 FROM student_classes osc
+		           JOIN classes oc ON osc.class_id = oc.id
+		           WHERE osc.student_id = s.id AND oc.teacher_id <> $2
+		       )
+		FROM students s
+		JOIN student_classes sc ON sc.student_id = s.id AND sc.is_active = true
+		JOIN classes c ON sc.class_id = c.id
 		WHERE s.id = $1 AND c.teacher_id = $2 AND s.is_active = true
+		LIMIT 1
 	`
-	err = h.db.QueryRow(checkQuery, studentUUID, userID).Scan(&existingStudent.ID, &existingStudent.ClassID)
+	err = h.db.QueryRow(checkQuery, studentUUID, userID).Scan(&existingStudent.ID,
+		&existingStudent.StudentNumber, &existingCivilID, &existingStudent.DateOfBirth, &shared)
 	if err == sql.ErrNoRows {
 		return c.Status(404).JSON(models.ErrorResponse{
 			Error:   true,
 			Message: "Student not found",
 		})
 	}
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch student",
+		})
+	}
 	
 	var req models.UpdateStudentRequest
 	if err := c.BodyParser(&req); err != nil {
@@ -264,13 +310,22 @@ func (h *StudentHandler) UpdateStudent(c *fiber.Ctx) error {
 		})
 	}
 	
-	// Update the student
+	if shared && (req.StudentNumber != existingStudent.StudentNumber || req.CivilID != existingCivilID ||
+		req.DateOfBirth != existingStudent.DateOfBirth.Format("2006-01-02")) {
+		return c.Status(409).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Student number, civil ID and date of birth cannot be changed once other teachers have the student",
+		})
+	}
+	
+	// Update the student. Withdrawing goes through the enrollment endpoints,
+	// since other teachers may still have the student.
 	updateQuery := `
 		UPDATE students 
 		SET student_number = $1, civil_id = $2, first_name = $3, last_name = $4, 
 		    arabic_name = $5, date_of_birth = $6, gender = $7, nationality = $8, 
-		    address = $9, is_active = $10, updated_at = CURRENT_TIMESTAMP
-		WHERE id = $11
+		    address = $9, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $10
 		RETURNING updated_at
 	`
 	
@@ -285,7 +340,7 @@ func (h *StudentHandler) UpdateStudent(c *fiber.Ctx) error {
 	}
 	
 	var updatedAt time.Time
-	err = h.db.QueryRow(updateQuery, req.StudentNumber, civilID, req.FirstName, req.LastName, req.ArabicName, dateOfBirth, req.Gender, req.Nationality, address, req.IsActive, studentUUID).Scan(&updatedAt)
+	err = h.db.QueryRow(updateQuery, req.StudentNumber, civilID, req.FirstName, req.LastName, req.ArabicName, dateOfBirth, req.Gender, req.Nationality, address, studentUUID).Scan(&updatedAt)
 	if err != nil {
 		if pqErr, ok := err.(*pq.Error); ok {
 			if pqErr.Code == "23505" { // unique violation
@@ -310,7 +365,8 @@ func (h *StudentHandler) UpdateStudent(c *fiber.Ctx) error {
 	})
 }
 
-// DeleteStudent soft deletes a student
+// DeleteStudent withdraws a student from all of the teacher's classes. The
+// student is soft deleted once no other class has them enrolled.
 func (h *StudentHandler) DeleteStudent(c *fiber.Ctx) error {
 	userID := c.Locals("user_id").(uuid.UUID)
 	studentID := c.Params("id")
@@ -323,12 +379,14 @@ func (h *StudentHandler) DeleteStudent(c *fiber.Ctx) error {
 		})
 	}
 	
-	// Check if student exists and belongs to user's class
+	// Check if student exists and belongs to one of user's open classes
 	var existingStudent models.Student
 	checkQuery := `
 		SELECT s.id FROM students s
-		JOIN classes c ON s.class_id = c.id
+		JOIN student_classes sc ON sc.student_id = s.id AND sc.is_active = true AND sc.completion_date IS NULL
+		JOIN classes c ON sc.class_id = c.id
 		WHERE s.id = $1 AND c.teacher_id = $2 AND s.is_active = true
+		LIMIT 1
 	`
 	err = h.db.QueryRow(checkQuery, studentUUID, userID).Scan(&existingStudent.ID)
 	if err == sql.ErrNoRows {
@@ -338,16 +396,53 @@ func (h *StudentHandler) DeleteStudent(c *fiber.Ctx) error {
 		})
 	}
 	
-	// Soft delete the student
-	deleteQuery := `UPDATE students SET is_active = false, updated_at = CURRENT_TIMESTAMP WHERE id = $1`
-	_, err = h.db.Exec(deleteQuery, studentUUID)
+	// Begin transaction
+	tx, err := h.db.Begin()
 	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to begin transaction",
+		})
+	}
+	defer tx.Rollback()
+	
+	// Withdraw the student from the teacher's classes. Completed classes
+	// keep the student on their roster.
+	withdrawQuery := `
+		UPDATE student_classes SET is_active = false
+		WHERE student_id = $1 AND is_active = true AND completion_date IS NULL
+		  AND class_id IN (SELECT id FROM classes WHERE teacher_id = $2)
+	`
+	if _, err = tx.Exec(withdrawQuery, studentUUID, userID); err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to delete student",
+		})
+	}
+	
+	// Soft delete the student when no other class is teaching them
+	deleteQuery := `
+		UPDATE students SET is_active = false, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND NOT EXISTS (
+			SELECT 1 FROM student_classes
+			WHERE student_id = $1 AND is_active = true AND completion_date IS NULL
+		)
+	`
+	if _, err = tx.Exec(deleteQuery, studentUUID); err != nil {
 		return c.Status(500).JSON(models.ErrorResponse{
 			Error:   true,
 			Message: "Failed to delete student",
 		})
 	}
 	
+	// Commit transaction
+	if err = tx.Commit(); err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to commit transaction",
+		})
+	}
+	
 	return c.JSON(models.SuccessResponse{
 		Success: true,
 		Message: "Student deleted successfully",
@@ -372,11 +467,12 @@ func (h *StudentHandler) GetStudent(c *fiber.Ctx) error {
 		SELECT 
 			s.id, s.student_number, s.civil_id, s.first_name, s.last_name, 
 			s.arabic_name, s.date_of_birth, s.gender, s.nationality, s.address,
-			s.class_id, s.enrollment_date, s.is_active, s.created_at, s.updated_at,
+			sc.class_id, sc.enrollment_date, s.is_active, s.created_at, s.updated_at,
 			COALESCE(attendance_rates.rate, 0) as attendance_rate,
 			cl.name as class_name
 		FROM students s
-		JOIN classes cl ON s.class_id = cl.id
+		JOIN student_classes sc ON sc.student_id = s.id AND sc.is_active = true
+		JOIN classes cl ON sc.class_id = cl.id
 		LEFT JOIN (
 			SELECT 
 				student_id,
@@ -386,6 +482,8 @@ func (h *StudentHandler) GetStudent(c *fiber.Ctx) error {
 			GROUP BY student_id
 		) attendance_rates ON s.id = attendance_rates.student_id
 		WHERE s.id = $1 AND cl.teacher_id = $2 AND s.is_active = true
+		ORDER BY sc.enrollment_date DESC
+		LIMIT 1
 	`
 	
 	var student models.Student
@@ -412,5 +510,13 @@ func (h *StudentHandler) GetStudent(c *fiber.Ctx) error {
 		})
 	}
 	
+	student.Parents, err = loadStudentParents(h.db, studentUUID)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch parents",
+		})
+	}
+	
 	return c.JSON(student)
 }
\ No newline at end of file
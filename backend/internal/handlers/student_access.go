@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+
+	"moalemplus/internal/models"
+)
+
+// CreateStudentAccessCode issues the code a student signs in with, along
+// with their student number. A new code replaces the previous one, which
+// is also how a student locked out by wrong guesses gets back in.
+func (h *StudentHandler) CreateStudentAccessCode(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	studentUUID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid student ID",
+		})
+	}
+	if status, msg := checkTeacherStudent(h.db, studentUUID, userID); status != 0 {
+		return c.Status(status).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: msg,
+		})
+	}
+
+	code, codeHash, err := generateAccessCode()
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to generate access code",
+		})
+	}
+
+	access := models.StudentAccessCode{Code: code}
+	updateQuery := `
+		UPDATE students
+		SET access_code_hash = $1, access_code_created_at = CURRENT_TIMESTAMP, access_code_attempts = 0
+		WHERE id = $2
+		RETURNING student_number, access_code_created_at
+	`
+	if err := h.db.QueryRow(updateQuery, codeHash, studentUUID).Scan(&access.StudentNumber,
+		&access.CreatedAt); err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to save access code",
+		})
+	}
+
+	return c.Status(201).JSON(access)
+}
+
+// generateAccessCode returns a random six-digit sign-in code and its
+// bcrypt hash, which is all that is stored
+func generateAccessCode() (string, string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", "", err
+	}
+	code := fmt.Sprintf("%06d", n.Int64())
+	hash, err := bcrypt.GenerateFromPassword([]byte(code), bcrypt.DefaultCost)
+	if err != nil {
+		return "", "", err
+	}
+	return code, string(hash), nil
+}
@@ -0,0 +1,361 @@
+package handlers
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"moalemplus/internal/models"
+	"moalemplus/internal/roster"
+)
+
+var (
+	studentNumberPattern = regexp.MustCompile(`^[A-Z0-9]+$`)
+	civilIDPattern       = regexp.MustCompile(`^[0-9]{12}$`)
+)
+
+// BulkImportStudents adds the students of a roster file (CSV or XLSX) to a
+// class. Every row is validated first and the students are only written
+// when all rows are valid and fit within max_students. With dry_run=true the
+// validation report is returned without writing anything.
+func (h *StudentHandler) BulkImportStudents(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	classUUID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid class ID",
+		})
+	}
+
+	var maxStudents int
+	checkQuery := `SELECT max_students FROM classes WHERE id = $1 AND teacher_id = $2 AND is_active = true`
+	err = h.db.QueryRow(checkQuery, classUUID, userID).Scan(&maxStudents)
+	if err == sql.ErrNoRows {
+		return c.Status(404).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Class not found",
+		})
+	}
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch class",
+		})
+	}
+
+	// Accept the file as multipart upload, or the raw request body
+	var (
+		reader io.Reader
+		format = strings.ToLower(formOrQuery(c, "format"))
+	)
+	if fileHeader, err := c.FormFile("file"); err == nil {
+		file, err := fileHeader.Open()
+		if err != nil {
+			return c.Status(400).JSON(models.ErrorResponse{
+				Error:   true,
+				Message: "Failed to read uploaded file",
+			})
+		}
+		defer file.Close()
+		reader = file
+		if format == "" {
+			format = roster.DetectFormat(fileHeader.Filename)
+		}
+	} else {
+		reader = bytes.NewReader(c.Body())
+	}
+
+	if format == "" {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Roster format is required (csv, xlsx)",
+		})
+	}
+	dryRun := formOrQuery(c, "dry_run") == "true"
+
+	records, err := roster.Parse(format, reader)
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: err.Error(),
+		})
+	}
+
+	report := models.StudentImportReport{
+		Format:      format,
+		DryRun:      dryRun,
+		TotalRows:   len(records),
+		MaxStudents: maxStudents,
+		Errors:      []models.ImportRowError{},
+	}
+
+	// Validate every row against the same rules as CreateStudent
+	dates := make([]*time.Time, len(records))
+	for i := range records {
+		if records[i].Err != nil {
+			report.Errors = append(report.Errors, models.ImportRowError{Row: records[i].Row, Message: records[i].Err.Error()})
+			continue
+		}
+		dob, err := validateStudentRequest(&records[i].Student)
+		if err != nil {
+			report.Errors = append(report.Errors, models.ImportRowError{Row: records[i].Row, Message: err.Error()})
+			continue
+		}
+		dates[i] = &dob
+	}
+
+	// Student numbers and civil IDs must be unique within the file and
+	// across all students, including inactive ones
+	rowErrors, err := h.checkRosterDuplicates(records, dates)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to check existing students",
+		})
+	}
+	report.Errors = append(report.Errors, rowErrors...)
+
+	if err := h.db.QueryRow(classStudentCountQuery, classUUID).Scan(&report.CurrentStudents); err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to check class capacity",
+		})
+	}
+	report.Errors = append(report.Errors, capacityErrors(records, dates, maxStudents-report.CurrentStudents, maxStudents)...)
+
+	report.ValidRows = report.TotalRows - countRows(report.Errors)
+
+	if dryRun {
+		return c.JSON(report)
+	}
+
+	if len(report.Errors) > 0 {
+		return c.Status(422).JSON(report)
+	}
+
+	// Begin transaction
+	tx, err := h.db.Begin()
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to begin transaction",
+		})
+	}
+	defer tx.Rollback()
+
+	// Lock the class so concurrent imports cannot overfill it
+	var current int
+	lockQuery := `SELECT id FROM classes WHERE id = $1 FOR UPDATE`
+	if _, err := tx.Exec(lockQuery, classUUID); err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to lock class",
+		})
+	}
+	if err := tx.QueryRow(classStudentCountQuery, classUUID).Scan(&current); err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to check class capacity",
+		})
+	}
+	if current+len(records) > maxStudents {
+		return c.Status(409).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Students were added to the class meanwhile. Run the import again",
+		})
+	}
+
+	insertQuery := `
+		INSERT INTO students (id, student_number, civil_id, first_name, last_name,
+		                      arabic_name, date_of_birth, gender, nationality, address)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`
+	enrollQuery := `INSERT INTO student_classes (student_id, class_id) VALUES ($1, $2)`
+	for i := range records {
+		s := &records[i].Student
+		studentID := uuid.New()
+		_, err := tx.Exec(insertQuery, studentID, s.StudentNumber, nullableString(s.CivilID), s.FirstName,
+			s.LastName, s.ArabicName, *dates[i], s.Gender, s.Nationality, nullableString(s.Address))
+		if err == nil {
+			_, err = tx.Exec(enrollQuery, studentID, classUUID)
+		}
+		if err != nil {
+			message := "Failed to import students"
+			if pqErr, ok := err.(*pq.Error); ok {
+				message = pqErr.Message
+			}
+			report.Errors = append(report.Errors, models.ImportRowError{Row: records[i].Row, Message: message})
+			report.ValidRows = report.TotalRows - countRows(report.Errors)
+			report.StudentIDs = nil
+			return c.Status(422).JSON(report)
+		}
+		report.StudentIDs = append(report.StudentIDs, studentID)
+	}
+
+	// Commit transaction
+	if err := tx.Commit(); err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to commit imported students",
+		})
+	}
+
+	report.ImportedCount = len(report.StudentIDs)
+	report.CurrentStudents = current + report.ImportedCount
+	return c.Status(201).JSON(report)
+}
+
+// validateStudentRequest checks a student request against the students
+// table rules and returns the parsed date of birth
+func validateStudentRequest(req *models.CreateStudentRequest) (time.Time, error) {
+	req.StudentNumber = strings.TrimSpace(req.StudentNumber)
+	req.CivilID = strings.TrimSpace(req.CivilID)
+	req.FirstName = strings.TrimSpace(req.FirstName)
+	req.LastName = strings.TrimSpace(req.LastName)
+	req.ArabicName = strings.TrimSpace(req.ArabicName)
+	req.Nationality = strings.TrimSpace(req.Nationality)
+	req.Address = strings.TrimSpace(req.Address)
+
+	switch {
+	case req.StudentNumber == "":
+		return time.Time{}, errors.New("Student number is required")
+	case !studentNumberPattern.MatchString(req.StudentNumber) || len(req.StudentNumber) > 20:
+		return time.Time{}, fmt.Errorf("Student number %q must be up to 20 capital letters and digits", req.StudentNumber)
+	case req.CivilID != "" && !civilIDPattern.MatchString(req.CivilID):
+		return time.Time{}, fmt.Errorf("Civil ID %q must be 12 digits", req.CivilID)
+	case req.FirstName == "" || req.LastName == "" || req.ArabicName == "":
+		return time.Time{}, errors.New("First name, last name and Arabic name are required")
+	case utf8.RuneCountInString(req.FirstName) > 100 || utf8.RuneCountInString(req.LastName) > 100 ||
+		utf8.RuneCountInString(req.ArabicName) > 255:
+		return time.Time{}, errors.New("Name is too long")
+	case req.Gender != "male" && req.Gender != "female":
+		return time.Time{}, errors.New("Gender must be male or female")
+	case req.Nationality == "":
+		return time.Time{}, errors.New("Nationality is required")
+	case utf8.RuneCountInString(req.Nationality) > 50:
+		return time.Time{}, errors.New("Nationality is too long")
+	}
+
+	dateOfBirth, err := time.Parse("2006-01-02", req.DateOfBirth)
+	if err != nil {
+		return time.Time{}, errors.New("Invalid date of birth format (YYYY-MM-DD)")
+	}
+	today := time.Now().Truncate(24 * time.Hour)
+	if !dateOfBirth.Before(today) {
+		return time.Time{}, errors.New("Date of birth must be in the past")
+	}
+	return dateOfBirth, nil
+}
+
+// checkRosterDuplicates reports valid rows whose student number or civil ID
+// repeats an earlier row or belongs to an existing student. Rejected rows
+// are cleared from dates.
+func (h *StudentHandler) checkRosterDuplicates(records []roster.Record, dates []*time.Time) ([]models.ImportRowError, error) {
+	var numbers, civilIDs []string
+	for i := range records {
+		if dates[i] == nil {
+			continue
+		}
+		numbers = append(numbers, records[i].Student.StudentNumber)
+		if records[i].Student.CivilID != "" {
+			civilIDs = append(civilIDs, records[i].Student.CivilID)
+		}
+	}
+	if len(numbers) == 0 {
+		return nil, nil
+	}
+
+	existingNumbers, err := existingValues(h.db, `SELECT student_number FROM students WHERE student_number = ANY($1)`, numbers)
+	if err != nil {
+		return nil, err
+	}
+	existingCivilIDs, err := existingValues(h.db, `SELECT civil_id FROM students WHERE civil_id = ANY($1)`, civilIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	var rowErrors []models.ImportRowError
+	seenNumbers := map[string]int{}
+	seenCivilIDs := map[string]int{}
+	for i := range records {
+		if dates[i] == nil {
+			continue
+		}
+		s := records[i].Student
+		row := records[i].Row
+		var message string
+		switch {
+		case existingNumbers[s.StudentNumber]:
+			message = fmt.Sprintf("Student number %s already exists. Enroll the existing student instead", s.StudentNumber)
+		case seenNumbers[s.StudentNumber] != 0:
+			message = fmt.Sprintf("Student number %s is repeated from row %d", s.StudentNumber, seenNumbers[s.StudentNumber])
+		case s.CivilID != "" && existingCivilIDs[s.CivilID]:
+			message = fmt.Sprintf("Civil ID %s already belongs to a student", s.CivilID)
+		case s.CivilID != "" && seenCivilIDs[s.CivilID] != 0:
+			message = fmt.Sprintf("Civil ID %s is repeated from row %d", s.CivilID, seenCivilIDs[s.CivilID])
+		}
+		if seenNumbers[s.StudentNumber] == 0 {
+			seenNumbers[s.StudentNumber] = row
+		}
+		if s.CivilID != "" && seenCivilIDs[s.CivilID] == 0 {
+			seenCivilIDs[s.CivilID] = row
+		}
+		if message != "" {
+			rowErrors = append(rowErrors, models.ImportRowError{Row: row, Message: message})
+			dates[i] = nil
+		}
+	}
+	return rowErrors, nil
+}
+
+// capacityErrors reports the valid rows that no longer fit in the class
+func capacityErrors(records []roster.Record, dates []*time.Time, available, maxStudents int) []models.ImportRowError {
+	var rowErrors []models.ImportRowError
+	for i := range records {
+		if dates[i] == nil {
+			continue
+		}
+		if available > 0 {
+			available--
+			continue
+		}
+		rowErrors = append(rowErrors, models.ImportRowError{
+			Row:     records[i].Row,
+			Message: fmt.Sprintf("Class is full (%d students at most)", maxStudents),
+		})
+	}
+	return rowErrors
+}
+
+// existingValues returns which of values the query finds
+func existingValues(db *sql.DB, query string, values []string) (map[string]bool, error) {
+	found := map[string]bool{}
+	if len(values) == 0 {
+		return found, nil
+	}
+	rows, err := db.Query(query, pq.Array(values))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			return nil, err
+		}
+		found[value] = true
+	}
+	return found, rows.Err()
+}
@@ -0,0 +1,989 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"moalemplus/internal/models"
+)
+
+// reorderOffset temporarily moves question_order values out of the way so
+// that renumbering never collides with UNIQUE(test_id, question_order)
+const reorderOffset = 100000
+
+type TestHandler struct {
+	db *sql.DB
+}
+
+func NewTestHandler(db *sql.DB) *TestHandler {
+	return &TestHandler{db: db}
+}
+
+// testColumns is the select list shared by the test queries
+const testColumns = `
+	t.id, t.title, t.title_arabic, t.description, t.description_arabic,
+	t.class_id, t.created_by, t.test_type, t.duration_minutes, t.total_points,
+	t.passing_score, t.instructions, t.instructions_arabic, t.is_randomized,
+	t.show_results_immediately, t.allow_retakes, t.max_attempts,
+	t.scheduled_start, t.scheduled_end, t.is_published, t.is_active,
+	t.created_at, t.updated_at,
+	c.name as class_name,
+	(SELECT COUNT(*) FROM test_questions tq WHERE tq.test_id = t.id) as question_count
+`
+
+func scanTest(row rowScanner, test *models.Test) error {
+	return row.Scan(
+		&test.ID, &test.Title, &test.TitleArabic, &test.Description,
+		&test.DescriptionArabic, &test.ClassID, &test.CreatedBy, &test.TestType,
+		&test.DurationMinutes, &test.TotalPoints, &test.PassingScore,
+		&test.Instructions, &test.InstructionsArabic, &test.IsRandomized,
+		&test.ShowResultsImmediately, &test.AllowRetakes, &test.MaxAttempts,
+		&test.ScheduledStart, &test.ScheduledEnd, &test.IsPublished,
+		&test.IsActive, &test.CreatedAt, &test.UpdatedAt, &test.ClassName,
+		&test.QuestionCount,
+	)
+}
+
+// GetTests retrieves all tests in the teacher's classes
+func (h *TestHandler) GetTests(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	conditions := []string{"c.teacher_id = $1", "t.is_active = true"}
+	args := []interface{}{userID}
+
+	if classID := c.Query("class_id"); classID != "" {
+		classUUID, err := uuid.Parse(classID)
+		if err != nil {
+			return c.Status(400).JSON(models.ErrorResponse{
+				Error:   true,
+				Message: "Invalid class ID",
+			})
+		}
+		args = append(args, classUUID)
+		conditions = append(conditions, fmt.Sprintf("t.class_id = $%d", len(args)))
+	}
+
+	switch c.Query("status") {
+	case "published":
+		conditions = append(conditions, "t.is_published = true")
+	case "draft":
+		conditions = append(conditions, "t.is_published = false")
+	}
+
+	query := `
+		SELECT ` + testColumns + `
+		FROM tests t
+		JOIN classes c ON t.class_id = c.id
+		WHERE ` + strings.Join(conditions, " AND ") + `
+		ORDER BY t.created_at DESC
+	`
+
+	rows, err := h.db.Query(query, args...)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch tests",
+		})
+	}
+	defer rows.Close()
+
+	var tests []models.Test
+	for rows.Next() {
+		var test models.Test
+		if err := scanTest(rows, &test); err != nil {
+			continue
+		}
+		tests = append(tests, test)
+	}
+
+	// Ensure we always return an array, never null
+	if tests == nil {
+		tests = []models.Test{}
+	}
+
+	return c.JSON(tests)
+}
+
+// GetTest retrieves a test with its questions in order
+func (h *TestHandler) GetTest(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	testUUID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid test ID",
+		})
+	}
+
+	test, err := getTeacherTest(h.db, testUUID, userID)
+	if err == sql.ErrNoRows {
+		return c.Status(404).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Test not found",
+		})
+	}
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch test",
+		})
+	}
+
+	test.Questions, err = loadTestQuestions(h.db, userID, testUUID)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch test questions",
+		})
+	}
+
+	return c.JSON(test)
+}
+
+// CreateTest creates a new draft test for one of the teacher's classes
+func (h *TestHandler) CreateTest(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	var req models.CreateTestRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid request body",
+		})
+	}
+
+	classUUID, err := uuid.Parse(req.ClassID)
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid class ID",
+		})
+	}
+
+	// Check if class exists and belongs to user
+	var existingClass models.Class
+	checkQuery := `SELECT id FROM classes WHERE id = $1 AND teacher_id = $2 AND is_active = true`
+	err = h.db.QueryRow(checkQuery, classUUID, userID).Scan(&existingClass.ID)
+	if err == sql.ErrNoRows {
+		return c.Status(404).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Class not found",
+		})
+	}
+
+	if err := validateTestSettings(req.Title, req.TitleArabic, req.TestType, req.DurationMinutes, &req.MaxAttempts, req.ScheduledStart, req.ScheduledEnd); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: err.Error(),
+		})
+	}
+
+	// A new test has no questions yet, so it cannot require any points to pass
+	if req.PassingScore != 0 {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Passing score can only be set after questions are added",
+		})
+	}
+
+	// Create the test
+	testID := uuid.New()
+	insertQuery := `
+		INSERT INTO tests (id, title, title_arabic, description, description_arabic, class_id,
+		                   created_by, test_type, duration_minutes, passing_score, instructions,
+		                   instructions_arabic, is_randomized, show_results_immediately,
+		                   allow_retakes, max_attempts, scheduled_start, scheduled_end)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)
+		RETURNING created_at, updated_at
+	`
+
+	var createdAt, updatedAt time.Time
+	err = h.db.QueryRow(insertQuery, testID, req.Title, req.TitleArabic,
+		nullableString(req.Description), nullableString(req.DescriptionArabic), classUUID,
+		userID, req.TestType, req.DurationMinutes, req.PassingScore,
+		nullableString(req.Instructions), nullableString(req.InstructionsArabic),
+		req.IsRandomized, req.ShowResultsImmediately, req.AllowRetakes, req.MaxAttempts,
+		req.ScheduledStart, req.ScheduledEnd,
+	).Scan(&createdAt, &updatedAt)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to create test",
+		})
+	}
+
+	test := models.Test{
+		ID:                     testID,
+		Title:                  req.Title,
+		TitleArabic:            req.TitleArabic,
+		Description:            nullableString(req.Description),
+		DescriptionArabic:      nullableString(req.DescriptionArabic),
+		ClassID:                classUUID,
+		CreatedBy:              userID,
+		TestType:               req.TestType,
+		DurationMinutes:        req.DurationMinutes,
+		PassingScore:           req.PassingScore,
+		Instructions:           nullableString(req.Instructions),
+		InstructionsArabic:     nullableString(req.InstructionsArabic),
+		IsRandomized:           req.IsRandomized,
+		ShowResultsImmediately: req.ShowResultsImmediately,
+		AllowRetakes:           req.AllowRetakes,
+		MaxAttempts:            req.MaxAttempts,
+		ScheduledStart:         req.ScheduledStart,
+		ScheduledEnd:           req.ScheduledEnd,
+		IsActive:               true,
+		CreatedAt:              createdAt,
+		UpdatedAt:              updatedAt,
+	}
+
+	return c.Status(201).JSON(test)
+}
+
+// UpdateTest updates the settings of a draft test
+func (h *TestHandler) UpdateTest(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	test, status, msg := h.findDraftTest(c, userID)
+	if status != 0 {
+		return c.Status(status).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: msg,
+		})
+	}
+
+	var req models.UpdateTestRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid request body",
+		})
+	}
+
+	if err := validateTestSettings(req.Title, req.TitleArabic, req.TestType, req.DurationMinutes, &req.MaxAttempts, req.ScheduledStart, req.ScheduledEnd); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: err.Error(),
+		})
+	}
+
+	if req.PassingScore < 0 || req.PassingScore > test.TotalPoints {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: fmt.Sprintf("Passing score must be between 0 and the test's total points (%d)", test.TotalPoints),
+		})
+	}
+
+	// Update the test
+	updateQuery := `
+		UPDATE tests
+		SET title = $1, title_arabic = $2, description = $3, description_arabic = $4,
+		    test_type = $5, duration_minutes = $6, passing_score = $7, instructions = $8,
+		    instructions_arabic = $9, is_randomized = $10, show_results_immediately = $11,
+		    allow_retakes = $12, max_attempts = $13, scheduled_start = $14,
+		    scheduled_end = $15, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $16
+		RETURNING updated_at
+	`
+
+	var updatedAt time.Time
+	err := h.db.QueryRow(updateQuery, req.Title, req.TitleArabic,
+		nullableString(req.Description), nullableString(req.DescriptionArabic),
+		req.TestType, req.DurationMinutes, req.PassingScore,
+		nullableString(req.Instructions), nullableString(req.InstructionsArabic),
+		req.IsRandomized, req.ShowResultsImmediately, req.AllowRetakes, req.MaxAttempts,
+		req.ScheduledStart, req.ScheduledEnd, test.ID,
+	).Scan(&updatedAt)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to update test",
+		})
+	}
+
+	return c.JSON(models.SuccessResponse{
+		Success: true,
+		Message: "Test updated successfully",
+		Data: fiber.Map{
+			"updated_at": updatedAt,
+		},
+	})
+}
+
+// DeleteTest soft deletes a test
+func (h *TestHandler) DeleteTest(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	testUUID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid test ID",
+		})
+	}
+
+	test, err := getTeacherTest(h.db, testUUID, userID)
+	if err == sql.ErrNoRows {
+		return c.Status(404).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Test not found",
+		})
+	}
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch test",
+		})
+	}
+
+	if status, message := checkNoAttemptsInProgress(h.db, test.ID); status != 0 {
+		return c.Status(status).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: message,
+		})
+	}
+
+	// Soft delete the test
+	deleteQuery := `UPDATE tests SET is_active = false, is_published = false, updated_at = CURRENT_TIMESTAMP WHERE id = $1`
+	_, err = h.db.Exec(deleteQuery, test.ID)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to delete test",
+		})
+	}
+
+	return c.JSON(models.SuccessResponse{
+		Success: true,
+		Message: "Test deleted successfully",
+	})
+}
+
+// AddTestQuestion adds a question from the bank to a draft test. Without a
+// question_order the question is appended at the end.
+func (h *TestHandler) AddTestQuestion(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	test, status, msg := h.findDraftTest(c, userID)
+	if status != 0 {
+		return c.Status(status).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: msg,
+		})
+	}
+
+	var req models.AddTestQuestionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid request body",
+		})
+	}
+
+	if req.PointsOverride != nil && *req.PointsOverride <= 0 {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Points override must be positive",
+		})
+	}
+
+	// The question must be visible to the teacher
+	var questionID uuid.UUID
+	checkQuery := `SELECT id FROM questions WHERE id = $1 AND is_active = true AND (created_by = $2 OR is_public = true)`
+	err := h.db.QueryRow(checkQuery, req.QuestionID, userID).Scan(&questionID)
+	if err == sql.ErrNoRows {
+		return c.Status(404).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Question not found",
+		})
+	}
+
+	// Begin transaction
+	tx, err := h.db.Begin()
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to begin transaction",
+		})
+	}
+	defer tx.Rollback()
+
+	order := test.QuestionCount + 1
+	if req.QuestionOrder > 0 && req.QuestionOrder <= test.QuestionCount {
+		// Make room by shifting the questions at and after the position
+		order = req.QuestionOrder
+		if err := shiftTestQuestions(tx, test.ID, order, 1); err != nil {
+			return c.Status(500).JSON(models.ErrorResponse{
+				Error:   true,
+				Message: "Failed to reorder test questions",
+			})
+		}
+	}
+
+	testQuestion := models.TestQuestion{
+		ID:             uuid.New(),
+		TestID:         test.ID,
+		QuestionID:     questionID,
+		QuestionOrder:  order,
+		PointsOverride: req.PointsOverride,
+	}
+
+	insertQuery := `
+		INSERT INTO test_questions (id, test_id, question_id, question_order, points_override)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING created_at
+	`
+	err = tx.QueryRow(insertQuery, testQuestion.ID, test.ID, questionID, order, req.PointsOverride).Scan(&testQuestion.CreatedAt)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok {
+			if pqErr.Code == "23505" { // unique violation
+				return c.Status(409).JSON(models.ErrorResponse{
+					Error:   true,
+					Message: "Question is already in this test",
+				})
+			}
+		}
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to add question to test",
+		})
+	}
+
+	// Commit transaction
+	if err := tx.Commit(); err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to add question to test",
+		})
+	}
+
+	return c.Status(201).JSON(testQuestion)
+}
+
+// UpdateTestQuestion sets or clears the points override of a question in a
+// draft test
+func (h *TestHandler) UpdateTestQuestion(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	test, status, msg := h.findDraftTest(c, userID)
+	if status != 0 {
+		return c.Status(status).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: msg,
+		})
+	}
+
+	questionUUID, err := uuid.Parse(c.Params("questionId"))
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid question ID",
+		})
+	}
+
+	var req models.UpdateTestQuestionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid request body",
+		})
+	}
+
+	if req.PointsOverride != nil && *req.PointsOverride <= 0 {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Points override must be positive",
+		})
+	}
+
+	// Begin transaction
+	tx, err := h.db.Begin()
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to begin transaction",
+		})
+	}
+	defer tx.Rollback()
+
+	// Lowering points may drop the total below the passing score
+	var newPoints int
+	pointsQuery := `
+		SELECT COALESCE($3::int, q.points) FROM test_questions tq
+		JOIN questions q ON tq.question_id = q.id
+		WHERE tq.test_id = $1 AND tq.question_id = $2
+	`
+	err = tx.QueryRow(pointsQuery, test.ID, questionUUID, req.PointsOverride).Scan(&newPoints)
+	if err == sql.ErrNoRows {
+		return c.Status(404).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Question is not in this test",
+		})
+	}
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to update test question",
+		})
+	}
+
+	if err := clampPassingScore(tx, test.ID, questionUUID, newPoints); err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to update test question",
+		})
+	}
+
+	updateQuery := `UPDATE test_questions SET points_override = $1 WHERE test_id = $2 AND question_id = $3`
+	if _, err := tx.Exec(updateQuery, req.PointsOverride, test.ID, questionUUID); err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to update test question",
+		})
+	}
+
+	// Commit transaction
+	if err := tx.Commit(); err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to update test question",
+		})
+	}
+
+	return c.JSON(models.SuccessResponse{
+		Success: true,
+		Message: "Test question updated successfully",
+		Data: fiber.Map{
+			"points": newPoints,
+		},
+	})
+}
+
+// RemoveTestQuestion removes a question from a draft test and closes the gap
+// in the question order
+func (h *TestHandler) RemoveTestQuestion(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	test, status, msg := h.findDraftTest(c, userID)
+	if status != 0 {
+		return c.Status(status).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: msg,
+		})
+	}
+
+	questionUUID, err := uuid.Parse(c.Params("questionId"))
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid question ID",
+		})
+	}
+
+	// Begin transaction
+	tx, err := h.db.Begin()
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to begin transaction",
+		})
+	}
+	defer tx.Rollback()
+
+	var order int
+	err = tx.QueryRow(`SELECT question_order FROM test_questions WHERE test_id = $1 AND question_id = $2`, test.ID, questionUUID).Scan(&order)
+	if err == sql.ErrNoRows {
+		return c.Status(404).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Question is not in this test",
+		})
+	}
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to remove question from test",
+		})
+	}
+
+	if err := clampPassingScore(tx, test.ID, questionUUID, 0); err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to remove question from test",
+		})
+	}
+
+	if _, err := tx.Exec(`DELETE FROM test_questions WHERE test_id = $1 AND question_id = $2`, test.ID, questionUUID); err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to remove question from test",
+		})
+	}
+
+	if err := shiftTestQuestions(tx, test.ID, order+1, -1); err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to reorder test questions",
+		})
+	}
+
+	// Commit transaction
+	if err := tx.Commit(); err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to remove question from test",
+		})
+	}
+
+	return c.JSON(models.SuccessResponse{
+		Success: true,
+		Message: "Question removed from test successfully",
+	})
+}
+
+// ReorderTestQuestions renumbers a draft test's questions. The request must
+// list every question of the test exactly once.
+func (h *TestHandler) ReorderTestQuestions(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	test, status, msg := h.findDraftTest(c, userID)
+	if status != 0 {
+		return c.Status(status).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: msg,
+		})
+	}
+
+	var req models.ReorderTestQuestionsRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid request body",
+		})
+	}
+
+	// Begin transaction
+	tx, err := h.db.Begin()
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to begin transaction",
+		})
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`SELECT question_id FROM test_questions WHERE test_id = $1 FOR UPDATE`, test.ID)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch test questions",
+		})
+	}
+	current := map[uuid.UUID]bool{}
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err == nil {
+			current[id] = true
+		}
+	}
+	rows.Close()
+
+	seen := map[uuid.UUID]bool{}
+	for _, id := range req.QuestionIDs {
+		if !current[id] || seen[id] {
+			return c.Status(400).JSON(models.ErrorResponse{
+				Error:   true,
+				Message: "Question list must contain every question of the test exactly once",
+			})
+		}
+		seen[id] = true
+	}
+	if len(seen) != len(current) {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Question list must contain every question of the test exactly once",
+		})
+	}
+
+	if _, err := tx.Exec(`UPDATE test_questions SET question_order = question_order + $2 WHERE test_id = $1`, test.ID, reorderOffset); err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to reorder test questions",
+		})
+	}
+
+	for i, id := range req.QuestionIDs {
+		if _, err := tx.Exec(`UPDATE test_questions SET question_order = $1 WHERE test_id = $2 AND question_id = $3`, i+1, test.ID, id); err != nil {
+			return c.Status(500).JSON(models.ErrorResponse{
+				Error:   true,
+				Message: "Failed to reorder test questions",
+			})
+		}
+	}
+
+	// Commit transaction
+	if err := tx.Commit(); err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to reorder test questions",
+		})
+	}
+
+	return c.JSON(models.SuccessResponse{
+		Success: true,
+		Message: "Test questions reordered successfully",
+	})
+}
+
+// PublishTest makes a test available to students
+func (h *TestHandler) PublishTest(c *fiber.Ctx) error {
+	return h.setPublished(c, true)
+}
+
+// UnpublishTest returns a test to draft so it can be edited again
+func (h *TestHandler) UnpublishTest(c *fiber.Ctx) error {
+	return h.setPublished(c, false)
+}
+
+func (h *TestHandler) setPublished(c *fiber.Ctx, publish bool) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	testUUID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid test ID",
+		})
+	}
+
+	test, err := getTeacherTest(h.db, testUUID, userID)
+	if err == sql.ErrNoRows {
+		return c.Status(404).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Test not found",
+		})
+	}
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch test",
+		})
+	}
+
+	if test.IsPublished == publish {
+		message := "Test is already a draft"
+		if publish {
+			message = "Test is already published"
+		}
+		return c.Status(409).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: message,
+		})
+	}
+
+	if publish {
+		if test.QuestionCount == 0 {
+			return c.Status(400).JSON(models.ErrorResponse{
+				Error:   true,
+				Message: "Cannot publish a test without questions",
+			})
+		}
+		if test.ScheduledEnd != nil && test.ScheduledEnd.Before(time.Now()) {
+			return c.Status(400).JSON(models.ErrorResponse{
+				Error:   true,
+				Message: "Cannot publish a test whose schedule has already ended",
+			})
+		}
+	}
+
+	if !publish {
+		if status, message := checkNoAttemptsInProgress(h.db, test.ID); status != 0 {
+			return c.Status(status).JSON(models.ErrorResponse{
+				Error:   true,
+				Message: message,
+			})
+		}
+	}
+
+	var updatedAt time.Time
+	updateQuery := `UPDATE tests SET is_published = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2 RETURNING updated_at`
+	if err := h.db.QueryRow(updateQuery, publish, test.ID).Scan(&updatedAt); err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to update test",
+		})
+	}
+
+	message := "Test unpublished successfully"
+	if publish {
+		message = "Test published successfully"
+	}
+
+	return c.JSON(models.SuccessResponse{
+		Success: true,
+		Message: message,
+		Data: fiber.Map{
+			"is_published": publish,
+			"updated_at":   updatedAt,
+		},
+	})
+}
+
+// findDraftTest loads the test in the :id parameter and makes sure it
+// belongs to the teacher and is not published. A non-zero status means the
+// request must be rejected with the returned message.
+func (h *TestHandler) findDraftTest(c *fiber.Ctx, userID uuid.UUID) (*models.Test, int, string) {
+	testUUID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return nil, 400, "Invalid test ID"
+	}
+
+	test, err := getTeacherTest(h.db, testUUID, userID)
+	if err == sql.ErrNoRows {
+		return nil, 404, "Test not found"
+	}
+	if err != nil {
+		return nil, 500, "Failed to fetch test"
+	}
+
+	if test.IsPublished {
+		return nil, 409, "Unpublish the test before editing it"
+	}
+
+	return test, 0, ""
+}
+
+// checkNoAttemptsInProgress refuses to take a test offline while students
+// are still answering it. A non-zero status means the request must be
+// rejected with the returned message.
+func checkNoAttemptsInProgress(db *sql.DB, testID uuid.UUID) (int, string) {
+	var inProgress int
+	query := `SELECT COUNT(*) FROM test_submissions WHERE test_id = $1 AND status = 'in_progress'`
+	if err := db.QueryRow(query, testID).Scan(&inProgress); err != nil {
+		return 500, "Failed to check test attempts"
+	}
+	if inProgress > 0 {
+		return 409, "Students are taking this test. Wait until their attempts end"
+	}
+	return 0, ""
+}
+
+// getTeacherTest loads an active test that belongs to one of the teacher's classes
+func getTeacherTest(db *sql.DB, testID, userID uuid.UUID) (*models.Test, error) {
+	query := `
+		SELECT ` + testColumns + `
+		FROM tests t
+		JOIN classes c ON t.class_id = c.id
+		WHERE t.id = $1 AND c.teacher_id = $2 AND t.is_active = true
+	`
+	var test models.Test
+	if err := scanTest(db.QueryRow(query, testID, userID), &test); err != nil {
+		return nil, err
+	}
+	return &test, nil
+}
+
+// loadTestQuestions loads a test's questions in order, including the full
+// question and the points it is worth in this test
+func loadTestQuestions(db *sql.DB, userID, testID uuid.UUID) ([]models.TestQuestion, error) {
+	query := `
+		SELECT ` + questionColumns + `,
+			tq.id, tq.test_id, tq.question_id, tq.question_order, tq.points_override,
+			tq.created_at, COALESCE(tq.points_override, q.points) as points
+		FROM test_questions tq
+		JOIN questions q ON tq.question_id = q.id
+		LEFT JOIN subjects s ON q.subject_id = s.id
+		WHERE tq.test_id = $2
+		ORDER BY tq.question_order
+	`
+
+	rows, err := db.Query(query, userID, testID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	testQuestions := []models.TestQuestion{}
+	for rows.Next() {
+		var tq models.TestQuestion
+		question := &models.Question{}
+		err := scanQuestion(rows, question,
+			&tq.ID, &tq.TestID, &tq.QuestionID, &tq.QuestionOrder,
+			&tq.PointsOverride, &tq.CreatedAt, &tq.Points,
+		)
+		if err != nil {
+			return nil, err
+		}
+		tq.Question = question
+		testQuestions = append(testQuestions, tq)
+	}
+	return testQuestions, rows.Err()
+}
+
+// shiftTestQuestions moves every question at or after position from by delta
+func shiftTestQuestions(tx *sql.Tx, testID uuid.UUID, from, delta int) error {
+	_, err := tx.Exec(`
+		UPDATE test_questions SET question_order = question_order + $3
+		WHERE test_id = $1 AND question_order >= $2
+	`, testID, from, reorderOffset)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`
+		UPDATE test_questions SET question_order = question_order - $2 + $3
+		WHERE test_id = $1 AND question_order >= $2
+	`, testID, reorderOffset, delta)
+	return err
+}
+
+// clampPassingScore lowers the passing score when a question's points
+// change to newPoints, so the total never drops below it
+func clampPassingScore(tx *sql.Tx, testID, questionID uuid.UUID, newPoints int) error {
+	_, err := tx.Exec(`
+		UPDATE tests t
+		SET passing_score = LEAST(t.passing_score, t.total_points - COALESCE(tq.points_override, q.points) + $3)
+		FROM test_questions tq
+		JOIN questions q ON tq.question_id = q.id
+		WHERE t.id = $1 AND tq.test_id = t.id AND tq.question_id = $2
+	`, testID, questionID, newPoints)
+	return err
+}
+
+// validateTestSettings checks the fields shared by create and update
+func validateTestSettings(title, titleArabic, testType string, duration int, maxAttempts *int, start, end *time.Time) error {
+	if strings.TrimSpace(title) == "" || strings.TrimSpace(titleArabic) == "" {
+		return errors.New("Test title is required in both languages")
+	}
+	switch testType {
+	case "quiz", "exam", "assessment", "practice":
+	default:
+		return errors.New("Invalid test type. Must be quiz, exam, assessment, or practice")
+	}
+	if duration <= 0 {
+		return errors.New("Duration must be positive")
+	}
+	if *maxAttempts == 0 {
+		*maxAttempts = 1
+	}
+	if *maxAttempts < 0 {
+		return errors.New("Max attempts must be positive")
+	}
+	if start != nil && end != nil && !start.Before(*end) {
+		return errors.New("Scheduled start must be before scheduled end")
+	}
+	return nil
+}
+
+// nullableString converts an empty string into a SQL NULL
+func nullableString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
@@ -0,0 +1,232 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"moalemplus/internal/itemanalysis"
+	"moalemplus/internal/models"
+	"moalemplus/internal/testvariant"
+)
+
+// refreshStatisticsQuery recounts question_statistics for every question
+// answered in an attempt that was graded or regraded since the question's
+// statistics were last written. Only fully graded attempts count, and
+// difficulty_rating is the share of wrong answers.
+const refreshStatisticsQuery = `
+	WITH stale AS (
+		SELECT DISTINCT tsa.question_id
+		FROM test_submission_answers tsa
+		JOIN test_submissions ts ON tsa.submission_id = ts.id
+		LEFT JOIN question_statistics qs ON qs.question_id = tsa.question_id
+		WHERE ` + gradedSubmission + `
+		  AND GREATEST(ts.updated_at, tsa.updated_at) > COALESCE(qs.updated_at, '-infinity')
+	)
+	INSERT INTO question_statistics (question_id, times_used, correct_answers, total_answers, difficulty_rating, last_used_at)
+	SELECT tsa.question_id,
+	       COUNT(DISTINCT ts.test_id),
+	       COUNT(*) FILTER (WHERE tsa.is_correct),
+	       COUNT(*),
+	       ROUND(1 - COUNT(*) FILTER (WHERE tsa.is_correct)::numeric / COUNT(*), 2),
+	       MAX(COALESCE(ts.submitted_at, ts.expires_at, ts.started_at))
+	FROM test_submission_answers tsa
+	JOIN test_submissions ts ON tsa.submission_id = ts.id
+	WHERE tsa.question_id IN (SELECT question_id FROM stale)
+	  AND ` + gradedSubmission + `
+	  AND tsa.grading_status IN ('auto_graded', 'manually_graded')
+	GROUP BY tsa.question_id
+	ON CONFLICT (question_id) DO UPDATE
+	SET times_used = EXCLUDED.times_used,
+	    correct_answers = EXCLUDED.correct_answers,
+	    total_answers = EXCLUDED.total_answers,
+	    difficulty_rating = EXCLUDED.difficulty_rating,
+	    last_used_at = EXCLUDED.last_used_at
+`
+
+// RefreshQuestionStatistics updates question_statistics from graded
+// attempts and returns how many questions were updated. It is run
+// periodically, so recently graded tests show up within minutes.
+func RefreshQuestionStatistics(db *sql.DB) (int64, error) {
+	result, err := db.Exec(refreshStatisticsQuery)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// GetTestAnalysis returns the item analysis of a test
+func (h *TestHandler) GetTestAnalysis(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	testUUID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid test ID",
+		})
+	}
+
+	test, err := getTeacherTest(h.db, testUUID, userID)
+	if err == sql.ErrNoRows {
+		return c.Status(404).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Test not found",
+		})
+	}
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch test",
+		})
+	}
+
+	questions, err := loadTestQuestions(h.db, userID, test.ID)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch test questions",
+		})
+	}
+
+	items := make([]itemanalysis.Item, len(questions))
+	multipleChoice := map[uuid.UUID]bool{}
+	for i, tq := range questions {
+		items[i] = itemanalysis.Item{
+			QuestionID: tq.QuestionID,
+			Points:     tq.Points,
+		}
+		if tq.Question.QuestionType == models.QuestionTypeMultipleChoice {
+			items[i].Options = optionLabels(tq.Question)
+			items[i].CorrectOption = tq.Question.CorrectAnswer
+			multipleChoice[tq.QuestionID] = true
+		}
+	}
+
+	responses, err := h.loadAnalysisResponses(test.ID, multipleChoice)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch answers",
+		})
+	}
+
+	result := itemanalysis.Analyze(items, responses)
+
+	analysis := models.TestAnalysis{
+		TestID:      test.ID,
+		Students:    result.Students,
+		TotalPoints: test.TotalPoints,
+		MeanScore:   result.MeanScore,
+		StdDev:      result.StdDev,
+		KR20:        result.KR20,
+		GroupSize:   result.GroupSize,
+		Items:       make([]models.ItemAnalysis, len(questions)),
+		GeneratedAt: time.Now(),
+	}
+	for i, tq := range questions {
+		text := tq.Question.QuestionTextArabic
+		if strings.TrimSpace(text) == "" {
+			text = tq.Question.QuestionText
+		}
+		item := result.Items[i]
+		analysis.Items[i] = models.ItemAnalysis{
+			QuestionID:     tq.QuestionID,
+			QuestionOrder:  tq.QuestionOrder,
+			QuestionType:   tq.Question.QuestionType,
+			QuestionText:   text,
+			Points:         tq.Points,
+			Responses:      item.Responses,
+			Omitted:        item.Omitted,
+			PValue:         item.PValue,
+			Discrimination: item.Discrimination,
+			Distractors:    item.Distractors,
+		}
+	}
+
+	return c.JSON(analysis)
+}
+
+// loadAnalysisResponses loads the answers of each student's first finished
+// attempt, skipping attempts that still wait for manual grading. Multiple
+// choice answers are mapped back to the bank's option letters.
+func (h *TestHandler) loadAnalysisResponses(testID uuid.UUID, multipleChoice map[uuid.UUID]bool) ([]itemanalysis.Response, error) {
+	layouts := map[uuid.UUID][]models.VariantQuestion{}
+	variantRows, err := h.db.Query(`SELECT id, layout FROM test_variants WHERE test_id = $1`, testID)
+	if err != nil {
+		return nil, err
+	}
+	for variantRows.Next() {
+		var id uuid.UUID
+		var raw []byte
+		var layout []models.VariantQuestion
+		if err := variantRows.Scan(&id, &raw); err != nil {
+			variantRows.Close()
+			return nil, err
+		}
+		json.Unmarshal(raw, &layout)
+		layouts[id] = layout
+	}
+	variantRows.Close()
+
+	query := `
+		WITH analysed AS (
+			SELECT id, variant_id FROM (
+				SELECT DISTINCT ON (ts.student_id) ts.id, ts.variant_id, ` + gradedSubmission + ` AS graded
+				FROM test_submissions ts
+				WHERE ts.test_id = $1 AND ts.status <> 'in_progress'
+				ORDER BY ts.student_id, ts.attempt_number
+			) first_attempts
+			WHERE graded
+		)
+		SELECT a.id, a.variant_id, tsa.question_id, COALESCE(tsa.student_answer, ''),
+		       COALESCE(tsa.is_correct, false), COALESCE(tsa.points_awarded, 0)
+		FROM analysed a
+		LEFT JOIN test_submission_answers tsa ON tsa.submission_id = a.id
+		ORDER BY a.id
+	`
+	rows, err := h.db.Query(query, testID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	responses := []itemanalysis.Response{}
+	var current uuid.UUID
+	for rows.Next() {
+		var submissionID uuid.UUID
+		var variantID, questionID *uuid.UUID
+		var answer string
+		var isCorrect bool
+		var points int
+		if err := rows.Scan(&submissionID, &variantID, &questionID, &answer, &isCorrect, &points); err != nil {
+			return nil, err
+		}
+		if len(responses) == 0 || submissionID != current {
+			responses = append(responses, itemanalysis.Response{})
+			current = submissionID
+		}
+		if questionID == nil {
+			continue
+		}
+
+		option := ""
+		if answer != "" && multipleChoice[*questionID] {
+			option = strings.ToUpper(answer)
+			if variantID != nil {
+				option = testvariant.CanonicalAnswer(layouts[*variantID], *questionID, option)
+			}
+		}
+		responses[len(responses)-1][*questionID] = itemanalysis.Answer{
+			Answered: answer != "",
+			Correct:  isCorrect,
+			Points:   points,
+			Option:   option,
+		}
+	}
+	return responses, rows.Err()
+}
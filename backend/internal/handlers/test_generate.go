@@ -0,0 +1,267 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"moalemplus/internal/models"
+	"moalemplus/internal/testgen"
+)
+
+// GenerateTest builds a draft test from a blueprint by picking questions
+// from the bank. The picks are reproducible: the seed used is returned and
+// passing it back yields the same questions as long as the bank has not
+// changed. Blueprint cells the bank cannot fill are reported in "unfilled".
+func (h *TestHandler) GenerateTest(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	var req models.GenerateTestRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid request body",
+		})
+	}
+
+	classUUID, err := uuid.Parse(req.ClassID)
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid class ID",
+		})
+	}
+
+	// Check if class exists and belongs to user
+	var subjectID uuid.UUID
+	checkQuery := `SELECT subject_id FROM classes WHERE id = $1 AND teacher_id = $2 AND is_active = true`
+	err = h.db.QueryRow(checkQuery, classUUID, userID).Scan(&subjectID)
+	if err == sql.ErrNoRows {
+		return c.Status(404).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Class not found",
+		})
+	}
+
+	maxAttempts := 1
+	if err := validateTestSettings(req.Title, req.TitleArabic, req.TestType, req.DurationMinutes, &maxAttempts, nil, nil); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: err.Error(),
+		})
+	}
+
+	// Validate the blueprint
+	requested := 0
+	for level, count := range req.DifficultyCounts {
+		if !isValidDifficulty(level) {
+			return c.Status(400).JSON(models.ErrorResponse{
+				Error:   true,
+				Message: fmt.Sprintf("Invalid difficulty level %q. Must be easy, medium, or hard", level),
+			})
+		}
+		if count < 0 {
+			return c.Status(400).JSON(models.ErrorResponse{
+				Error:   true,
+				Message: "Question counts cannot be negative",
+			})
+		}
+		requested += count
+	}
+	if requested == 0 {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Blueprint must request at least one question",
+		})
+	}
+	for _, questionType := range req.QuestionTypes {
+		if !isValidQuestionType(questionType) {
+			return c.Status(400).JSON(models.ErrorResponse{
+				Error:   true,
+				Message: fmt.Sprintf("Invalid question type %q", questionType),
+			})
+		}
+	}
+	if req.TargetPoints < 0 || (req.TargetPoints > 0 && req.TargetPoints < requested) {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Target points must be at least one point per requested question",
+		})
+	}
+
+	candidates, err := h.loadCandidates(userID, subjectID, req.CurriculumUnitIDs, req.QuestionTypes)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch questions",
+		})
+	}
+
+	seed := time.Now().UnixNano()
+	if req.Seed != nil {
+		seed = *req.Seed
+	}
+
+	result := testgen.Generate(candidates, testgen.Blueprint{
+		Counts:       req.DifficultyCounts,
+		TargetPoints: req.TargetPoints,
+	}, seed)
+
+	response := models.GenerateTestResponse{
+		DryRun:      req.DryRun,
+		Seed:        seed,
+		TotalPoints: result.TotalPoints,
+		Questions:   []models.GeneratedQuestion{},
+		Unfilled:    []models.BlueprintShortfall{},
+	}
+	for i, pick := range result.Picks {
+		generated := models.GeneratedQuestion{
+			QuestionID:      pick.ID,
+			QuestionOrder:   i + 1,
+			QuestionType:    pick.QuestionType,
+			DifficultyLevel: pick.Difficulty,
+			Points:          pick.Points,
+		}
+		if pick.UnitID != uuid.Nil {
+			unitID := pick.UnitID
+			generated.CurriculumUnitID = &unitID
+		}
+		response.Questions = append(response.Questions, generated)
+	}
+	for _, shortfall := range result.Shortfalls {
+		response.Unfilled = append(response.Unfilled, models.BlueprintShortfall{
+			DifficultyLevel: shortfall.DifficultyLevel,
+			Requested:       shortfall.Requested,
+			Available:       shortfall.Available,
+		})
+	}
+
+	if req.DryRun {
+		return c.JSON(response)
+	}
+
+	if len(result.Picks) == 0 {
+		return c.Status(422).JSON(response)
+	}
+
+	// Begin transaction
+	tx, err := h.db.Begin()
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to begin transaction",
+		})
+	}
+	defer tx.Rollback()
+
+	testID := uuid.New()
+	insertQuery := `
+		INSERT INTO tests (id, title, title_arabic, class_id, created_by, test_type,
+		                   duration_minutes, max_attempts)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+	_, err = tx.Exec(insertQuery, testID, req.Title, req.TitleArabic, classUUID, userID,
+		req.TestType, req.DurationMinutes, maxAttempts)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to create test",
+		})
+	}
+
+	questionQuery := `
+		INSERT INTO test_questions (test_id, question_id, question_order, points_override)
+		VALUES ($1, $2, $3, $4)
+	`
+	for i, pick := range result.Picks {
+		// Only store an override when the points differ from the bank
+		var pointsOverride *int
+		if pick.Points != pick.Candidate.Points {
+			points := pick.Points
+			pointsOverride = &points
+		}
+		if _, err := tx.Exec(questionQuery, testID, pick.ID, i+1, pointsOverride); err != nil {
+			return c.Status(500).JSON(models.ErrorResponse{
+				Error:   true,
+				Message: "Failed to add questions to test",
+			})
+		}
+	}
+
+	// Commit transaction
+	if err := tx.Commit(); err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to create test",
+		})
+	}
+
+	response.Test, err = getTeacherTest(h.db, testID, userID)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch test",
+		})
+	}
+
+	return c.Status(201).JSON(response)
+}
+
+// loadCandidates loads the active questions of a subject that the teacher
+// may use, restricted to the given units and question types when set
+func (h *TestHandler) loadCandidates(userID, subjectID uuid.UUID, unitIDs []uuid.UUID, questionTypes []string) ([]testgen.Candidate, error) {
+	conditions := []string{
+		"q.is_active = true",
+		"(q.created_by = $1 OR q.is_public = true)",
+		"q.subject_id = $2",
+	}
+	args := []interface{}{userID, subjectID}
+
+	if len(unitIDs) > 0 {
+		ids := make([]string, len(unitIDs))
+		for i, id := range unitIDs {
+			ids[i] = id.String()
+		}
+		args = append(args, pq.Array(ids))
+		conditions = append(conditions, fmt.Sprintf("q.curriculum_unit_id = ANY($%d::uuid[])", len(args)))
+	}
+	if len(questionTypes) > 0 {
+		args = append(args, pq.Array(questionTypes))
+		conditions = append(conditions, fmt.Sprintf("q.question_type = ANY($%d)", len(args)))
+	}
+
+	query := `
+		SELECT q.id, q.curriculum_unit_id, q.question_type, q.difficulty_level, q.points,
+		       COALESCE(qs.difficulty_rating, 0), COALESCE(qs.total_answers, 0)
+		FROM questions q
+		LEFT JOIN question_statistics qs ON qs.question_id = q.id
+		WHERE ` + strings.Join(conditions, " AND ")
+
+	rows, err := h.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var candidates []testgen.Candidate
+	for rows.Next() {
+		var candidate testgen.Candidate
+		var unitID *uuid.UUID
+		err := rows.Scan(&candidate.ID, &unitID, &candidate.QuestionType,
+			&candidate.DifficultyLevel, &candidate.Points,
+			&candidate.DifficultyRating, &candidate.TotalAnswers)
+		if err != nil {
+			return nil, err
+		}
+		if unitID != nil {
+			candidate.UnitID = *unitID
+		}
+		candidates = append(candidates, candidate)
+	}
+	return candidates, rows.Err()
+}
@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"moalemplus/internal/models"
+	"moalemplus/internal/pdf"
+	"moalemplus/internal/testvariant"
+)
+
+// GenerateTestPDF renders the printable test. With ?variant=B the questions
+// and options follow that variant.
+func (h *TestHandler) GenerateTestPDF(c *fiber.Ctx) error {
+	return h.renderTestDocument(c, false)
+}
+
+// GenerateAnswerKeyPDF renders the answer key, for the canonical test or
+// for the variant given in ?variant=
+func (h *TestHandler) GenerateAnswerKeyPDF(c *fiber.Ctx) error {
+	return h.renderTestDocument(c, true)
+}
+
+func (h *TestHandler) renderTestDocument(c *fiber.Ctx, answerKey bool) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	testUUID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid test ID",
+		})
+	}
+
+	test, err := getTeacherTest(h.db, testUUID, userID)
+	if err == sql.ErrNoRows {
+		return c.Status(404).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Test not found",
+		})
+	}
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch test",
+		})
+	}
+
+	var variant *models.TestVariant
+	if label := formOrQuery(c, "variant"); label != "" {
+		variant, err = getTestVariant(h.db, test.ID, label)
+		if err == sql.ErrNoRows {
+			return c.Status(404).JSON(models.ErrorResponse{
+				Error:   true,
+				Message: "Variant not found",
+			})
+		}
+		if err != nil {
+			return c.Status(500).JSON(models.ErrorResponse{
+				Error:   true,
+				Message: "Failed to fetch variant",
+			})
+		}
+	}
+
+	questions, err := loadTestQuestions(h.db, userID, test.ID)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch test questions",
+		})
+	}
+	if len(questions) == 0 {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Test has no questions",
+		})
+	}
+
+	questions, ok := orderForVariant(questions, variant)
+	if !ok {
+		return c.Status(409).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "The test's questions changed after the variants were created. Regenerate the variants",
+		})
+	}
+
+	sheet, err := h.buildTestSheet(test, questions, variant)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to load school details",
+		})
+	}
+
+	var buf bytes.Buffer
+	filename := "test"
+	if answerKey {
+		err = pdf.RenderAnswerKey(&buf, sheet)
+		filename = "answer-key"
+	} else {
+		err = pdf.RenderTest(&buf, sheet)
+	}
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to generate PDF",
+		})
+	}
+	if variant != nil {
+		filename += "-" + variant.Label
+	}
+
+	c.Set(fiber.HeaderContentType, "application/pdf")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s-%s.pdf"`, filename, test.ID))
+	return c.Send(buf.Bytes())
+}
+
+// buildTestSheet collects the header details and lays the questions out as
+// printed, applying the variant's option order when there is one
+func (h *TestHandler) buildTestSheet(test *models.Test, questions []models.TestQuestion, variant *models.TestVariant) (*pdf.TestSheet, error) {
+	sheet := &pdf.TestSheet{
+		Title:           test.TitleArabic,
+		ClassName:       test.ClassName,
+		DurationMinutes: test.DurationMinutes,
+		TotalPoints:     test.TotalPoints,
+	}
+	if sheet.Title == "" {
+		sheet.Title = test.Title
+	}
+	if test.InstructionsArabic != nil && *test.InstructionsArabic != "" {
+		sheet.Instructions = *test.InstructionsArabic
+	} else if test.Instructions != nil {
+		sheet.Instructions = *test.Instructions
+	}
+	if variant != nil {
+		sheet.VariantLabel = variant.Label
+	}
+
+	headerQuery := `
+		SELECT s.name, s.district, u.full_name, COALESCE(sub.name_arabic, '')
+		FROM classes c
+		JOIN users u ON c.teacher_id = u.id
+		JOIN schools s ON u.school_id = s.id
+		LEFT JOIN subjects sub ON c.subject_id = sub.id
+		WHERE c.id = $1
+	`
+	err := h.db.QueryRow(headerQuery, test.ClassID).Scan(&sheet.SchoolName, &sheet.District,
+		&sheet.TeacherName, &sheet.SubjectName)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, tq := range questions {
+		q := tq.Question
+		sq := pdf.SheetQuestion{
+			Number: i + 1,
+			Type:   q.QuestionType,
+			Text:   q.QuestionTextArabic,
+			Points: tq.Points,
+			Answer: q.CorrectAnswer,
+		}
+		if strings.TrimSpace(sq.Text) == "" {
+			sq.Text = q.QuestionText
+		}
+
+		switch q.QuestionType {
+		case models.QuestionTypeMultipleChoice:
+			var options map[string]string
+			json.Unmarshal(q.Options, &options)
+			for _, label := range optionLabels(q) {
+				canonical := label
+				if variant != nil {
+					canonical = testvariant.CanonicalAnswer(variant.Layout, q.ID, label)
+				}
+				sq.Options = append(sq.Options, pdf.SheetOption{Label: label, Text: options[canonical]})
+			}
+			if variant != nil {
+				sq.Answer = testvariant.DisplayedAnswer(variant.Layout, q.ID, q.CorrectAnswer)
+			}
+		case models.QuestionTypeMatching:
+			var options struct {
+				Left  []string `json:"left"`
+				Right []string `json:"right"`
+			}
+			json.Unmarshal(q.Options, &options)
+			sq.MatchLeft, sq.MatchRight = options.Left, options.Right
+		}
+
+		sheet.Questions = append(sheet.Questions, sq)
+	}
+	return sheet, nil
+}
@@ -0,0 +1,372 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"sort"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"moalemplus/internal/models"
+	"moalemplus/internal/testvariant"
+)
+
+// GenerateVariants derives count variants of a test, replacing any existing
+// ones. Without a seed the variants are derived from the test ID, so
+// regenerating them gives the same forms.
+func (h *TestHandler) GenerateVariants(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	testUUID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid test ID",
+		})
+	}
+
+	var req models.GenerateVariantsRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid request body",
+		})
+	}
+
+	if req.Count < 1 || req.Count > 26 {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Variant count must be between 1 and 26",
+		})
+	}
+
+	test, err := getTeacherTest(h.db, testUUID, userID)
+	if err == sql.ErrNoRows {
+		return c.Status(404).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Test not found",
+		})
+	}
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch test",
+		})
+	}
+
+	questions, err := loadTestQuestions(h.db, userID, test.ID)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch test questions",
+		})
+	}
+	if len(questions) == 0 {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Cannot create variants of a test without questions",
+		})
+	}
+
+	// Variants that students already answered must be kept for grading
+	var usedCount int
+	usedQuery := `
+		SELECT COUNT(*) FROM test_submissions ts
+		JOIN test_variants tv ON ts.variant_id = tv.id
+		WHERE tv.test_id = $1
+	`
+	if err := h.db.QueryRow(usedQuery, test.ID).Scan(&usedCount); err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to check existing variants",
+		})
+	}
+	if usedCount > 0 {
+		return c.Status(409).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Variants cannot be regenerated after students have answered them",
+		})
+	}
+
+	items := make([]testvariant.Item, len(questions))
+	for i, tq := range questions {
+		items[i] = testvariant.Item{
+			QuestionID:   tq.QuestionID,
+			OptionLabels: optionLabels(tq.Question),
+		}
+	}
+
+	seed := testvariant.DefaultSeed(test.ID)
+	if req.Seed != nil {
+		seed = *req.Seed
+	}
+
+	// Begin transaction
+	tx, err := h.db.Begin()
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to begin transaction",
+		})
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM test_variants WHERE test_id = $1`, test.ID); err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to replace existing variants",
+		})
+	}
+
+	variants := make([]models.TestVariant, req.Count)
+	insertQuery := `
+		INSERT INTO test_variants (id, test_id, label, variant_number, seed, layout)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING created_at, updated_at
+	`
+	for i := range variants {
+		variant := &variants[i]
+		variant.ID = uuid.New()
+		variant.TestID = test.ID
+		variant.Label = testvariant.Label(i)
+		variant.VariantNumber = i + 1
+		variant.Seed = testvariant.VariantSeed(seed, i)
+		variant.Layout = testvariant.Build(items, variant.Seed)
+
+		layout, _ := json.Marshal(variant.Layout)
+		err := tx.QueryRow(insertQuery, variant.ID, test.ID, variant.Label, variant.VariantNumber,
+			variant.Seed, layout).Scan(&variant.CreatedAt, &variant.UpdatedAt)
+		if err != nil {
+			return c.Status(500).JSON(models.ErrorResponse{
+				Error:   true,
+				Message: "Failed to create variants",
+			})
+		}
+	}
+
+	// Commit transaction
+	if err := tx.Commit(); err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to create variants",
+		})
+	}
+
+	return c.Status(201).JSON(variants)
+}
+
+// GetVariants lists the variants of a test
+func (h *TestHandler) GetVariants(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	testUUID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid test ID",
+		})
+	}
+
+	test, err := getTeacherTest(h.db, testUUID, userID)
+	if err == sql.ErrNoRows {
+		return c.Status(404).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Test not found",
+		})
+	}
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch test",
+		})
+	}
+
+	rows, err := h.db.Query(`
+		SELECT id, test_id, label, variant_number, seed, layout, created_at, updated_at
+		FROM test_variants
+		WHERE test_id = $1
+		ORDER BY variant_number
+	`, test.ID)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch variants",
+		})
+	}
+	defer rows.Close()
+
+	variants := []models.TestVariant{}
+	for rows.Next() {
+		var variant models.TestVariant
+		if err := scanVariant(rows, &variant); err != nil {
+			continue
+		}
+		variants = append(variants, variant)
+	}
+
+	return c.JSON(variants)
+}
+
+// GetVariantAnswerKey returns the answer key of one variant, with the
+// multiple choice answers translated to the letters printed on it
+func (h *TestHandler) GetVariantAnswerKey(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	testUUID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid test ID",
+		})
+	}
+
+	test, err := getTeacherTest(h.db, testUUID, userID)
+	if err == sql.ErrNoRows {
+		return c.Status(404).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Test not found",
+		})
+	}
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch test",
+		})
+	}
+
+	variant, err := getTestVariant(h.db, test.ID, c.Params("label"))
+	if err == sql.ErrNoRows {
+		return c.Status(404).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Variant not found",
+		})
+	}
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch variant",
+		})
+	}
+
+	questions, err := loadTestQuestions(h.db, userID, test.ID)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch test questions",
+		})
+	}
+
+	key, ok := buildAnswerKey(test.ID, questions, variant)
+	if !ok {
+		return c.Status(409).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "The test's questions changed after the variants were created. Regenerate the variants",
+		})
+	}
+
+	return c.JSON(key)
+}
+
+func scanVariant(row rowScanner, variant *models.TestVariant) error {
+	var layout []byte
+	err := row.Scan(&variant.ID, &variant.TestID, &variant.Label, &variant.VariantNumber,
+		&variant.Seed, &layout, &variant.CreatedAt, &variant.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(layout, &variant.Layout)
+}
+
+// getTestVariant loads a variant of a test by its label
+func getTestVariant(db *sql.DB, testID uuid.UUID, label string) (*models.TestVariant, error) {
+	row := db.QueryRow(`
+		SELECT id, test_id, label, variant_number, seed, layout, created_at, updated_at
+		FROM test_variants
+		WHERE test_id = $1 AND label = upper($2)
+	`, testID, label)
+
+	var variant models.TestVariant
+	if err := scanVariant(row, &variant); err != nil {
+		return nil, err
+	}
+	return &variant, nil
+}
+
+// orderForVariant returns the test questions in the variant's display order.
+// Without a variant the canonical order is kept. It reports false when the
+// variant does not cover exactly the test's current questions.
+func orderForVariant(questions []models.TestQuestion, variant *models.TestVariant) ([]models.TestQuestion, bool) {
+	if variant == nil {
+		return questions, true
+	}
+	if len(variant.Layout) != len(questions) {
+		return nil, false
+	}
+
+	byID := make(map[uuid.UUID]models.TestQuestion, len(questions))
+	for _, tq := range questions {
+		byID[tq.QuestionID] = tq
+	}
+
+	ordered := make([]models.TestQuestion, 0, len(questions))
+	for _, entry := range variant.Layout {
+		tq, ok := byID[entry.QuestionID]
+		if !ok {
+			return nil, false
+		}
+		ordered = append(ordered, tq)
+	}
+	return ordered, true
+}
+
+// buildAnswerKey lists the correct answers in the variant's order and
+// lettering, or the canonical ones when variant is nil
+func buildAnswerKey(testID uuid.UUID, questions []models.TestQuestion, variant *models.TestVariant) (*models.AnswerKey, bool) {
+	ordered, ok := orderForVariant(questions, variant)
+	if !ok {
+		return nil, false
+	}
+
+	key := &models.AnswerKey{
+		TestID:  testID,
+		Entries: []models.AnswerKeyEntry{},
+	}
+	if variant != nil {
+		key.VariantID = &variant.ID
+		key.Label = variant.Label
+	}
+
+	for i, tq := range ordered {
+		answer := tq.Question.CorrectAnswer
+		if variant != nil {
+			answer = testvariant.DisplayedAnswer(variant.Layout, tq.QuestionID, answer)
+		}
+		key.Entries = append(key.Entries, models.AnswerKeyEntry{
+			Number:        i + 1,
+			QuestionID:    tq.QuestionID,
+			QuestionType:  tq.Question.QuestionType,
+			CorrectAnswer: answer,
+			Points:        tq.Points,
+		})
+	}
+	return key, true
+}
+
+// optionLabels returns the sorted option letters of a multiple choice
+// question, or nil for other question types
+func optionLabels(question *models.Question) []string {
+	if question == nil || question.QuestionType != models.QuestionTypeMultipleChoice {
+		return nil
+	}
+	var options map[string]string
+	if err := json.Unmarshal(question.Options, &options); err != nil {
+		return nil
+	}
+	labels := make([]string, 0, len(options))
+	for label := range options {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+	return labels
+}
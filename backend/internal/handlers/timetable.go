@@ -0,0 +1,708 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"moalemplus/internal/models"
+)
+
+// classScheduleColumns are the columns scanned by scanClassSchedule
+const classScheduleColumns = `
+	cs.id, cs.class_id, cs.day_of_week, cs.period_number,
+	to_char(cs.start_time, 'HH24:MI'), to_char(cs.end_time, 'HH24:MI'),
+	cs.room, cs.starts_on, cs.ends_on, cs.created_at, cs.updated_at,
+	c.name, COALESCE(sub.name_arabic, '')
+`
+
+// schoolTime is the time zone of the school day. Kuwait keeps UTC+3 all
+// year round.
+var schoolTime = time.FixedZone("Asia/Kuwait", 3*60*60)
+
+// upcomingLookahead is how many days ahead GetUpcomingClasses searches
+const upcomingLookahead = 14
+
+type TimetableHandler struct {
+	db *sql.DB
+}
+
+func NewTimetableHandler(db *sql.DB) *TimetableHandler {
+	return &TimetableHandler{db: db}
+}
+
+// GetClassSchedule returns a class's weekly sessions
+func (h *TimetableHandler) GetClassSchedule(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	classUUID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid class ID",
+		})
+	}
+
+	if status, msg := checkTeacherClass(h.db, classUUID, userID); status != 0 {
+		return c.Status(status).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: msg,
+		})
+	}
+
+	query := `
+		SELECT ` + classScheduleColumns + `
+		FROM class_schedules cs
+		JOIN classes c ON cs.class_id = c.id
+		LEFT JOIN subjects sub ON c.subject_id = sub.id
+		WHERE cs.class_id = $1
+		ORDER BY cs.starts_on NULLS FIRST, cs.day_of_week, cs.start_time
+	`
+	schedules, err := queryClassSchedules(h.db, query, classUUID)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch schedule",
+		})
+	}
+
+	return c.JSON(schedules)
+}
+
+// CreateClassSchedule adds a weekly session to a class. It may not overlap
+// another of the teacher's sessions or reuse the class's period that day.
+func (h *TimetableHandler) CreateClassSchedule(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	classUUID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid class ID",
+		})
+	}
+
+	var req models.SaveClassScheduleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid request body",
+		})
+	}
+	startsOn, endsOn, err := validateScheduleRequest(&req)
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: err.Error(),
+		})
+	}
+
+	if status, msg := checkTeacherClass(h.db, classUUID, userID); status != 0 {
+		return c.Status(status).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: msg,
+		})
+	}
+	if status, msg := checkScheduleConflict(h.db, userID, classUUID, nil, &req, startsOn, endsOn); status != 0 {
+		return c.Status(status).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: msg,
+		})
+	}
+
+	var scheduleID uuid.UUID
+	insertQuery := `
+		INSERT INTO class_schedules (class_id, day_of_week, period_number, start_time, end_time, room, starts_on, ends_on)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id
+	`
+	err = h.db.QueryRow(insertQuery, classUUID, req.DayOfWeek, req.PeriodNumber, req.StartTime, req.EndTime,
+		nullableString(req.Room), startsOn, endsOn).Scan(&scheduleID)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to create schedule",
+		})
+	}
+
+	schedule, err := loadClassSchedule(h.db, scheduleID)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch schedule",
+		})
+	}
+
+	return c.Status(201).JSON(schedule)
+}
+
+// UpdateClassSchedule changes a weekly session
+func (h *TimetableHandler) UpdateClassSchedule(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	scheduleUUID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid schedule ID",
+		})
+	}
+
+	var req models.SaveClassScheduleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid request body",
+		})
+	}
+	startsOn, endsOn, err := validateScheduleRequest(&req)
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: err.Error(),
+		})
+	}
+
+	classID, status, msg := scheduleClass(h.db, scheduleUUID, userID)
+	if status != 0 {
+		return c.Status(status).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: msg,
+		})
+	}
+	if status, msg := checkScheduleConflict(h.db, userID, classID, &scheduleUUID, &req, startsOn, endsOn); status != 0 {
+		return c.Status(status).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: msg,
+		})
+	}
+
+	updateQuery := `
+		UPDATE class_schedules
+		SET day_of_week = $2, period_number = $3, start_time = $4, end_time = $5, room = $6,
+		    starts_on = $7, ends_on = $8
+		WHERE id = $1
+	`
+	_, err = h.db.Exec(updateQuery, scheduleUUID, req.DayOfWeek, req.PeriodNumber, req.StartTime, req.EndTime,
+		nullableString(req.Room), startsOn, endsOn)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to update schedule",
+		})
+	}
+
+	schedule, err := loadClassSchedule(h.db, scheduleUUID)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch schedule",
+		})
+	}
+
+	return c.JSON(schedule)
+}
+
+// DeleteClassSchedule removes a weekly session from the timetable.
+// Attendance already taken in it is kept.
+func (h *TimetableHandler) DeleteClassSchedule(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	scheduleUUID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid schedule ID",
+		})
+	}
+
+	if _, status, msg := scheduleClass(h.db, scheduleUUID, userID); status != 0 {
+		return c.Status(status).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: msg,
+		})
+	}
+
+	if _, err := h.db.Exec(`DELETE FROM class_schedules WHERE id = $1`, scheduleUUID); err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to delete schedule",
+		})
+	}
+
+	return c.JSON(models.SuccessResponse{
+		Success: true,
+		Message: "Schedule deleted successfully",
+	})
+}
+
+// GetTimetable returns the teacher's weekly timetable in effect on
+// ?date=, which defaults to today
+func (h *TimetableHandler) GetTimetable(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	date, err := schoolDateParam(c, "date")
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid date format (YYYY-MM-DD)",
+		})
+	}
+
+	query := `
+		SELECT ` + classScheduleColumns + `
+		FROM class_schedules cs
+		JOIN classes c ON cs.class_id = c.id
+		LEFT JOIN subjects sub ON c.subject_id = sub.id
+		WHERE c.teacher_id = $1 AND c.is_active = true
+		  AND (cs.starts_on IS NULL OR cs.starts_on <= $2)
+		  AND (cs.ends_on IS NULL OR cs.ends_on >= $2)
+		ORDER BY cs.day_of_week, cs.start_time
+	`
+	schedules, err := queryClassSchedules(h.db, query, userID, date)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch timetable",
+		})
+	}
+
+	return c.JSON(schedules)
+}
+
+// GetScheduleExceptions lists the teacher's holidays and cancelled
+// sessions from ?from=, which defaults to today
+func (h *TimetableHandler) GetScheduleExceptions(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	from, err := schoolDateParam(c, "from")
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid from format (YYYY-MM-DD)",
+		})
+	}
+
+	query := `
+		SELECT id, class_id, date, period_number, reason, created_at
+		FROM schedule_exceptions
+		WHERE teacher_id = $1 AND date >= $2
+		ORDER BY date, period_number NULLS FIRST
+	`
+	rows, err := h.db.Query(query, userID, from)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch schedule exceptions",
+		})
+	}
+	defer rows.Close()
+
+	exceptions := []models.ScheduleException{}
+	for rows.Next() {
+		var exception models.ScheduleException
+		if err := rows.Scan(&exception.ID, &exception.ClassID, &exception.Date, &exception.PeriodNumber,
+			&exception.Reason, &exception.CreatedAt); err != nil {
+			return c.Status(500).JSON(models.ErrorResponse{
+				Error:   true,
+				Message: "Failed to scan schedule exception",
+			})
+		}
+		exceptions = append(exceptions, exception)
+	}
+	if err := rows.Err(); err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch schedule exceptions",
+		})
+	}
+
+	return c.JSON(exceptions)
+}
+
+// CreateScheduleException cancels the sessions of one class, or of all
+// the teacher's classes, on a day or in one period of it
+func (h *TimetableHandler) CreateScheduleException(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	var req models.CreateScheduleExceptionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid request body",
+		})
+	}
+
+	date, err := time.Parse("2006-01-02", req.Date)
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid date format (YYYY-MM-DD)",
+		})
+	}
+	if req.PeriodNumber != nil && (*req.PeriodNumber < 1 || *req.PeriodNumber > maxPeriodNumber) {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: fmt.Sprintf("period_number must be between 1 and %d", maxPeriodNumber),
+		})
+	}
+	if req.ClassID != nil {
+		if status, msg := checkTeacherClass(h.db, *req.ClassID, userID); status != 0 {
+			return c.Status(status).JSON(models.ErrorResponse{
+				Error:   true,
+				Message: msg,
+			})
+		}
+	}
+
+	var exception models.ScheduleException
+	insertQuery := `
+		INSERT INTO schedule_exceptions (teacher_id, class_id, date, period_number, reason)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, class_id, date, period_number, reason, created_at
+	`
+	err = h.db.QueryRow(insertQuery, userID, req.ClassID, date, req.PeriodNumber,
+		nullableString(strings.TrimSpace(req.Reason))).Scan(&exception.ID, &exception.ClassID, &exception.Date,
+		&exception.PeriodNumber, &exception.Reason, &exception.CreatedAt)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to create schedule exception",
+		})
+	}
+
+	return c.Status(201).JSON(exception)
+}
+
+// DeleteScheduleException puts cancelled sessions back on the timetable
+func (h *TimetableHandler) DeleteScheduleException(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	exceptionUUID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid schedule exception ID",
+		})
+	}
+
+	result, err := h.db.Exec(`DELETE FROM schedule_exceptions WHERE id = $1 AND teacher_id = $2`, exceptionUUID, userID)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to delete schedule exception",
+		})
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return c.Status(404).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Schedule exception not found",
+		})
+	}
+
+	return c.JSON(models.SuccessResponse{
+		Success: true,
+		Message: "Schedule exception deleted successfully",
+	})
+}
+
+// GetUpcomingClasses returns the teacher's next sessions, starting with
+// the one in progress, for the dashboard. ?limit= defaults to 5.
+func (h *TimetableHandler) GetUpcomingClasses(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+	limit, _ := parseLimitOffset(c, 5, 20)
+
+	now := time.Now().In(schoolTime)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	upcoming := []models.ScheduledSession{}
+	for day := 0; day < upcomingLookahead && len(upcoming) < limit; day++ {
+		sessions, err := teacherSessions(h.db, userID, today.AddDate(0, 0, day), now)
+		if err != nil {
+			return c.Status(500).JSON(models.ErrorResponse{
+				Error:   true,
+				Message: "Failed to fetch upcoming classes",
+			})
+		}
+		for _, session := range sessions {
+			if session.Status == models.SessionCompleted {
+				continue
+			}
+			upcoming = append(upcoming, session)
+			if len(upcoming) == limit {
+				break
+			}
+		}
+	}
+
+	return c.JSON(upcoming)
+}
+
+// GetPendingAttendance lists the sessions on ?date=, which defaults to
+// today, that have started but whose attendance was not taken yet
+func (h *TimetableHandler) GetPendingAttendance(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uuid.UUID)
+
+	date, err := schoolDateParam(c, "date")
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Invalid date format (YYYY-MM-DD)",
+		})
+	}
+
+	sessions, err := teacherSessions(h.db, userID, date, time.Now().In(schoolTime))
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error:   true,
+			Message: "Failed to fetch sessions",
+		})
+	}
+
+	pending := []models.ScheduledSession{}
+	for _, session := range sessions {
+		if !session.AttendanceTaken && session.Status != models.SessionUpcoming {
+			pending = append(pending, session)
+		}
+	}
+
+	return c.JSON(pending)
+}
+
+// teacherSessions returns the sessions of the teacher's classes on a
+// date, leaving out those cancelled by a schedule exception and days that
+// are not school days in the school calendar, with their status at now
+func teacherSessions(db *sql.DB, teacherID uuid.UUID, date, now time.Time) ([]models.ScheduledSession, error) {
+	if date.Weekday() > time.Thursday {
+		return nil, nil
+	}
+	schoolID, err := userSchoolID(db, teacherID)
+	if err != nil {
+		return nil, err
+	}
+	cal, err := loadSchoolCalendar(db, schoolID, date, date)
+	if err != nil {
+		return nil, err
+	}
+	if !cal.IsSchoolDay(date) {
+		return nil, nil
+	}
+
+	query := `
+		SELECT cs.id, cs.class_id, c.name, COALESCE(sub.name_arabic, ''), cs.period_number,
+		       to_char(cs.start_time, 'HH24:MI'), to_char(cs.end_time, 'HH24:MI'),
+		       EXTRACT(EPOCH FROM cs.end_time - cs.start_time)::int / 60, cs.room,
+		       (SELECT COUNT(*) FROM student_classes sc
+		        JOIN students s ON sc.student_id = s.id
+		        WHERE sc.class_id = c.id AND sc.is_active = true AND s.is_active = true),
+		       EXISTS (SELECT 1 FROM attendance a
+		               WHERE a.class_id = c.id AND a.date = $2 AND a.period_number = cs.period_number)
+		FROM class_schedules cs
+		JOIN classes c ON cs.class_id = c.id
+		LEFT JOIN subjects sub ON c.subject_id = sub.id
+		WHERE c.teacher_id = $1 AND c.is_active = true AND cs.day_of_week = $3
+		  AND (cs.starts_on IS NULL OR cs.starts_on <= $2)
+		  AND (cs.ends_on IS NULL OR cs.ends_on >= $2)
+		  AND NOT EXISTS (
+		      SELECT 1 FROM schedule_exceptions e
+		      WHERE e.teacher_id = c.teacher_id AND e.date = $2
+		        AND (e.class_id IS NULL OR e.class_id = c.id)
+		        AND (e.period_number IS NULL OR e.period_number = cs.period_number)
+		  )
+		ORDER BY cs.start_time
+	`
+	rows, err := db.Query(query, teacherID, date, int(date.Weekday()))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []models.ScheduledSession
+	for rows.Next() {
+		session := models.ScheduledSession{Date: date}
+		if err := rows.Scan(&session.ScheduleID, &session.ClassID, &session.ClassName, &session.SubjectName,
+			&session.PeriodNumber, &session.StartTime, &session.EndTime, &session.DurationMinutes,
+			&session.Room, &session.StudentCount, &session.AttendanceTaken); err != nil {
+			return nil, err
+		}
+		session.Status = sessionStatus(date, session.StartTime, session.EndTime, now)
+		sessions = append(sessions, session)
+	}
+	return sessions, rows.Err()
+}
+
+// sessionStatus says whether a session on date between the HH:MM times
+// start and end is over, running or still to come at now
+func sessionStatus(date time.Time, start, end string, now time.Time) string {
+	switch {
+	case !now.Before(clockOn(date, end)):
+		return models.SessionCompleted
+	case !now.Before(clockOn(date, start)):
+		return models.SessionInProgress
+	}
+	return models.SessionUpcoming
+}
+
+// schoolDateParam reads a YYYY-MM-DD query parameter, defaulting to
+// today in the school's time zone
+func schoolDateParam(c *fiber.Ctx, name string) (time.Time, error) {
+	if value := c.Query(name); value != "" {
+		return time.Parse("2006-01-02", value)
+	}
+	now := time.Now().In(schoolTime)
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC), nil
+}
+
+// validateScheduleRequest checks a weekly session and returns its
+// semester dates
+func validateScheduleRequest(req *models.SaveClassScheduleRequest) (startsOn, endsOn *time.Time, err error) {
+	req.Room = strings.TrimSpace(req.Room)
+
+	start, startErr := time.Parse("15:04", req.StartTime)
+	end, endErr := time.Parse("15:04", req.EndTime)
+	switch {
+	case req.DayOfWeek < 0 || req.DayOfWeek > 4:
+		return nil, nil, errors.New("Day of week must be between 0 (Sunday) and 4 (Thursday)")
+	case req.PeriodNumber < 1 || req.PeriodNumber > maxPeriodNumber:
+		return nil, nil, fmt.Errorf("Period number must be between 1 and %d", maxPeriodNumber)
+	case startErr != nil || endErr != nil:
+		return nil, nil, errors.New("Invalid time format (HH:MM)")
+	case !end.After(start):
+		return nil, nil, errors.New("End time must be after start time")
+	case utf8.RuneCountInString(req.Room) > 50:
+		return nil, nil, errors.New("Room is too long")
+	}
+
+	if startsOn, err = optionalDate(req.StartsOn); err != nil {
+		return nil, nil, errors.New("Invalid starts_on format (YYYY-MM-DD)")
+	}
+	if endsOn, err = optionalDate(req.EndsOn); err != nil {
+		return nil, nil, errors.New("Invalid ends_on format (YYYY-MM-DD)")
+	}
+	if startsOn != nil && endsOn != nil && endsOn.Before(*startsOn) {
+		return nil, nil, errors.New("ends_on must not be before starts_on")
+	}
+	return startsOn, endsOn, nil
+}
+
+// optionalDate parses a YYYY-MM-DD date that may be left empty
+func optionalDate(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// checkScheduleConflict returns a non-zero status and message when a
+// session would overlap another of the teacher's sessions, or take a
+// period the class already has that day, while both are in effect.
+// excludeID is the session being changed.
+func checkScheduleConflict(db queryRower, teacherID, classID uuid.UUID, excludeID *uuid.UUID,
+	req *models.SaveClassScheduleRequest, startsOn, endsOn *time.Time) (int, string) {
+	query := `
+		SELECT c.name, to_char(cs.start_time, 'HH24:MI'), to_char(cs.end_time, 'HH24:MI')
+		FROM class_schedules cs
+		JOIN classes c ON cs.class_id = c.id
+		WHERE c.teacher_id = $1 AND c.is_active = true AND cs.day_of_week = $2
+		  AND ($3::uuid IS NULL OR cs.id <> $3)
+		  AND ((cs.start_time < $5::time AND cs.end_time > $4::time)
+		       OR (cs.class_id = $6 AND cs.period_number = $7))
+		  AND (cs.ends_on IS NULL OR $8::date IS NULL OR cs.ends_on >= $8)
+		  AND (cs.starts_on IS NULL OR $9::date IS NULL OR cs.starts_on <= $9)
+		ORDER BY cs.start_time
+		LIMIT 1
+	`
+	var className, start, end string
+	err := db.QueryRow(query, teacherID, req.DayOfWeek, excludeID, req.StartTime, req.EndTime,
+		classID, req.PeriodNumber, startsOn, endsOn).Scan(&className, &start, &end)
+	if err == sql.ErrNoRows {
+		return 0, ""
+	}
+	if err != nil {
+		return 500, "Failed to check schedule"
+	}
+	return 409, fmt.Sprintf("Clashes with %s from %s to %s on the same day", className, start, end)
+}
+
+// checkTeacherClass returns a non-zero status and message when the class
+// does not belong to the teacher
+func checkTeacherClass(db queryRower, classID, userID uuid.UUID) (int, string) {
+	var id uuid.UUID
+	query := `SELECT id FROM classes WHERE id = $1 AND teacher_id = $2 AND is_active = true`
+	err := db.QueryRow(query, classID, userID).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 404, "Class not found"
+	}
+	if err != nil {
+		return 500, "Failed to fetch class"
+	}
+	return 0, ""
+}
+
+// scheduleClass returns the class of a weekly session in one of the
+// teacher's classes
+func scheduleClass(db queryRower, scheduleID, userID uuid.UUID) (uuid.UUID, int, string) {
+	var classID uuid.UUID
+	query := `
+		SELECT cs.class_id FROM class_schedules cs
+		JOIN classes c ON cs.class_id = c.id
+		WHERE cs.id = $1 AND c.teacher_id = $2 AND c.is_active = true
+	`
+	err := db.QueryRow(query, scheduleID, userID).Scan(&classID)
+	if err == sql.ErrNoRows {
+		return uuid.Nil, 404, "Schedule not found"
+	}
+	if err != nil {
+		return uuid.Nil, 500, "Failed to fetch schedule"
+	}
+	return classID, 0, ""
+}
+
+func loadClassSchedule(db queryRower, scheduleID uuid.UUID) (*models.ClassSchedule, error) {
+	query := `
+		SELECT ` + classScheduleColumns + `
+		FROM class_schedules cs
+		JOIN classes c ON cs.class_id = c.id
+		LEFT JOIN subjects sub ON c.subject_id = sub.id
+		WHERE cs.id = $1
+	`
+	var schedule models.ClassSchedule
+	if err := scanClassSchedule(db.QueryRow(query, scheduleID), &schedule); err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+func queryClassSchedules(db *sql.DB, query string, args ...interface{}) ([]models.ClassSchedule, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	schedules := []models.ClassSchedule{}
+	for rows.Next() {
+		var schedule models.ClassSchedule
+		if err := scanClassSchedule(rows, &schedule); err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, schedule)
+	}
+	return schedules, rows.Err()
+}
+
+func scanClassSchedule(row rowScanner, schedule *models.ClassSchedule) error {
+	return row.Scan(&schedule.ID, &schedule.ClassID, &schedule.DayOfWeek, &schedule.PeriodNumber,
+		&schedule.StartTime, &schedule.EndTime, &schedule.Room, &schedule.StartsOn, &schedule.EndsOn,
+		&schedule.CreatedAt, &schedule.UpdatedAt, &schedule.ClassName, &schedule.SubjectName)
+}
@@ -0,0 +1,106 @@
+// Package hijri converts between Gregorian and Hijri dates using the
+// tabular Islamic calendar. Religious holidays in Kuwait follow the
+// sighting of the moon, so a computed date can be a day off the announced
+// one; callers should let the school correct it.
+package hijri
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// MonthNames are the Arabic names of the Hijri months
+var MonthNames = [...]string{
+	"محرم", "صفر", "ربيع الأول", "ربيع الآخر", "جمادى الأولى", "جمادى الآخرة",
+	"رجب", "شعبان", "رمضان", "شوال", "ذو القعدة", "ذو الحجة",
+}
+
+// epoch is the Julian day number of 1 Muharram 1 AH
+const epoch = 1948440
+
+// unixEpochJDN is the Julian day number of 1 January 1970
+const unixEpochJDN = 2440588
+
+// Date is a day in the Hijri calendar
+type Date struct {
+	Year, Month, Day int
+}
+
+// Parse reads a Hijri date written YYYY-MM-DD
+func Parse(value string) (Date, error) {
+	var d Date
+	if _, err := fmt.Sscanf(value, "%d-%d-%d", &d.Year, &d.Month, &d.Day); err != nil {
+		return Date{}, fmt.Errorf("hijri: invalid date %q", value)
+	}
+	if err := d.Validate(); err != nil {
+		return Date{}, err
+	}
+	return d, nil
+}
+
+// Validate reports whether the date exists in the calendar
+func (d Date) Validate() error {
+	if d.Year < 1 || d.Month < 1 || d.Month > 12 || d.Day < 1 || d.Day > MonthLength(d.Year, d.Month) {
+		return fmt.Errorf("hijri: %s is not a valid date", d)
+	}
+	return nil
+}
+
+// MonthLength returns the number of days in a month: 30 in odd months, 29
+// in even ones, and 30 in Dhu al-Hijjah of a leap year
+func MonthLength(year, month int) int {
+	if month%2 == 1 || (month == 12 && IsLeapYear(year)) {
+		return 30
+	}
+	return 29
+}
+
+// IsLeapYear reports whether Dhu al-Hijjah has 30 days in the year, which
+// happens 11 times in each 30-year cycle
+func IsLeapYear(year int) bool {
+	return (14+11*year)%30 < 11
+}
+
+// FromGregorian returns the Hijri date of a day
+func FromGregorian(t time.Time) Date {
+	jdn := julianDay(t)
+	year := int(math.Floor(float64(30*(jdn-epoch)+10646) / 10631))
+	month := int(math.Ceil(float64(jdn-29-Date{year, 1, 1}.julianDay())/29.5)) + 1
+	if month > 12 {
+		month = 12
+	}
+	if month < 1 {
+		month = 1
+	}
+	day := jdn - Date{year, month, 1}.julianDay() + 1
+	return Date{year, month, day}
+}
+
+// Gregorian returns the day as midnight UTC in the Gregorian calendar
+func (d Date) Gregorian() time.Time {
+	return time.Unix(int64(d.julianDay()-unixEpochJDN)*24*60*60, 0).UTC()
+}
+
+// String writes the date as YYYY-MM-DD
+func (d Date) String() string {
+	return fmt.Sprintf("%04d-%02d-%02d", d.Year, d.Month, d.Day)
+}
+
+// Arabic writes the date in words, such as "1 شوال 1447 هـ"
+func (d Date) Arabic() string {
+	if d.Month < 1 || d.Month > 12 {
+		return d.String()
+	}
+	return fmt.Sprintf("%d %s %d هـ", d.Day, MonthNames[d.Month-1], d.Year)
+}
+
+func (d Date) julianDay() int {
+	return d.Day + (59*(d.Month-1)+1)/2 + (d.Year-1)*354 + (3+11*d.Year)/30 + epoch - 1
+}
+
+func julianDay(t time.Time) int {
+	y, m, d := t.Date()
+	days := time.Date(y, m, d, 0, 0, 0, 0, time.UTC).Unix() / (24 * 60 * 60)
+	return int(days) + unixEpochJDN
+}
@@ -0,0 +1,115 @@
+// Package ical writes iCalendar (RFC 5545) feeds that calendar clients
+// such as Google Calendar, Outlook and Apple Calendar can subscribe to.
+package ical
+
+import (
+	"bytes"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// maxLineOctets is the longest a content line may be before it is folded
+const maxLineOctets = 75
+
+// Event is a VEVENT. An all-day event runs from the date of Start to the
+// date of End, both included; other events are written in UTC.
+type Event struct {
+	UID         string
+	Start       time.Time
+	End         time.Time
+	AllDay      bool
+	Summary     string
+	Description string
+	Location    string
+	Categories  []string
+}
+
+// Calendar is a VCALENDAR feed
+type Calendar struct {
+	ProductID string
+	Name      string
+	// Stamp is written as the DTSTAMP of every event
+	Stamp  time.Time
+	Events []Event
+}
+
+// Bytes encodes the calendar
+func (c *Calendar) Bytes() []byte {
+	var buf bytes.Buffer
+	write := func(name, value string) {
+		writeLine(&buf, name+":"+value)
+	}
+
+	write("BEGIN", "VCALENDAR")
+	write("VERSION", "2.0")
+	write("PRODID", c.ProductID)
+	write("CALSCALE", "GREGORIAN")
+	write("METHOD", "PUBLISH")
+	if c.Name != "" {
+		write("X-WR-CALNAME", escapeText(c.Name))
+	}
+	write("REFRESH-INTERVAL;VALUE=DURATION", "PT1H")
+	write("X-PUBLISHED-TTL", "PT1H")
+
+	stamp := formatTime(c.Stamp)
+	for _, event := range c.Events {
+		write("BEGIN", "VEVENT")
+		write("UID", event.UID)
+		write("DTSTAMP", stamp)
+		if event.AllDay {
+			// DTEND of an all-day event is the day after it ends
+			write("DTSTART;VALUE=DATE", event.Start.Format("20060102"))
+			write("DTEND;VALUE=DATE", event.End.AddDate(0, 0, 1).Format("20060102"))
+			write("TRANSP", "TRANSPARENT")
+		} else {
+			write("DTSTART", formatTime(event.Start))
+			write("DTEND", formatTime(event.End))
+		}
+		write("SUMMARY", escapeText(event.Summary))
+		if event.Description != "" {
+			write("DESCRIPTION", escapeText(event.Description))
+		}
+		if event.Location != "" {
+			write("LOCATION", escapeText(event.Location))
+		}
+		if len(event.Categories) > 0 {
+			categories := make([]string, len(event.Categories))
+			for i, category := range event.Categories {
+				categories[i] = escapeText(category)
+			}
+			write("CATEGORIES", strings.Join(categories, ","))
+		}
+		write("END", "VEVENT")
+	}
+	write("END", "VCALENDAR")
+	return buf.Bytes()
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
+
+// escapeText escapes a TEXT value
+func escapeText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", "").Replace(s)
+}
+
+// writeLine writes a content line ending in CRLF, folding it so that no
+// line is longer than 75 octets and no UTF-8 character is split
+func writeLine(buf *bytes.Buffer, line string) {
+	limit := maxLineOctets
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		buf.WriteString(line[:cut])
+		buf.WriteString("\r\n ")
+		line = line[cut:]
+		// The space that starts a continuation line counts towards it
+		limit = maxLineOctets - 1
+	}
+	buf.WriteString(line)
+	buf.WriteString("\r\n")
+}
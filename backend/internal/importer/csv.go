@@ -0,0 +1,175 @@
+package importer
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"moalemplus/internal/models"
+)
+
+// parseCSV reads a spreadsheet export with a header row. Recognised columns:
+// subject_id, curriculum_unit_id, question_text, question_text_arabic,
+// question_type, difficulty_level, points, options (JSON), option_a to
+// option_j, correct_answer, explanation, explanation_arabic, tags (separated
+// by ";" or ",") and is_public.
+func parseCSV(r io.Reader) ([]Record, error) {
+	reader := csv.NewReader(stripBOM(r))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("CSV file is empty")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns["correct_answer"]; !ok {
+		return nil, errors.New("CSV header must include a correct_answer column")
+	}
+
+	var records []Record
+	row := 1
+	for {
+		fields, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		row++
+		if err != nil {
+			records = append(records, Record{Row: row, Err: err})
+			continue
+		}
+		if isBlankRow(fields) {
+			continue
+		}
+
+		get := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(fields) {
+				return strings.TrimSpace(fields[i])
+			}
+			return ""
+		}
+
+		record := Record{Row: row}
+		q := &record.Question
+		q.SubjectID = get("subject_id")
+		q.CurriculumUnitID = get("curriculum_unit_id")
+		q.QuestionText = get("question_text")
+		q.QuestionTextArabic = get("question_text_arabic")
+		q.QuestionType = strings.ToLower(get("question_type"))
+		q.DifficultyLevel = strings.ToLower(get("difficulty_level"))
+		q.CorrectAnswer = get("correct_answer")
+		q.Explanation = get("explanation")
+		q.ExplanationArabic = get("explanation_arabic")
+		q.Tags = splitTags(get("tags"))
+
+		if points := get("points"); points != "" {
+			q.Points, err = strconv.Atoi(points)
+			if err != nil {
+				record.Err = fmt.Errorf("invalid points %q", points)
+			}
+		}
+
+		if public := get("is_public"); public != "" {
+			q.IsPublic = parseBool(public)
+		}
+
+		if options := get("options"); options != "" {
+			if !json.Valid([]byte(options)) {
+				record.Err = errors.New("options column must contain valid JSON")
+			} else {
+				q.Options = json.RawMessage(options)
+			}
+		} else if options := letteredOptions(get); options != nil {
+			q.Options = options
+		}
+
+		if q.QuestionType == "" {
+			q.QuestionType = guessType(q)
+		}
+
+		records = append(records, record)
+	}
+
+	return records, nil
+}
+
+// letteredOptions collects option_a, option_b, ... columns into the
+// multiple choice options object
+func letteredOptions(get func(string) string) json.RawMessage {
+	options := map[string]string{}
+	for i := 0; i < 10; i++ {
+		label := optionLabel(i)
+		if text := get("option_" + strings.ToLower(label)); text != "" {
+			options[label] = text
+		}
+	}
+	if len(options) == 0 {
+		return nil
+	}
+	raw, _ := json.Marshal(options)
+	return raw
+}
+
+// guessType infers the question type for rows that leave it blank
+func guessType(q *models.CreateQuestionRequest) string {
+	answer := strings.ToLower(q.CorrectAnswer)
+	switch {
+	case len(q.Options) > 0:
+		return models.QuestionTypeMultipleChoice
+	case answer == "true" || answer == "false":
+		return models.QuestionTypeTrueFalse
+	}
+	return models.QuestionTypeShortAnswer
+}
+
+func splitTags(s string) []string {
+	var tags []string
+	for _, tag := range strings.FieldsFunc(s, func(r rune) bool { return r == ';' || r == ',' || r == '،' }) {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+func parseBool(s string) bool {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "1", "true", "yes", "y", "نعم":
+		return true
+	}
+	return false
+}
+
+func isBlankRow(fields []string) bool {
+	for _, field := range fields {
+		if strings.TrimSpace(field) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// stripBOM drops the UTF-8 byte order mark that Excel prepends to CSV exports
+func stripBOM(r io.Reader) io.Reader {
+	buf := make([]byte, 3)
+	n, err := io.ReadFull(r, buf)
+	if err != nil {
+		return io.MultiReader(strings.NewReader(string(buf[:n])), r)
+	}
+	if buf[0] == 0xEF && buf[1] == 0xBB && buf[2] == 0xBF {
+		return r
+	}
+	return io.MultiReader(strings.NewReader(string(buf)), r)
+}
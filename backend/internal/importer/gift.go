@@ -0,0 +1,384 @@
+package importer
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"moalemplus/internal/models"
+)
+
+// giftBlankPlaceholder replaces the answer block of fill-in-the-blank
+// questions, which GIFT writes inline in the middle of the sentence
+const giftBlankPlaceholder = "_____"
+
+// giftItem is a single answer inside a GIFT answer block
+type giftItem struct {
+	marker   rune // '=' or '~'
+	text     string
+	feedback string
+	weight   float64
+}
+
+// parseGIFT reads questions written in Moodle's GIFT format. Questions are
+// separated by blank lines, "//" starts a comment line and "$CATEGORY:"
+// lines tag the questions that follow.
+func parseGIFT(r io.Reader) ([]Record, error) {
+	scanner := bufio.NewScanner(stripBOM(r))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var (
+		records  []Record
+		block    []string
+		startRow int
+		category string
+		lineNo   int
+	)
+
+	flush := func() {
+		if len(block) == 0 {
+			return
+		}
+		text := strings.TrimSpace(strings.Join(block, "\n"))
+		block = nil
+		if text == "" {
+			return
+		}
+		if strings.HasPrefix(text, "$CATEGORY:") {
+			category = giftCategoryTag(strings.TrimPrefix(text, "$CATEGORY:"))
+			return
+		}
+		record := Record{Row: startRow}
+		record.Err = parseGIFTQuestion(text, &record.Question)
+		if category != "" {
+			record.Question.Tags = append(record.Question.Tags, category)
+		}
+		records = append(records, record)
+	}
+
+	for scanner.Scan() {
+		lineNo++
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "//") {
+			continue
+		}
+		if trimmed == "" {
+			flush()
+			continue
+		}
+		if len(block) == 0 {
+			startRow = lineNo
+		}
+		block = append(block, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read GIFT file: %w", err)
+	}
+	flush()
+
+	if len(records) == 0 {
+		return nil, errors.New("GIFT file contains no questions")
+	}
+	return records, nil
+}
+
+// parseGIFTQuestion maps one GIFT question onto q
+func parseGIFTQuestion(text string, q *models.CreateQuestionRequest) error {
+	// Optional ::title::
+	if strings.HasPrefix(text, "::") {
+		end := indexUnescaped(text[2:], "::")
+		if end < 0 {
+			return errors.New("unterminated question title")
+		}
+		text = strings.TrimSpace(text[end+4:])
+	}
+
+	open := indexUnescaped(text, "{")
+	if open < 0 {
+		return errors.New("question has no answer block")
+	}
+	closeIdx := indexUnescaped(text[open:], "}")
+	if closeIdx < 0 {
+		return errors.New("unterminated answer block")
+	}
+	closeIdx += open
+
+	before := stripGIFTFormat(strings.TrimSpace(text[:open]))
+	after := strings.TrimSpace(text[closeIdx+1:])
+	answers := strings.TrimSpace(text[open+1 : closeIdx])
+
+	questionText := giftUnescape(before)
+	if after != "" {
+		questionText = strings.TrimSpace(questionText + " " + giftBlankPlaceholder + " " + giftUnescape(after))
+	}
+	setText(q, plainText(questionText))
+
+	upper := strings.ToUpper(strings.SplitN(answers, "#", 2)[0])
+	upper = strings.TrimSpace(upper)
+
+	switch {
+	case answers == "":
+		q.QuestionType = models.QuestionTypeEssay
+		return nil
+
+	case upper == "T" || upper == "TRUE" || upper == "F" || upper == "FALSE":
+		q.QuestionType = models.QuestionTypeTrueFalse
+		q.CorrectAnswer = strconv.FormatBool(strings.HasPrefix(upper, "T"))
+		if parts := splitUnescaped(answers, "#"); len(parts) > 1 {
+			setExplanation(q, giftUnescape(strings.TrimSpace(parts[1])))
+		}
+		return nil
+
+	case strings.HasPrefix(answers, "#"):
+		return parseGIFTNumerical(answers[1:], q)
+	}
+
+	items, err := giftItems(answers)
+	if err != nil {
+		return err
+	}
+
+	if strings.Contains(answers, "->") {
+		return giftMatching(items, q)
+	}
+
+	hasWrong := false
+	for _, item := range items {
+		if item.marker == '~' {
+			hasWrong = true
+		}
+	}
+
+	if hasWrong {
+		return giftMultipleChoice(items, q)
+	}
+
+	// Only correct answers: short answer, or fill in the blank when the
+	// answer block sits inside the sentence
+	var accepted []string
+	for _, item := range items {
+		accepted = append(accepted, item.text)
+		setExplanation(q, item.feedback)
+	}
+	q.CorrectAnswer = strings.Join(accepted, "|")
+	if after != "" {
+		q.QuestionType = models.QuestionTypeFillBlank
+	} else {
+		q.QuestionType = models.QuestionTypeShortAnswer
+	}
+	return nil
+}
+
+func giftMultipleChoice(items []giftItem, q *models.CreateQuestionRequest) error {
+	options := map[string]string{}
+	for i, item := range items {
+		label := optionLabel(i)
+		options[label] = item.text
+		if item.marker == '=' || item.weight >= 100 {
+			if q.CorrectAnswer != "" {
+				return errors.New("multiple choice question has more than one correct answer")
+			}
+			q.CorrectAnswer = label
+			setExplanation(q, item.feedback)
+		}
+	}
+	if q.CorrectAnswer == "" {
+		return errors.New("multiple choice question has no correct answer")
+	}
+	q.QuestionType = models.QuestionTypeMultipleChoice
+	q.Options, _ = json.Marshal(options)
+	return nil
+}
+
+func giftMatching(items []giftItem, q *models.CreateQuestionRequest) error {
+	var left, right []string
+	pairs := map[string]string{}
+	for _, item := range items {
+		parts := strings.SplitN(item.text, "->", 2)
+		if len(parts) != 2 {
+			return errors.New("matching item is missing \"->\"")
+		}
+		l, r := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+		if l != "" {
+			left = append(left, l)
+			pairs[l] = r
+		}
+		right = append(right, r)
+	}
+	options, _ := json.Marshal(map[string][]string{"left": left, "right": right})
+	answer, _ := json.Marshal(pairs)
+	q.QuestionType = models.QuestionTypeMatching
+	q.Options = options
+	q.CorrectAnswer = string(answer)
+	return nil
+}
+
+// parseGIFTNumerical maps {#value:tolerance} and {#min..max} answers to a
+// fill-in-the-blank question with a "value:tolerance" correct answer
+func parseGIFTNumerical(answers string, q *models.CreateQuestionRequest) error {
+	answers = strings.TrimSpace(answers)
+	if strings.HasPrefix(answers, "=") {
+		items, err := giftItems(answers)
+		if err != nil {
+			return err
+		}
+		answers = ""
+		for _, item := range items {
+			if item.marker == '=' && (item.weight == 0 || item.weight >= 100) {
+				answers = item.text
+				setExplanation(q, item.feedback)
+				break
+			}
+		}
+	} else if parts := splitUnescaped(answers, "#"); len(parts) > 1 {
+		answers = strings.TrimSpace(parts[0])
+		setExplanation(q, giftUnescape(strings.TrimSpace(parts[1])))
+	}
+
+	if lo, hi, ok := strings.Cut(answers, ".."); ok {
+		min, err1 := strconv.ParseFloat(strings.TrimSpace(lo), 64)
+		max, err2 := strconv.ParseFloat(strings.TrimSpace(hi), 64)
+		if err1 != nil || err2 != nil || max < min {
+			return fmt.Errorf("invalid numerical range %q", answers)
+		}
+		answers = fmt.Sprintf("%s:%s", formatNumber((min+max)/2), formatNumber((max-min)/2))
+	}
+
+	value, _, _ := strings.Cut(answers, ":")
+	if _, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err != nil {
+		return fmt.Errorf("invalid numerical answer %q", answers)
+	}
+
+	q.QuestionType = models.QuestionTypeFillBlank
+	q.CorrectAnswer = strings.ReplaceAll(answers, " ", "")
+	return nil
+}
+
+// giftItems splits an answer block into its "=" and "~" items
+func giftItems(answers string) ([]giftItem, error) {
+	var items []giftItem
+	var current *giftItem
+	var buf strings.Builder
+
+	finish := func() {
+		if current == nil {
+			return
+		}
+		raw := strings.TrimSpace(buf.String())
+		buf.Reset()
+		if strings.HasPrefix(raw, "%") {
+			if end := strings.Index(raw[1:], "%"); end >= 0 {
+				current.weight, _ = strconv.ParseFloat(raw[1:end+1], 64)
+				raw = strings.TrimSpace(raw[end+2:])
+			}
+		}
+		parts := splitUnescaped(raw, "#")
+		current.text = giftUnescape(strings.TrimSpace(parts[0]))
+		if len(parts) > 1 {
+			current.feedback = giftUnescape(strings.TrimSpace(strings.Join(parts[1:], "#")))
+		}
+		items = append(items, *current)
+		current = nil
+	}
+
+	escaped := false
+	for _, r := range answers {
+		if escaped {
+			buf.WriteRune('\\')
+			buf.WriteRune(r)
+			escaped = false
+			continue
+		}
+		switch r {
+		case '\\':
+			escaped = true
+		case '=', '~':
+			// "->" in matching questions never starts a new item, and "="
+			// only does so at the start of an answer
+			finish()
+			current = &giftItem{marker: r}
+		default:
+			if current == nil {
+				if r == ' ' || r == '\n' || r == '\t' || r == '\r' {
+					continue
+				}
+				return nil, errors.New("answer block must start with \"=\" or \"~\"")
+			}
+			buf.WriteRune(r)
+		}
+	}
+	finish()
+
+	if len(items) == 0 {
+		return nil, errors.New("answer block has no answers")
+	}
+	return items, nil
+}
+
+// indexUnescaped finds sep in s, skipping backslash-escaped characters
+func indexUnescaped(s, sep string) int {
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' {
+			i++
+			continue
+		}
+		if strings.HasPrefix(s[i:], sep) {
+			return i
+		}
+	}
+	return -1
+}
+
+// splitUnescaped splits s around unescaped occurrences of sep
+func splitUnescaped(s, sep string) []string {
+	var parts []string
+	for {
+		i := indexUnescaped(s, sep)
+		if i < 0 {
+			return append(parts, s)
+		}
+		parts = append(parts, s[:i])
+		s = s[i+len(sep):]
+	}
+}
+
+var giftUnescaper = strings.NewReplacer(
+	`\~`, "~", `\=`, "=", `\#`, "#", `\{`, "{", `\}`, "}", `\:`, ":", `\n`, "\n", `\\`, `\`,
+)
+
+func giftUnescape(s string) string {
+	return giftUnescaper.Replace(s)
+}
+
+// stripGIFTFormat removes a leading [html], [moodle], [plain] or [markdown]
+// format marker
+func stripGIFTFormat(s string) string {
+	if strings.HasPrefix(s, "[") {
+		if end := strings.Index(s, "]"); end > 0 {
+			switch strings.ToLower(s[1:end]) {
+			case "html", "moodle", "plain", "markdown":
+				return strings.TrimSpace(s[end+1:])
+			}
+		}
+	}
+	return s
+}
+
+// giftCategoryTag turns "$course$/Unit 1/Fractions" into "fractions"
+func giftCategoryTag(path string) string {
+	path = strings.TrimSpace(path)
+	if i := strings.LastIndex(path, "/"); i >= 0 {
+		path = path[i+1:]
+	}
+	path = strings.Trim(path, "$")
+	return strings.ToLower(strings.TrimSpace(path))
+}
+
+func formatNumber(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
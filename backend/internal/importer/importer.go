@@ -0,0 +1,148 @@
+// Package importer parses question bank exports into question requests.
+//
+// Parsers only map foreign formats onto models.CreateQuestionRequest; the
+// caller is responsible for validating the mapped requests against the
+// questions table rules before anything is written.
+package importer
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"regexp"
+	"strings"
+
+	"moalemplus/internal/arabic"
+	"moalemplus/internal/models"
+)
+
+// Supported import formats
+const (
+	FormatCSV       = "csv"
+	FormatJSON      = "json"
+	FormatGIFT      = "gift"
+	FormatMoodleXML = "moodle_xml"
+)
+
+// Defaults are applied to every record that does not set the field itself
+type Defaults struct {
+	SubjectID        string
+	CurriculumUnitID string
+	DifficultyLevel  string
+	IsPublic         bool
+}
+
+// Record is a single parsed question together with its position in the
+// source file. Err is set when the source row could not be mapped.
+type Record struct {
+	Row      int
+	Question models.CreateQuestionRequest
+	Err      error
+}
+
+// Parse reads all records of the given format from r
+func Parse(format string, r io.Reader, defaults Defaults) ([]Record, error) {
+	var (
+		records []Record
+		err     error
+	)
+
+	switch format {
+	case FormatCSV:
+		records, err = parseCSV(r)
+	case FormatJSON:
+		records, err = parseJSON(r)
+	case FormatGIFT:
+		records, err = parseGIFT(r)
+	case FormatMoodleXML:
+		records, err = parseMoodleXML(r)
+	default:
+		return nil, fmt.Errorf("unsupported import format %q", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range records {
+		applyDefaults(&records[i].Question, defaults)
+	}
+	return records, nil
+}
+
+// DetectFormat guesses the import format from a file name
+func DetectFormat(filename string) string {
+	name := strings.ToLower(filename)
+	switch {
+	case strings.HasSuffix(name, ".csv"):
+		return FormatCSV
+	case strings.HasSuffix(name, ".json"):
+		return FormatJSON
+	case strings.HasSuffix(name, ".gift"), strings.HasSuffix(name, ".txt"):
+		return FormatGIFT
+	case strings.HasSuffix(name, ".xml"):
+		return FormatMoodleXML
+	}
+	return ""
+}
+
+func applyDefaults(q *models.CreateQuestionRequest, defaults Defaults) {
+	if q.SubjectID == "" {
+		q.SubjectID = defaults.SubjectID
+	}
+	if q.CurriculumUnitID == "" {
+		q.CurriculumUnitID = defaults.CurriculumUnitID
+	}
+	if q.DifficultyLevel == "" {
+		q.DifficultyLevel = defaults.DifficultyLevel
+	}
+	if defaults.IsPublic {
+		q.IsPublic = true
+	}
+
+	// Formats with a single text field fill both language columns
+	if q.QuestionTextArabic == "" {
+		q.QuestionTextArabic = q.QuestionText
+	}
+	if q.QuestionText == "" {
+		q.QuestionText = q.QuestionTextArabic
+	}
+}
+
+// setText stores text in the Arabic or the default text field depending on
+// its script
+func setText(q *models.CreateQuestionRequest, text string) {
+	if arabic.ContainsArabic(text) {
+		q.QuestionTextArabic = text
+	} else {
+		q.QuestionText = text
+	}
+}
+
+// setExplanation stores feedback in the Arabic or default explanation field
+func setExplanation(q *models.CreateQuestionRequest, text string) {
+	if text == "" {
+		return
+	}
+	if arabic.ContainsArabic(text) {
+		q.ExplanationArabic = text
+	} else {
+		q.Explanation = text
+	}
+}
+
+// optionLabel returns the multiple choice label for the i-th option
+func optionLabel(i int) string {
+	return string(rune('A' + i))
+}
+
+var (
+	htmlTagPattern    = regexp.MustCompile(`(?s)<[^>]*>`)
+	whitespacePattern = regexp.MustCompile(`\s+`)
+)
+
+// plainText strips HTML markup and collapses whitespace
+func plainText(s string) string {
+	s = htmlTagPattern.ReplaceAllString(s, " ")
+	s = html.UnescapeString(s)
+	return strings.TrimSpace(whitespacePattern.ReplaceAllString(s, " "))
+}
@@ -0,0 +1,48 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// parseJSON reads either an array of question objects using the API field
+// names or an object with a "questions" array
+func parseJSON(r io.Reader) ([]Record, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JSON: %w", err)
+	}
+	data = bytes.TrimSpace(data)
+
+	var items []json.RawMessage
+	if len(data) > 0 && data[0] == '{' {
+		var wrapper struct {
+			Questions []json.RawMessage `json:"questions"`
+		}
+		if err := json.Unmarshal(data, &wrapper); err != nil {
+			return nil, fmt.Errorf("invalid JSON: %w", err)
+		}
+		items = wrapper.Questions
+	} else if err := json.Unmarshal(data, &items); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	if len(items) == 0 {
+		return nil, errors.New("JSON file contains no questions")
+	}
+
+	records := make([]Record, 0, len(items))
+	for i, item := range items {
+		record := Record{Row: i + 1}
+		if err := json.Unmarshal(item, &record.Question); err != nil {
+			record.Err = fmt.Errorf("invalid question object: %w", err)
+		} else if record.Question.QuestionType == "" {
+			record.Question.QuestionType = guessType(&record.Question)
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
@@ -0,0 +1,178 @@
+package importer
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"moalemplus/internal/models"
+)
+
+type moodleText struct {
+	Text string `xml:"text"`
+}
+
+type moodleAnswer struct {
+	Fraction  string     `xml:"fraction,attr"`
+	Text      string     `xml:"text"`
+	Feedback  moodleText `xml:"feedback"`
+	Tolerance string     `xml:"tolerance"`
+}
+
+type moodleSubquestion struct {
+	Text   string     `xml:"text"`
+	Answer moodleText `xml:"answer"`
+}
+
+type moodleQuestion struct {
+	Type            string              `xml:"type,attr"`
+	Category        moodleText          `xml:"category"`
+	QuestionText    moodleText          `xml:"questiontext"`
+	GeneralFeedback moodleText          `xml:"generalfeedback"`
+	DefaultGrade    string              `xml:"defaultgrade"`
+	Answers         []moodleAnswer      `xml:"answer"`
+	Subquestions    []moodleSubquestion `xml:"subquestion"`
+	Tags            []moodleText        `xml:"tags>tag"`
+}
+
+// parseMoodleXML reads a Moodle XML question export. Category entries tag
+// the questions that follow them; description questions are skipped.
+func parseMoodleXML(r io.Reader) ([]Record, error) {
+	var quiz struct {
+		Questions []moodleQuestion `xml:"question"`
+	}
+	if err := xml.NewDecoder(r).Decode(&quiz); err != nil {
+		return nil, fmt.Errorf("invalid Moodle XML: %w", err)
+	}
+
+	var records []Record
+	category := ""
+	for i, mq := range quiz.Questions {
+		if mq.Type == "category" {
+			category = giftCategoryTag(mq.Category.Text)
+			continue
+		}
+		if mq.Type == "description" {
+			continue
+		}
+
+		record := Record{Row: i + 1}
+		record.Err = mapMoodleQuestion(&mq, &record.Question)
+		for _, tag := range mq.Tags {
+			if tag.Text != "" {
+				record.Question.Tags = append(record.Question.Tags, tag.Text)
+			}
+		}
+		if category != "" {
+			record.Question.Tags = append(record.Question.Tags, category)
+		}
+		records = append(records, record)
+	}
+
+	if len(records) == 0 {
+		return nil, errors.New("Moodle XML file contains no questions")
+	}
+	return records, nil
+}
+
+func mapMoodleQuestion(mq *moodleQuestion, q *models.CreateQuestionRequest) error {
+	setText(q, plainText(mq.QuestionText.Text))
+	setExplanation(q, plainText(mq.GeneralFeedback.Text))
+
+	if grade, err := strconv.ParseFloat(strings.TrimSpace(mq.DefaultGrade), 64); err == nil && grade >= 1 {
+		q.Points = int(math.Round(grade))
+	}
+
+	switch mq.Type {
+	case "multichoice":
+		options := map[string]string{}
+		best := 0.0
+		for i, answer := range mq.Answers {
+			label := optionLabel(i)
+			options[label] = plainText(answer.Text)
+			if fraction := moodleFraction(answer.Fraction); fraction > best {
+				best = fraction
+				q.CorrectAnswer = label
+			}
+		}
+		if q.CorrectAnswer == "" {
+			return errors.New("multiple choice question has no correct answer")
+		}
+		q.QuestionType = models.QuestionTypeMultipleChoice
+		q.Options, _ = json.Marshal(options)
+
+	case "truefalse":
+		for _, answer := range mq.Answers {
+			if moodleFraction(answer.Fraction) >= 100 {
+				q.CorrectAnswer = strconv.FormatBool(strings.EqualFold(plainText(answer.Text), "true"))
+			}
+		}
+		if q.CorrectAnswer == "" {
+			return errors.New("true/false question has no correct answer")
+		}
+		q.QuestionType = models.QuestionTypeTrueFalse
+
+	case "shortanswer":
+		var accepted []string
+		for _, answer := range mq.Answers {
+			if moodleFraction(answer.Fraction) >= 100 {
+				accepted = append(accepted, plainText(answer.Text))
+			}
+		}
+		if len(accepted) == 0 {
+			return errors.New("short answer question has no fully correct answer")
+		}
+		q.QuestionType = models.QuestionTypeShortAnswer
+		q.CorrectAnswer = strings.Join(accepted, "|")
+
+	case "numerical":
+		for _, answer := range mq.Answers {
+			if moodleFraction(answer.Fraction) >= 100 {
+				q.CorrectAnswer = strings.TrimSpace(answer.Text)
+				if tolerance := strings.TrimSpace(answer.Tolerance); tolerance != "" && tolerance != "0" {
+					q.CorrectAnswer += ":" + tolerance
+				}
+				break
+			}
+		}
+		if q.CorrectAnswer == "" {
+			return errors.New("numerical question has no fully correct answer")
+		}
+		q.QuestionType = models.QuestionTypeFillBlank
+
+	case "essay":
+		q.QuestionType = models.QuestionTypeEssay
+
+	case "matching":
+		var left, right []string
+		pairs := map[string]string{}
+		for _, sub := range mq.Subquestions {
+			l, r := plainText(sub.Text), plainText(sub.Answer.Text)
+			if l != "" {
+				left = append(left, l)
+				pairs[l] = r
+			}
+			right = append(right, r)
+		}
+		options, _ := json.Marshal(map[string][]string{"left": left, "right": right})
+		answer, _ := json.Marshal(pairs)
+		q.QuestionType = models.QuestionTypeMatching
+		q.Options = options
+		q.CorrectAnswer = string(answer)
+
+	default:
+		return fmt.Errorf("unsupported Moodle question type %q", mq.Type)
+	}
+
+	return nil
+}
+
+func moodleFraction(s string) float64 {
+	f, _ := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	return f
+}
@@ -0,0 +1,218 @@
+// Package itemanalysis computes classical test statistics for the
+// questions of a test from its graded attempts: difficulty (p-value),
+// discrimination between strong and weak students, how often each multiple
+// choice option was picked, and the KR-20 reliability of the whole test.
+package itemanalysis
+
+import (
+	"math"
+	"sort"
+
+	"github.com/google/uuid"
+
+	"moalemplus/internal/models"
+)
+
+// GroupFraction is the share of students in each of the upper and lower
+// groups used for discrimination
+const GroupFraction = 0.27
+
+// Item is a question of the analysed test
+type Item struct {
+	QuestionID uuid.UUID
+	Points     int
+	// Options and CorrectOption are the bank's option letters of a multiple
+	// choice question; both are empty for other types
+	Options       []string
+	CorrectOption string
+}
+
+// Answer is one student's graded answer to an item
+type Answer struct {
+	Answered bool
+	Correct  bool
+	Points   int
+	Option   string // bank option letter picked on a multiple choice question
+}
+
+// Response holds one student's answers by question
+type Response map[uuid.UUID]Answer
+
+// Result is the analysis of a set of responses. Items are in the order they
+// were given.
+type Result struct {
+	Students  int
+	MeanScore float64
+	StdDev    float64
+	KR20      *float64
+	GroupSize int
+	Items     []ItemResult
+}
+
+// ItemResult holds the statistics of one item
+type ItemResult struct {
+	Responses      int
+	Omitted        int
+	PValue         float64
+	Discrimination *float64
+	Distractors    []models.DistractorFrequency
+}
+
+// Analyze computes the statistics of items over responses
+func Analyze(items []Item, responses []Response) Result {
+	n := len(responses)
+	result := Result{Students: n, Items: make([]ItemResult, len(items))}
+	if n == 0 {
+		return result
+	}
+
+	totals := make([]float64, n)
+	for i, response := range responses {
+		for _, item := range items {
+			totals[i] += float64(response[item.QuestionID].Points)
+		}
+	}
+	result.MeanScore, result.StdDev = meanStdDev(totals)
+	result.MeanScore, result.StdDev = round(result.MeanScore), round(result.StdDev)
+
+	// Rank students by score to form the upper and lower groups
+	ranked := make([]int, n)
+	for i := range ranked {
+		ranked[i] = i
+	}
+	sort.SliceStable(ranked, func(a, b int) bool { return totals[ranked[a]] > totals[ranked[b]] })
+
+	groupSize := int(math.Round(GroupFraction * float64(n)))
+	if groupSize < 1 && n >= 2 {
+		groupSize = 1
+	}
+	if 2*groupSize > n {
+		groupSize = n / 2
+	}
+	result.GroupSize = groupSize
+	upper, lower := ranked[:groupSize], ranked[n-groupSize:]
+
+	for j, item := range items {
+		result.Items[j] = analyzeItem(item, responses, upper, lower)
+	}
+	result.KR20 = kr20(items, responses)
+	return result
+}
+
+func analyzeItem(item Item, responses []Response, upper, lower []int) ItemResult {
+	n := len(responses)
+	var r ItemResult
+
+	earned := 0
+	chosen := map[string]int{}
+	for _, response := range responses {
+		answer := response[item.QuestionID]
+		if !answer.Answered {
+			r.Omitted++
+			continue
+		}
+		r.Responses++
+		earned += answer.Points
+		if answer.Option != "" {
+			chosen[answer.Option]++
+		}
+	}
+	if item.Points > 0 {
+		r.PValue = round(float64(earned) / float64(item.Points*n))
+	}
+
+	if len(upper) > 0 && item.Points > 0 {
+		d := groupPValue(item, responses, upper) - groupPValue(item, responses, lower)
+		d = round(d)
+		r.Discrimination = &d
+	}
+
+	if len(item.Options) > 0 {
+		r.Distractors = make([]models.DistractorFrequency, 0, len(item.Options))
+		for _, option := range item.Options {
+			r.Distractors = append(r.Distractors, models.DistractorFrequency{
+				Option:     option,
+				IsCorrect:  option == item.CorrectOption,
+				Count:      chosen[option],
+				Proportion: round(float64(chosen[option]) / float64(n)),
+				UpperCount: countOption(item, responses, upper, option),
+				LowerCount: countOption(item, responses, lower, option),
+			})
+		}
+	}
+	return r
+}
+
+// groupPValue is the share of the item's points earned by a group
+func groupPValue(item Item, responses []Response, group []int) float64 {
+	earned := 0
+	for _, i := range group {
+		earned += responses[i][item.QuestionID].Points
+	}
+	return float64(earned) / float64(item.Points*len(group))
+}
+
+func countOption(item Item, responses []Response, group []int, option string) int {
+	count := 0
+	for _, i := range group {
+		if responses[i][item.QuestionID].Option == option {
+			count++
+		}
+	}
+	return count
+}
+
+// kr20 is the Kuder-Richardson 20 reliability, treating every item as
+// right or wrong. It is nil when there are too few items or students, or
+// when every student got the same number of items right.
+func kr20(items []Item, responses []Response) *float64 {
+	k, n := len(items), len(responses)
+	if k < 2 || n < 2 {
+		return nil
+	}
+
+	correctCounts := make([]float64, n)
+	sumPQ := 0.0
+	for _, item := range items {
+		correct := 0
+		for i, response := range responses {
+			if response[item.QuestionID].Correct {
+				correct++
+				correctCounts[i]++
+			}
+		}
+		p := float64(correct) / float64(n)
+		sumPQ += p * (1 - p)
+	}
+
+	_, stdDev := meanStdDev(correctCounts)
+	variance := stdDev * stdDev
+	if variance == 0 {
+		return nil
+	}
+	reliability := round(float64(k) / float64(k-1) * (1 - sumPQ/variance))
+	return &reliability
+}
+
+// meanStdDev returns the mean and population standard deviation of values
+func meanStdDev(values []float64) (float64, float64) {
+	if len(values) == 0 {
+		return 0, 0
+	}
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+
+	squares := 0.0
+	for _, v := range values {
+		squares += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(squares / float64(len(values)))
+}
+
+// round keeps four decimal places
+func round(x float64) float64 {
+	return math.Round(x*10000) / 10000
+}
@@ -54,6 +54,83 @@ func AuthMiddleware(authService *auth.Service) fiber.Handler {
 	}
 }
 
+// StudentAuthMiddleware validates student JWT tokens and sets the student
+// context. Teacher tokens are rejected.
+func StudentAuthMiddleware(authService *auth.Service) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		tokenString, message := bearerToken(c)
+		if message != "" {
+			return c.Status(fiber.StatusUnauthorized).JSON(models.ErrorResponse{
+				Error:   true,
+				Message: message,
+			})
+		}
+
+		// Verify token
+		studentID, err := authService.VerifyStudentToken(tokenString)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(models.ErrorResponse{
+				Error:   true,
+				Message: "Invalid or expired token",
+			})
+		}
+
+		// Set student ID in context
+		c.Locals("student_id", studentID)
+
+		return c.Next()
+	}
+}
+
+// ParentAuthMiddleware validates parent JWT tokens and sets the parent
+// context. Teacher and student tokens are rejected; handlers check that the
+// parent is linked to the student they ask about.
+func ParentAuthMiddleware(authService *auth.Service) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		tokenString, message := bearerToken(c)
+		if message != "" {
+			return c.Status(fiber.StatusUnauthorized).JSON(models.ErrorResponse{
+				Error:   true,
+				Message: message,
+			})
+		}
+
+		// Verify token
+		parentID, err := authService.VerifyParentToken(tokenString)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(models.ErrorResponse{
+				Error:   true,
+				Message: "Invalid or expired token",
+			})
+		}
+
+		// Set parent ID in context
+		c.Locals("parent_id", parentID)
+
+		return c.Next()
+	}
+}
+
+// bearerToken extracts the token from the Authorization header. A non-empty
+// message explains why the header was rejected.
+func bearerToken(c *fiber.Ctx) (string, string) {
+	authHeader := c.Get("Authorization")
+	if authHeader == "" {
+		return "", "Authorization header is required"
+	}
+
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		return "", "Invalid authorization header format"
+	}
+
+	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
+	if tokenString == "" {
+		return "", "Token is required"
+	}
+
+	return tokenString, ""
+}
+
 // OptionalAuthMiddleware validates JWT tokens but doesn't require them
 func OptionalAuthMiddleware(authService *auth.Service) fiber.Handler {
 	return func(c *fiber.Ctx) error {
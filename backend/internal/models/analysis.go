@@ -0,0 +1,50 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TestAnalysis is the item analysis of a test's finished attempts. Each
+// student's first finished attempt counts; attempts with answers still
+// waiting for manual grading are left out.
+type TestAnalysis struct {
+	TestID      uuid.UUID      `json:"test_id"`
+	Students    int            `json:"students"`
+	TotalPoints int            `json:"total_points"`
+	MeanScore   float64        `json:"mean_score"`
+	StdDev      float64        `json:"std_dev"`
+	KR20        *float64       `json:"kr20,omitempty"`
+	GroupSize   int            `json:"group_size"`
+	Items       []ItemAnalysis `json:"items"`
+	GeneratedAt time.Time      `json:"generated_at"`
+}
+
+// ItemAnalysis describes how one question performed. PValue is the share
+// of available points earned (the proportion correct for right/wrong
+// items). Discrimination is the difference in PValue between the top and
+// bottom 27% of students.
+type ItemAnalysis struct {
+	QuestionID     uuid.UUID             `json:"question_id"`
+	QuestionOrder  int                   `json:"question_order"`
+	QuestionType   string                `json:"question_type"`
+	QuestionText   string                `json:"question_text"`
+	Points         int                   `json:"points"`
+	Responses      int                   `json:"responses"`
+	Omitted        int                   `json:"omitted"`
+	PValue         float64               `json:"p_value"`
+	Discrimination *float64              `json:"discrimination,omitempty"`
+	Distractors    []DistractorFrequency `json:"distractors,omitempty"`
+}
+
+// DistractorFrequency counts how often a multiple choice option was chosen,
+// using the bank's option letters whatever variant the student answered
+type DistractorFrequency struct {
+	Option     string  `json:"option"`
+	IsCorrect  bool    `json:"is_correct"`
+	Count      int     `json:"count"`
+	Proportion float64 `json:"proportion"`
+	UpperCount int     `json:"upper_count"`
+	LowerCount int     `json:"lower_count"`
+}
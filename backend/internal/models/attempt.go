@@ -0,0 +1,96 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Statuses of a test submission
+const (
+	SubmissionInProgress = "in_progress"
+	SubmissionSubmitted  = "submitted"
+	SubmissionGraded     = "graded"
+	SubmissionExpired    = "expired"
+)
+
+// StudentAccessCode is the code a student signs in with, shown to the
+// teacher only when it is issued
+type StudentAccessCode struct {
+	StudentNumber string    `json:"student_number"`
+	Code          string    `json:"code"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// StudentTest is a published test as listed for a student
+type StudentTest struct {
+	ID              uuid.UUID  `json:"id"`
+	Title           string     `json:"title"`
+	TitleArabic     string     `json:"title_arabic"`
+	TestType        string     `json:"test_type"`
+	DurationMinutes int        `json:"duration_minutes"`
+	TotalPoints     int        `json:"total_points"`
+	QuestionCount   int        `json:"question_count"`
+	ScheduledStart  *time.Time `json:"scheduled_start,omitempty"`
+	ScheduledEnd    *time.Time `json:"scheduled_end,omitempty"`
+	AttemptsUsed    int        `json:"attempts_used"`
+	AttemptsAllowed int        `json:"attempts_allowed"`
+	ActiveAttemptID *uuid.UUID `json:"active_attempt_id,omitempty"`
+	CanStart        bool       `json:"can_start"`
+}
+
+// TestAttempt is a student's attempt at a test, backed by test_submissions
+type TestAttempt struct {
+	ID               uuid.UUID  `json:"id" db:"id"`
+	TestID           uuid.UUID  `json:"test_id" db:"test_id"`
+	StudentID        uuid.UUID  `json:"student_id" db:"student_id"`
+	AttemptNumber    int        `json:"attempt_number" db:"attempt_number"`
+	VariantID        *uuid.UUID `json:"variant_id,omitempty" db:"variant_id"`
+	Status           string     `json:"status" db:"status"`
+	StartedAt        time.Time  `json:"started_at" db:"started_at"`
+	ExpiresAt        *time.Time `json:"expires_at,omitempty" db:"expires_at"`
+	SubmittedAt      *time.Time `json:"submitted_at,omitempty" db:"submitted_at"`
+	GradedAt         *time.Time `json:"graded_at,omitempty" db:"graded_at"`
+	DurationSeconds  *int       `json:"duration_seconds,omitempty" db:"duration_seconds"`
+	RemainingSeconds int        `json:"remaining_seconds"`
+	TotalScore       *int       `json:"total_score,omitempty" db:"total_score"`
+	PercentageScore  *float64   `json:"percentage_score,omitempty" db:"percentage_score"`
+	IsPassed         *bool      `json:"is_passed,omitempty" db:"is_passed"`
+
+	// Joined fields
+	Title              string            `json:"title" db:"title"`
+	TitleArabic        string            `json:"title_arabic" db:"title_arabic"`
+	Instructions       *string           `json:"instructions,omitempty" db:"instructions"`
+	InstructionsArabic *string           `json:"instructions_arabic,omitempty" db:"instructions_arabic"`
+	TotalPoints        int               `json:"total_points" db:"total_points"`
+	Questions          []AttemptQuestion `json:"questions,omitempty"`
+	Answers            map[string]string `json:"answers"`
+}
+
+// AttemptQuestion is a question as shown to a student during an attempt.
+// It never carries the correct answer. Options use the letters of the
+// student's variant.
+type AttemptQuestion struct {
+	Number             int             `json:"number"`
+	QuestionID         uuid.UUID       `json:"question_id"`
+	QuestionType       string          `json:"question_type"`
+	QuestionText       string          `json:"question_text"`
+	QuestionTextArabic string          `json:"question_text_arabic"`
+	Points             int             `json:"points"`
+	Options            json.RawMessage `json:"options,omitempty"`
+}
+
+// AttemptAnswer is a student's answer to one question. Answer is a JSON
+// string for most question types, or an object mapping left items to right
+// items for matching questions. A null or empty answer clears it.
+type AttemptAnswer struct {
+	QuestionID       uuid.UUID       `json:"question_id" validate:"required"`
+	Answer           json.RawMessage `json:"answer"`
+	TimeSpentSeconds *int            `json:"time_spent_seconds,omitempty"`
+}
+
+// SaveAnswersRequest represents the request to save answers during an attempt
+type SaveAnswersRequest struct {
+	Answers []AttemptAnswer `json:"answers"`
+}
@@ -0,0 +1,68 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Attendance alert statuses
+const (
+	AlertOpen         = "open"
+	AlertAcknowledged = "acknowledged"
+	AlertResolved     = "resolved"
+)
+
+// AttendanceAlert is an early warning that a student's attendance in a
+// class crossed one of the school's thresholds
+type AttendanceAlert struct {
+	ID               uuid.UUID  `json:"id" db:"id"`
+	ClassID          uuid.UUID  `json:"class_id" db:"class_id"`
+	StudentID        uuid.UUID  `json:"student_id" db:"student_id"`
+	RuleType         string     `json:"rule_type" db:"rule_type"`
+	Since            time.Time  `json:"since" db:"since"`
+	Value            float64    `json:"value" db:"value"`
+	Threshold        float64    `json:"threshold" db:"threshold"`
+	Message          string     `json:"message" db:"message"`
+	Status           string     `json:"status" db:"status"`
+	Note             *string    `json:"note,omitempty" db:"note"`
+	AcknowledgedAt   *time.Time `json:"acknowledged_at,omitempty" db:"acknowledged_at"`
+	ResolvedAt       *time.Time `json:"resolved_at,omitempty" db:"resolved_at"`
+	ParentNotifiedAt *time.Time `json:"parent_notified_at,omitempty" db:"parent_notified_at"`
+	ReferredAt       *time.Time `json:"referred_at,omitempty" db:"referred_at"`
+	CreatedAt        time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at" db:"updated_at"`
+
+	// Joined fields
+	ClassName     string `json:"class_name,omitempty" db:"class_name"`
+	StudentName   string `json:"student_name,omitempty" db:"student_name"`
+	StudentNumber string `json:"student_number,omitempty" db:"student_number"`
+}
+
+// AttendanceAlertRules are a school's early-warning thresholds, where zero
+// turns a rule off, and what happens when an alert is raised
+type AttendanceAlertRules struct {
+	ConsecutiveAbsences int     `json:"consecutive_absences"`
+	AbsenceRate         float64 `json:"absence_rate"`
+	MinRecordedDays     int     `json:"min_recorded_days"`
+	LateCount           int     `json:"late_count"`
+	NotifyParent        bool    `json:"notify_parent"`
+	ReferSocialWorker   bool    `json:"refer_social_worker"`
+	SocialWorkerName    string  `json:"social_worker_name"`
+	SocialWorkerPhone   string  `json:"social_worker_phone"`
+	SocialWorkerEmail   string  `json:"social_worker_email"`
+	IsCustom            bool    `json:"is_custom"`
+}
+
+// UpdateAttendanceAlertRequest represents the request to acknowledge or
+// resolve an alert
+type UpdateAttendanceAlertRequest struct {
+	Status string `json:"status" validate:"required,oneof=acknowledged resolved"`
+	Note   string `json:"note,omitempty"`
+}
+
+// ReferAttendanceAlertRequest represents the request to refer a student to
+// the school's social worker
+type ReferAttendanceAlertRequest struct {
+	Note string `json:"note,omitempty"`
+}
@@ -0,0 +1,84 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Calendar event types
+const (
+	CalendarHoliday = "holiday"
+	CalendarExam    = "exam"
+)
+
+// SchoolTerm is a semester of a school year. IsCustom is set when the
+// school changed the ministry dates.
+type SchoolTerm struct {
+	SchoolYear        string    `json:"school_year"`
+	Semester          string    `json:"semester"`
+	StartsOn          time.Time `json:"starts_on"`
+	EndsOn            time.Time `json:"ends_on"`
+	IsCustom          bool      `json:"is_custom"`
+	InstructionalDays int       `json:"instructional_days"`
+}
+
+// SaveSchoolTermRequest represents the request to set a semester's dates
+type SaveSchoolTermRequest struct {
+	StartsOn string `json:"starts_on" validate:"required"`
+	EndsOn   string `json:"ends_on" validate:"required"`
+}
+
+// CalendarEvent is a holiday or exam period. An event that recurs every
+// year is listed once per occurrence, with the dates of that occurrence.
+type CalendarEvent struct {
+	ID              uuid.UUID  `json:"id"`
+	SchoolYear      *string    `json:"school_year,omitempty"`
+	EventType       string     `json:"event_type"`
+	Name            string     `json:"name"`
+	StartsOn        time.Time  `json:"starts_on"`
+	EndsOn          time.Time  `json:"ends_on"`
+	HijriDate       string     `json:"hijri_date"`
+	HijriLabel      string     `json:"hijri_label"`
+	IsRecurring     bool       `json:"is_recurring"`
+	Calendar        *string    `json:"calendar,omitempty"`
+	Month           *int       `json:"month,omitempty"`
+	Day             *int       `json:"day,omitempty"`
+	DurationDays    int        `json:"duration_days"`
+	ReplacesEventID *uuid.UUID `json:"replaces_event_id,omitempty"`
+	IsNational      bool       `json:"is_national"`
+}
+
+// SaveCalendarEventRequest represents the request to add or change an
+// event. An event in a school year starts on StartsOn or on HijriDate
+// (YYYY-MM-DD in the Hijri calendar) and ends on EndsOn or after Days
+// days. Without a school year it recurs every year on Month and Day of
+// Calendar.
+type SaveCalendarEventRequest struct {
+	SchoolYear      string     `json:"school_year,omitempty"`
+	EventType       string     `json:"event_type" validate:"required,oneof=holiday exam"`
+	Name            string     `json:"name" validate:"required"`
+	StartsOn        string     `json:"starts_on,omitempty"`
+	EndsOn          string     `json:"ends_on,omitempty"`
+	HijriDate       string     `json:"hijri_date,omitempty"`
+	Days            int        `json:"days,omitempty"`
+	Calendar        string     `json:"calendar,omitempty" validate:"omitempty,oneof=gregorian hijri"`
+	Month           int        `json:"month,omitempty"`
+	Day             int        `json:"day,omitempty"`
+	ReplacesEventID *uuid.UUID `json:"replaces_event_id,omitempty"`
+}
+
+// SchoolCalendar is a school year's terms, holidays and exam periods
+type SchoolCalendar struct {
+	SchoolYear        string          `json:"school_year"`
+	Terms             []SchoolTerm    `json:"terms"`
+	Events            []CalendarEvent `json:"events"`
+	InstructionalDays int             `json:"instructional_days"`
+}
+
+// CalendarFeed is a teacher's iCalendar subscription. The URL holds the
+// secret token and is only shown when it is issued.
+type CalendarFeed struct {
+	URL       string    `json:"url"`
+	CreatedAt time.Time `json:"created_at"`
+}
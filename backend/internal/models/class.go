@@ -19,10 +19,10 @@ type Class struct {
 	IsActive     bool      `json:"is_active" db:"is_active"`
 	CreatedAt    time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
-	
+
 	// Joined fields
-	SubjectName   string `json:"subject_name,omitempty" db:"subject_name"`
-	StudentCount  int    `json:"student_count,omitempty" db:"student_count"`
+	SubjectName  string `json:"subject_name,omitempty" db:"subject_name"`
+	StudentCount int    `json:"student_count,omitempty" db:"student_count"`
 }
 
 // Student represents a student in the system
@@ -37,32 +37,34 @@ type Student struct {
 	Gender         string    `json:"gender" db:"gender"`
 	Nationality    string    `json:"nationality" db:"nationality"`
 	Address        *string   `json:"address,omitempty" db:"address"`
-	ClassID        uuid.UUID `json:"class_id" db:"class_id"`
 	EnrollmentDate time.Time `json:"enrollment_date" db:"enrollment_date"`
 	IsActive       bool      `json:"is_active" db:"is_active"`
 	CreatedAt      time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt      time.Time `json:"updated_at" db:"updated_at"`
-	
+
 	// Joined fields
-	AttendanceRate *float64 `json:"attendance_rate,omitempty" db:"attendance_rate"`
-	ClassName      string   `json:"class_name,omitempty" db:"class_name"`
+	AttendanceRate *float64        `json:"attendance_rate,omitempty" db:"attendance_rate"`
+	ClassID        *uuid.UUID      `json:"class_id,omitempty" db:"class_id"`
+	ClassName      string          `json:"class_name,omitempty" db:"class_name"`
+	Parents        []StudentParent `json:"parents,omitempty"`
 }
 
 // Attendance represents an attendance record
 type Attendance struct {
-	ID         uuid.UUID `json:"id" db:"id"`
-	StudentID  uuid.UUID `json:"student_id" db:"student_id"`
-	ClassID    uuid.UUID `json:"class_id" db:"class_id"`
-	Date       time.Time `json:"date" db:"date"`
-	Status     string    `json:"status" db:"status"`
-	Notes      *string   `json:"notes,omitempty" db:"notes"`
-	RecordedBy uuid.UUID `json:"recorded_by" db:"recorded_by"`
-	RecordedAt time.Time `json:"recorded_at" db:"recorded_at"`
-	CreatedAt  time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt  time.Time `json:"updated_at" db:"updated_at"`
-	
+	ID           uuid.UUID `json:"id" db:"id"`
+	StudentID    uuid.UUID `json:"student_id" db:"student_id"`
+	ClassID      uuid.UUID `json:"class_id" db:"class_id"`
+	Date         time.Time `json:"date" db:"date"`
+	PeriodNumber int       `json:"period_number" db:"period_number"`
+	Status       string    `json:"status" db:"status"`
+	Notes        *string   `json:"notes,omitempty" db:"notes"`
+	RecordedBy   uuid.UUID `json:"recorded_by" db:"recorded_by"`
+	RecordedAt   time.Time `json:"recorded_at" db:"recorded_at"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
+
 	// Joined fields
-	StudentName string `json:"student_name,omitempty" db:"student_name"`
+	StudentName   string `json:"student_name,omitempty" db:"student_name"`
 	StudentNumber string `json:"student_number,omitempty" db:"student_number"`
 }
 
@@ -99,6 +101,22 @@ type CreateStudentRequest struct {
 	Address       string `json:"address,omitempty"`
 }
 
+// EnrollStudentRequest represents the request to enroll an existing student
+// in a class. Besides the student number, the civil ID must match, or the
+// date of birth (YYYY-MM-DD) when the student has no civil ID on file.
+type EnrollStudentRequest struct {
+	StudentNumber string `json:"student_number" validate:"required"`
+	CivilID       string `json:"civil_id,omitempty"`
+	DateOfBirth   string `json:"date_of_birth,omitempty"`
+}
+
+// TransferStudentRequest represents the request to move a student from one
+// of the teacher's classes to another
+type TransferStudentRequest struct {
+	FromClassID string `json:"from_class_id" validate:"required"`
+	ToClassID   string `json:"to_class_id" validate:"required"`
+}
+
 // UpdateStudentRequest represents the request to update a student
 type UpdateStudentRequest struct {
 	StudentNumber string `json:"student_number" validate:"required"`
@@ -110,7 +128,6 @@ type UpdateStudentRequest struct {
 	Gender        string `json:"gender" validate:"required,oneof=male female"`
 	Nationality   string `json:"nationality" validate:"required"`
 	Address       string `json:"address,omitempty"`
-	IsActive      bool   `json:"is_active"`
 }
 
 // AttendanceRecord represents a single attendance record for bulk operations
@@ -121,29 +138,31 @@ type AttendanceRecord struct {
 }
 
 // CreateAttendanceRequest represents the request to create attendance records
+// for one period; PeriodNumber defaults to the first period
 type CreateAttendanceRequest struct {
-	ClassID uuid.UUID          `json:"class_id" validate:"required"`
-	Date    string             `json:"date" validate:"required"`
-	Records []AttendanceRecord `json:"records" validate:"required,min=1"`
+	ClassID      uuid.UUID          `json:"class_id" validate:"required"`
+	Date         string             `json:"date" validate:"required"`
+	PeriodNumber int                `json:"period_number,omitempty" validate:"omitempty,min=1,max=12"`
+	Records      []AttendanceRecord `json:"records" validate:"required,min=1"`
 }
 
 // AttendanceStats represents attendance statistics for a student or class
 type AttendanceStats struct {
-	TotalDays    int     `json:"total_days"`
-	PresentDays  int     `json:"present_days"`
-	AbsentDays   int     `json:"absent_days"`
-	LateDays     int     `json:"late_days"`
-	ExcusedDays  int     `json:"excused_days"`
+	TotalDays      int     `json:"total_days"`
+	PresentDays    int     `json:"present_days"`
+	AbsentDays     int     `json:"absent_days"`
+	LateDays       int     `json:"late_days"`
+	ExcusedDays    int     `json:"excused_days"`
 	AttendanceRate float64 `json:"attendance_rate"`
 }
 
 // ClassStats represents statistics for a class
 type ClassStats struct {
-	TotalStudents    int     `json:"total_students"`
+	TotalStudents     int     `json:"total_students"`
 	AverageAttendance float64 `json:"average_attendance"`
-	ActiveStudents   int     `json:"active_students"`
-	MaleStudents     int     `json:"male_students"`
-	FemaleStudents   int     `json:"female_students"`
+	ActiveStudents    int     `json:"active_students"`
+	MaleStudents      int     `json:"male_students"`
+	FemaleStudents    int     `json:"female_students"`
 }
 
 // Subject represents a subject in the system
@@ -156,10 +175,39 @@ type Subject struct {
 	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
 }
 
-// AttendanceReport represents attendance data for reports
+// AttendanceDay is a student's attendance on one school day, combined
+// from the periods recorded that day
+type AttendanceDay struct {
+	Date          time.Time `json:"date"`
+	Status        string    `json:"status"`
+	Periods       int       `json:"periods"`
+	AbsentPeriods int       `json:"absent_periods"`
+}
+
+// AttendanceReport represents attendance data for reports. Stats counts
+// school days and PeriodStats counts the periods recorded. When the school
+// calendar has term dates, the attendance rate is taken over the
+// instructional days so far and MissingDays lists those without a record.
 type AttendanceReport struct {
-	Date      time.Time `json:"date" db:"date"`
-	Students  []Student `json:"students"`
-	Attendances []Attendance `json:"attendances"`
-	Stats     AttendanceStats `json:"stats"`
-}
\ No newline at end of file
+	Date              time.Time        `json:"date" db:"date"`
+	Students          []Student        `json:"students"`
+	Attendances       []Attendance     `json:"attendances"`
+	Days              []AttendanceDay  `json:"days,omitempty"`
+	Stats             AttendanceStats  `json:"stats"`
+	PeriodStats       *AttendanceStats `json:"period_stats,omitempty"`
+	InstructionalDays int              `json:"instructional_days,omitempty"`
+	MissingDays       []time.Time      `json:"missing_days,omitempty"`
+}
+
+// StudentImportReport represents the outcome of a roster import
+type StudentImportReport struct {
+	Format          string           `json:"format"`
+	DryRun          bool             `json:"dry_run"`
+	TotalRows       int              `json:"total_rows"`
+	ValidRows       int              `json:"valid_rows"`
+	ImportedCount   int              `json:"imported_count"`
+	MaxStudents     int              `json:"max_students"`
+	CurrentStudents int              `json:"current_students"`
+	StudentIDs      []uuid.UUID      `json:"student_ids,omitempty"`
+	Errors          []ImportRowError `json:"errors"`
+}
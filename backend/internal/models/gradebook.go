@@ -0,0 +1,129 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Grade category types
+const (
+	GradeCategoryQuiz          = "quiz"
+	GradeCategoryExam          = "exam"
+	GradeCategoryHomework      = "homework"
+	GradeCategoryParticipation = "participation"
+	GradeCategoryProject       = "project"
+	GradeCategoryOther         = "other"
+)
+
+// GradeCategory is a weighted part of a class's semester grade, such as
+// quizzes or the final exam. Weight is a percentage.
+type GradeCategory struct {
+	ID           uuid.UUID `json:"id" db:"id"`
+	ClassID      uuid.UUID `json:"class_id" db:"class_id"`
+	Name         string    `json:"name" db:"name"`
+	NameArabic   string    `json:"name_arabic" db:"name_arabic"`
+	CategoryType string    `json:"category_type" db:"category_type"`
+	Weight       float64   `json:"weight" db:"weight"`
+	SortOrder    int       `json:"sort_order" db:"sort_order"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// SaveGradeCategoryRequest represents the request to create or update a
+// grade category
+type SaveGradeCategoryRequest struct {
+	Name         string  `json:"name" validate:"required"`
+	NameArabic   string  `json:"name_arabic" validate:"required"`
+	CategoryType string  `json:"category_type" validate:"omitempty,oneof=quiz exam homework participation project other"`
+	Weight       float64 `json:"weight" validate:"gt=0,max=100"`
+	SortOrder    int     `json:"sort_order"`
+}
+
+// GradeItem is a piece of graded work in a category. TestID is set for
+// items whose scores were imported from an online test.
+type GradeItem struct {
+	ID          uuid.UUID  `json:"id" db:"id"`
+	ClassID     uuid.UUID  `json:"class_id" db:"class_id"`
+	CategoryID  uuid.UUID  `json:"category_id" db:"category_id"`
+	Title       string     `json:"title" db:"title"`
+	TitleArabic string     `json:"title_arabic" db:"title_arabic"`
+	MaxScore    float64    `json:"max_score" db:"max_score"`
+	DueDate     *time.Time `json:"due_date,omitempty" db:"due_date"`
+	TestID      *uuid.UUID `json:"test_id,omitempty" db:"test_id"`
+	CreatedBy   uuid.UUID  `json:"created_by" db:"created_by"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// SaveGradeItemRequest represents the request to create or update a grade item
+type SaveGradeItemRequest struct {
+	CategoryID  string  `json:"category_id" validate:"required"`
+	Title       string  `json:"title" validate:"required"`
+	TitleArabic string  `json:"title_arabic" validate:"required"`
+	MaxScore    float64 `json:"max_score" validate:"gt=0"`
+	DueDate     string  `json:"due_date,omitempty"`
+}
+
+// ImportTestGradesRequest represents the request to bring an online test's
+// results into the gradebook
+type ImportTestGradesRequest struct {
+	TestID     string `json:"test_id" validate:"required"`
+	CategoryID string `json:"category_id" validate:"required"`
+}
+
+// GradeScore is a student's score on a grade item. A nil Score means no
+// score was recorded yet; excused items do not count.
+type GradeScore struct {
+	StudentID uuid.UUID `json:"student_id" validate:"required"`
+	Score     *float64  `json:"score"`
+	IsExcused bool      `json:"is_excused"`
+	Notes     string    `json:"notes,omitempty"`
+}
+
+// SaveGradeScoresRequest represents the request to record scores for a
+// grade item
+type SaveGradeScoresRequest struct {
+	Scores []GradeScore `json:"scores" validate:"required,min=1"`
+}
+
+// GradebookScore is one cell of the gradebook
+type GradebookScore struct {
+	GradeItemID uuid.UUID `json:"grade_item_id"`
+	Score       *float64  `json:"score"`
+	IsExcused   bool      `json:"is_excused"`
+	Notes       *string   `json:"notes,omitempty"`
+}
+
+// CategoryGrade is a student's percentage in one category
+type CategoryGrade struct {
+	CategoryID uuid.UUID `json:"category_id"`
+	Running    *float64  `json:"running"`
+	Final      *float64  `json:"final"`
+}
+
+// GradebookStudent is a row of the gradebook. RunningGrade only counts
+// recorded scores; TermGrade counts missing scores as zero and is what is
+// written to student_classes.final_grade when the class is closed.
+type GradebookStudent struct {
+	StudentID      uuid.UUID        `json:"student_id"`
+	StudentNumber  string           `json:"student_number"`
+	StudentName    string           `json:"student_name"`
+	Scores         []GradebookScore `json:"scores"`
+	CategoryGrades []CategoryGrade  `json:"category_grades"`
+	RunningGrade   *float64         `json:"running_grade"`
+	TermGrade      *float64         `json:"term_grade"`
+}
+
+// Gradebook is a class's categories, items and student grades
+type Gradebook struct {
+	ClassID     uuid.UUID          `json:"class_id"`
+	ClassName   string             `json:"class_name"`
+	SchoolYear  string             `json:"school_year"`
+	Semester    string             `json:"semester"`
+	ClosedAt    *time.Time         `json:"closed_at,omitempty"`
+	TotalWeight float64            `json:"total_weight"`
+	Categories  []GradeCategory    `json:"categories"`
+	Items       []GradeItem        `json:"items"`
+	Students    []GradebookStudent `json:"students"`
+}
@@ -0,0 +1,29 @@
+package models
+
+// Notification events. Referrals go to the school's social worker; the
+// others go to parents.
+const (
+	NotificationAbsent       = "absent"
+	NotificationLate         = "late"
+	NotificationAbsenceAlert = "absence_alert"
+	NotificationReferral     = "referral"
+)
+
+// NotificationTemplate is the Arabic message sent to parents for an event.
+// Subject and body may use {{placeholders}} such as {{student_name}}.
+type NotificationTemplate struct {
+	EventType    string   `json:"event_type"`
+	Subject      string   `json:"subject"`
+	Body         string   `json:"body"`
+	IsActive     bool     `json:"is_active"`
+	IsCustom     bool     `json:"is_custom"`
+	Placeholders []string `json:"placeholders"`
+}
+
+// SaveNotificationTemplateRequest represents the request to set a school's
+// template for an event. An inactive template stops the notifications.
+type SaveNotificationTemplateRequest struct {
+	Subject  string `json:"subject" validate:"required"`
+	Body     string `json:"body" validate:"required"`
+	IsActive bool   `json:"is_active"`
+}
@@ -0,0 +1,83 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Parent types
+const (
+	ParentTypeFather   = "father"
+	ParentTypeMother   = "mother"
+	ParentTypeGuardian = "guardian"
+)
+
+// Parent is a parent or guardian. Siblings link to the same record, which
+// is identified by its civil ID.
+type Parent struct {
+	ID             uuid.UUID `json:"id" db:"id"`
+	CivilID        *string   `json:"civil_id,omitempty" db:"civil_id"`
+	FirstName      string    `json:"first_name" db:"first_name"`
+	LastName       string    `json:"last_name" db:"last_name"`
+	ArabicName     string    `json:"arabic_name" db:"arabic_name"`
+	PhonePrimary   string    `json:"phone_primary" db:"phone_primary"`
+	PhoneSecondary *string   `json:"phone_secondary,omitempty" db:"phone_secondary"`
+	Email          *string   `json:"email,omitempty" db:"email"`
+	Workplace      *string   `json:"workplace,omitempty" db:"workplace"`
+	JobTitle       *string   `json:"job_title,omitempty" db:"job_title"`
+	Address        *string   `json:"address,omitempty" db:"address"`
+	IsActive       bool      `json:"is_active" db:"is_active"`
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// StudentParent is a parent as a contact of one student
+type StudentParent struct {
+	Parent
+	ParentType       string `json:"parent_type" db:"parent_type"`
+	IsPrimaryContact bool   `json:"is_primary_contact" db:"is_primary_contact"`
+	// SiblingCount is the number of the parent's other children on file
+	SiblingCount int `json:"sibling_count" db:"sibling_count"`
+}
+
+// SaveStudentParentRequest represents the request to add or update a
+// student's parent. Adding a parent whose civil ID is already on file links
+// the existing record.
+type SaveStudentParentRequest struct {
+	ParentType       string `json:"parent_type" validate:"required,oneof=father mother guardian"`
+	CivilID          string `json:"civil_id,omitempty"`
+	FirstName        string `json:"first_name" validate:"required"`
+	LastName         string `json:"last_name" validate:"required"`
+	ArabicName       string `json:"arabic_name" validate:"required"`
+	PhonePrimary     string `json:"phone_primary" validate:"required"`
+	PhoneSecondary   string `json:"phone_secondary,omitempty"`
+	Email            string `json:"email,omitempty"`
+	Workplace        string `json:"workplace,omitempty"`
+	JobTitle         string `json:"job_title,omitempty"`
+	Address          string `json:"address,omitempty"`
+	IsPrimaryContact bool   `json:"is_primary_contact"`
+}
+
+// ParentAccessCode is a one-time code a teacher gives a parent for their
+// first sign-in to the parent portal
+type ParentAccessCode struct {
+	Code      string    `json:"code"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// ParentTestResult is a graded test attempt as shown to a parent
+type ParentTestResult struct {
+	SubmissionID    uuid.UUID  `json:"submission_id"`
+	TestID          uuid.UUID  `json:"test_id"`
+	Title           string     `json:"title"`
+	TitleArabic     string     `json:"title_arabic"`
+	ClassName       string     `json:"class_name"`
+	SubjectName     string     `json:"subject_name"`
+	AttemptNumber   int        `json:"attempt_number"`
+	SubmittedAt     *time.Time `json:"submitted_at"`
+	TotalScore      int        `json:"total_score"`
+	TotalPoints     int        `json:"total_points"`
+	PercentageScore float64    `json:"percentage_score"`
+	IsPassed        bool       `json:"is_passed"`
+}
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PromotionCandidate is a student on the roster of a class being promoted
+type PromotionCandidate struct {
+	StudentID     uuid.UUID `json:"student_id"`
+	StudentNumber string    `json:"student_number"`
+	StudentName   string    `json:"student_name"`
+	Grade         *float64  `json:"grade"`
+	IsFinal       bool      `json:"is_final"`
+	IsPassing     *bool     `json:"is_passing"`
+	// Selected suggests carrying the student over: they passed, or have no
+	// grade yet
+	Selected bool `json:"selected"`
+}
+
+// PromotionPreview is the first step of the promotion wizard: the class,
+// the suggested next term and its students with their grades
+type PromotionPreview struct {
+	ClassID        uuid.UUID            `json:"class_id"`
+	ClassName      string               `json:"class_name"`
+	ClassSection   string               `json:"class_section"`
+	SchoolYear     string               `json:"school_year"`
+	Semester       string               `json:"semester"`
+	MaxStudents    int                  `json:"max_students"`
+	ClosedAt       *time.Time           `json:"closed_at"`
+	NextSchoolYear string               `json:"next_school_year"`
+	NextSemester   string               `json:"next_semester"`
+	Students       []PromotionCandidate `json:"students"`
+}
+
+// PromoteClassRequest creates the class for the next term and enrolls the
+// selected students. Empty fields default to the source class and the
+// suggested next term.
+type PromoteClassRequest struct {
+	Name         string   `json:"name"`
+	ClassSection string   `json:"class_section"`
+	SchoolYear   string   `json:"school_year"`
+	Semester     string   `json:"semester"`
+	MaxStudents  int      `json:"max_students"`
+	StudentIDs   []string `json:"student_ids"`
+}
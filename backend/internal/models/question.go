@@ -0,0 +1,109 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// Question types supported by the questions table
+const (
+	QuestionTypeMultipleChoice = "multiple_choice"
+	QuestionTypeTrueFalse      = "true_false"
+	QuestionTypeShortAnswer    = "short_answer"
+	QuestionTypeEssay          = "essay"
+	QuestionTypeFillBlank      = "fill_blank"
+	QuestionTypeMatching       = "matching"
+)
+
+// Question represents a question in the question bank.
+//
+// Options and CorrectAnswer depend on the question type:
+//   - multiple_choice: options {"A": "...", "B": "..."}, correct_answer "B"
+//   - true_false: no options, correct_answer "true" or "false"
+//   - fill_blank / short_answer: no options, accepted answers separated by "|"
+//   - matching: options {"left": [...], "right": [...]}, correct_answer is a
+//     JSON object mapping each left item to its right item
+//   - essay: no options, correct_answer holds a model answer
+type Question struct {
+	ID                 uuid.UUID       `json:"id" db:"id"`
+	SubjectID          uuid.UUID       `json:"subject_id" db:"subject_id"`
+	CurriculumUnitID   *uuid.UUID      `json:"curriculum_unit_id,omitempty" db:"curriculum_unit_id"`
+	QuestionText       string          `json:"question_text" db:"question_text"`
+	QuestionTextArabic string          `json:"question_text_arabic" db:"question_text_arabic"`
+	QuestionType       string          `json:"question_type" db:"question_type"`
+	DifficultyLevel    string          `json:"difficulty_level" db:"difficulty_level"`
+	Points             int             `json:"points" db:"points"`
+	Options            json.RawMessage `json:"options,omitempty" db:"options"`
+	CorrectAnswer      string          `json:"correct_answer" db:"correct_answer"`
+	Explanation        *string         `json:"explanation,omitempty" db:"explanation"`
+	ExplanationArabic  *string         `json:"explanation_arabic,omitempty" db:"explanation_arabic"`
+	Tags               pq.StringArray  `json:"tags" db:"tags"`
+	CreatedBy          uuid.UUID       `json:"created_by" db:"created_by"`
+	IsPublic           bool            `json:"is_public" db:"is_public"`
+	IsActive           bool            `json:"is_active" db:"is_active"`
+	CreatedAt          time.Time       `json:"created_at" db:"created_at"`
+	UpdatedAt          time.Time       `json:"updated_at" db:"updated_at"`
+
+	// Joined fields
+	SubjectName string `json:"subject_name,omitempty" db:"subject_name"`
+	IsOwner     bool   `json:"is_owner" db:"is_owner"`
+}
+
+// CreateQuestionRequest represents the request to create a new question
+type CreateQuestionRequest struct {
+	SubjectID          string          `json:"subject_id" validate:"required"`
+	CurriculumUnitID   string          `json:"curriculum_unit_id,omitempty"`
+	QuestionText       string          `json:"question_text" validate:"required"`
+	QuestionTextArabic string          `json:"question_text_arabic" validate:"required"`
+	QuestionType       string          `json:"question_type" validate:"required,oneof=multiple_choice true_false short_answer essay fill_blank matching"`
+	DifficultyLevel    string          `json:"difficulty_level" validate:"required,oneof=easy medium hard"`
+	Points             int             `json:"points" validate:"min=1"`
+	Options            json.RawMessage `json:"options,omitempty"`
+	CorrectAnswer      string          `json:"correct_answer"`
+	Explanation        string          `json:"explanation,omitempty"`
+	ExplanationArabic  string          `json:"explanation_arabic,omitempty"`
+	Tags               []string        `json:"tags,omitempty"`
+	IsPublic           bool            `json:"is_public"`
+}
+
+// UpdateQuestionRequest represents the request to update a question
+type UpdateQuestionRequest struct {
+	CreateQuestionRequest
+}
+
+// QuestionSearchResult represents a ranked question bank search hit
+type QuestionSearchResult struct {
+	Question
+	Rank          float64  `json:"rank"`
+	MatchedFields []string `json:"matched_fields"`
+}
+
+// QuestionSearchResponse represents a page of question bank search results
+type QuestionSearchResponse struct {
+	Query    string                 `json:"query"`
+	Terms    []string               `json:"terms"`
+	Results  []QuestionSearchResult `json:"results"`
+	Page     int                    `json:"page"`
+	PageSize int                    `json:"page_size"`
+	Total    int                    `json:"total"`
+}
+
+// ImportRowError describes why a single row of an import file was rejected
+type ImportRowError struct {
+	Row     int    `json:"row"`
+	Message string `json:"message"`
+}
+
+// QuestionImportReport represents the outcome of a bulk question import
+type QuestionImportReport struct {
+	Format        string           `json:"format"`
+	DryRun        bool             `json:"dry_run"`
+	TotalRows     int              `json:"total_rows"`
+	ValidRows     int              `json:"valid_rows"`
+	ImportedCount int              `json:"imported_count"`
+	QuestionIDs   []uuid.UUID      `json:"question_ids,omitempty"`
+	Errors        []ImportRowError `json:"errors"`
+}
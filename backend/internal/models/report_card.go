@@ -0,0 +1,60 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// GradingBand maps grades from MinGrade up to the next band to a letter and
+// descriptor, such as 90 and above to "A" (ممتاز)
+type GradingBand struct {
+	MinGrade         float64 `json:"min_grade" validate:"min=0,max=100"`
+	Letter           string  `json:"letter" validate:"required"`
+	Descriptor       string  `json:"descriptor" validate:"required"`
+	DescriptorArabic string  `json:"descriptor_arabic" validate:"required"`
+	IsPassing        bool    `json:"is_passing"`
+}
+
+// GradingScale is the scale used for one school stage. IsCustom is set when
+// the school replaced the ministry default.
+type GradingScale struct {
+	SchoolType string        `json:"school_type"`
+	IsCustom   bool          `json:"is_custom"`
+	Bands      []GradingBand `json:"bands"`
+}
+
+// SaveGradingScaleRequest represents the request to replace a school's
+// scale for a stage
+type SaveGradingScaleRequest struct {
+	Bands []GradingBand `json:"bands" validate:"required,min=2"`
+}
+
+// ReportCardSubject is one subject on a report card. IsFinal is false while
+// the class is still open and the grade may change.
+type ReportCardSubject struct {
+	ClassID     uuid.UUID       `json:"class_id"`
+	ClassName   string          `json:"class_name"`
+	SubjectName string          `json:"subject_name"`
+	TeacherName string          `json:"teacher_name"`
+	Grade       *float64        `json:"grade"`
+	Band        *GradingBand    `json:"band,omitempty"`
+	IsFinal     bool            `json:"is_final"`
+	Attendance  AttendanceStats `json:"attendance"`
+}
+
+// ReportCard is a student's results for one semester
+type ReportCard struct {
+	StudentID     uuid.UUID           `json:"student_id"`
+	StudentNumber string              `json:"student_number"`
+	StudentName   string              `json:"student_name"`
+	SchoolName    string              `json:"school_name"`
+	District      string              `json:"district"`
+	SchoolType    string              `json:"school_type"`
+	SchoolYear    string              `json:"school_year"`
+	Semester      string              `json:"semester"`
+	Subjects      []ReportCardSubject `json:"subjects"`
+	Average       *float64            `json:"average"`
+	AverageBand   *GradingBand        `json:"average_band,omitempty"`
+	GeneratedAt   time.Time           `json:"generated_at"`
+}
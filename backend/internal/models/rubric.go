@@ -0,0 +1,73 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RubricLevel is one performance level of a criterion, such as "excellent"
+type RubricLevel struct {
+	Label       string `json:"label"`
+	LabelArabic string `json:"label_arabic,omitempty"`
+	Description string `json:"description,omitempty"`
+	Points      int    `json:"points"`
+}
+
+// RubricCriterion is one aspect an answer is graded on. ID identifies the
+// criterion when points are awarded.
+type RubricCriterion struct {
+	ID         string        `json:"id"`
+	Name       string        `json:"name"`
+	NameArabic string        `json:"name_arabic,omitempty"`
+	Levels     []RubricLevel `json:"levels"`
+}
+
+// Rubric holds the grading criteria of a short answer or essay question
+type Rubric struct {
+	ID         uuid.UUID         `json:"id" db:"id"`
+	QuestionID uuid.UUID         `json:"question_id" db:"question_id"`
+	Criteria   []RubricCriterion `json:"criteria" db:"criteria"`
+	MaxPoints  int               `json:"max_points" db:"max_points"`
+	CreatedBy  uuid.UUID         `json:"created_by" db:"created_by"`
+	CreatedAt  time.Time         `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time         `json:"updated_at" db:"updated_at"`
+}
+
+// SaveRubricRequest represents the request to create or replace a rubric
+type SaveRubricRequest struct {
+	Criteria []RubricCriterion `json:"criteria" validate:"required,min=1"`
+}
+
+// CriterionScore is the points awarded for one rubric criterion
+type CriterionScore struct {
+	CriterionID string `json:"criterion_id"`
+	Points      int    `json:"points"`
+}
+
+// GradeAnswerRequest represents a teacher's grade for one answer. Questions
+// with a rubric are graded per criterion; others take Points directly.
+type GradeAnswerRequest struct {
+	CriterionScores []CriterionScore `json:"criterion_scores,omitempty"`
+	Points          *int             `json:"points,omitempty"`
+	Feedback        string           `json:"feedback,omitempty"`
+}
+
+// GradingQueueItem is an answer waiting for a teacher
+type GradingQueueItem struct {
+	AnswerID           uuid.UUID  `json:"answer_id"`
+	SubmissionID       uuid.UUID  `json:"submission_id"`
+	StudentID          uuid.UUID  `json:"student_id"`
+	StudentName        string     `json:"student_name"`
+	AttemptNumber      int        `json:"attempt_number"`
+	SubmittedAt        *time.Time `json:"submitted_at,omitempty"`
+	QuestionID         uuid.UUID  `json:"question_id"`
+	QuestionOrder      int        `json:"question_order"`
+	QuestionType       string     `json:"question_type"`
+	QuestionText       string     `json:"question_text"`
+	QuestionTextArabic string     `json:"question_text_arabic"`
+	ModelAnswer        string     `json:"model_answer,omitempty"`
+	StudentAnswer      string     `json:"student_answer"`
+	Points             int        `json:"points"`
+	Rubric             *Rubric    `json:"rubric,omitempty"`
+}
@@ -0,0 +1,196 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Test represents a test created for a class
+type Test struct {
+	ID                     uuid.UUID  `json:"id" db:"id"`
+	Title                  string     `json:"title" db:"title"`
+	TitleArabic            string     `json:"title_arabic" db:"title_arabic"`
+	Description            *string    `json:"description,omitempty" db:"description"`
+	DescriptionArabic      *string    `json:"description_arabic,omitempty" db:"description_arabic"`
+	ClassID                uuid.UUID  `json:"class_id" db:"class_id"`
+	CreatedBy              uuid.UUID  `json:"created_by" db:"created_by"`
+	TestType               string     `json:"test_type" db:"test_type"`
+	DurationMinutes        int        `json:"duration_minutes" db:"duration_minutes"`
+	TotalPoints            int        `json:"total_points" db:"total_points"`
+	PassingScore           int        `json:"passing_score" db:"passing_score"`
+	Instructions           *string    `json:"instructions,omitempty" db:"instructions"`
+	InstructionsArabic     *string    `json:"instructions_arabic,omitempty" db:"instructions_arabic"`
+	IsRandomized           bool       `json:"is_randomized" db:"is_randomized"`
+	ShowResultsImmediately bool       `json:"show_results_immediately" db:"show_results_immediately"`
+	AllowRetakes           bool       `json:"allow_retakes" db:"allow_retakes"`
+	MaxAttempts            int        `json:"max_attempts" db:"max_attempts"`
+	ScheduledStart         *time.Time `json:"scheduled_start,omitempty" db:"scheduled_start"`
+	ScheduledEnd           *time.Time `json:"scheduled_end,omitempty" db:"scheduled_end"`
+	IsPublished            bool       `json:"is_published" db:"is_published"`
+	IsActive               bool       `json:"is_active" db:"is_active"`
+	CreatedAt              time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt              time.Time  `json:"updated_at" db:"updated_at"`
+
+	// Joined fields
+	ClassName     string         `json:"class_name,omitempty" db:"class_name"`
+	QuestionCount int            `json:"question_count" db:"question_count"`
+	Questions     []TestQuestion `json:"questions,omitempty"`
+}
+
+// TestQuestion represents a question placed in a test
+type TestQuestion struct {
+	ID             uuid.UUID `json:"id" db:"id"`
+	TestID         uuid.UUID `json:"test_id" db:"test_id"`
+	QuestionID     uuid.UUID `json:"question_id" db:"question_id"`
+	QuestionOrder  int       `json:"question_order" db:"question_order"`
+	PointsOverride *int      `json:"points_override,omitempty" db:"points_override"`
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+
+	// Joined fields
+	Points   int       `json:"points" db:"points"`
+	Question *Question `json:"question,omitempty"`
+}
+
+// CreateTestRequest represents the request to create a new test
+type CreateTestRequest struct {
+	ClassID                string     `json:"class_id" validate:"required"`
+	Title                  string     `json:"title" validate:"required"`
+	TitleArabic            string     `json:"title_arabic" validate:"required"`
+	Description            string     `json:"description,omitempty"`
+	DescriptionArabic      string     `json:"description_arabic,omitempty"`
+	TestType               string     `json:"test_type" validate:"required,oneof=quiz exam assessment practice"`
+	DurationMinutes        int        `json:"duration_minutes" validate:"required,min=1"`
+	PassingScore           int        `json:"passing_score" validate:"min=0"`
+	Instructions           string     `json:"instructions,omitempty"`
+	InstructionsArabic     string     `json:"instructions_arabic,omitempty"`
+	IsRandomized           bool       `json:"is_randomized"`
+	ShowResultsImmediately bool       `json:"show_results_immediately"`
+	AllowRetakes           bool       `json:"allow_retakes"`
+	MaxAttempts            int        `json:"max_attempts" validate:"min=1"`
+	ScheduledStart         *time.Time `json:"scheduled_start,omitempty"`
+	ScheduledEnd           *time.Time `json:"scheduled_end,omitempty"`
+}
+
+// UpdateTestRequest represents the request to update a test
+type UpdateTestRequest struct {
+	Title                  string     `json:"title" validate:"required"`
+	TitleArabic            string     `json:"title_arabic" validate:"required"`
+	Description            string     `json:"description,omitempty"`
+	DescriptionArabic      string     `json:"description_arabic,omitempty"`
+	TestType               string     `json:"test_type" validate:"required,oneof=quiz exam assessment practice"`
+	DurationMinutes        int        `json:"duration_minutes" validate:"required,min=1"`
+	PassingScore           int        `json:"passing_score" validate:"min=0"`
+	Instructions           string     `json:"instructions,omitempty"`
+	InstructionsArabic     string     `json:"instructions_arabic,omitempty"`
+	IsRandomized           bool       `json:"is_randomized"`
+	ShowResultsImmediately bool       `json:"show_results_immediately"`
+	AllowRetakes           bool       `json:"allow_retakes"`
+	MaxAttempts            int        `json:"max_attempts" validate:"min=1"`
+	ScheduledStart         *time.Time `json:"scheduled_start,omitempty"`
+	ScheduledEnd           *time.Time `json:"scheduled_end,omitempty"`
+}
+
+// AddTestQuestionRequest represents the request to add a question to a test
+type AddTestQuestionRequest struct {
+	QuestionID     uuid.UUID `json:"question_id" validate:"required"`
+	QuestionOrder  int       `json:"question_order,omitempty"`
+	PointsOverride *int      `json:"points_override,omitempty"`
+}
+
+// UpdateTestQuestionRequest represents the request to change a question's points in a test
+type UpdateTestQuestionRequest struct {
+	PointsOverride *int `json:"points_override"`
+}
+
+// ReorderTestQuestionsRequest lists all of a test's question IDs in their new order
+type ReorderTestQuestionsRequest struct {
+	QuestionIDs []uuid.UUID `json:"question_ids" validate:"required,min=1"`
+}
+
+// GenerateTestRequest describes a blueprint for building a test from the
+// question bank
+type GenerateTestRequest struct {
+	ClassID           string         `json:"class_id" validate:"required"`
+	Title             string         `json:"title" validate:"required"`
+	TitleArabic       string         `json:"title_arabic" validate:"required"`
+	TestType          string         `json:"test_type" validate:"required,oneof=quiz exam assessment practice"`
+	DurationMinutes   int            `json:"duration_minutes" validate:"required,min=1"`
+	CurriculumUnitIDs []uuid.UUID    `json:"curriculum_unit_ids,omitempty"`
+	DifficultyCounts  map[string]int `json:"difficulty_counts" validate:"required"`
+	QuestionTypes     []string       `json:"question_types,omitempty"`
+	TargetPoints      int            `json:"target_points,omitempty"`
+	Seed              *int64         `json:"seed,omitempty"`
+	DryRun            bool           `json:"dry_run"`
+}
+
+// GeneratedQuestion is a question picked for a generated test
+type GeneratedQuestion struct {
+	QuestionID       uuid.UUID  `json:"question_id"`
+	QuestionOrder    int        `json:"question_order"`
+	CurriculumUnitID *uuid.UUID `json:"curriculum_unit_id,omitempty"`
+	QuestionType     string     `json:"question_type"`
+	DifficultyLevel  string     `json:"difficulty_level"`
+	Points           int        `json:"points"`
+}
+
+// BlueprintShortfall reports a difficulty level the bank could not fill
+type BlueprintShortfall struct {
+	DifficultyLevel string `json:"difficulty_level"`
+	Requested       int    `json:"requested"`
+	Available       int    `json:"available"`
+}
+
+// GenerateTestResponse is the result of generating a test. Test is only set
+// when the test was actually created.
+type GenerateTestResponse struct {
+	Test        *Test                `json:"test,omitempty"`
+	DryRun      bool                 `json:"dry_run"`
+	Seed        int64                `json:"seed"`
+	TotalPoints int                  `json:"total_points"`
+	Questions   []GeneratedQuestion  `json:"questions"`
+	Unfilled    []BlueprintShortfall `json:"unfilled"`
+}
+
+// VariantQuestion places a question in a test variant. OptionMap maps the
+// option letter shown on the variant to the canonical letter stored in the
+// question bank; it is only set for multiple choice questions.
+type VariantQuestion struct {
+	QuestionID uuid.UUID         `json:"question_id"`
+	OptionMap  map[string]string `json:"option_map,omitempty"`
+}
+
+// TestVariant is one printed form (A, B, C...) of a test
+type TestVariant struct {
+	ID            uuid.UUID         `json:"id" db:"id"`
+	TestID        uuid.UUID         `json:"test_id" db:"test_id"`
+	Label         string            `json:"label" db:"label"`
+	VariantNumber int               `json:"variant_number" db:"variant_number"`
+	Seed          int64             `json:"seed" db:"seed"`
+	Layout        []VariantQuestion `json:"layout" db:"layout"`
+	CreatedAt     time.Time         `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time         `json:"updated_at" db:"updated_at"`
+}
+
+// GenerateVariantsRequest represents the request to derive variants of a test
+type GenerateVariantsRequest struct {
+	Count int    `json:"count" validate:"required,min=1,max=26"`
+	Seed  *int64 `json:"seed,omitempty"`
+}
+
+// AnswerKeyEntry is one line of a variant's answer key
+type AnswerKeyEntry struct {
+	Number        int       `json:"number"`
+	QuestionID    uuid.UUID `json:"question_id"`
+	QuestionType  string    `json:"question_type"`
+	CorrectAnswer string    `json:"correct_answer"`
+	Points        int       `json:"points"`
+}
+
+// AnswerKey lists the correct answers of a test variant in display order
+type AnswerKey struct {
+	TestID    uuid.UUID        `json:"test_id"`
+	VariantID *uuid.UUID       `json:"variant_id,omitempty"`
+	Label     string           `json:"label"`
+	Entries   []AnswerKeyEntry `json:"entries"`
+}
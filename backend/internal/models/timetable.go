@@ -0,0 +1,85 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Session statuses
+const (
+	SessionUpcoming   = "upcoming"
+	SessionInProgress = "in_progress"
+	SessionCompleted  = "completed"
+)
+
+// ClassSchedule is a weekly session of a class in the timetable. StartsOn
+// and EndsOn limit it to a semester; without them it always applies.
+type ClassSchedule struct {
+	ID           uuid.UUID  `json:"id" db:"id"`
+	ClassID      uuid.UUID  `json:"class_id" db:"class_id"`
+	DayOfWeek    int        `json:"day_of_week" db:"day_of_week"`
+	PeriodNumber int        `json:"period_number" db:"period_number"`
+	StartTime    string     `json:"start_time" db:"start_time"`
+	EndTime      string     `json:"end_time" db:"end_time"`
+	Room         *string    `json:"room,omitempty" db:"room"`
+	StartsOn     *time.Time `json:"starts_on,omitempty" db:"starts_on"`
+	EndsOn       *time.Time `json:"ends_on,omitempty" db:"ends_on"`
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at" db:"updated_at"`
+
+	// Joined fields
+	ClassName   string `json:"class_name,omitempty" db:"class_name"`
+	SubjectName string `json:"subject_name,omitempty" db:"subject_name"`
+}
+
+// SaveClassScheduleRequest represents the request to add or change a
+// weekly session. Days run from 0 (Sunday) to 4 (Thursday), times are
+// HH:MM and dates YYYY-MM-DD.
+type SaveClassScheduleRequest struct {
+	DayOfWeek    int    `json:"day_of_week" validate:"min=0,max=4"`
+	PeriodNumber int    `json:"period_number" validate:"required,min=1,max=12"`
+	StartTime    string `json:"start_time" validate:"required"`
+	EndTime      string `json:"end_time" validate:"required"`
+	Room         string `json:"room,omitempty"`
+	StartsOn     string `json:"starts_on,omitempty"`
+	EndsOn       string `json:"ends_on,omitempty"`
+}
+
+// ScheduleException is a day or period on which the teacher's classes do
+// not meet, such as a holiday or a cancelled session
+type ScheduleException struct {
+	ID           uuid.UUID  `json:"id" db:"id"`
+	ClassID      *uuid.UUID `json:"class_id,omitempty" db:"class_id"`
+	Date         time.Time  `json:"date" db:"date"`
+	PeriodNumber *int       `json:"period_number,omitempty" db:"period_number"`
+	Reason       *string    `json:"reason,omitempty" db:"reason"`
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+}
+
+// CreateScheduleExceptionRequest represents the request to cancel
+// sessions. Without a class it covers all the teacher's classes and
+// without a period the whole day.
+type CreateScheduleExceptionRequest struct {
+	ClassID      *uuid.UUID `json:"class_id,omitempty"`
+	Date         string     `json:"date" validate:"required"`
+	PeriodNumber *int       `json:"period_number,omitempty" validate:"omitempty,min=1,max=12"`
+	Reason       string     `json:"reason,omitempty"`
+}
+
+// ScheduledSession is one meeting of a class on a given date
+type ScheduledSession struct {
+	ScheduleID      uuid.UUID `json:"schedule_id"`
+	ClassID         uuid.UUID `json:"class_id"`
+	ClassName       string    `json:"class_name"`
+	SubjectName     string    `json:"subject_name"`
+	Date            time.Time `json:"date"`
+	PeriodNumber    int       `json:"period_number"`
+	StartTime       string    `json:"start_time"`
+	EndTime         string    `json:"end_time"`
+	DurationMinutes int       `json:"duration_minutes"`
+	Room            *string   `json:"room,omitempty"`
+	StudentCount    int       `json:"student_count"`
+	Status          string    `json:"status"`
+	AttendanceTaken bool      `json:"attendance_taken"`
+}
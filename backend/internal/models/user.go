@@ -85,6 +85,44 @@ type RefreshTokenRequest struct {
 	RefreshToken string `json:"refresh_token" validate:"required"`
 }
 
+// StudentLoginRequest represents the student login request payload. The
+// access code is issued by one of the student's teachers.
+type StudentLoginRequest struct {
+	StudentNumber string `json:"student_number" validate:"required"`
+	AccessCode    string `json:"access_code" validate:"required"`
+}
+
+// StudentAuthResponse represents the student authentication response.
+// Student tokens cannot be refreshed; the student signs in again.
+type StudentAuthResponse struct {
+	Student     Student `json:"student"`
+	AccessToken string  `json:"access_token"`
+	ExpiresIn   int64   `json:"expires_in"`
+}
+
+// ParentLoginRequest represents the parent login request payload. Either
+// the password or a one-time access code from a teacher is required.
+type ParentLoginRequest struct {
+	CivilID  string `json:"civil_id" validate:"required"`
+	Password string `json:"password,omitempty"`
+	OTP      string `json:"otp,omitempty"`
+}
+
+// ParentAuthResponse represents the parent authentication response.
+// PasswordSet is false until the parent chooses a password.
+type ParentAuthResponse struct {
+	Parent      Parent `json:"parent"`
+	PasswordSet bool   `json:"password_set"`
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+// SetParentPasswordRequest represents the request to set a parent's
+// portal password
+type SetParentPasswordRequest struct {
+	Password string `json:"password" validate:"required,min=8"`
+}
+
 // ErrorResponse represents an error response
 type ErrorResponse struct {
 	Error   bool   `json:"error"`
@@ -0,0 +1,142 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"errors"
+	"mime"
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+)
+
+// smtpServer accepts one SMTP session on a local port and sends the
+// recipient and message it received on the returned channel
+func smtpServer(t *testing.T) (host string, port int, received <-chan [2]string) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	out := make(chan [2]string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		text := textproto.NewConn(conn)
+		text.PrintfLine("220 localhost ESMTP")
+
+		var rcpt string
+		for {
+			line, err := text.ReadLine()
+			if err != nil {
+				return
+			}
+			command := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+			switch command {
+			case "EHLO", "HELO":
+				text.PrintfLine("250 localhost")
+			case "MAIL":
+				text.PrintfLine("250 OK")
+			case "RCPT":
+				rcpt = strings.Trim(strings.TrimPrefix(line, "RCPT TO:"), "<>")
+				text.PrintfLine("250 OK")
+			case "DATA":
+				text.PrintfLine("354 Go ahead")
+				data, err := text.ReadDotBytes()
+				if err != nil {
+					return
+				}
+				text.PrintfLine("250 Queued")
+				out <- [2]string{rcpt, string(data)}
+			case "QUIT":
+				text.PrintfLine("221 Bye")
+				return
+			default:
+				text.PrintfLine("502 Not implemented")
+			}
+		}
+	}()
+
+	addr := listener.Addr().(*net.TCPAddr)
+	return addr.IP.String(), addr.Port, out
+}
+
+func TestEmailNotifierSend(t *testing.T) {
+	host, port, received := smtpServer(t)
+	email := &EmailNotifier{Host: host, Port: port, From: "school@example.com"}
+	msg := Message{
+		ID:      "9",
+		Email:   "parent@example.com",
+		Subject: "غياب الطالب",
+		Body:    strings.Repeat("نحيطكم علماً بغياب ابنكم. ", 5),
+	}
+
+	if err := email.Send(context.Background(), msg); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	got := <-received
+	if got[0] != "parent@example.com" {
+		t.Errorf("recipient = %q, want parent@example.com", got[0])
+	}
+
+	reader := textproto.NewReader(bufio.NewReader(strings.NewReader(got[1])))
+	header, err := reader.ReadMIMEHeader()
+	if err != nil {
+		t.Fatalf("read header: %v", err)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(header.Get("Subject"))
+	if err != nil || subject != msg.Subject {
+		t.Errorf("subject = %q (%v), want %q", subject, err, msg.Subject)
+	}
+	if header.Get("Message-Id") != "<9@"+host+">" {
+		t.Errorf("Message-ID = %q", header.Get("Message-Id"))
+	}
+	if header.Get("Content-Transfer-Encoding") != "base64" {
+		t.Errorf("Content-Transfer-Encoding = %q, want base64", header.Get("Content-Transfer-Encoding"))
+	}
+
+	var encoded strings.Builder
+	for {
+		line, err := reader.ReadLine()
+		if err != nil {
+			break
+		}
+		if len(line) > 76 {
+			t.Errorf("body line is %d characters long", len(line))
+		}
+		encoded.WriteString(line)
+	}
+	body, err := base64.StdEncoding.DecodeString(encoded.String())
+	if err != nil || string(body) != msg.Body {
+		t.Errorf("body = %q (%v), want %q", body, err, msg.Body)
+	}
+}
+
+func TestEmailNotifierWithoutEmail(t *testing.T) {
+	email := &EmailNotifier{Host: "127.0.0.1", Port: 1, From: "school@example.com"}
+	if err := email.Send(context.Background(), Message{Phone: "99887766"}); !errors.Is(err, ErrNoRecipient) {
+		t.Errorf("err = %v, want ErrNoRecipient", err)
+	}
+}
+
+func TestEmailNotifierServerDown(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+
+	email := &EmailNotifier{Host: "127.0.0.1", Port: port, From: "school@example.com"}
+	if err := email.Send(context.Background(), Message{Email: "parent@example.com"}); err == nil {
+		t.Error("Send succeeded without a server")
+	}
+}
@@ -0,0 +1,47 @@
+package notify
+
+import (
+	"context"
+	"log"
+	"sync"
+)
+
+// Fake is a local provider for development and testing. It keeps messages
+// in memory instead of sending them and logs only their IDs, never the
+// recipient or the text.
+type Fake struct {
+	mu   sync.Mutex
+	sent []Message
+	// Err, when set, is returned by Send to simulate a provider outage
+	Err error
+}
+
+func NewFake() *Fake {
+	return &Fake{}
+}
+
+func (f *Fake) Channel() string {
+	return ChannelFake
+}
+
+func (f *Fake) Send(ctx context.Context, msg Message) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.Err != nil {
+		return f.Err
+	}
+	if msg.Phone == "" && msg.Email == "" {
+		return ErrNoRecipient
+	}
+	f.sent = append(f.sent, msg)
+	log.Printf("notify (fake): message %s not sent, fake channel", msg.ID)
+	return nil
+}
+
+// Sent returns the messages sent so far
+func (f *Fake) Sent() []Message {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Message(nil), f.sent...)
+}
@@ -0,0 +1,31 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestFakeDoesNotLogContents(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	fake := NewFake()
+	msg := Message{ID: "42", Phone: "99887766", Email: "parent@example.com", Body: "غياب الطالب"}
+	if err := fake.Send(context.Background(), msg); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	for _, secret := range []string{msg.Phone, msg.Email, msg.Body} {
+		if strings.Contains(buf.String(), secret) {
+			t.Errorf("log %q contains %q", buf.String(), secret)
+		}
+	}
+	if sent := fake.Sent(); len(sent) != 1 || sent[0] != msg {
+		t.Errorf("Sent() = %v, want [%v]", sent, msg)
+	}
+}
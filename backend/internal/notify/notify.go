@@ -0,0 +1,133 @@
+// Package notify delivers messages to parents over SMS, email or a
+// WhatsApp-style webhook. Messages are queued in the notification outbox
+// and handed to the configured notifiers in order of preference; a notifier
+// that has no address for the recipient passes the message on to the next.
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Delivery channels
+const (
+	ChannelSMS      = "sms"
+	ChannelEmail    = "email"
+	ChannelWhatsApp = "whatsapp"
+	ChannelFake     = "fake"
+)
+
+// ErrNoRecipient is returned by a notifier when the message has no address
+// it can deliver to, such as an email notifier for a parent without email
+var ErrNoRecipient = errors.New("notify: no recipient for this channel")
+
+// Message is a notification to one parent
+type Message struct {
+	ID      string
+	Phone   string
+	Email   string
+	Subject string
+	Body    string
+}
+
+// Notifier sends messages over one channel
+type Notifier interface {
+	Channel() string
+	Send(ctx context.Context, msg Message) error
+}
+
+// Deliver sends msg with the first notifier that can reach the recipient
+// and returns that notifier's channel. Errors other than ErrNoRecipient
+// stop the delivery so it can be retried later.
+func Deliver(ctx context.Context, notifiers []Notifier, msg Message) (string, error) {
+	for _, n := range notifiers {
+		err := n.Send(ctx, msg)
+		if errors.Is(err, ErrNoRecipient) {
+			continue
+		}
+		if err != nil {
+			return n.Channel(), fmt.Errorf("%s: %w", n.Channel(), err)
+		}
+		return n.Channel(), nil
+	}
+	return "", ErrNoRecipient
+}
+
+// FromEnv builds the notifiers listed in NOTIFY_CHANNELS (for example
+// "whatsapp,sms,email"), in that order. It returns no notifiers when
+// NOTIFY_CHANNELS is not set, so that messages stay queued until a channel
+// is configured; the fake notifier is only used when it is listed.
+func FromEnv() ([]Notifier, error) {
+	channels := strings.TrimSpace(os.Getenv("NOTIFY_CHANNELS"))
+	if channels == "" {
+		return nil, nil
+	}
+
+	var notifiers []Notifier
+	for _, channel := range strings.Split(channels, ",") {
+		switch strings.TrimSpace(channel) {
+		case ChannelSMS:
+			sms := &SMSNotifier{
+				URL:    os.Getenv("SMS_GATEWAY_URL"),
+				APIKey: os.Getenv("SMS_API_KEY"),
+				Sender: os.Getenv("SMS_SENDER"),
+			}
+			if sms.URL == "" {
+				return nil, errors.New("notify: SMS_GATEWAY_URL is required for the sms channel")
+			}
+			notifiers = append(notifiers, sms)
+		case ChannelEmail:
+			port, _ := strconv.Atoi(os.Getenv("SMTP_PORT"))
+			if port == 0 {
+				port = 587
+			}
+			email := &EmailNotifier{
+				Host:     os.Getenv("SMTP_HOST"),
+				Port:     port,
+				Username: os.Getenv("SMTP_USERNAME"),
+				Password: os.Getenv("SMTP_PASSWORD"),
+				From:     os.Getenv("SMTP_FROM"),
+			}
+			if email.Host == "" || email.From == "" {
+				return nil, errors.New("notify: SMTP_HOST and SMTP_FROM are required for the email channel")
+			}
+			notifiers = append(notifiers, email)
+		case ChannelWhatsApp:
+			webhook := &WebhookNotifier{
+				Name:   ChannelWhatsApp,
+				URL:    os.Getenv("WHATSAPP_WEBHOOK_URL"),
+				Secret: os.Getenv("WHATSAPP_WEBHOOK_SECRET"),
+			}
+			if webhook.URL == "" {
+				return nil, errors.New("notify: WHATSAPP_WEBHOOK_URL is required for the whatsapp channel")
+			}
+			notifiers = append(notifiers, webhook)
+		case ChannelFake:
+			notifiers = append(notifiers, NewFake())
+		default:
+			return nil, fmt.Errorf("notify: unknown channel %q", channel)
+		}
+	}
+	return notifiers, nil
+}
+
+// KuwaitPhone returns a phone number in international form without the
+// plus sign, adding Kuwait's 965 code to local eight-digit numbers. It
+// returns "" when no digits are left.
+func KuwaitPhone(phone string) string {
+	var digits strings.Builder
+	for _, r := range phone {
+		if r >= '0' && r <= '9' {
+			digits.WriteRune(r)
+		}
+	}
+	number := strings.TrimPrefix(digits.String(), "00")
+	if len(number) == 8 {
+		number = "965" + number
+	}
+	return number
+}
@@ -0,0 +1,112 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestDeliverFallsThroughWithoutRecipient(t *testing.T) {
+	email := &EmailNotifier{Host: "mail.invalid", Port: 25, From: "school@example.com"}
+	fake := NewFake()
+	msg := Message{ID: "1", Phone: "99887766", Body: "غياب"}
+
+	channel, err := Deliver(context.Background(), []Notifier{email, fake}, msg)
+	if err != nil {
+		t.Fatalf("Deliver: %v", err)
+	}
+	if channel != ChannelFake {
+		t.Errorf("channel = %q, want %q", channel, ChannelFake)
+	}
+	if sent := fake.Sent(); len(sent) != 1 || sent[0].ID != "1" {
+		t.Errorf("fake sent %v, want message 1", sent)
+	}
+}
+
+func TestDeliverStopsOnProviderError(t *testing.T) {
+	outage := errors.New("gateway down")
+	first := NewFake()
+	first.Err = outage
+	second := NewFake()
+
+	channel, err := Deliver(context.Background(), []Notifier{first, second}, Message{Phone: "99887766"})
+	if !errors.Is(err, outage) {
+		t.Fatalf("err = %v, want %v", err, outage)
+	}
+	if channel != ChannelFake {
+		t.Errorf("channel = %q, want %q", channel, ChannelFake)
+	}
+	if len(second.Sent()) != 0 {
+		t.Error("message was passed on after a provider error")
+	}
+}
+
+func TestDeliverWithoutAnyRecipient(t *testing.T) {
+	_, err := Deliver(context.Background(), []Notifier{NewFake()}, Message{Body: "text"})
+	if !errors.Is(err, ErrNoRecipient) {
+		t.Errorf("err = %v, want ErrNoRecipient", err)
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      map[string]string
+		channels []string
+		wantErr  bool
+	}{
+		{name: "unset", env: map[string]string{"NOTIFY_CHANNELS": ""}},
+		{name: "fake", env: map[string]string{"NOTIFY_CHANNELS": "fake"}, channels: []string{ChannelFake}},
+		{
+			name: "in order",
+			env: map[string]string{
+				"NOTIFY_CHANNELS":      "whatsapp, sms,email",
+				"WHATSAPP_WEBHOOK_URL": "https://wa.example.com/send",
+				"SMS_GATEWAY_URL":      "https://sms.example.com/send",
+				"SMTP_HOST":            "smtp.example.com",
+				"SMTP_FROM":            "school@example.com",
+			},
+			channels: []string{ChannelWhatsApp, ChannelSMS, ChannelEmail},
+		},
+		{name: "sms without gateway", env: map[string]string{"NOTIFY_CHANNELS": "sms", "SMS_GATEWAY_URL": ""}, wantErr: true},
+		{name: "unknown", env: map[string]string{"NOTIFY_CHANNELS": "pigeon"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+			notifiers, err := FromEnv()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(notifiers) != len(tt.channels) {
+				t.Fatalf("got %d notifiers, want %d", len(notifiers), len(tt.channels))
+			}
+			for i, n := range notifiers {
+				if n.Channel() != tt.channels[i] {
+					t.Errorf("notifier %d is %q, want %q", i, n.Channel(), tt.channels[i])
+				}
+			}
+		})
+	}
+}
+
+func TestKuwaitPhone(t *testing.T) {
+	tests := []struct {
+		phone, want string
+	}{
+		{"99887766", "96599887766"},
+		{"9988 7766", "96599887766"},
+		{"+965 9988-7766", "96599887766"},
+		{"0096599887766", "96599887766"},
+		{"+971501234567", "971501234567"},
+		{"", ""},
+		{"n/a", ""},
+	}
+	for _, tt := range tests {
+		if got := KuwaitPhone(tt.phone); got != tt.want {
+			t.Errorf("KuwaitPhone(%q) = %q, want %q", tt.phone, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// httpTimeout bounds each call to a delivery provider
+const httpTimeout = 15 * time.Second
+
+// SMSNotifier sends text messages through an HTTP SMS gateway that accepts
+// a JSON body of {"to", "from", "text"} with a bearer API key
+type SMSNotifier struct {
+	URL    string
+	APIKey string
+	Sender string
+	Client *http.Client
+}
+
+func (n *SMSNotifier) Channel() string {
+	return ChannelSMS
+}
+
+func (n *SMSNotifier) Send(ctx context.Context, msg Message) error {
+	to := KuwaitPhone(msg.Phone)
+	if to == "" {
+		return ErrNoRecipient
+	}
+
+	body, err := json.Marshal(map[string]string{
+		"to":   to,
+		"from": n.Sender,
+		"text": msg.Body,
+	})
+	if err != nil {
+		return err
+	}
+	headers := map[string]string{}
+	if n.APIKey != "" {
+		headers["Authorization"] = "Bearer " + n.APIKey
+	}
+	return postJSON(ctx, n.Client, n.URL, body, headers)
+}
+
+// postJSON posts body to url and treats any non-2xx response as a failure
+func postJSON(ctx context.Context, client *http.Client, url string, body []byte, headers map[string]string) error {
+	if client == nil {
+		client = &http.Client{Timeout: httpTimeout}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("provider returned %s: %s", resp.Status, bytes.TrimSpace(detail))
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSMSNotifierSend(t *testing.T) {
+	var got map[string]string
+	var auth, contentType string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		contentType = r.Header.Get("Content-Type")
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decode body: %v", err)
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	sms := &SMSNotifier{URL: server.URL, APIKey: "key", Sender: "School"}
+	if err := sms.Send(context.Background(), Message{Phone: "9988 7766", Body: "غياب"}); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	want := map[string]string{"to": "96599887766", "from": "School", "text": "غياب"}
+	for key, value := range want {
+		if got[key] != value {
+			t.Errorf("%s = %q, want %q", key, got[key], value)
+		}
+	}
+	if auth != "Bearer key" {
+		t.Errorf("Authorization = %q, want Bearer key", auth)
+	}
+	if contentType != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", contentType)
+	}
+}
+
+func TestSMSNotifierProviderError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "insufficient credit", http.StatusPaymentRequired)
+	}))
+	defer server.Close()
+
+	sms := &SMSNotifier{URL: server.URL}
+	err := sms.Send(context.Background(), Message{Phone: "99887766", Body: "text"})
+	if err == nil || !strings.Contains(err.Error(), "402") || !strings.Contains(err.Error(), "insufficient credit") {
+		t.Errorf("err = %v, want the provider's status and reply", err)
+	}
+}
+
+func TestSMSNotifierWithoutPhone(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("gateway called without a phone number")
+	}))
+	defer server.Close()
+
+	sms := &SMSNotifier{URL: server.URL}
+	if err := sms.Send(context.Background(), Message{Email: "parent@example.com"}); !errors.Is(err, ErrNoRecipient) {
+		t.Errorf("err = %v, want ErrNoRecipient", err)
+	}
+}
@@ -0,0 +1,59 @@
+package notify
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Placeholders lists the fields a message template may use, written as
+// {{name}} in the template
+var Placeholders = []string{
+	"student_name", "parent_name", "class_name", "subject_name", "school_name", "teacher_name",
+	"date", "day", "alert", "note",
+}
+
+var placeholderPattern = regexp.MustCompile(`{{\s*([a-z_]+)\s*}}`)
+
+var arabicWeekdays = [...]string{"الأحد", "الاثنين", "الثلاثاء", "الأربعاء", "الخميس", "الجمعة", "السبت"}
+
+// Render fills a template's placeholders from vars. Unknown placeholders
+// are left as they are.
+func Render(template string, vars map[string]string) string {
+	return placeholderPattern.ReplaceAllStringFunc(template, func(match string) string {
+		name := placeholderPattern.FindStringSubmatch(match)[1]
+		if value, ok := vars[name]; ok {
+			return value
+		}
+		return match
+	})
+}
+
+// CheckTemplate returns an error naming the first placeholder in template
+// that Render cannot fill
+func CheckTemplate(template string) error {
+	for _, match := range placeholderPattern.FindAllStringSubmatch(template, -1) {
+		known := false
+		for _, name := range Placeholders {
+			if match[1] == name {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return fmt.Errorf("unknown placeholder {{%s}}; use one of {{%s}}", match[1],
+				strings.Join(Placeholders, "}}, {{"))
+		}
+	}
+	return nil
+}
+
+// DateVars returns the date and day placeholders for a school day, with
+// the date written day first as is usual in Kuwait
+func DateVars(date time.Time) map[string]string {
+	return map[string]string{
+		"date": date.Format("02/01/2006"),
+		"day":  arabicWeekdays[date.Weekday()],
+	}
+}
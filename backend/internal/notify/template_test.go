@@ -0,0 +1,52 @@
+package notify
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRender(t *testing.T) {
+	vars := map[string]string{"student_name": "أحمد", "date": "12/10/2025"}
+	tests := []struct {
+		template, want string
+	}{
+		{"غياب الطالب {{student_name}}", "غياب الطالب أحمد"},
+		{"{{ student_name }} - {{date}}", "أحمد - 12/10/2025"},
+		{"{{class_name}}", "{{class_name}}"},
+		{"{{unknown}} {{student_name}}", "{{unknown}} أحمد"},
+		{"بدون متغيرات", "بدون متغيرات"},
+	}
+	for _, tt := range tests {
+		if got := Render(tt.template, vars); got != tt.want {
+			t.Errorf("Render(%q) = %q, want %q", tt.template, got, tt.want)
+		}
+	}
+}
+
+func TestCheckTemplate(t *testing.T) {
+	for _, template := range []string{
+		"",
+		"ولي أمر الطالب {{student_name}} المحترم، يوم {{day}} {{date}}. {{school_name}}",
+		"{{ teacher_name }}",
+	} {
+		if err := CheckTemplate(template); err != nil {
+			t.Errorf("CheckTemplate(%q) = %v, want nil", template, err)
+		}
+	}
+
+	err := CheckTemplate("{{student_name}} {{grade}}")
+	if err == nil || !strings.Contains(err.Error(), "{{grade}}") {
+		t.Errorf("CheckTemplate with {{grade}} = %v, want an error naming it", err)
+	}
+}
+
+func TestDateVars(t *testing.T) {
+	vars := DateVars(time.Date(2025, 10, 12, 0, 0, 0, 0, time.UTC))
+	if vars["date"] != "12/10/2025" {
+		t.Errorf("date = %q, want 12/10/2025", vars["date"])
+	}
+	if vars["day"] != "الأحد" {
+		t.Errorf("day = %q, want الأحد", vars["day"])
+	}
+}
@@ -0,0 +1,47 @@
+package notify
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+)
+
+// WebhookNotifier posts messages to a WhatsApp-style messaging webhook as
+// {"id", "to", "text"}. With a secret, the body is signed with HMAC-SHA256
+// in the X-Signature header so the receiver can verify it.
+type WebhookNotifier struct {
+	Name   string
+	URL    string
+	Secret string
+	Client *http.Client
+}
+
+func (n *WebhookNotifier) Channel() string {
+	return n.Name
+}
+
+func (n *WebhookNotifier) Send(ctx context.Context, msg Message) error {
+	to := KuwaitPhone(msg.Phone)
+	if to == "" {
+		return ErrNoRecipient
+	}
+
+	body, err := json.Marshal(map[string]string{
+		"id":   msg.ID,
+		"to":   to,
+		"text": msg.Body,
+	})
+	if err != nil {
+		return err
+	}
+	headers := map[string]string{}
+	if n.Secret != "" {
+		mac := hmac.New(sha256.New, []byte(n.Secret))
+		mac.Write(body)
+		headers["X-Signature"] = "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	}
+	return postJSON(ctx, n.Client, n.URL, body, headers)
+}
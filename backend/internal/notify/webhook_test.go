@@ -0,0 +1,73 @@
+package notify
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWebhookNotifierSend(t *testing.T) {
+	var body []byte
+	var signature string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		signature = r.Header.Get("X-Signature")
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	webhook := &WebhookNotifier{Name: ChannelWhatsApp, URL: server.URL, Secret: "secret"}
+	msg := Message{ID: "7", Phone: "99887766", Body: "تأخر"}
+	if err := webhook.Send(context.Background(), msg); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	var got map[string]string
+	if err := json.Unmarshal(body, &got); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	if got["id"] != "7" || got["to"] != "96599887766" || got["text"] != "تأخر" {
+		t.Errorf("body = %v", got)
+	}
+
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write(body)
+	if want := "sha256=" + hex.EncodeToString(mac.Sum(nil)); signature != want {
+		t.Errorf("X-Signature = %q, want %q", signature, want)
+	}
+}
+
+func TestWebhookNotifierUnsigned(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Signature") != "" {
+			t.Error("body signed without a secret")
+		}
+	}))
+	defer server.Close()
+
+	webhook := &WebhookNotifier{Name: ChannelWhatsApp, URL: server.URL}
+	if err := webhook.Send(context.Background(), Message{Phone: "99887766"}); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+}
+
+func TestWebhookNotifierErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	webhook := &WebhookNotifier{Name: ChannelWhatsApp, URL: server.URL}
+	if err := webhook.Send(context.Background(), Message{Phone: "99887766"}); err == nil {
+		t.Error("Send succeeded on a 500 response")
+	}
+	if err := webhook.Send(context.Background(), Message{}); !errors.Is(err, ErrNoRecipient) {
+		t.Errorf("err = %v, want ErrNoRecipient", err)
+	}
+}
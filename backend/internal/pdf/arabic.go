@@ -0,0 +1,290 @@
+package pdf
+
+import (
+	"strings"
+	"unicode"
+
+	"moalemplus/internal/arabic"
+)
+
+// PDF text is drawn glyph by glyph from left to right without any shaping
+// engine, so Arabic text has to be converted to its presentation forms and
+// reordered visually before it is handed to fpdf.
+
+// forms holds the isolated, final, initial and medial presentation forms of
+// a letter. Letters that only join to the preceding letter have no initial
+// or medial form.
+type forms [4]rune
+
+const (
+	isolated = iota
+	final
+	initial
+	medial
+)
+
+var letterForms = map[rune]forms{
+	'ء': {0xFE80, 0, 0, 0},
+	'آ': {0xFE81, 0xFE82, 0, 0},
+	'أ': {0xFE83, 0xFE84, 0, 0},
+	'ؤ': {0xFE85, 0xFE86, 0, 0},
+	'إ': {0xFE87, 0xFE88, 0, 0},
+	'ئ': {0xFE89, 0xFE8A, 0xFE8B, 0xFE8C},
+	'ا': {0xFE8D, 0xFE8E, 0, 0},
+	'ب': {0xFE8F, 0xFE90, 0xFE91, 0xFE92},
+	'ة': {0xFE93, 0xFE94, 0, 0},
+	'ت': {0xFE95, 0xFE96, 0xFE97, 0xFE98},
+	'ث': {0xFE99, 0xFE9A, 0xFE9B, 0xFE9C},
+	'ج': {0xFE9D, 0xFE9E, 0xFE9F, 0xFEA0},
+	'ح': {0xFEA1, 0xFEA2, 0xFEA3, 0xFEA4},
+	'خ': {0xFEA5, 0xFEA6, 0xFEA7, 0xFEA8},
+	'د': {0xFEA9, 0xFEAA, 0, 0},
+	'ذ': {0xFEAB, 0xFEAC, 0, 0},
+	'ر': {0xFEAD, 0xFEAE, 0, 0},
+	'ز': {0xFEAF, 0xFEB0, 0, 0},
+	'س': {0xFEB1, 0xFEB2, 0xFEB3, 0xFEB4},
+	'ش': {0xFEB5, 0xFEB6, 0xFEB7, 0xFEB8},
+	'ص': {0xFEB9, 0xFEBA, 0xFEBB, 0xFEBC},
+	'ض': {0xFEBD, 0xFEBE, 0xFEBF, 0xFEC0},
+	'ط': {0xFEC1, 0xFEC2, 0xFEC3, 0xFEC4},
+	'ظ': {0xFEC5, 0xFEC6, 0xFEC7, 0xFEC8},
+	'ع': {0xFEC9, 0xFECA, 0xFECB, 0xFECC},
+	'غ': {0xFECD, 0xFECE, 0xFECF, 0xFED0},
+	'ف': {0xFED1, 0xFED2, 0xFED3, 0xFED4},
+	'ق': {0xFED5, 0xFED6, 0xFED7, 0xFED8},
+	'ك': {0xFED9, 0xFEDA, 0xFEDB, 0xFEDC},
+	'ل': {0xFEDD, 0xFEDE, 0xFEDF, 0xFEE0},
+	'م': {0xFEE1, 0xFEE2, 0xFEE3, 0xFEE4},
+	'ن': {0xFEE5, 0xFEE6, 0xFEE7, 0xFEE8},
+	'ه': {0xFEE9, 0xFEEA, 0xFEEB, 0xFEEC},
+	'و': {0xFEED, 0xFEEE, 0, 0},
+	'ى': {0xFEEF, 0xFEF0, 0, 0},
+	'ي': {0xFEF1, 0xFEF2, 0xFEF3, 0xFEF4},
+	'پ': {0xFB56, 0xFB57, 0xFB58, 0xFB59},
+	'چ': {0xFB7A, 0xFB7B, 0xFB7C, 0xFB7D},
+	'ژ': {0xFB8A, 0xFB8B, 0, 0},
+	'ک': {0xFB8E, 0xFB8F, 0xFB90, 0xFB91},
+	'گ': {0xFB92, 0xFB93, 0xFB94, 0xFB95},
+	'ی': {0xFBFC, 0xFBFD, 0xFBFE, 0xFBFF},
+}
+
+// lamAlef maps the alef following a lam to the isolated form of the
+// ligature; the final form is the next code point
+var lamAlef = map[rune]rune{
+	'آ': 0xFEF5,
+	'أ': 0xFEF7,
+	'إ': 0xFEF9,
+	'ا': 0xFEFB,
+}
+
+const tatweel = 'ـ'
+
+// joinsBefore reports whether r connects to the following letter
+func joinsBefore(r rune) bool {
+	if r == tatweel {
+		return true
+	}
+	f, ok := letterForms[r]
+	return ok && f[initial] != 0
+}
+
+// joinsAfter reports whether r connects to the preceding letter
+func joinsAfter(r rune) bool {
+	if r == tatweel {
+		return true
+	}
+	f, ok := letterForms[r]
+	return ok && f[final] != 0
+}
+
+// neighbour returns the closest letter before (step -1) or after (step 1)
+// position i, skipping diacritics
+func neighbour(runes []rune, i, step int) rune {
+	for j := i + step; j >= 0 && j < len(runes); j += step {
+		if !arabic.IsDiacritic(runes[j]) || runes[j] == tatweel {
+			return runes[j]
+		}
+	}
+	return 0
+}
+
+// Shape replaces Arabic letters with the presentation form matching their
+// position in the word and merges lam-alef pairs into ligatures. The text
+// stays in logical order.
+func Shape(s string) string {
+	runes := []rune(s)
+	var b strings.Builder
+	b.Grow(len(s))
+
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		f, ok := letterForms[r]
+		if !ok {
+			b.WriteRune(r)
+			continue
+		}
+
+		prev := neighbour(runes, i, -1)
+		connectsPrev := prev != 0 && joinsBefore(prev) && joinsAfter(r)
+
+		next := neighbour(runes, i, 1)
+
+		// Diacritics between the lam and the alef go after the ligature
+		if r == 'ل' {
+			if ligature, ok := lamAlef[next]; ok {
+				if connectsPrev {
+					ligature++
+				}
+				b.WriteRune(ligature)
+				for i++; runes[i] != next; i++ {
+					b.WriteRune(runes[i])
+				}
+				continue
+			}
+		}
+
+		connectsNext := next != 0 && joinsBefore(r) && joinsAfter(next)
+
+		switch {
+		case connectsPrev && connectsNext:
+			b.WriteRune(f[medial])
+		case connectsPrev:
+			b.WriteRune(f[final])
+		case connectsNext:
+			b.WriteRune(f[initial])
+		default:
+			b.WriteRune(f[isolated])
+		}
+	}
+	return b.String()
+}
+
+// direction of a character for the simplified bidi pass
+type direction int
+
+const (
+	neutral direction = iota
+	ltr
+	rtl
+)
+
+func classify(r rune) direction {
+	switch {
+	case r >= 0x0590 && r <= 0x08FF, r >= 0xFB1D && r <= 0xFDFF, r >= 0xFE70 && r <= 0xFEFF:
+		if r >= 0x0660 && r <= 0x0669 {
+			// Arabic-Indic digits run left to right like other numbers
+			return ltr
+		}
+		return rtl
+	case unicode.IsLetter(r), unicode.IsDigit(r):
+		return ltr
+	default:
+		return neutral
+	}
+}
+
+var mirrored = map[rune]rune{
+	'(': ')', ')': '(',
+	'[': ']', ']': '[',
+	'{': '}', '}': '{',
+	'<': '>', '>': '<',
+	'«': '»', '»': '«',
+}
+
+// ContainsRTL reports whether s contains right-to-left text
+func ContainsRTL(s string) bool {
+	for _, r := range s {
+		if classify(r) == rtl {
+			return true
+		}
+	}
+	return false
+}
+
+// Visual shapes a single line of text and reorders it for left-to-right
+// drawing. Lines containing Arabic are laid out right to left, keeping
+// embedded Latin words and numbers in their reading order; other lines are
+// returned unchanged. Diacritics stay after their base letter so that they
+// are drawn over it.
+func Visual(line string) string {
+	if !ContainsRTL(line) {
+		return line
+	}
+
+	runes := []rune(Shape(line))
+
+	// Resolve each character's direction. Neutrals between two
+	// left-to-right characters join them, everything else follows the
+	// right-to-left paragraph.
+	dirs := make([]direction, len(runes))
+	for i, r := range runes {
+		dirs[i] = classify(r)
+		if arabic.IsDiacritic(r) && i > 0 {
+			dirs[i] = dirs[i-1]
+		}
+	}
+	for i := range dirs {
+		if dirs[i] != neutral {
+			continue
+		}
+		before, after := neutral, neutral
+		for j := i - 1; j >= 0; j-- {
+			if classify(runes[j]) != neutral {
+				before = classify(runes[j])
+				break
+			}
+		}
+		for j := i + 1; j < len(runes); j++ {
+			if classify(runes[j]) != neutral {
+				after = classify(runes[j])
+				break
+			}
+		}
+		if before == ltr && after == ltr {
+			dirs[i] = ltr
+		} else {
+			dirs[i] = rtl
+		}
+	}
+
+	// Split into runs of the same direction, then lay the runs out right
+	// to left. Left-to-right runs keep their order; right-to-left runs are
+	// reversed cluster by cluster.
+	var out []rune
+	end := len(runes)
+	for end > 0 {
+		start := end - 1
+		for start > 0 && dirs[start-1] == dirs[end-1] {
+			start--
+		}
+		run := runes[start:end]
+		if dirs[start] == ltr {
+			out = append(out, run...)
+		} else {
+			out = append(out, reverseClusters(run)...)
+		}
+		end = start
+	}
+	return string(out)
+}
+
+// reverseClusters reverses a right-to-left run, keeping each base letter
+// ahead of its diacritics and mirroring paired punctuation
+func reverseClusters(run []rune) []rune {
+	out := make([]rune, 0, len(run))
+	end := len(run)
+	for end > 0 {
+		start := end - 1
+		for start > 0 && arabic.IsDiacritic(run[start]) && run[start] != tatweel {
+			start--
+		}
+		for _, r := range run[start:end] {
+			if m, ok := mirrored[r]; ok {
+				r = m
+			}
+			out = append(out, r)
+		}
+		end = start
+	}
+	return out
+}
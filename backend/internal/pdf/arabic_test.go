@@ -0,0 +1,26 @@
+package pdf
+
+import "testing"
+
+func TestShapeLamAlef(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"isolated", "لا", "ﻻ"},
+		{"final", "سلام", "ﺳﻼﻡ"},
+		{"hamza above", "لأن", "ﻷﻥ"},
+		{"shadda between", "اللّا", "ﺍﻟﻼّ"},
+		{"fatha and shadda between", "لَّا", "ﻻَّ"},
+		{"diacritic on alef", "لاً", "ﻻً"},
+		{"lam without alef", "لم", "ﻟﻢ"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Shape(tt.in); got != tt.want {
+				t.Errorf("Shape(%q) = %U, want %U", tt.in, []rune(got), []rune(tt.want))
+			}
+		})
+	}
+}
@@ -0,0 +1,218 @@
+// Package pdf renders printable documents with right-to-left Arabic text.
+//
+// Fonts are embedded in the binary, so rendering works offline. Text passed
+// to the drawing helpers is in logical order; shaping and visual reordering
+// happen here.
+package pdf
+
+import (
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/go-fonts/dejavu/dejavusans"
+	"github.com/go-fonts/dejavu/dejavusansbold"
+	"github.com/go-pdf/fpdf"
+)
+
+const (
+	fontFamily = "dejavu"
+	margin     = 15.0
+	lineHeight = 1.5 // line height as a multiple of the font size in mm
+
+	// pageCountAlias is replaced by the total page count. It must survive
+	// visual reordering, so it contains only left-to-right letters.
+	pageCountAlias = "NBPAGES"
+)
+
+// Document is an A4 page flow with right-to-left helpers
+type Document struct {
+	pdf      *fpdf.Fpdf
+	fontSize float64
+}
+
+// New creates an empty portrait A4 document
+func New() *Document {
+	return newDocument("P")
+}
+
+// NewLandscape creates an empty landscape A4 document
+func NewLandscape() *Document {
+	return newDocument("L")
+}
+
+func newDocument(orientation string) *Document {
+	p := fpdf.New(orientation, "mm", "A4", "")
+	p.SetMargins(margin, margin, margin)
+	p.SetAutoPageBreak(true, margin)
+	p.AddUTF8FontFromBytes(fontFamily, "", dejavusans.TTF)
+	p.AddUTF8FontFromBytes(fontFamily, "B", dejavusansbold.TTF)
+	p.SetFont(fontFamily, "", 11)
+	p.AliasNbPages(pageCountAlias)
+
+	d := &Document{pdf: p, fontSize: 11}
+	p.SetFooterFunc(func() {
+		p.SetY(-margin + 4)
+		p.SetFont(fontFamily, "", 8)
+		p.CellFormat(0, 4, Visual(pageLabel(p.PageNo())), "", 0, "C", false, 0, "")
+		p.SetFont(fontFamily, "", d.fontSize)
+	})
+	p.AddPage()
+	return d
+}
+
+func pageLabel(page int) string {
+	return "صفحة " + strconv.Itoa(page) + " من " + pageCountAlias
+}
+
+// SetFont changes the current font size and weight
+func (d *Document) SetFont(size float64, bold bool) {
+	style := ""
+	if bold {
+		style = "B"
+	}
+	d.fontSize = size
+	d.pdf.SetFont(fontFamily, style, size)
+}
+
+// lineMM is the height of one text line in the current font
+func (d *Document) lineMM() float64 {
+	return d.fontSize * 0.3528 * lineHeight
+}
+
+// ContentWidth is the usable width between the margins
+func (d *Document) ContentWidth() float64 {
+	w, _ := d.pdf.GetPageSize()
+	return w - 2*margin
+}
+
+// Paragraph writes wrapped text across the full width. Text containing
+// Arabic is right aligned, anything else left aligned.
+func (d *Document) Paragraph(text string) {
+	d.Text(text, d.ContentWidth(), "")
+}
+
+// Text writes wrapped text in a box of the given width at the right edge of
+// the content area (or the left edge for left-to-right text). align may be
+// "C" to center every line; otherwise the direction of the text decides.
+func (d *Document) Text(text string, width float64, align string) {
+	if align == "" {
+		align = "L"
+		if ContainsRTL(text) {
+			align = "R"
+		}
+	}
+	x := margin
+	if align == "R" {
+		x = margin + d.ContentWidth() - width
+	}
+	for _, line := range d.Wrap(text, width) {
+		d.pdf.SetX(x)
+		d.pdf.CellFormat(width, d.lineMM(), Visual(line), "", 1, align, false, 0, "")
+	}
+}
+
+// Wrap breaks text into lines no wider than width. Words are measured in
+// their shaped form so the break points are right for Arabic.
+func (d *Document) Wrap(text string, width float64) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(text, "\n") {
+		words := strings.Fields(paragraph)
+		if len(words) == 0 {
+			lines = append(lines, "")
+			continue
+		}
+		line := words[0]
+		for _, word := range words[1:] {
+			candidate := line + " " + word
+			if d.pdf.GetStringWidth(Shape(candidate)) > width {
+				lines = append(lines, line)
+				line = word
+			} else {
+				line = candidate
+			}
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// Space adds vertical space in millimetres
+func (d *Document) Space(mm float64) {
+	d.pdf.Ln(mm)
+}
+
+// Rule draws a horizontal line across the content area
+func (d *Document) Rule() {
+	y := d.pdf.GetY()
+	d.pdf.Line(margin, y, margin+d.ContentWidth(), y)
+	d.pdf.Ln(2)
+}
+
+// AnswerLines draws n dotted lines for handwritten answers
+func (d *Document) AnswerLines(n int) {
+	d.pdf.SetDashPattern([]float64{0.5, 1}, 0)
+	for i := 0; i < n; i++ {
+		d.pdf.Ln(7)
+		y := d.pdf.GetY()
+		d.pdf.Line(margin, y, margin+d.ContentWidth(), y)
+	}
+	d.pdf.SetDashPattern([]float64{}, 0)
+	d.pdf.Ln(3)
+}
+
+// Row draws one line of cells from right to left. The first cell is the
+// rightmost one, matching the reading order of an Arabic table.
+func (d *Document) Row(cells []string, widths []float64, border bool, fill bool) {
+	borderStr := ""
+	if border {
+		borderStr = "1"
+	}
+	if fill {
+		d.pdf.SetFillColor(230, 230, 230)
+	}
+
+	total := 0.0
+	for _, w := range widths {
+		total += w
+	}
+	d.pdf.SetX(margin + d.ContentWidth() - total)
+
+	for i := len(cells) - 1; i >= 0; i-- {
+		align := "C"
+		if ContainsRTL(cells[i]) {
+			align = "R"
+		}
+		d.pdf.CellFormat(widths[i], d.lineMM()+1, Visual(cells[i]), borderStr, 0, align, fill, 0, "")
+	}
+	d.pdf.Ln(-1)
+}
+
+// Box draws a bordered box of the given height with a caption at its top
+// right, for student details such as name and number
+func (d *Document) Box(caption string, height float64) {
+	x, y := margin, d.pdf.GetY()
+	d.pdf.Rect(x, y, d.ContentWidth(), height, "D")
+	d.pdf.SetXY(x, y+1)
+	d.pdf.CellFormat(d.ContentWidth()-2, d.lineMM(), Visual(caption), "", 0, "R", false, 0, "")
+	d.pdf.SetXY(x, y+height)
+	d.pdf.Ln(3)
+}
+
+// EnsureSpace starts a new page unless mm millimetres are left on this one
+func (d *Document) EnsureSpace(mm float64) {
+	_, h := d.pdf.GetPageSize()
+	if d.pdf.GetY()+mm > h-margin {
+		d.pdf.AddPage()
+	}
+}
+
+// NewPage starts a new page
+func (d *Document) NewPage() {
+	d.pdf.AddPage()
+}
+
+// Output writes the finished document
+func (d *Document) Output(w io.Writer) error {
+	return d.pdf.Output(w)
+}
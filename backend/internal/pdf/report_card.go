@@ -0,0 +1,79 @@
+package pdf
+
+import (
+	"io"
+)
+
+// ReportCard holds everything printed on a student's semester report card.
+// Grades and descriptors are already formatted.
+type ReportCard struct {
+	SchoolName    string
+	District      string
+	StudentName   string
+	StudentNumber string
+	SchoolYear    string
+	Semester      string
+	Rows          []ReportCardRow
+	Average       string
+	AverageBand   string
+	Provisional   bool
+}
+
+// ReportCardRow is one subject on the report card
+type ReportCardRow struct {
+	Subject    string
+	Teacher    string
+	Grade      string
+	Letter     string
+	Descriptor string
+	Absences   string
+}
+
+// semesterNames are the printed names of the semesters
+var semesterNames = map[string]string{
+	"first":  "الفصل الدراسي الأول",
+	"second": "الفصل الدراسي الثاني",
+}
+
+// RenderReportCard writes a student's report card
+func RenderReportCard(w io.Writer, card *ReportCard) error {
+	d := New()
+
+	semester := semesterNames[card.Semester]
+	if semester == "" {
+		semester = card.Semester
+	}
+	left := []string{
+		"العام الدراسي: " + card.SchoolYear,
+		semester,
+	}
+	d.header(card.District, card.SchoolName, left, "الشهادة الدراسية")
+
+	d.SetFont(11, false)
+	d.Paragraph("اسم الطالب: " + card.StudentName)
+	d.Paragraph("الرقم: " + card.StudentNumber)
+	d.Space(3)
+
+	widths := []float64{55, 50, 20, 15, 25, d.ContentWidth() - 165}
+	d.SetFont(10, true)
+	d.Row([]string{"المادة", "المعلم", "الدرجة", "الرمز", "التقدير", "الغياب"}, widths, true, true)
+	d.SetFont(10, false)
+	for _, row := range card.Rows {
+		d.Row([]string{row.Subject, row.Teacher, row.Grade, row.Letter, row.Descriptor, row.Absences}, widths, true, false)
+	}
+
+	d.SetFont(10, true)
+	d.Row([]string{"المعدل", "", card.Average, "", card.AverageBand, ""}, widths, true, true)
+
+	if card.Provisional {
+		d.Space(4)
+		d.SetFont(9, false)
+		d.Paragraph("* الدرجات المميزة بنجمة غير نهائية وقد تتغير قبل نهاية الفصل الدراسي.")
+	}
+
+	d.Space(15)
+	d.SetFont(11, false)
+	half := d.ContentWidth() / 2
+	d.Row([]string{"مدير المدرسة: ....................", "ولي الأمر: ...................."}, []float64{half, half}, false, false)
+	return d.Output(w)
+}
@@ -0,0 +1,79 @@
+package pdf
+
+import (
+	"io"
+)
+
+// Table is a titled list such as a class roster or an attendance sheet.
+// Widths are relative and scaled to the page; the first column is printed
+// rightmost.
+type Table struct {
+	SchoolName string
+	District   string
+	Title      string
+	Details    []string
+	Columns    []string
+	Widths     []float64
+	Rows       [][]string
+	Notes      []string
+	Landscape  bool
+	FontSize   float64
+}
+
+// RenderTable writes a table under the school header. The column headings
+// are repeated at the top of every page.
+func RenderTable(w io.Writer, t *Table) error {
+	d := New()
+	if t.Landscape {
+		d = NewLandscape()
+	}
+	d.header(t.District, t.SchoolName, t.Details, t.Title)
+
+	size := t.FontSize
+	if size == 0 {
+		size = 10
+	}
+	widths := scaleWidths(t.Widths, len(t.Columns), d.ContentWidth())
+	headings := func() {
+		d.SetFont(size, true)
+		d.Row(t.Columns, widths, true, true)
+		d.SetFont(size, false)
+	}
+
+	headings()
+	for _, row := range t.Rows {
+		page := d.pdf.PageNo()
+		d.EnsureSpace(d.lineMM() + 1)
+		if d.pdf.PageNo() != page {
+			headings()
+		}
+		d.Row(row, widths, true, false)
+	}
+
+	if len(t.Notes) > 0 {
+		d.Space(4)
+		d.SetFont(9, false)
+		for _, note := range t.Notes {
+			d.Paragraph(note)
+		}
+	}
+	return d.Output(w)
+}
+
+// scaleWidths stretches relative column widths to fill width. Columns
+// without a width share it equally.
+func scaleWidths(relative []float64, columns int, width float64) []float64 {
+	widths := make([]float64, columns)
+	total := 0.0
+	for i := range widths {
+		widths[i] = 1
+		if i < len(relative) && relative[i] > 0 {
+			widths[i] = relative[i]
+		}
+		total += widths[i]
+	}
+	for i := range widths {
+		widths[i] = widths[i] / total * width
+	}
+	return widths
+}
@@ -0,0 +1,308 @@
+package pdf
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// blankPlaceholder marks the gap in fill-in-the-blank question text
+const blankPlaceholder = "_____"
+
+// TestSheet holds everything printed on a test or its answer key
+type TestSheet struct {
+	SchoolName      string
+	District        string
+	SubjectName     string
+	ClassName       string
+	TeacherName     string
+	Title           string
+	VariantLabel    string
+	DurationMinutes int
+	TotalPoints     int
+	Instructions    string
+	Questions       []SheetQuestion
+}
+
+// SheetQuestion is a numbered question as printed. Options are in display
+// order; Answer is only used by the answer key and must already use the
+// printed option letters.
+type SheetQuestion struct {
+	Number     int
+	Type       string
+	Text       string
+	Points     int
+	Options    []SheetOption
+	MatchLeft  []string
+	MatchRight []string
+	Answer     string
+}
+
+// SheetOption is one multiple choice option
+type SheetOption struct {
+	Label string
+	Text  string
+}
+
+// arabicLetters label options and matching items the way Arabic tests do
+var arabicLetters = []string{"أ", "ب", "ج", "د", "هـ", "و", "ز", "ح", "ط", "ي"}
+
+// OptionLabel converts an option letter (A, B, C...) to the Arabic letter
+// printed next to it
+func OptionLabel(label string) string {
+	if len(label) == 1 && label[0] >= 'A' && int(label[0]-'A') < len(arabicLetters) {
+		return arabicLetters[label[0]-'A']
+	}
+	return label
+}
+
+func itemLabel(i int) string {
+	if i < len(arabicLetters) {
+		return arabicLetters[i]
+	}
+	return fmt.Sprint(i + 1)
+}
+
+// pointsLabel writes a score with the Arabic counted noun
+func pointsLabel(n int) string {
+	switch {
+	case n == 1:
+		return "درجة واحدة"
+	case n == 2:
+		return "درجتان"
+	case n >= 3 && n <= 10:
+		return fmt.Sprintf("%d درجات", n)
+	default:
+		return fmt.Sprintf("%d درجة", n)
+	}
+}
+
+// RenderTest writes the printable test
+func RenderTest(w io.Writer, sheet *TestSheet) error {
+	d := New()
+	d.sheetHeader(sheet, sheet.Title)
+
+	// Student details
+	d.SetFont(11, false)
+	d.Box("اسم الطالب: ......................................................   الرقم: ................   الصف: ..........", 10)
+
+	if sheet.Instructions != "" {
+		d.SetFont(10, true)
+		d.Paragraph("التعليمات:")
+		d.SetFont(10, false)
+		d.Paragraph(sheet.Instructions)
+		d.Space(2)
+	}
+	d.Rule()
+
+	for _, q := range sheet.Questions {
+		d.EnsureSpace(25)
+		d.question(q)
+		d.Space(3)
+	}
+
+	d.SetFont(11, true)
+	d.Text("انتهت الأسئلة", d.ContentWidth(), "C")
+	return d.Output(w)
+}
+
+// RenderAnswerKey writes the answer key of a test
+func RenderAnswerKey(w io.Writer, sheet *TestSheet) error {
+	d := New()
+	d.sheetHeader(sheet, "نموذج الإجابة - "+sheet.Title)
+
+	widths := []float64{20, d.ContentWidth() - 50, 30}
+	d.SetFont(10, true)
+	d.Row([]string{"السؤال", "الإجابة", "الدرجة"}, widths, true, true)
+	d.SetFont(10, false)
+
+	for _, q := range sheet.Questions {
+		lines := d.Wrap(formatAnswer(q), widths[1]-2)
+		for i, line := range lines {
+			number, points := "", ""
+			if i == 0 {
+				number, points = fmt.Sprint(q.Number), fmt.Sprint(q.Points)
+			}
+			d.Row([]string{number, line, points}, widths, true, false)
+		}
+	}
+
+	d.SetFont(10, true)
+	d.Row([]string{"المجموع", "", fmt.Sprint(sheet.TotalPoints)}, widths, true, true)
+	return d.Output(w)
+}
+
+// sheetHeader prints the ministry and school block, the test details and
+// the title
+func (d *Document) sheetHeader(sheet *TestSheet, title string) {
+	var left []string
+	if sheet.SubjectName != "" {
+		left = append(left, "المادة: "+sheet.SubjectName)
+	}
+	if sheet.ClassName != "" {
+		left = append(left, "الصف: "+sheet.ClassName)
+	}
+	if sheet.TeacherName != "" {
+		left = append(left, "المعلم: "+sheet.TeacherName)
+	}
+	left = append(left, fmt.Sprintf("الزمن: %d دقيقة", sheet.DurationMinutes))
+	left = append(left, "الدرجة الكلية: "+pointsLabel(sheet.TotalPoints))
+	if sheet.VariantLabel != "" {
+		left = append(left, "النموذج: "+sheet.VariantLabel)
+	}
+
+	d.header(sheet.District, sheet.SchoolName, left, title)
+}
+
+// header prints the ministry and school block on the right, details on the
+// left and the title beneath them
+func (d *Document) header(district, schoolName string, left []string, title string) {
+	right := []string{"دولة الكويت", "وزارة التربية"}
+	if district != "" {
+		right = append(right, "منطقة "+district+" التعليمية")
+	}
+	if schoolName != "" {
+		right = append(right, schoolName)
+	}
+
+	d.SetFont(10, false)
+	half := d.ContentWidth() / 2
+	top := d.pdf.GetY()
+	for i, line := range right {
+		d.pdf.SetXY(margin+half, top+float64(i)*d.lineMM())
+		d.pdf.CellFormat(half, d.lineMM(), Visual(line), "", 0, "R", false, 0, "")
+	}
+	for i, line := range left {
+		d.pdf.SetXY(margin, top+float64(i)*d.lineMM())
+		d.pdf.CellFormat(half, d.lineMM(), Visual(line), "", 0, "R", false, 0, "")
+	}
+	rows := len(right)
+	if len(left) > rows {
+		rows = len(left)
+	}
+	d.pdf.SetXY(margin, top+float64(rows)*d.lineMM())
+	d.Space(2)
+
+	d.SetFont(14, true)
+	d.Text(title, d.ContentWidth(), "C")
+	d.Space(2)
+	d.Rule()
+}
+
+// question prints a numbered question followed by the answer area for its
+// type
+func (d *Document) question(q SheetQuestion) {
+	text := strings.ReplaceAll(q.Text, blankPlaceholder, "....................")
+
+	d.SetFont(11, true)
+	d.Paragraph(fmt.Sprintf("%d. %s (%s)", q.Number, text, pointsLabel(q.Points)))
+	d.SetFont(11, false)
+
+	indent := d.ContentWidth() - 8
+	switch q.Type {
+	case "multiple_choice":
+		for _, option := range q.Options {
+			d.Text(OptionLabel(option.Label)+") "+option.Text, indent, "R")
+		}
+	case "true_false":
+		d.Text("(    ) صح          (    ) خطأ", indent, "R")
+	case "fill_blank":
+		if !strings.Contains(q.Text, blankPlaceholder) {
+			d.AnswerLines(1)
+		}
+	case "short_answer":
+		d.AnswerLines(2)
+	case "essay":
+		d.AnswerLines(8)
+	case "matching":
+		d.matching(q)
+	}
+}
+
+// matching prints the two columns of a matching question. The right-hand
+// column is sorted so that it does not give away the pairs.
+func (d *Document) matching(q SheetQuestion) {
+	right := sortedItems(q.MatchRight)
+	rows := len(q.MatchLeft)
+	if len(right) > rows {
+		rows = len(right)
+	}
+
+	side := (d.ContentWidth() - 36) / 2
+	widths := []float64{10, side, 16, 10, side}
+	d.SetFont(10, true)
+	d.Row([]string{"", "العمود الأول", "", "", "العمود الثاني"}, widths, false, false)
+	d.SetFont(11, false)
+	for i := 0; i < rows; i++ {
+		cells := []string{"", "", "", "", ""}
+		if i < len(q.MatchLeft) {
+			cells[0], cells[1], cells[2] = fmt.Sprint(i+1), q.MatchLeft[i], "(    )"
+		}
+		if i < len(right) {
+			cells[3], cells[4] = itemLabel(i), right[i]
+		}
+		d.Row(cells, widths, false, false)
+	}
+}
+
+func sortedItems(items []string) []string {
+	sorted := make([]string, len(items))
+	copy(sorted, items)
+	sort.Strings(sorted)
+	return sorted
+}
+
+// formatAnswer writes a question's answer for the key
+func formatAnswer(q SheetQuestion) string {
+	switch q.Type {
+	case "multiple_choice":
+		for _, option := range q.Options {
+			if option.Label == q.Answer {
+				return OptionLabel(option.Label) + ") " + option.Text
+			}
+		}
+		return OptionLabel(q.Answer)
+	case "true_false":
+		if q.Answer == "true" {
+			return "صح"
+		}
+		return "خطأ"
+	case "fill_blank", "short_answer":
+		accepted := strings.Split(q.Answer, "|")
+		for i, answer := range accepted {
+			if value, tolerance, ok := strings.Cut(answer, ":"); ok && isNumber(value) && isNumber(tolerance) {
+				accepted[i] = value + " ± " + tolerance
+			}
+		}
+		return strings.Join(accepted, " أو ")
+	case "matching":
+		var pairs map[string]string
+		if err := json.Unmarshal([]byte(q.Answer), &pairs); err != nil {
+			return q.Answer
+		}
+		right := sortedItems(q.MatchRight)
+		var parts []string
+		for i, left := range q.MatchLeft {
+			for j, item := range right {
+				if item == pairs[left] {
+					parts = append(parts, fmt.Sprintf("%d - %s", i+1, itemLabel(j)))
+					break
+				}
+			}
+		}
+		return strings.Join(parts, "   ")
+	case "essay":
+		if q.Answer == "" {
+			return "تصحح حسب معايير التقييم"
+		}
+	}
+	return q.Answer
+}
+
+func isNumber(s string) bool {
+	_, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	return err == nil
+}
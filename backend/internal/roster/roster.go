@@ -0,0 +1,245 @@
+// Package roster parses class rosters exported by the school administration
+// into student requests.
+//
+// Rosters come as CSV files or Excel workbooks with a header row. Columns
+// are matched by their English field name or the Arabic headings used on
+// ministry sheets. Like the question importer, parsing only maps the rows;
+// the caller validates them before anything is written.
+package roster
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"moalemplus/internal/arabic"
+	"moalemplus/internal/models"
+)
+
+// Supported roster formats
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+// Record is one student row together with its row number in the sheet. Err
+// is set when the row could not be mapped.
+type Record struct {
+	Row     int
+	Student models.CreateStudentRequest
+	Err     error
+}
+
+// sheetRow is a row of cells read from a file
+type sheetRow struct {
+	Number int
+	Cells  []string
+	// Numeric marks cells stored as numbers in a workbook, so dates can be
+	// told apart from text
+	Numeric []bool
+}
+
+// headerAliases maps normalized column headings to student fields
+var headerAliases = map[string]string{
+	"student_number": "student_number",
+	"رقم الطالب":     "student_number",
+	"الرقم":          "student_number",
+	"civil_id":       "civil_id",
+	"الرقم المدني":   "civil_id",
+	"first_name":     "first_name",
+	"الاسم الاول":    "first_name",
+	"last_name":      "last_name",
+	"اسم العائله":    "last_name",
+	"arabic_name":    "arabic_name",
+	"الاسم":          "arabic_name",
+	"اسم الطالب":     "arabic_name",
+	"الاسم بالعربي":  "arabic_name",
+	"date_of_birth":  "date_of_birth",
+	"تاريخ الميلاد":  "date_of_birth",
+	"gender":         "gender",
+	"الجنس":          "gender",
+	"nationality":    "nationality",
+	"الجنسيه":        "nationality",
+	"address":        "address",
+	"العنوان":        "address",
+}
+
+// genders maps the ways a sheet may write the gender
+var genders = map[string]string{
+	"male": "male", "m": "male", "ذكر": "male",
+	"female": "female", "f": "female", "انثي": "female",
+}
+
+// dateLayouts are the date formats accepted in text cells
+var dateLayouts = []string{"2006-01-02", "02/01/2006", "2/1/2006", "2006/01/02", "02-01-2006"}
+
+// Parse reads all rows of the given format from r
+func Parse(format string, r io.Reader) ([]Record, error) {
+	var (
+		rows []sheetRow
+		err  error
+	)
+
+	switch format {
+	case FormatCSV:
+		rows, err = readCSV(r)
+	case FormatXLSX:
+		rows, err = readXLSX(r)
+	default:
+		return nil, fmt.Errorf("unsupported roster format %q", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, errors.New("Roster is empty")
+	}
+
+	columns := map[string]int{}
+	for i, name := range rows[0].Cells {
+		if field, ok := headerAliases[arabic.Normalize(name)]; ok {
+			if _, seen := columns[field]; !seen {
+				columns[field] = i
+			}
+		}
+	}
+	for _, required := range []string{"student_number", "arabic_name"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("Roster header must include a %s column", required)
+		}
+	}
+
+	var records []Record
+	for _, row := range rows[1:] {
+		if isBlankRow(row.Cells) {
+			continue
+		}
+		records = append(records, mapRow(row, columns))
+	}
+	return records, nil
+}
+
+// DetectFormat guesses the roster format from a file name
+func DetectFormat(filename string) string {
+	name := strings.ToLower(filename)
+	switch {
+	case strings.HasSuffix(name, ".csv"):
+		return FormatCSV
+	case strings.HasSuffix(name, ".xlsx"):
+		return FormatXLSX
+	}
+	return ""
+}
+
+func mapRow(row sheetRow, columns map[string]int) Record {
+	get := func(field string) string {
+		if i, ok := columns[field]; ok && i < len(row.Cells) {
+			return strings.TrimSpace(row.Cells[i])
+		}
+		return ""
+	}
+
+	record := Record{Row: row.Number}
+	s := &record.Student
+	s.StudentNumber = strings.ToUpper(arabic.WesternDigits(get("student_number")))
+	s.CivilID = arabic.WesternDigits(get("civil_id"))
+	s.FirstName = get("first_name")
+	s.LastName = get("last_name")
+	s.ArabicName = strings.Join(strings.Fields(get("arabic_name")), " ")
+	s.Nationality = get("nationality")
+	s.Address = get("address")
+
+	// Fall back to the Arabic name for the first and last name columns that
+	// ministry sheets usually leave out
+	names := strings.Fields(s.ArabicName)
+	if s.FirstName == "" && len(names) > 0 {
+		s.FirstName = names[0]
+	}
+	if s.LastName == "" && len(names) > 1 {
+		s.LastName = names[len(names)-1]
+	}
+
+	if gender := get("gender"); gender != "" {
+		s.Gender = genders[arabic.Normalize(gender)]
+		if s.Gender == "" {
+			record.Err = fmt.Errorf("Unknown gender %q", gender)
+		}
+	}
+
+	if dob := get("date_of_birth"); dob != "" {
+		i := columns["date_of_birth"]
+		numeric := i < len(row.Numeric) && row.Numeric[i]
+		date, err := parseDate(arabic.WesternDigits(dob), numeric)
+		if err != nil {
+			record.Err = err
+		} else {
+			s.DateOfBirth = date.Format("2006-01-02")
+		}
+	}
+	return record
+}
+
+// parseDate reads a date cell. Workbooks store dates as the number of days
+// since 1899-12-30.
+func parseDate(s string, numeric bool) (time.Time, error) {
+	if numeric {
+		serial, err := strconv.ParseFloat(s, 64)
+		if err == nil {
+			days := int(math.Floor(serial))
+			return time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC).AddDate(0, 0, days), nil
+		}
+	}
+	for _, layout := range dateLayouts {
+		if date, err := time.Parse(layout, s); err == nil {
+			return date, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("Invalid date of birth %q (use YYYY-MM-DD or DD/MM/YYYY)", s)
+}
+
+// readCSV reads every row of a CSV file
+func readCSV(r io.Reader) ([]sheetRow, error) {
+	reader := csv.NewReader(stripBOM(r))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var rows []sheetRow
+	for number := 1; ; number++ {
+		fields, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV row %d: %w", number, err)
+		}
+		rows = append(rows, sheetRow{Number: number, Cells: fields})
+	}
+	return rows, nil
+}
+
+func isBlankRow(cells []string) bool {
+	for _, cell := range cells {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// stripBOM drops the UTF-8 byte order mark that Excel prepends to CSV exports
+func stripBOM(r io.Reader) io.Reader {
+	buf := make([]byte, 3)
+	n, err := io.ReadFull(r, buf)
+	if err != nil {
+		return io.MultiReader(strings.NewReader(string(buf[:n])), r)
+	}
+	if buf[0] == 0xEF && buf[1] == 0xBB && buf[2] == 0xBF {
+		return r
+	}
+	return io.MultiReader(strings.NewReader(string(buf)), r)
+}
@@ -0,0 +1,202 @@
+package roster
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// maxXLSXPartSize caps how much of one workbook part is read, so a
+// compressed bomb cannot exhaust memory
+const maxXLSXPartSize = 20 << 20
+
+// maxXLSXColumns is the number of columns Excel allows in a sheet
+const maxXLSXColumns = 16384
+
+type xlsxWorkbook struct {
+	Sheets []struct {
+		ID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xlsxRelationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+// xlsxText is a string item: plain text or formatted runs
+type xlsxText struct {
+	T    string `xml:"t"`
+	Runs []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xlsxText) String() string {
+	if len(t.Runs) == 0 {
+		return t.T
+	}
+	var b strings.Builder
+	for _, run := range t.Runs {
+		b.WriteString(run.T)
+	}
+	return b.String()
+}
+
+type xlsxSharedStrings struct {
+	Items []xlsxText `xml:"si"`
+}
+
+type xlsxSheet struct {
+	Rows []struct {
+		Number int `xml:"r,attr"`
+		Cells  []struct {
+			Ref    string   `xml:"r,attr"`
+			Type   string   `xml:"t,attr"`
+			Value  string   `xml:"v"`
+			Inline xlsxText `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+// readXLSX reads the rows of the first worksheet of an Excel workbook
+func readXLSX(r io.Reader) ([]sheetRow, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read workbook: %w", err)
+	}
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, errors.New("File is not a valid Excel workbook (.xlsx)")
+	}
+	parts := map[string]*zip.File{}
+	for _, f := range archive.File {
+		parts[f.Name] = f
+	}
+
+	sheetPath, err := firstSheetPath(parts)
+	if err != nil {
+		return nil, err
+	}
+
+	var shared xlsxSharedStrings
+	if f, ok := parts["xl/sharedStrings.xml"]; ok {
+		if err := decodePart(f, &shared); err != nil {
+			return nil, err
+		}
+	}
+
+	f, ok := parts[sheetPath]
+	if !ok {
+		return nil, errors.New("Workbook has no worksheet")
+	}
+	var sheet xlsxSheet
+	if err := decodePart(f, &sheet); err != nil {
+		return nil, err
+	}
+
+	rows := make([]sheetRow, 0, len(sheet.Rows))
+	for i, row := range sheet.Rows {
+		number := row.Number
+		if number == 0 {
+			number = i + 1
+		}
+		out := sheetRow{Number: number}
+		for j, cell := range row.Cells {
+			col := columnIndex(cell.Ref)
+			if col < 0 {
+				col = j
+			}
+			if col >= maxXLSXColumns {
+				continue
+			}
+			for len(out.Cells) <= col {
+				out.Cells = append(out.Cells, "")
+				out.Numeric = append(out.Numeric, false)
+			}
+
+			switch cell.Type {
+			case "s":
+				index, err := strconv.Atoi(cell.Value)
+				if err == nil && index >= 0 && index < len(shared.Items) {
+					out.Cells[col] = shared.Items[index].String()
+				}
+			case "inlineStr":
+				out.Cells[col] = cell.Inline.String()
+			case "", "n":
+				out.Cells[col] = cell.Value
+				out.Numeric[col] = cell.Value != ""
+			default:
+				out.Cells[col] = cell.Value
+			}
+		}
+		rows = append(rows, out)
+	}
+	sort.SliceStable(rows, func(a, b int) bool { return rows[a].Number < rows[b].Number })
+	return rows, nil
+}
+
+// firstSheetPath finds the part of the workbook's first sheet
+func firstSheetPath(parts map[string]*zip.File) (string, error) {
+	var workbook xlsxWorkbook
+	var rels xlsxRelationships
+	wb, ok1 := parts["xl/workbook.xml"]
+	rel, ok2 := parts["xl/_rels/workbook.xml.rels"]
+	if ok1 && ok2 {
+		if err := decodePart(wb, &workbook); err != nil {
+			return "", err
+		}
+		if err := decodePart(rel, &rels); err != nil {
+			return "", err
+		}
+		if len(workbook.Sheets) > 0 {
+			for _, r := range rels.Relationships {
+				if r.ID != workbook.Sheets[0].ID {
+					continue
+				}
+				if strings.HasPrefix(r.Target, "/") {
+					return strings.TrimPrefix(r.Target, "/"), nil
+				}
+				return path.Join("xl", r.Target), nil
+			}
+		}
+	}
+	if _, ok := parts["xl/worksheets/sheet1.xml"]; ok {
+		return "xl/worksheets/sheet1.xml", nil
+	}
+	return "", errors.New("Workbook has no worksheet")
+}
+
+func decodePart(f *zip.File, v interface{}) error {
+	rc, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", f.Name, err)
+	}
+	defer rc.Close()
+	if err := xml.NewDecoder(io.LimitReader(rc, maxXLSXPartSize)).Decode(v); err != nil {
+		return fmt.Errorf("failed to read %s: %w", f.Name, err)
+	}
+	return nil
+}
+
+// columnIndex converts the letters of a cell reference such as "C12" to a
+// zero-based column index, or -1 when the reference has none
+func columnIndex(ref string) int {
+	col := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		col = col*26 + int(r-'A'+1)
+	}
+	return col - 1
+}
@@ -0,0 +1,145 @@
+// Package schoolcal works out a school's instructional days from its term
+// dates and holidays, and places holidays that recur every year, in the
+// Gregorian or the Hijri calendar, within a school year.
+package schoolcal
+
+import (
+	"fmt"
+	"time"
+
+	"moalemplus/internal/hijri"
+)
+
+// Calendars a recurring event can follow
+const (
+	CalendarGregorian = "gregorian"
+	CalendarHijri     = "hijri"
+)
+
+// Span is a run of days from Start to End, both included
+type Span struct {
+	Start, End time.Time
+}
+
+// Contains reports whether the day falls within the span
+func (s Span) Contains(day time.Time) bool {
+	return !day.Before(s.Start) && !day.After(s.End)
+}
+
+// Rule is a holiday that falls on the same date every year
+type Rule struct {
+	Calendar string
+	Month    int
+	Day      int
+	Days     int
+}
+
+// Occurrences returns the spans of the rule that start between from and
+// to. A Hijri date comes round about 11 days earlier each Gregorian year,
+// so it can occur twice in one school year.
+func (r Rule) Occurrences(from, to time.Time) []Span {
+	days := r.Days
+	if days < 1 {
+		days = 1
+	}
+
+	var starts []time.Time
+	switch r.Calendar {
+	case CalendarGregorian:
+		for year := from.Year(); year <= to.Year(); year++ {
+			start := time.Date(year, time.Month(r.Month), r.Day, 0, 0, 0, 0, time.UTC)
+			if start.Day() == r.Day {
+				starts = append(starts, start)
+			}
+		}
+	case CalendarHijri:
+		for year := hijri.FromGregorian(from).Year; year <= hijri.FromGregorian(to).Year; year++ {
+			date := hijri.Date{Year: year, Month: r.Month, Day: r.Day}
+			if date.Validate() != nil {
+				// The 30th of a 29-day month falls on the 1st of the next
+				date.Day = hijri.MonthLength(year, r.Month)
+				starts = append(starts, date.Gregorian().AddDate(0, 0, 1))
+				continue
+			}
+			starts = append(starts, date.Gregorian())
+		}
+	}
+
+	var spans []Span
+	for _, start := range starts {
+		if start.Before(from) || start.After(to) {
+			continue
+		}
+		spans = append(spans, Span{start, start.AddDate(0, 0, days-1)})
+	}
+	return spans
+}
+
+// YearRange returns the first and last day of a "2025-2026" style school
+// year, which runs from August to July
+func YearRange(schoolYear string) (time.Time, time.Time, error) {
+	var start, end int
+	if _, err := fmt.Sscanf(schoolYear, "%d-%d", &start, &end); err != nil || end != start+1 {
+		return time.Time{}, time.Time{}, fmt.Errorf("schoolcal: invalid school year %q", schoolYear)
+	}
+	from := time.Date(start, time.August, 1, 0, 0, 0, 0, time.UTC)
+	return from, from.AddDate(1, 0, -1), nil
+}
+
+// SchoolYear returns the school year a day belongs to
+func SchoolYear(day time.Time) string {
+	year := day.Year()
+	if day.Month() < time.August {
+		year--
+	}
+	return fmt.Sprintf("%d-%d", year, year+1)
+}
+
+// IsWeekend reports whether the day is a Friday or a Saturday
+func IsWeekend(day time.Time) bool {
+	return day.Weekday() == time.Friday || day.Weekday() == time.Saturday
+}
+
+// Calendar is a school's terms and holidays
+type Calendar struct {
+	Terms    []Span
+	Holidays []Span
+}
+
+// HasTerms reports whether any term dates are known. Without them every
+// weekday that is not a holiday counts as a school day.
+func (c *Calendar) HasTerms() bool {
+	return len(c.Terms) > 0
+}
+
+// IsSchoolDay reports whether lessons are held on the day
+func (c *Calendar) IsSchoolDay(day time.Time) bool {
+	if IsWeekend(day) {
+		return false
+	}
+	for _, holiday := range c.Holidays {
+		if holiday.Contains(day) {
+			return false
+		}
+	}
+	if !c.HasTerms() {
+		return true
+	}
+	for _, term := range c.Terms {
+		if term.Contains(day) {
+			return true
+		}
+	}
+	return false
+}
+
+// SchoolDays returns the school days from from to to, both included
+func (c *Calendar) SchoolDays(from, to time.Time) []time.Time {
+	var days []time.Time
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		if c.IsSchoolDay(day) {
+			days = append(days, day)
+		}
+	}
+	return days
+}
@@ -0,0 +1,219 @@
+// Package testgen picks questions from the question bank to fill a test
+// blueprint.
+//
+// Generation is deterministic: the same candidates, blueprint and seed
+// always produce the same selection, so a generated test can be rebuilt or
+// audited later from the seed alone.
+package testgen
+
+import (
+	"bytes"
+	"math/rand"
+	"sort"
+
+	"github.com/google/uuid"
+)
+
+// Difficulty levels, matching questions.difficulty_level
+const (
+	Easy   = "easy"
+	Medium = "medium"
+	Hard   = "hard"
+)
+
+// Levels lists the difficulty levels in blueprint order
+var Levels = []string{Easy, Medium, Hard}
+
+// MinAnswersForRating is the number of recorded answers a question needs
+// before its measured difficulty rating overrides the level set by the
+// teacher
+const MinAnswersForRating = 20
+
+// Candidate is a question that may be picked for a test
+type Candidate struct {
+	ID               uuid.UUID
+	UnitID           uuid.UUID // uuid.Nil when the question has no unit
+	QuestionType     string
+	DifficultyLevel  string
+	Points           int
+	DifficultyRating float64 // 0 (everyone answers correctly) to 1 (nobody does)
+	TotalAnswers     int
+}
+
+// Blueprint describes the test to generate
+type Blueprint struct {
+	// Counts is the number of questions wanted per difficulty level
+	Counts map[string]int
+	// TargetPoints, when positive, rescales the picked questions' points so
+	// that they add up to exactly this total
+	TargetPoints int
+}
+
+// Pick is a selected question together with the points it is worth in the
+// generated test
+type Pick struct {
+	Candidate
+	Difficulty string
+	Points     int
+}
+
+// Shortfall reports a blueprint cell the bank could not fill
+type Shortfall struct {
+	DifficultyLevel string
+	Requested       int
+	Available       int
+}
+
+// Result is the outcome of a generation run
+type Result struct {
+	Picks       []Pick
+	Shortfalls  []Shortfall
+	TotalPoints int
+}
+
+// Difficulty returns the level a candidate counts as. Once enough students
+// have answered it, the measured rating wins over the teacher's label.
+func Difficulty(c Candidate) string {
+	if c.TotalAnswers >= MinAnswersForRating {
+		switch {
+		case c.DifficultyRating < 0.3:
+			return Easy
+		case c.DifficultyRating > 0.7:
+			return Hard
+		default:
+			return Medium
+		}
+	}
+	return c.DifficultyLevel
+}
+
+// Generate selects questions for every difficulty level of the blueprint.
+// Within a level, picks rotate through the curriculum units so the test
+// covers them evenly instead of drawing everything from the largest unit.
+func Generate(candidates []Candidate, bp Blueprint, seed int64) Result {
+	rng := rand.New(rand.NewSource(seed))
+
+	// Sort first so the shuffle does not depend on the database's row order
+	sorted := make([]Candidate, len(candidates))
+	copy(sorted, candidates)
+	sort.Slice(sorted, func(i, j int) bool {
+		return bytes.Compare(sorted[i].ID[:], sorted[j].ID[:]) < 0
+	})
+
+	byLevel := map[string][]Candidate{}
+	for _, c := range sorted {
+		level := Difficulty(c)
+		byLevel[level] = append(byLevel[level], c)
+	}
+
+	var result Result
+	for _, level := range Levels {
+		wanted := bp.Counts[level]
+		if wanted <= 0 {
+			continue
+		}
+		pool := byLevel[level]
+		picked := pickRoundRobin(pool, wanted, rng)
+		for _, c := range picked {
+			result.Picks = append(result.Picks, Pick{Candidate: c, Difficulty: level, Points: c.Points})
+		}
+		if len(picked) < wanted {
+			result.Shortfalls = append(result.Shortfalls, Shortfall{
+				DifficultyLevel: level,
+				Requested:       wanted,
+				Available:       len(pool),
+			})
+		}
+	}
+
+	if bp.TargetPoints > 0 {
+		DistributePoints(result.Picks, bp.TargetPoints)
+	}
+	for _, p := range result.Picks {
+		result.TotalPoints += p.Points
+	}
+	return result
+}
+
+// pickRoundRobin shuffles each unit's questions and then takes one question
+// per unit in turn until n questions are picked or the pool runs out
+func pickRoundRobin(pool []Candidate, n int, rng *rand.Rand) []Candidate {
+	var units []uuid.UUID
+	byUnit := map[uuid.UUID][]Candidate{}
+	for _, c := range pool {
+		if _, ok := byUnit[c.UnitID]; !ok {
+			units = append(units, c.UnitID)
+		}
+		byUnit[c.UnitID] = append(byUnit[c.UnitID], c)
+	}
+
+	rng.Shuffle(len(units), func(i, j int) { units[i], units[j] = units[j], units[i] })
+	for _, unit := range units {
+		list := byUnit[unit]
+		rng.Shuffle(len(list), func(i, j int) { list[i], list[j] = list[j], list[i] })
+	}
+
+	var picked []Candidate
+	for len(picked) < n {
+		progress := false
+		for _, unit := range units {
+			if len(picked) == n {
+				break
+			}
+			if list := byUnit[unit]; len(list) > 0 {
+				picked = append(picked, list[0])
+				byUnit[unit] = list[1:]
+				progress = true
+			}
+		}
+		if !progress {
+			break
+		}
+	}
+	return picked
+}
+
+// DistributePoints rescales the picks' points in proportion to their bank
+// points so that they sum to exactly target. Every pick keeps at least one
+// point; leftover points go to the largest fractional remainders first.
+// When target is smaller than the number of picks each pick gets one point.
+func DistributePoints(picks []Pick, target int) {
+	if len(picks) == 0 {
+		return
+	}
+	if target <= len(picks) {
+		for i := range picks {
+			picks[i].Points = 1
+		}
+		return
+	}
+
+	bankTotal := 0
+	for _, p := range picks {
+		bankTotal += p.Candidate.Points
+	}
+
+	// Hand out one point each, then share the rest proportionally
+	remaining := target - len(picks)
+	type share struct {
+		index     int
+		remainder float64
+	}
+	shares := make([]share, len(picks))
+	assigned := 0
+	for i, p := range picks {
+		exact := float64(remaining) * float64(p.Candidate.Points) / float64(bankTotal)
+		whole := int(exact)
+		picks[i].Points = 1 + whole
+		assigned += whole
+		shares[i] = share{index: i, remainder: exact - float64(whole)}
+	}
+
+	sort.SliceStable(shares, func(i, j int) bool {
+		return shares[i].remainder > shares[j].remainder
+	})
+	for i := 0; assigned < remaining; i++ {
+		picks[shares[i%len(shares)].index].Points++
+		assigned++
+	}
+}
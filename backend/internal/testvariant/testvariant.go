@@ -0,0 +1,116 @@
+// Package testvariant derives printed variants (A, B, C...) of a test.
+//
+// A variant shuffles the question order and the options of every multiple
+// choice question. Students answer with the letters printed on their
+// variant, so answers must be mapped back to the canonical letters with
+// CanonicalAnswer before they are compared with questions.correct_answer.
+package testvariant
+
+import (
+	"hash/fnv"
+	"math/rand"
+	"sort"
+
+	"github.com/google/uuid"
+
+	"moalemplus/internal/models"
+)
+
+// Item is a question of the canonical test, in canonical order
+type Item struct {
+	QuestionID uuid.UUID
+	// OptionLabels are the multiple choice option letters; empty for other
+	// question types, whose options are never shuffled
+	OptionLabels []string
+}
+
+// Label returns the variant label for a zero-based index: A, B, ... Z, AA
+func Label(index int) string {
+	label := ""
+	for index >= 0 {
+		label = string(rune('A'+index%26)) + label
+		index = index/26 - 1
+	}
+	return label
+}
+
+// DefaultSeed derives a stable seed from the test ID so that regenerating
+// variants without an explicit seed yields the same forms
+func DefaultSeed(testID uuid.UUID) int64 {
+	h := fnv.New64a()
+	h.Write(testID[:])
+	return int64(h.Sum64() >> 1)
+}
+
+// VariantSeed is the seed of the variant at index, derived from the base seed
+func VariantSeed(base int64, index int) int64 {
+	return base + int64(index)
+}
+
+// Build lays out one variant from its seed. The same items and seed always
+// give the same layout.
+func Build(items []Item, seed int64) []models.VariantQuestion {
+	rng := rand.New(rand.NewSource(seed))
+
+	layout := make([]models.VariantQuestion, len(items))
+	for i, item := range items {
+		layout[i] = models.VariantQuestion{QuestionID: item.QuestionID}
+		if len(item.OptionLabels) < 2 {
+			continue
+		}
+
+		displayed := make([]string, len(item.OptionLabels))
+		copy(displayed, item.OptionLabels)
+		sort.Strings(displayed)
+		canonical := make([]string, len(displayed))
+		copy(canonical, displayed)
+		rng.Shuffle(len(canonical), func(a, b int) { canonical[a], canonical[b] = canonical[b], canonical[a] })
+
+		layout[i].OptionMap = make(map[string]string, len(displayed))
+		for j, label := range displayed {
+			layout[i].OptionMap[label] = canonical[j]
+		}
+	}
+
+	rng.Shuffle(len(layout), func(a, b int) { layout[a], layout[b] = layout[b], layout[a] })
+	return layout
+}
+
+// find returns the layout entry of a question
+func find(layout []models.VariantQuestion, questionID uuid.UUID) *models.VariantQuestion {
+	for i := range layout {
+		if layout[i].QuestionID == questionID {
+			return &layout[i]
+		}
+	}
+	return nil
+}
+
+// CanonicalAnswer maps an answer given on the variant back to the canonical
+// option letter. Answers to questions without an option map are returned
+// unchanged.
+func CanonicalAnswer(layout []models.VariantQuestion, questionID uuid.UUID, answer string) string {
+	entry := find(layout, questionID)
+	if entry == nil || entry.OptionMap == nil {
+		return answer
+	}
+	if canonical, ok := entry.OptionMap[answer]; ok {
+		return canonical
+	}
+	return answer
+}
+
+// DisplayedAnswer maps a canonical option letter to the letter printed on
+// the variant, for answer keys
+func DisplayedAnswer(layout []models.VariantQuestion, questionID uuid.UUID, canonical string) string {
+	entry := find(layout, questionID)
+	if entry == nil || entry.OptionMap == nil {
+		return canonical
+	}
+	for displayed, c := range entry.OptionMap {
+		if c == canonical {
+			return displayed
+		}
+	}
+	return canonical
+}